@@ -20,11 +20,14 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/online-cake-shop/backend/internal/config"
+	"github.com/online-cake-shop/backend/internal/domain"
 	"github.com/online-cake-shop/backend/internal/email"
 	"github.com/online-cake-shop/backend/internal/handler"
 	custmw "github.com/online-cake-shop/backend/internal/middleware"
+	"github.com/online-cake-shop/backend/internal/payment"
 	"github.com/online-cake-shop/backend/internal/repository/db"
 	"github.com/online-cake-shop/backend/internal/service"
+	"github.com/online-cake-shop/backend/internal/storage"
 )
 
 func main() {
@@ -71,6 +74,10 @@ func main() {
 	}
 	logger.Info("database migrations applied")
 
+	// Background workers stop when appCtx is cancelled on shutdown.
+	appCtx, appCancel := context.WithCancel(context.Background())
+	defer appCancel()
+
 	// Dependency graph
 	queries := db.New(pool)
 
@@ -81,17 +88,47 @@ func main() {
 		emailSender = email.NewMockSender(logger)
 	}
 
-	authSvc := service.NewAuthService(queries, emailSender, cfg.JWT)
-	productSvc := service.NewProductService(queries)
-	cartSvc := service.NewCartService(queries)
-	orderSvc := service.NewOrderService(pool, queries)
+	revocations := service.NewRevocationStore(queries, 30*time.Second)
+	go revocations.Run(appCtx)
+
+	go service.NewReservationSweeper(queries, time.Minute).Run(appCtx)
+	go service.NewGuestCartSweeper(queries, cfg.Cart.GuestCartTTL, time.Hour).Run(appCtx)
+
+	imageStore, err := storage.NewLocalDisk(cfg.Storage.Dir)
+	if err != nil {
+		logger.Error("failed to open image storage", "dir", cfg.Storage.Dir, "error", err)
+		os.Exit(1)
+	}
+
+	authSvc := service.NewAuthService(pool, queries, emailSender, revocations, cfg.JWT)
+	productSvc := service.NewProductService(pool, queries, imageStore, cfg.Storage)
+	promotionEngine := service.NewPromotionEngine(cfg.Order.DeliveryFee)
+	cartSvc := service.NewCartService(pool, queries, promotionEngine, cfg.Cart)
+	paymentProviders := payment.NewRegistry(
+		payment.NewMockProvider(cfg.Payment.MockWebhookSecret, logger),
+	)
+	if _, err := paymentProviders.Get(cfg.Payment.Provider); err != nil {
+		logger.Error("invalid payment provider", "provider", cfg.Payment.Provider, "error", err)
+		os.Exit(1)
+	}
+	paymentSvc := service.NewPaymentService(pool, queries, paymentProviders, emailSender, cfg.Payment)
+	orderSvc := service.NewOrderService(pool, queries, promotionEngine, paymentSvc, emailSender)
+	couponSvc := service.NewCouponService(pool, queries)
+	promotionSvc := service.NewPromotionService(queries)
+	wishlistSvc := service.NewWishlistService(pool, queries, cartSvc)
+
+	go service.NewRefundSweeper(paymentSvc, time.Minute).Run(appCtx)
 
-	authHandler := handler.NewAuthHandler(authSvc)
+	authHandler := handler.NewAuthHandler(authSvc, cartSvc)
 	productHandler := handler.NewProductHandler(productSvc)
-	cartHandler := handler.NewCartHandler(cartSvc)
+	cartHandler := handler.NewCartHandler(cartSvc, cfg.Cart.GuestCartTTL)
 	orderHandler := handler.NewOrderHandler(orderSvc)
+	paymentHandler := handler.NewPaymentHandler(paymentSvc)
+	couponHandler := handler.NewCouponHandler(couponSvc)
+	promotionHandler := handler.NewPromotionHandler(promotionSvc)
+	wishlistHandler := handler.NewWishlistHandler(wishlistSvc)
 
-	authMiddleware := custmw.NewAuthMiddleware(cfg.JWT.Secret)
+	authMiddleware := custmw.NewAuthMiddleware(cfg.JWT.Secret, revocations)
 
 	// Router
 	r := chi.NewRouter()
@@ -102,7 +139,7 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.Server.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -118,6 +155,15 @@ func main() {
 			r.Post("/register", authHandler.Register)
 			r.Post("/verify-otp", authHandler.VerifyOTP)
 			r.Post("/resend-otp", authHandler.ResendOTP)
+			r.Post("/login", authHandler.Login)
+			r.Post("/login/verify", authHandler.VerifyLogin)
+			r.Post("/refresh", authHandler.Refresh)
+
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.Authenticate)
+				r.Post("/logout", authHandler.Logout)
+				r.Post("/logout-all", authHandler.LogoutAll)
+			})
 		})
 
 		// Products (public)
@@ -126,23 +172,113 @@ func main() {
 			r.Get("/{id}", productHandler.GetByID)
 		})
 		r.Get("/categories", productHandler.ListCategories)
+		r.Get("/allergens", productHandler.ListAllergens)
+		r.Get("/images/*", productHandler.ServeImage)
+
+		// Shared wishlists (public, read-only)
+		r.Get("/shared-wishlists/{token}", wishlistHandler.GetShared)
+
+		// Payment provider webhooks (authenticated by signature)
+		r.Post("/webhooks/payments/{provider}", paymentHandler.Webhook)
+
+		// Cart (signed-in users, or guests by cookie)
+		r.Route("/cart", func(r chi.Router) {
+			r.Use(authMiddleware.OptionalAuthenticate)
+			r.Get("/", cartHandler.GetCart)
+			r.Post("/items", cartHandler.AddItem)
+			r.Put("/items/{itemId}", cartHandler.UpdateItem)
+			r.Delete("/items/{itemId}", cartHandler.RemoveItem)
+			r.Delete("/", cartHandler.ClearCart)
+			r.Post("/coupon", cartHandler.ApplyCoupon)
+		})
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
 
-			r.Route("/cart", func(r chi.Router) {
-				r.Get("/", cartHandler.GetCart)
-				r.Post("/items", cartHandler.AddItem)
-				r.Put("/items/{itemId}", cartHandler.UpdateItem)
-				r.Delete("/items/{itemId}", cartHandler.RemoveItem)
-				r.Delete("/", cartHandler.ClearCart)
-			})
-
 			r.Route("/orders", func(r chi.Router) {
 				r.Post("/", orderHandler.CreateOrder)
 				r.Get("/", orderHandler.ListOrders)
 				r.Get("/{id}", orderHandler.GetOrder)
+				r.Post("/{id}/cancel", orderHandler.CancelOrder)
+			})
+
+			r.Route("/wishlists", func(r chi.Router) {
+				r.Get("/", wishlistHandler.List)
+				r.Post("/", wishlistHandler.Create)
+				r.Get("/{id}", wishlistHandler.Get)
+				r.Put("/{id}", wishlistHandler.Rename)
+				r.Delete("/{id}", wishlistHandler.Delete)
+				r.Post("/{id}/items", wishlistHandler.AddItem)
+				r.Delete("/{id}/items/{itemId}", wishlistHandler.RemoveItem)
+				r.Post("/{id}/items/{itemId}/move-to-cart", wishlistHandler.MoveItemToCart)
+				r.Post("/{id}/share", wishlistHandler.Share)
+				r.Delete("/{id}/share", wishlistHandler.Unshare)
+			})
+
+			// Back-office (staff and admins)
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(custmw.RequireRole(domain.RoleStaff, domain.RoleAdmin))
+
+				r.Route("/products", func(r chi.Router) {
+					r.Get("/", productHandler.AdminList)
+					r.Get("/{id}", productHandler.AdminGet)
+
+					// Changing the catalog is for admins only.
+					admin := r.With(custmw.RequireRole(domain.RoleAdmin))
+					admin.Post("/", productHandler.Create)
+					admin.Put("/{id}", productHandler.Update)
+					admin.Delete("/{id}", productHandler.Delete)
+					admin.Post("/{id}/restore", productHandler.Restore)
+					admin.Post("/{id}/variants", productHandler.AddVariant)
+					admin.Put("/{id}/variants/{variantId}", productHandler.UpdateVariant)
+					admin.Post("/{id}/images", productHandler.UploadImage)
+					admin.Put("/{id}/images", productHandler.ReorderImages)
+					admin.Delete("/{id}/images/{imageId}", productHandler.DeleteImage)
+				})
+
+				r.Route("/categories", func(r chi.Router) {
+					r.Post("/", productHandler.CreateCategory)
+					r.Get("/{id}", productHandler.GetCategory)
+					r.Put("/{id}", productHandler.UpdateCategory)
+					r.Delete("/{id}", productHandler.DeleteCategory)
+				})
+
+				r.Route("/allergens", func(r chi.Router) {
+					r.Post("/", productHandler.CreateAllergen)
+					r.Put("/{id}", productHandler.UpdateAllergen)
+					r.Delete("/{id}", productHandler.DeleteAllergen)
+				})
+
+				r.Route("/coupons", func(r chi.Router) {
+					r.Get("/", couponHandler.List)
+					r.Post("/", couponHandler.Create)
+					r.Get("/{id}", couponHandler.Get)
+					r.Put("/{id}", couponHandler.Update)
+					r.Delete("/{id}", couponHandler.Delete)
+				})
+
+				r.Route("/promotions", func(r chi.Router) {
+					r.Get("/", promotionHandler.List)
+					r.Post("/", promotionHandler.Create)
+					r.Get("/{id}", promotionHandler.Get)
+					r.Put("/{id}", promotionHandler.Update)
+					r.Delete("/{id}", promotionHandler.Delete)
+				})
+
+				r.Get("/orders/{id}", orderHandler.AdminGetOrder)
+				r.Put("/orders/{id}/status", orderHandler.AdminUpdateStatus)
+				r.Post("/orders/{id}/advance", orderHandler.AdminAdvanceStatus)
+				r.With(custmw.RequireRole(domain.RoleAdmin)).
+					Post("/orders/{id}/refund", orderHandler.AdminRefund)
+
+				// Development only: stands in for the mock gateway's webhook.
+				if cfg.Server.Env == "development" && cfg.Payment.Provider == payment.MockProviderName {
+					r.Post("/orders/{id}/settle-mock-payment", paymentHandler.SettleMockPayment)
+				}
+
+				r.With(custmw.RequireRole(domain.RoleAdmin)).
+					Put("/users/{id}/role", authHandler.UpdateUserRole)
 			})
 		})
 	})
@@ -168,6 +304,7 @@ func main() {
 
 	<-quit
 	logger.Info("shutting down server...")
+	appCancel()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_email_otps_user_purpose;
+
+ALTER TABLE email_otps DROP COLUMN IF EXISTS purpose;
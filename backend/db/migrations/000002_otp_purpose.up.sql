@@ -0,0 +1,10 @@
+-- ============================================================
+-- EMAIL OTP PURPOSE
+-- ============================================================
+-- Separates registration codes from login codes so one can never be
+-- redeemed in place of the other.
+ALTER TABLE email_otps
+    ADD COLUMN purpose VARCHAR(20) NOT NULL DEFAULT 'registration'
+        CHECK (purpose IN ('registration', 'login'));
+
+CREATE INDEX idx_email_otps_user_purpose ON email_otps (user_id, purpose, created_at DESC);
@@ -0,0 +1,23 @@
+-- ============================================================
+-- SESSIONS
+-- ============================================================
+-- One row per issued refresh token. Tokens issued by rotating an
+-- earlier token share its family_id, so a replayed token can revoke
+-- every descendant in one statement.
+CREATE TABLE sessions (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id   UUID        NOT NULL,
+    token_hash  VARCHAR(64) NOT NULL,
+    user_agent  TEXT,
+    ip_address  VARCHAR(64),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    rotated_at  TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_sessions_token_hash ON sessions (token_hash);
+CREATE INDEX idx_sessions_family_id  ON sessions (family_id);
+CREATE INDEX idx_sessions_user_id    ON sessions (user_id);
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
@@ -0,0 +1,16 @@
+-- ============================================================
+-- TOKEN REVOCATION
+-- ============================================================
+-- Bumping token_version invalidates every access token issued to a user
+-- before the bump ("log out everywhere").
+ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+-- Individually revoked access tokens, kept until they would have expired.
+CREATE TABLE revoked_tokens (
+    jti        UUID        PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
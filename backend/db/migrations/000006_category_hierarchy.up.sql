@@ -0,0 +1,8 @@
+-- ============================================================
+-- CATEGORY HIERARCHY
+-- ============================================================
+ALTER TABLE categories
+    ADD COLUMN parent_id UUID REFERENCES categories (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
@@ -0,0 +1,26 @@
+-- ============================================================
+-- ORDER STATUS LIFECYCLE
+-- ============================================================
+UPDATE orders SET status = 'baking' WHERE status = 'preparing';
+
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'pending', 'confirmed', 'baking', 'ready',
+        'out_for_delivery', 'delivered', 'cancelled', 'refunded'
+    ));
+
+CREATE TABLE order_status_history (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    UUID        NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status VARCHAR(50),
+    to_status   VARCHAR(50) NOT NULL,
+    actor_id    UUID        REFERENCES users (id) ON DELETE SET NULL,
+    note        TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history (order_id, created_at);
+
+-- Existing orders start their history at their current status.
+INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, created_at)
+SELECT id, NULL, status, user_id, created_at FROM orders;
@@ -0,0 +1,12 @@
+-- ============================================================
+-- IDEMPOTENCY KEYS
+-- ============================================================
+CREATE TABLE idempotency_keys (
+    user_id       UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key           VARCHAR(255) NOT NULL,
+    fingerprint   CHAR(64)     NOT NULL,
+    response_body JSONB,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ  NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
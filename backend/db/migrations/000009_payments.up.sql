@@ -0,0 +1,24 @@
+-- ============================================================
+-- PAYMENTS
+-- ============================================================
+CREATE TABLE payments (
+    id                 UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id           UUID           NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider           VARCHAR(50)    NOT NULL,
+    provider_intent_id VARCHAR(255),
+    status             VARCHAR(50)    NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'requires_capture', 'processing', 'succeeded', 'failed')),
+    amount             NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    currency           CHAR(3)        NOT NULL,
+    failure_reason     TEXT,
+    created_at         TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_payments_order_id ON payments (order_id);
+CREATE UNIQUE INDEX idx_payments_provider_intent
+    ON payments (provider, provider_intent_id) WHERE provider_intent_id IS NOT NULL;
+
+CREATE TRIGGER set_updated_at_payments
+    BEFORE UPDATE ON payments
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS payment_events;
+
+UPDATE orders SET status = 'cancelled' WHERE status = 'payment_failed';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'pending', 'confirmed', 'baking', 'ready',
+        'out_for_delivery', 'delivered', 'cancelled', 'refunded'
+    ));
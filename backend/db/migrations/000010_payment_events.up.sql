@@ -0,0 +1,28 @@
+-- ============================================================
+-- PAYMENT WEBHOOK EVENTS
+-- ============================================================
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'pending', 'confirmed', 'baking', 'ready',
+        'out_for_delivery', 'delivered', 'cancelled', 'refunded',
+        'payment_failed'
+    ));
+
+-- Every verified webhook delivery, kept for audit. The unique key makes
+-- replayed deliveries of the same event no-ops.
+CREATE TABLE payment_events (
+    id           UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider     VARCHAR(50)  NOT NULL,
+    event_id     VARCHAR(255) NOT NULL,
+    event_type   VARCHAR(100) NOT NULL,
+    payment_id   UUID         REFERENCES payments (id) ON DELETE SET NULL,
+    payload      JSONB        NOT NULL,
+    outcome      VARCHAR(50),
+    occurred_at  TIMESTAMPTZ,
+    received_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+    UNIQUE (provider, event_id)
+);
+
+CREATE INDEX idx_payment_events_payment_id ON payment_events (payment_id);
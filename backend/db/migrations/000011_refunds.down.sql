@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS refund_items;
+DROP TABLE IF EXISTS refunds;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_refunded_quantity_check,
+    DROP COLUMN IF EXISTS refunded_quantity;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_amount;
+
+UPDATE orders SET status = 'refunded' WHERE status = 'partially_refunded';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'pending', 'confirmed', 'baking', 'ready',
+        'out_for_delivery', 'delivered', 'cancelled', 'refunded',
+        'payment_failed'
+    ));
@@ -0,0 +1,50 @@
+-- ============================================================
+-- REFUNDS
+-- ============================================================
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'pending', 'confirmed', 'baking', 'ready',
+        'out_for_delivery', 'delivered', 'cancelled', 'refunded',
+        'payment_failed', 'partially_refunded'
+    ));
+
+-- Quantity of each line covered by pending or succeeded refunds.
+ALTER TABLE order_items
+    ADD COLUMN refunded_quantity INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT order_items_refunded_quantity_check
+        CHECK (refunded_quantity BETWEEN 0 AND quantity);
+
+-- What has been refunded so far, partial refunds included.
+ALTER TABLE orders
+    ADD COLUMN refunded_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0);
+
+CREATE TABLE refunds (
+    id                 UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id           UUID           NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    payment_id         UUID           REFERENCES payments (id),
+    provider_refund_id VARCHAR(255),
+    status             VARCHAR(50)    NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'failed')),
+    amount             NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    reason             TEXT,
+    restock            BOOLEAN        NOT NULL DEFAULT FALSE,
+    actor_id           UUID           REFERENCES users (id) ON DELETE SET NULL,
+    failure_reason     TEXT,
+    created_at         TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds (order_id);
+
+CREATE TRIGGER set_updated_at_refunds
+    BEFORE UPDATE ON refunds
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE refund_items (
+    refund_id     UUID           NOT NULL REFERENCES refunds (id) ON DELETE CASCADE,
+    order_item_id UUID           NOT NULL REFERENCES order_items (id) ON DELETE CASCADE,
+    quantity      INT            NOT NULL CHECK (quantity > 0),
+    amount        NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (refund_id, order_item_id)
+);
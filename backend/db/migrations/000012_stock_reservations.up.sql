@@ -0,0 +1,17 @@
+-- ============================================================
+-- STOCK RESERVATIONS
+-- ============================================================
+-- A cart line holds its quantity until expires_at. Available-to-promise
+-- stock is stock_quantity minus unexpired holds.
+CREATE TABLE stock_reservations (
+    id         UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    cart_id    UUID        NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id UUID        NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity   INT         NOT NULL CHECK (quantity > 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (cart_id, product_id)
+);
+
+CREATE INDEX idx_stock_reservations_product_id ON stock_reservations (product_id, expires_at);
+CREATE INDEX idx_stock_reservations_expires_at ON stock_reservations (expires_at);
@@ -0,0 +1,46 @@
+-- Products get back the cheapest active variant's price and the stock of
+-- all active variants. Cart lines and holds for other variants of the same
+-- product are dropped.
+ALTER TABLE products
+    ADD COLUMN price          NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    ADD COLUMN stock_quantity INT            NOT NULL DEFAULT 0 CHECK (stock_quantity >= 0);
+
+UPDATE products p
+SET price          = COALESCE(v.price, 0),
+    stock_quantity = COALESCE(v.stock_quantity, 0)
+FROM (
+    SELECT product_id,
+           MIN(price) FILTER (WHERE is_active)          AS price,
+           SUM(stock_quantity) FILTER (WHERE is_active) AS stock_quantity
+    FROM product_variants
+    GROUP BY product_id
+) v
+WHERE v.product_id = p.id;
+
+ALTER TABLE products ALTER COLUMN price DROP DEFAULT;
+
+CREATE INDEX idx_products_price ON products (price) WHERE deleted_at IS NULL AND is_active = TRUE;
+
+DELETE FROM stock_reservations;
+DROP INDEX IF EXISTS idx_stock_reservations_variant_id;
+ALTER TABLE stock_reservations
+    DROP CONSTRAINT IF EXISTS stock_reservations_cart_id_variant_id_key,
+    DROP COLUMN variant_id,
+    ADD COLUMN product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    ADD CONSTRAINT stock_reservations_cart_id_product_id_key UNIQUE (cart_id, product_id);
+
+CREATE INDEX idx_stock_reservations_product_id ON stock_reservations (product_id, expires_at);
+
+DROP INDEX IF EXISTS idx_order_items_variant_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
+
+DELETE FROM cart_items a
+USING cart_items b
+WHERE a.cart_id = b.cart_id AND a.product_id = b.product_id AND (a.created_at, a.id) > (b.created_at, b.id);
+DROP INDEX IF EXISTS idx_cart_items_variant_id;
+ALTER TABLE cart_items
+    DROP CONSTRAINT IF EXISTS cart_items_cart_id_variant_id_key,
+    DROP COLUMN variant_id,
+    ADD CONSTRAINT cart_items_cart_id_product_id_key UNIQUE (cart_id, product_id);
+
+DROP TABLE IF EXISTS product_variants;
@@ -0,0 +1,68 @@
+-- ============================================================
+-- PRODUCT VARIANTS
+-- ============================================================
+-- A product is sold as one or more variants (size, flavour, tiers, ...),
+-- each with its own SKU, price and stock. options maps option names to
+-- values, e.g. {"size": "8\"", "flavour": "chocolate"}; a product without
+-- options has a single variant with empty options.
+CREATE TABLE product_variants (
+    id             UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id     UUID           NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku            VARCHAR(64)    NOT NULL,
+    options        JSONB          NOT NULL DEFAULT '{}'
+        CHECK (jsonb_typeof(options) = 'object'),
+    price          NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    stock_quantity INT            NOT NULL DEFAULT 0 CHECK (stock_quantity >= 0),
+    position       INT            NOT NULL DEFAULT 0,
+    is_active      BOOLEAN        NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_product_variants_sku     ON product_variants (sku);
+CREATE UNIQUE INDEX idx_product_variants_options ON product_variants (product_id, options);
+
+CREATE TRIGGER set_updated_at_product_variants
+    BEFORE UPDATE ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Every existing product becomes a single variant carrying its price and stock.
+INSERT INTO product_variants (product_id, sku, price, stock_quantity)
+SELECT id, 'SKU-' || UPPER(LEFT(REPLACE(id::text, '-', ''), 12)), price, stock_quantity
+FROM products;
+
+-- ─── Cart items ──────────────────────────────────────────────
+ALTER TABLE cart_items ADD COLUMN variant_id UUID REFERENCES product_variants (id) ON DELETE CASCADE;
+UPDATE cart_items ci SET variant_id = v.id FROM product_variants v WHERE v.product_id = ci.product_id;
+ALTER TABLE cart_items
+    ALTER COLUMN variant_id SET NOT NULL,
+    DROP CONSTRAINT cart_items_cart_id_product_id_key,
+    ADD CONSTRAINT cart_items_cart_id_variant_id_key UNIQUE (cart_id, variant_id);
+
+CREATE INDEX idx_cart_items_variant_id ON cart_items (variant_id);
+
+-- ─── Order items ─────────────────────────────────────────────
+ALTER TABLE order_items ADD COLUMN variant_id UUID REFERENCES product_variants (id);
+UPDATE order_items oi SET variant_id = v.id FROM product_variants v WHERE v.product_id = oi.product_id;
+ALTER TABLE order_items ALTER COLUMN variant_id SET NOT NULL;
+
+CREATE INDEX idx_order_items_variant_id ON order_items (variant_id);
+
+-- ─── Stock reservations ──────────────────────────────────────
+-- Holds are taken on the variant whose stock they draw on.
+ALTER TABLE stock_reservations ADD COLUMN variant_id UUID REFERENCES product_variants (id) ON DELETE CASCADE;
+UPDATE stock_reservations sr SET variant_id = v.id FROM product_variants v WHERE v.product_id = sr.product_id;
+DROP INDEX idx_stock_reservations_product_id;
+ALTER TABLE stock_reservations
+    DROP COLUMN product_id,
+    ALTER COLUMN variant_id SET NOT NULL,
+    ADD CONSTRAINT stock_reservations_cart_id_variant_id_key UNIQUE (cart_id, variant_id);
+
+CREATE INDEX idx_stock_reservations_variant_id ON stock_reservations (variant_id, expires_at);
+
+-- ─── Products ────────────────────────────────────────────────
+-- Price and stock now live on the variants only.
+DROP INDEX idx_products_price;
+ALTER TABLE products
+    DROP COLUMN price,
+    DROP COLUMN stock_quantity;
@@ -0,0 +1,14 @@
+-- Personalised cart lines of a variant collapse into the oldest one.
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS personalisation_price,
+    DROP COLUMN IF EXISTS personalisation;
+
+DELETE FROM cart_items a
+USING cart_items b
+WHERE a.cart_id = b.cart_id AND a.variant_id = b.variant_id AND (a.created_at, a.id) > (b.created_at, b.id);
+ALTER TABLE cart_items
+    DROP CONSTRAINT IF EXISTS cart_items_cart_id_variant_id_personalisation_key,
+    DROP COLUMN IF EXISTS personalisation,
+    ADD CONSTRAINT cart_items_cart_id_variant_id_key UNIQUE (cart_id, variant_id);
+
+ALTER TABLE products DROP COLUMN IF EXISTS personalisation_options;
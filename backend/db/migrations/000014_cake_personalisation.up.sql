@@ -0,0 +1,31 @@
+-- ============================================================
+-- CAKE PERSONALISATION
+-- ============================================================
+-- products.personalisation_options describes what a product can be
+-- personalised with and the surcharge for each, e.g.
+--   {"inscription": {"max_length": 30, "price": 3.00},
+--    "candles":     {"max": 10, "price_each": 0.20},
+--    "topper":      {"choices": [{"name": "Unicorn", "price": 6.50}]},
+--    "gift_box":    {"price": 4.00}}
+-- Options that are left out aren't offered.
+ALTER TABLE products
+    ADD COLUMN personalisation_options JSONB NOT NULL DEFAULT '{}'
+        CHECK (jsonb_typeof(personalisation_options) = 'object');
+
+-- A cart line's personalisation, e.g. {"inscription": "Happy Birthday Sara",
+-- "candles": 7}. The same variant can be in the cart several times with
+-- different personalisations.
+ALTER TABLE cart_items
+    ADD COLUMN personalisation JSONB NOT NULL DEFAULT '{}'
+        CHECK (jsonb_typeof(personalisation) = 'object'),
+    DROP CONSTRAINT cart_items_cart_id_variant_id_key,
+    ADD CONSTRAINT cart_items_cart_id_variant_id_personalisation_key
+        UNIQUE (cart_id, variant_id, personalisation);
+
+-- unit_price includes personalisation_price, the per-unit surcharge for the
+-- personalisation at the time of ordering.
+ALTER TABLE order_items
+    ADD COLUMN personalisation JSONB NOT NULL DEFAULT '{}'
+        CHECK (jsonb_typeof(personalisation) = 'object'),
+    ADD COLUMN personalisation_price NUMERIC(10, 2) NOT NULL DEFAULT 0
+        CHECK (personalisation_price >= 0);
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS refresh_product_search_categories ON categories;
+DROP FUNCTION IF EXISTS refresh_category_product_search();
+
+DROP TRIGGER IF EXISTS set_search_vector_products ON products;
+DROP FUNCTION IF EXISTS set_product_search_vector();
+
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+
+DROP FUNCTION IF EXISTS product_search_vector(TEXT, TEXT, UUID);
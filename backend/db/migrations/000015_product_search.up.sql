@@ -0,0 +1,48 @@
+-- ============================================================
+-- PRODUCT SEARCH
+-- ============================================================
+-- search_vector indexes a product's name (weight A), category name (B) and
+-- description (C). It is kept up to date by triggers on products and on
+-- categories, whose names are part of it.
+CREATE FUNCTION product_search_vector(p_name TEXT, p_description TEXT, p_category_id UUID)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(p_name, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE((SELECT name FROM categories WHERE id = p_category_id), '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(p_description, '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE products ADD COLUMN search_vector TSVECTOR;
+
+UPDATE products SET search_vector = product_search_vector(name, description, category_id);
+
+ALTER TABLE products ALTER COLUMN search_vector SET NOT NULL;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+
+CREATE OR REPLACE FUNCTION set_product_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector = product_search_vector(NEW.name, NEW.description, NEW.category_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_search_vector_products
+    BEFORE INSERT OR UPDATE OF name, description, category_id ON products
+    FOR EACH ROW EXECUTE FUNCTION set_product_search_vector();
+
+CREATE OR REPLACE FUNCTION refresh_category_product_search()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products
+    SET search_vector = product_search_vector(name, description, category_id)
+    WHERE category_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER refresh_product_search_categories
+    AFTER UPDATE OF name ON categories
+    FOR EACH ROW
+    WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION refresh_category_product_search();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_product_variants_size;
+DROP INDEX IF EXISTS idx_products_dietary_tags;
+ALTER TABLE products DROP COLUMN IF EXISTS dietary_tags;
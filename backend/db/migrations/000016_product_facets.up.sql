@@ -0,0 +1,10 @@
+-- ============================================================
+-- PRODUCT FACETS
+-- ============================================================
+-- Dietary tags are lower-case slugs such as "vegan" or "nut-free".
+ALTER TABLE products ADD COLUMN dietary_tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_products_dietary_tags ON products USING GIN (dietary_tags);
+
+-- Sizes are the "size" option of variants.
+CREATE INDEX idx_product_variants_size ON product_variants (product_id, (options->>'size')) WHERE is_active = TRUE;
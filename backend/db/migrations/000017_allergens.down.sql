@@ -0,0 +1,3 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS allergens;
+DROP TABLE IF EXISTS product_allergens;
+DROP TABLE IF EXISTS allergens;
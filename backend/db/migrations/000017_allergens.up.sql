@@ -0,0 +1,51 @@
+-- ============================================================
+-- ALLERGENS
+-- ============================================================
+-- The allergens products are labelled with, managed by admins. Seeded with
+-- the fourteen allergens that must be declared on food in the UK and EU.
+CREATE TABLE allergens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       VARCHAR(100) NOT NULL,
+    slug       VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_allergens_slug ON allergens (slug);
+
+CREATE TRIGGER set_updated_at_allergens
+    BEFORE UPDATE ON allergens
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+INSERT INTO allergens (name, slug) VALUES
+    ('Celery',      'celery'),
+    ('Crustaceans', 'crustaceans'),
+    ('Eggs',        'eggs'),
+    ('Fish',        'fish'),
+    ('Gluten',      'gluten'),
+    ('Lupin',       'lupin'),
+    ('Milk',        'milk'),
+    ('Molluscs',    'molluscs'),
+    ('Mustard',     'mustard'),
+    ('Peanuts',     'peanuts'),
+    ('Sesame',      'sesame'),
+    ('Soya',        'soya'),
+    ('Sulphites',   'sulphites'),
+    ('Tree nuts',   'tree-nuts');
+
+-- What a product declares about an allergen. Allergens a product doesn't
+-- mention are unknown, not absent. An allergen in use can't be deleted.
+CREATE TABLE product_allergens (
+    product_id  UUID        NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    allergen_id UUID        NOT NULL REFERENCES allergens (id) ON DELETE RESTRICT,
+    status      VARCHAR(20) NOT NULL CHECK (status IN ('contains', 'may_contain', 'free_from')),
+    PRIMARY KEY (product_id, allergen_id)
+);
+
+CREATE INDEX idx_product_allergens_allergen_id ON product_allergens (allergen_id, status);
+
+-- The declarations as they were when ordered, for the order confirmation:
+-- [{"name": "Milk", "slug": "milk", "status": "contains"}, ...]
+ALTER TABLE order_items
+    ADD COLUMN allergens JSONB NOT NULL DEFAULT '[]'
+        CHECK (jsonb_typeof(allergens) = 'array');
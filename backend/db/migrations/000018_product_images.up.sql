@@ -0,0 +1,20 @@
+-- ============================================================
+-- PRODUCT IMAGES
+-- ============================================================
+-- Images uploaded for a product, shown in position order. The files live
+-- in the configured storage under storage_key, with a smaller copy under
+-- thumbnail_key. products.image_url follows the first image.
+CREATE TABLE product_images (
+    id            UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id    UUID         NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    storage_key   VARCHAR(255) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(255) NOT NULL UNIQUE,
+    content_type  VARCHAR(50)  NOT NULL CHECK (content_type IN ('image/jpeg', 'image/png')),
+    width         INT          NOT NULL CHECK (width > 0),
+    height        INT          NOT NULL CHECK (height > 0),
+    size_bytes    BIGINT       NOT NULL CHECK (size_bytes > 0),
+    position      INT          NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_images_product_id ON product_images (product_id, position);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_carts_guest_updated_at;
+DELETE FROM carts WHERE user_id IS NULL;
+ALTER TABLE carts ALTER COLUMN user_id SET NOT NULL;
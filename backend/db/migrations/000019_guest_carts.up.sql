@@ -0,0 +1,9 @@
+-- ============================================================
+-- GUEST CARTS
+-- ============================================================
+-- Visitors who haven't signed in get a cart with no user, found through a
+-- signed cookie. On sign-in it is merged into the user's cart and deleted;
+-- abandoned ones are swept once idle (updated_at) for the guest cart TTL.
+ALTER TABLE carts ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE INDEX idx_carts_guest_updated_at ON carts (updated_at) WHERE user_id IS NULL;
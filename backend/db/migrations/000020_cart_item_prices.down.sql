@@ -0,0 +1 @@
+ALTER TABLE cart_items DROP COLUMN IF EXISTS added_price;
@@ -0,0 +1,8 @@
+-- ============================================================
+-- CART ITEM PRICES
+-- ============================================================
+-- The per-cake price, personalisation included, the customer was shown
+-- when they added the line or last changed its quantity, so the cart can
+-- point out prices that have changed since. NULL for lines added before
+-- prices were recorded.
+ALTER TABLE cart_items ADD COLUMN added_price NUMERIC(10, 2);
@@ -0,0 +1,73 @@
+-- ============================================================
+-- COUPONS
+-- ============================================================
+-- Discount codes customers enter in their cart. A coupon takes a percentage
+-- (discount_value 12.5 is 12.5%) or a fixed amount off the items it applies
+-- to: every item, or with restrictions only items of the listed products or
+-- categories (subcategories included). Codes are stored upper-case.
+--
+-- used_count counts orders placed with the coupon; it is checked against
+-- max_uses and bumped with the coupon row locked, inside the order's
+-- transaction. NULL limits and validity bounds mean no limit.
+CREATE TABLE coupons (
+    id                UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    code              VARCHAR(50)    NOT NULL,
+    description       TEXT,
+    discount_type     VARCHAR(20)    NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value    NUMERIC(10, 2) NOT NULL CHECK (discount_value > 0),
+    min_order_amount  NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (min_order_amount >= 0),
+    starts_at         TIMESTAMPTZ,
+    ends_at           TIMESTAMPTZ,
+    max_uses          INT            CHECK (max_uses > 0),
+    max_uses_per_user INT            CHECK (max_uses_per_user > 0),
+    used_count        INT            NOT NULL DEFAULT 0 CHECK (used_count >= 0),
+    is_active         BOOLEAN        NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    CHECK (discount_type <> 'percentage' OR discount_value <= 100),
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons (code);
+
+CREATE TRIGGER set_updated_at_coupons
+    BEFORE UPDATE ON coupons
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE coupon_categories (
+    coupon_id   UUID NOT NULL REFERENCES coupons (id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, category_id)
+);
+
+CREATE TABLE coupon_products (
+    coupon_id  UUID NOT NULL REFERENCES coupons (id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, product_id)
+);
+
+-- One row per order placed with a coupon, for the per-user limit. Removed
+-- again, with used_count lowered, when the order is cancelled or its
+-- payment fails. A coupon that has been used can't be deleted, only
+-- deactivated.
+CREATE TABLE coupon_redemptions (
+    id              UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id       UUID           NOT NULL REFERENCES coupons (id) ON DELETE RESTRICT,
+    user_id         UUID           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id        UUID           NOT NULL UNIQUE REFERENCES orders (id) ON DELETE CASCADE,
+    discount_amount NUMERIC(10, 2) NOT NULL CHECK (discount_amount >= 0),
+    created_at      TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions (coupon_id, user_id);
+
+-- The coupon a cart has applied; it is priced each time the cart is read.
+ALTER TABLE carts
+    ADD COLUMN coupon_id UUID REFERENCES coupons (id) ON DELETE SET NULL;
+
+-- What an order was discounted by. total_amount is after the discount;
+-- coupon_code is kept should the coupon later be deleted.
+ALTER TABLE orders
+    ADD COLUMN coupon_id       UUID REFERENCES coupons (id) ON DELETE SET NULL,
+    ADD COLUMN coupon_code     VARCHAR(50),
+    ADD COLUMN discount_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
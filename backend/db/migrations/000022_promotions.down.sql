@@ -0,0 +1,5 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS delivery_fee,
+    DROP COLUMN IF EXISTS promotion_discount;
+DROP TABLE IF EXISTS order_promotions;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,70 @@
+-- ============================================================
+-- PROMOTIONS
+-- ============================================================
+-- Discounts applied automatically to every cart they suit, no code needed:
+--
+--   buy_x_get_y    for every buy_quantity + get_quantity items, the
+--                  get_quantity cheapest are free
+--   percent_off    percent_off% off the items (12.5 is 12.5%)
+--   free_delivery  no delivery fee
+--
+-- Item promotions apply to every product, or only to one product or the
+-- products of one category and its subcategories. All kinds can require a
+-- minimum order (before discounts) and a validity window.
+--
+-- Promotions are applied highest priority first, each to what earlier ones
+-- left of the items' prices. An exclusive promotion is never combined with
+-- another: it is skipped if one has already applied, and stops the rest if
+-- it applies itself.
+CREATE TABLE promotions (
+    id               UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    name             VARCHAR(100)   NOT NULL,
+    description      TEXT,
+    kind             VARCHAR(20)    NOT NULL CHECK (kind IN ('buy_x_get_y', 'percent_off', 'free_delivery')),
+    priority         INT            NOT NULL DEFAULT 0,
+    exclusive        BOOLEAN        NOT NULL DEFAULT FALSE,
+    category_id      UUID           REFERENCES categories (id) ON DELETE CASCADE,
+    product_id       UUID           REFERENCES products (id) ON DELETE CASCADE,
+    buy_quantity     INT            CHECK (buy_quantity > 0),
+    get_quantity     INT            CHECK (get_quantity > 0),
+    percent_off      NUMERIC(5, 2)  CHECK (percent_off > 0 AND percent_off <= 100),
+    min_order_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (min_order_amount >= 0),
+    starts_at        TIMESTAMPTZ,
+    ends_at          TIMESTAMPTZ,
+    is_active        BOOLEAN        NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    CHECK (category_id IS NULL OR product_id IS NULL),
+    CHECK ((kind = 'buy_x_get_y') = (buy_quantity IS NOT NULL AND get_quantity IS NOT NULL)),
+    CHECK ((kind = 'percent_off') = (percent_off IS NOT NULL)),
+    CHECK (kind <> 'free_delivery' OR (category_id IS NULL AND product_id IS NULL)),
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+);
+
+CREATE INDEX idx_promotions_active ON promotions (priority DESC, created_at) WHERE is_active;
+
+CREATE TRIGGER set_updated_at_promotions
+    BEFORE UPDATE ON promotions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- The promotions an order was placed with, as they were explained to the
+-- customer. name and detail are kept should the promotion later change or
+-- be deleted.
+CREATE TABLE order_promotions (
+    id              UUID           PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id        UUID           NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    promotion_id    UUID           REFERENCES promotions (id) ON DELETE SET NULL,
+    name            VARCHAR(100)   NOT NULL,
+    detail          TEXT           NOT NULL,
+    discount_amount NUMERIC(10, 2) NOT NULL CHECK (discount_amount >= 0),
+    position        INT            NOT NULL
+);
+
+CREATE INDEX idx_order_promotions_order_id ON order_promotions (order_id);
+
+-- What an order's promotions took off its items, and the delivery fee it
+-- paid. total_amount = items - promotion_discount - discount_amount (the
+-- coupon) + delivery_fee.
+ALTER TABLE orders
+    ADD COLUMN promotion_discount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (promotion_discount >= 0),
+    ADD COLUMN delivery_fee       NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (delivery_fee >= 0);
@@ -0,0 +1,43 @@
+-- ============================================================
+-- WISHLISTS
+-- ============================================================
+-- Named lists, e.g. "Mum's birthday", where customers keep cakes for later
+-- without holding stock in their cart. Items name a variant, its
+-- personalisation and a quantity, much like cart lines, and are moved into
+-- the cart when the customer is ready.
+--
+-- A list with a share_token can be read, but not changed, by anyone who has
+-- the link. Clearing the token revokes the link.
+CREATE TABLE wishlists (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL,
+    share_token VARCHAR(64),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_wishlists_user_name ON wishlists (user_id, LOWER(name));
+CREATE UNIQUE INDEX idx_wishlists_share_token ON wishlists (share_token) WHERE share_token IS NOT NULL;
+
+CREATE TRIGGER set_updated_at_wishlists
+    BEFORE UPDATE ON wishlists
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE wishlist_items (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    wishlist_id     UUID        NOT NULL REFERENCES wishlists (id) ON DELETE CASCADE,
+    product_id      UUID        NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    variant_id      UUID        NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    personalisation JSONB       NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(personalisation) = 'object'),
+    quantity        INT         NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (wishlist_id, variant_id, personalisation)
+);
+
+CREATE INDEX idx_wishlist_items_product_id ON wishlist_items (product_id);
+
+CREATE TRIGGER set_updated_at_wishlist_items
+    BEFORE UPDATE ON wishlist_items
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,41 @@
+-- name: ListAllergens :many
+SELECT * FROM allergens
+ORDER BY name ASC;
+
+-- name: GetAllergenByID :one
+SELECT * FROM allergens
+WHERE id = $1;
+
+-- name: CreateAllergen :one
+INSERT INTO allergens (name, slug)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: UpdateAllergen :one
+UPDATE allergens
+SET name = $2, slug = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteAllergen :execrows
+DELETE FROM allergens
+WHERE id = $1;
+
+-- name: ListProductAllergens :many
+-- Declarations of the given products: contains first, then may_contain,
+-- then free_from, each by allergen name.
+SELECT pa.product_id, pa.allergen_id, pa.status, a.name, a.slug
+FROM product_allergens pa
+JOIN allergens a ON a.id = pa.allergen_id
+WHERE pa.product_id = ANY($1::uuid[])
+ORDER BY pa.product_id,
+         array_position(ARRAY['contains', 'may_contain', 'free_from']::varchar[], pa.status),
+         a.name;
+
+-- name: DeleteProductAllergens :exec
+DELETE FROM product_allergens
+WHERE product_id = $1;
+
+-- name: CreateProductAllergen :exec
+INSERT INTO product_allergens (product_id, allergen_id, status)
+VALUES ($1, $2, $3);
@@ -8,32 +8,54 @@ RETURNING *;
 SELECT * FROM carts WHERE user_id = $1;
 
 -- name: GetCartItems :many
+-- Lines of products or variants no longer sold are returned flagged as
+-- unavailable. available_quantity is the variant's stock not held by other
+-- carts.
 SELECT
     ci.id,
     ci.cart_id,
     ci.product_id,
+    ci.variant_id,
     ci.quantity,
+    ci.personalisation,
+    ci.added_price,
     ci.created_at,
     ci.updated_at,
-    p.name        AS product_name,
-    p.price       AS product_price,
-    p.image_url   AS product_image_url,
-    p.stock_quantity AS product_stock
+    p.name           AS product_name,
+    v.price          AS product_price,
+    p.image_url      AS product_image_url,
+    v.stock_quantity AS product_stock,
+    v.sku            AS variant_sku,
+    v.options        AS variant_options,
+    p.personalisation_options AS product_personalisation_options,
+    sr.expires_at    AS reserved_until,
+    (p.deleted_at IS NOT NULL OR NOT p.is_active OR NOT v.is_active) AS unavailable,
+    GREATEST(v.stock_quantity - COALESCE((
+        SELECT SUM(o.quantity) FROM stock_reservations o
+        WHERE o.variant_id = ci.variant_id
+          AND o.cart_id <> ci.cart_id
+          AND o.expires_at > NOW()
+    ), 0), 0)::INT AS available_quantity
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
+JOIN product_variants v ON v.id = ci.variant_id
+LEFT JOIN stock_reservations sr
+       ON sr.cart_id = ci.cart_id
+      AND sr.variant_id = ci.variant_id
+      AND sr.expires_at > NOW()
 WHERE ci.cart_id = $1
 ORDER BY ci.created_at ASC;
 
 -- name: UpsertCartItem :one
-INSERT INTO cart_items (cart_id, product_id, quantity)
-VALUES ($1, $2, $3)
-ON CONFLICT (cart_id, product_id)
-DO UPDATE SET quantity = $3, updated_at = NOW()
+INSERT INTO cart_items (cart_id, product_id, variant_id, personalisation, quantity, added_price)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (cart_id, variant_id, personalisation)
+DO UPDATE SET quantity = $5, added_price = $6, updated_at = NOW()
 RETURNING *;
 
 -- name: UpdateCartItemQuantity :one
 UPDATE cart_items
-SET quantity = $2, updated_at = NOW()
+SET quantity = $2, added_price = $4, updated_at = NOW()
 WHERE id = $1 AND cart_id = $3
 RETURNING *;
 
@@ -44,7 +66,54 @@ DELETE FROM cart_items WHERE id = $1 AND cart_id = $2;
 DELETE FROM cart_items WHERE cart_id = $1;
 
 -- name: GetCartItemByID :one
-SELECT ci.*, p.name AS product_name, p.price AS product_price
+SELECT ci.*, p.name AS product_name, v.price AS product_price
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
+JOIN product_variants v ON v.id = ci.variant_id
 WHERE ci.id = $1;
+
+-- name: GetCartItem :one
+SELECT * FROM cart_items WHERE id = $1 AND cart_id = $2;
+
+-- name: SumCartVariantQuantity :one
+-- The quantity of a variant across a cart's lines, which may differ in
+-- personalisation.
+SELECT COALESCE(SUM(quantity), 0)::INT FROM cart_items
+WHERE cart_id = $1 AND variant_id = $2;
+
+-- name: CreateGuestCart :one
+INSERT INTO carts (user_id) VALUES (NULL)
+RETURNING *;
+
+-- name: TouchGuestCart :one
+-- Returns a guest cart, marking it as used so it isn't swept.
+UPDATE carts SET updated_at = NOW()
+WHERE id = $1 AND user_id IS NULL
+RETURNING *;
+
+-- name: LockGuestCart :one
+SELECT * FROM carts WHERE id = $1 AND user_id IS NULL FOR UPDATE;
+
+-- name: ListCartItems :many
+SELECT * FROM cart_items WHERE cart_id = $1 ORDER BY created_at ASC;
+
+-- name: GetCartLineQuantity :one
+-- The quantity of the cart's line for a variant with a personalisation, or
+-- 0 if it has none.
+SELECT COALESCE(MAX(quantity), 0)::INT FROM cart_items
+WHERE cart_id = $1 AND variant_id = $2 AND personalisation = $3;
+
+-- name: DeleteCart :exec
+DELETE FROM carts WHERE id = $1;
+
+-- name: DeleteIdleGuestCarts :execrows
+DELETE FROM carts WHERE user_id IS NULL AND updated_at < $1;
+
+-- name: SetCartCoupon :exec
+UPDATE carts SET coupon_id = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: MergeCartCoupon :exec
+-- Gives a cart the coupon of a merged guest cart unless it has its own.
+UPDATE carts SET coupon_id = COALESCE(coupon_id, $2)
+WHERE id = $1;
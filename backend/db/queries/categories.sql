@@ -1,6 +1,6 @@
 -- name: CreateCategory :one
-INSERT INTO categories (name, slug)
-VALUES ($1, $2)
+INSERT INTO categories (name, slug, parent_id)
+VALUES ($1, $2, $3)
 RETURNING *;
 
 -- name: ListCategories :many
@@ -14,3 +14,32 @@ WHERE id = $1;
 -- name: GetCategoryBySlug :one
 SELECT * FROM categories
 WHERE slug = $1;
+
+-- name: UpdateCategory :one
+UPDATE categories
+SET name = $2, slug = $3, parent_id = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories
+WHERE id = $1;
+
+-- name: CountCategoryChildren :one
+SELECT COUNT(*) FROM categories
+WHERE parent_id = $1;
+
+-- name: ListCategorySlugsLike :many
+-- Slugs equal to $1 or of the form "$1-<suffix>", optionally ignoring one category.
+SELECT slug FROM categories
+WHERE (slug = $1 OR slug LIKE $1 || '-%')
+  AND ($2::uuid IS NULL OR id <> $2);
+
+-- name: ListCategoryDescendantIDs :many
+-- The category itself and every category below it.
+WITH RECURSIVE tree AS (
+    SELECT id FROM categories WHERE id = $1
+    UNION ALL
+    SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id FROM tree;
@@ -0,0 +1,98 @@
+-- name: ListCoupons :many
+SELECT * FROM coupons
+ORDER BY created_at DESC;
+
+-- name: GetCouponByID :one
+SELECT * FROM coupons
+WHERE id = $1;
+
+-- name: GetCouponByCode :one
+SELECT * FROM coupons
+WHERE code = $1;
+
+-- name: GetCouponForUpdate :one
+SELECT * FROM coupons
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CreateCoupon :one
+INSERT INTO coupons (code, description, discount_type, discount_value, min_order_amount,
+                     starts_at, ends_at, max_uses, max_uses_per_user, is_active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: UpdateCoupon :one
+UPDATE coupons
+SET code = $2, description = $3, discount_type = $4, discount_value = $5, min_order_amount = $6,
+    starts_at = $7, ends_at = $8, max_uses = $9, max_uses_per_user = $10, is_active = $11,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteCoupon :execrows
+DELETE FROM coupons
+WHERE id = $1;
+
+-- name: ListCouponCategories :many
+SELECT * FROM coupon_categories
+WHERE coupon_id = ANY($1::uuid[]);
+
+-- name: ListCouponProducts :many
+SELECT * FROM coupon_products
+WHERE coupon_id = ANY($1::uuid[]);
+
+-- name: DeleteCouponCategories :exec
+DELETE FROM coupon_categories
+WHERE coupon_id = $1;
+
+-- name: DeleteCouponProducts :exec
+DELETE FROM coupon_products
+WHERE coupon_id = $1;
+
+-- name: CreateCouponCategory :exec
+INSERT INTO coupon_categories (coupon_id, category_id)
+VALUES ($1, $2);
+
+-- name: CreateCouponProduct :exec
+INSERT INTO coupon_products (coupon_id, product_id)
+VALUES ($1, $2);
+
+-- name: ListCouponEligibleProducts :many
+-- Those of the given products the coupon applies to: all of them for a
+-- coupon without restrictions, otherwise the listed products and those in
+-- the listed categories or below them.
+WITH RECURSIVE tree AS (
+    SELECT category_id AS id FROM coupon_categories WHERE coupon_id = $1
+    UNION
+    SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT p.id FROM products p
+WHERE p.id = ANY($2::uuid[])
+  AND (
+      (NOT EXISTS (SELECT 1 FROM coupon_products WHERE coupon_id = $1)
+          AND NOT EXISTS (SELECT 1 FROM coupon_categories WHERE coupon_id = $1))
+      OR p.id IN (SELECT product_id FROM coupon_products WHERE coupon_id = $1)
+      OR p.category_id IN (SELECT id FROM tree)
+  );
+
+-- name: CountUserCouponRedemptions :one
+SELECT COUNT(*) FROM coupon_redemptions
+WHERE coupon_id = $1 AND user_id = $2;
+
+-- name: IncrementCouponUse :exec
+UPDATE coupons SET used_count = used_count + 1
+WHERE id = $1;
+
+-- name: CreateCouponRedemption :exec
+INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: ReleaseCouponRedemption :exec
+-- Frees the coupon use of a cancelled or unpaid order, if it took one.
+WITH released AS (
+    DELETE FROM coupon_redemptions WHERE order_id = $1
+    RETURNING coupon_id
+)
+UPDATE coupons SET used_count = used_count - 1
+FROM released
+WHERE coupons.id = released.coupon_id;
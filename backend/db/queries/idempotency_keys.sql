@@ -0,0 +1,25 @@
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2 AND expires_at > NOW();
+
+-- name: ClaimIdempotencyKey :one
+-- Inserts the key, or takes over an expired one. Returns no row while an
+-- unexpired key exists (blocking until a concurrent claim commits).
+INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    response_body = NULL,
+    created_at = NOW(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW()
+RETURNING *;
+
+-- name: SaveIdempotencyResponse :exec
+UPDATE idempotency_keys
+SET response_body = $3
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND expires_at <= NOW();
@@ -0,0 +1,38 @@
+-- name: ListProductImages :many
+-- Images of the given products in display order.
+SELECT * FROM product_images
+WHERE product_id = ANY($1::uuid[])
+ORDER BY product_id, position, created_at;
+
+-- name: GetProductImage :one
+SELECT * FROM product_images
+WHERE id = $1 AND product_id = $2;
+
+-- name: CountProductImages :one
+SELECT COUNT(*) FROM product_images
+WHERE product_id = $1;
+
+-- name: CreateProductImage :one
+-- New images go after the product's existing ones.
+INSERT INTO product_images (id, product_id, storage_key, thumbnail_key, content_type, width, height, size_bytes, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+        (SELECT COALESCE(MAX(position) + 1, 0) FROM product_images WHERE product_id = $2))
+RETURNING *;
+
+-- name: UpdateProductImagePosition :exec
+UPDATE product_images SET position = $2
+WHERE id = $1;
+
+-- name: DeleteProductImage :execrows
+DELETE FROM product_images
+WHERE id = $1;
+
+-- name: SetProductImageURL :exec
+UPDATE products SET image_url = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetProductImageURLForUpdate :one
+-- Locks a live product while its images change.
+SELECT image_url FROM products
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
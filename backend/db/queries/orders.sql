@@ -1,11 +1,14 @@
 -- name: CreateOrder :one
-INSERT INTO orders (user_id, delivery_address, delivery_date, notes, payment_method, total_amount)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO orders (user_id, delivery_address, delivery_date, notes, payment_method, total_amount,
+                    coupon_id, coupon_code, discount_amount, promotion_discount,
+                    delivery_fee)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: CreateOrderItem :one
-INSERT INTO order_items (order_id, product_id, quantity, unit_price, total_price)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO order_items (order_id, product_id, variant_id, quantity, unit_price, total_price,
+                         personalisation, personalisation_price, allergens)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetOrderByID :one
@@ -27,9 +30,12 @@ SELECT COUNT(*) FROM orders WHERE user_id = $1;
 SELECT
     oi.*,
     p.name      AS product_name,
-    p.image_url AS product_image_url
+    p.image_url AS product_image_url,
+    v.sku       AS variant_sku,
+    v.options   AS variant_options
 FROM order_items oi
 JOIN products p ON p.id = oi.product_id
+JOIN product_variants v ON v.id = oi.variant_id
 WHERE oi.order_id = $1;
 
 -- name: UpdateOrderStatus :one
@@ -37,3 +43,28 @@ UPDATE orders
 SET status = $2, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
+
+-- name: AddOrderRefundedAmount :one
+UPDATE orders
+SET refunded_amount = refunded_amount + $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetOrderByIDForUpdate :one
+SELECT * FROM orders WHERE id = $1 FOR UPDATE;
+
+-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListOrderStatusHistory :many
+SELECT * FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetStatusBeforePartialRefund :one
+SELECT from_status FROM order_status_history
+WHERE order_id = $1 AND to_status = 'partially_refunded'
+ORDER BY created_at DESC
+LIMIT 1;
@@ -1,11 +1,11 @@
 -- name: CreateOTP :one
-INSERT INTO email_otps (user_id, otp_hash, expires_at)
-VALUES ($1, $2, $3)
+INSERT INTO email_otps (user_id, otp_hash, expires_at, purpose)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetLatestOTPByUserID :one
 SELECT * FROM email_otps
-WHERE user_id = $1
+WHERE user_id = $1 AND purpose = $2
 ORDER BY created_at DESC
 LIMIT 1;
 
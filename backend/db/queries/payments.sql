@@ -0,0 +1,38 @@
+-- name: CreatePayment :one
+INSERT INTO payments (order_id, provider, amount, currency)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetPaymentByID :one
+SELECT * FROM payments WHERE id = $1;
+
+-- name: GetLatestPaymentByOrderID :one
+SELECT * FROM payments
+WHERE order_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: UpdatePaymentIntent :one
+UPDATE payments
+SET provider_intent_id = $2, status = $3, failure_reason = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetPaymentByIDForUpdate :one
+SELECT * FROM payments WHERE id = $1 FOR UPDATE;
+
+-- name: GetPaymentByProviderIntentForUpdate :one
+SELECT * FROM payments
+WHERE provider = $1 AND provider_intent_id = $2
+FOR UPDATE;
+
+-- name: CreatePaymentEvent :one
+INSERT INTO payment_events (provider, event_id, event_type, payload, occurred_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING *;
+
+-- name: MarkPaymentEventProcessed :exec
+UPDATE payment_events
+SET payment_id = $2, outcome = $3, processed_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,50 @@
+-- name: CreateProductVariant :one
+INSERT INTO product_variants (product_id, sku, options, price, stock_quantity, position, is_active)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetProductVariant :one
+SELECT * FROM product_variants WHERE id = $1 AND product_id = $2;
+
+-- name: ListProductVariants :many
+SELECT * FROM product_variants
+WHERE product_id = ANY($1::uuid[])
+ORDER BY product_id, position, created_at;
+
+-- name: UpdateProductVariant :one
+UPDATE product_variants
+SET sku = $2, options = $3, price = $4, stock_quantity = $5, position = $6, is_active = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: GetVariantsForOrder :many
+-- Locks the variants being ordered; only purchasable ones are returned.
+SELECT v.id, v.product_id, p.name, v.options, v.price, v.stock_quantity, p.personalisation_options
+FROM product_variants v
+JOIN products p ON p.id = v.product_id
+WHERE v.id = ANY($1::uuid[])
+  AND v.is_active = TRUE
+  AND p.deleted_at IS NULL
+  AND p.is_active = TRUE
+ORDER BY v.id
+FOR UPDATE OF v;
+
+-- name: GetVariantStockForUpdate :one
+SELECT v.id, v.product_id, p.name, v.options, v.price, v.stock_quantity, p.personalisation_options
+FROM product_variants v
+JOIN products p ON p.id = v.product_id
+WHERE v.id = $1
+  AND v.is_active = TRUE
+  AND p.deleted_at IS NULL
+  AND p.is_active = TRUE
+FOR UPDATE OF v;
+
+-- name: DeductVariantStock :exec
+UPDATE product_variants
+SET stock_quantity = stock_quantity - $2
+WHERE id = $1 AND stock_quantity >= $2;
+
+-- name: RestoreVariantStock :exec
+UPDATE product_variants
+SET stock_quantity = stock_quantity + $2
+WHERE id = $1;
@@ -1,53 +1,255 @@
 -- name: CreateProduct :one
-INSERT INTO products (category_id, name, description, price, image_url, stock_quantity)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO products (category_id, name, description, image_url, is_active, personalisation_options, dietary_tags)
+VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7::text[], '{}'))
 RETURNING *;
 
 -- name: GetProductByID :one
-SELECT p.*, c.name AS category_name, c.slug AS category_slug
+-- price and stock_quantity summarise the active variants: the lowest price
+-- and the total stock.
+SELECT p.*, c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity
 FROM products p
 LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
 WHERE p.id = $1 AND p.deleted_at IS NULL;
 
 -- name: ListProducts :many
-SELECT p.*, c.name AS category_name, c.slug AS category_slug
+-- search is a to_tsquery() query in the english configuration; when set,
+-- only matching products are listed, best matches first unless sorted by
+-- price, with their name and description highlighted between chr(2) and
+-- chr(3).
+-- The price range applies to the lowest price, in_stock keeps products with
+-- available-to-promise stock, tags must all be present and sizes match the
+-- "size" option of an active variant. free_from lists allergen slugs the
+-- product must be declared free from. Empty arrays don't filter.
+SELECT p.*, c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity,
+       ts_headline('english', p.name, s.query,
+                   'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS name_headline,
+       ts_headline('english', p.description, s.query,
+                   'MaxFragments=2, MaxWords=20, MinWords=8, FragmentDelimiter=" … ", StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS description_headline
 FROM products p
 LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+LEFT JOIN LATERAL (SELECT to_tsquery('english', $5::text) AS query) s ON $5::text IS NOT NULL
 WHERE p.deleted_at IS NULL
   AND p.is_active = TRUE
-  AND ($1::uuid IS NULL OR p.category_id = $1)
+  AND ($1::uuid IS NULL OR p.category_id IN (
+      WITH RECURSIVE tree AS (
+          SELECT id FROM categories WHERE id = $1
+          UNION ALL
+          SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+      )
+      SELECT id FROM tree
+  ))
+  AND ($5::text IS NULL OR p.search_vector @@ s.query)
+  AND ($6::numeric IS NULL OR v.price >= $6)
+  AND ($7::numeric IS NULL OR v.price <= $7)
+  AND (NOT $8::bool OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.stock_quantity > COALESCE((
+            SELECT SUM(r.quantity) FROM stock_reservations r
+            WHERE r.variant_id = sv.id AND r.expires_at > NOW()
+        ), 0)
+  ))
+  AND (COALESCE(cardinality($9::text[]), 0) = 0 OR p.dietary_tags @> $9)
+  AND (COALESCE(cardinality($10::text[]), 0) = 0 OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.options->>'size' = ANY($10)
+  ))
+  AND (COALESCE(cardinality($11::text[]), 0) = 0 OR (
+      SELECT COUNT(*) FROM product_allergens pa
+      JOIN allergens a ON a.id = pa.allergen_id
+      WHERE pa.product_id = p.id AND pa.status = 'free_from' AND a.slug = ANY($11)
+  ) = cardinality($11))
 ORDER BY
-  CASE WHEN $2::text = 'price_asc'  THEN p.price END ASC,
-  CASE WHEN $2::text = 'price_desc' THEN p.price END DESC,
+  CASE WHEN $2::text = 'price_asc'  THEN v.price END ASC,
+  CASE WHEN $2::text = 'price_desc' THEN v.price END DESC,
+  ts_rank_cd(p.search_vector, s.query) DESC NULLS LAST,
   p.created_at DESC
 LIMIT $3 OFFSET $4;
 
 -- name: CountProducts :one
-SELECT COUNT(*) FROM products
-WHERE deleted_at IS NULL
-  AND is_active = TRUE
-  AND ($1::uuid IS NULL OR category_id = $1);
-
--- name: DeductProductStock :exec
-UPDATE products
-SET stock_quantity = stock_quantity - $2, updated_at = NOW()
-WHERE id = $1 AND stock_quantity >= $2;
+-- Takes the filters of ListProducts.
+SELECT COUNT(*)
+FROM products p
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+WHERE p.deleted_at IS NULL
+  AND p.is_active = TRUE
+  AND ($1::uuid IS NULL OR p.category_id IN (
+      WITH RECURSIVE tree AS (
+          SELECT id FROM categories WHERE id = $1
+          UNION ALL
+          SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+      )
+      SELECT id FROM tree
+  ))
+  AND ($2::text IS NULL OR p.search_vector @@ to_tsquery('english', $2::text))
+  AND ($3::numeric IS NULL OR v.price >= $3)
+  AND ($4::numeric IS NULL OR v.price <= $4)
+  AND (NOT $5::bool OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.stock_quantity > COALESCE((
+            SELECT SUM(r.quantity) FROM stock_reservations r
+            WHERE r.variant_id = sv.id AND r.expires_at > NOW()
+        ), 0)
+  ))
+  AND (COALESCE(cardinality($6::text[]), 0) = 0 OR p.dietary_tags @> $6)
+  AND (COALESCE(cardinality($7::text[]), 0) = 0 OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.options->>'size' = ANY($7)
+  ))
+  AND (COALESCE(cardinality($8::text[]), 0) = 0 OR (
+      SELECT COUNT(*) FROM product_allergens pa
+      JOIN allergens a ON a.id = pa.allergen_id
+      WHERE pa.product_id = p.id AND pa.status = 'free_from' AND a.slug = ANY($8)
+  ) = cardinality($8));
 
--- name: GetProductsForOrder :many
-SELECT id, name, price, stock_quantity
-FROM products
-WHERE id = ANY($1::uuid[])
-  AND deleted_at IS NULL
-  AND is_active = TRUE;
+-- name: ProductFacets :many
+-- Counts the products ListProducts would find per category, price bucket,
+-- dietary tag, size and allergen declared free from. Each facet ignores its own filter, so that a
+-- sidebar can show what choosing another value would find. Price buckets
+-- are numbered by width_bucket() over bounds: 0 is below bounds[1].
+WITH matched AS (
+    SELECT p.id, p.category_id, p.dietary_tags, v.price,
+           ($1::uuid IS NULL OR p.category_id IN (
+               WITH RECURSIVE tree AS (
+                   SELECT id FROM categories WHERE id = $1
+                   UNION ALL
+                   SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+               )
+               SELECT id FROM tree
+           )) AS in_category,
+           (($3::numeric IS NULL OR v.price >= $3)
+               AND ($4::numeric IS NULL OR v.price <= $4)) AS in_price,
+           (NOT $5::bool OR EXISTS (
+               SELECT 1 FROM product_variants sv
+               WHERE sv.product_id = p.id AND sv.is_active = TRUE
+                 AND sv.stock_quantity > COALESCE((
+                     SELECT SUM(r.quantity) FROM stock_reservations r
+                     WHERE r.variant_id = sv.id AND r.expires_at > NOW()
+                 ), 0)
+           )) AS in_stock,
+           (COALESCE(cardinality($6::text[]), 0) = 0 OR p.dietary_tags @> $6) AS has_tags,
+           (COALESCE(cardinality($7::text[]), 0) = 0 OR EXISTS (
+               SELECT 1 FROM product_variants sv
+               WHERE sv.product_id = p.id AND sv.is_active = TRUE
+                 AND sv.options->>'size' = ANY($7)
+           )) AS has_size,
+           (COALESCE(cardinality($9::text[]), 0) = 0 OR (
+               SELECT COUNT(*) FROM product_allergens pa
+               JOIN allergens a ON a.id = pa.allergen_id
+               WHERE pa.product_id = p.id AND pa.status = 'free_from' AND a.slug = ANY($9)
+           ) = cardinality($9)) AS is_free_from
+    FROM products p
+    CROSS JOIN LATERAL (
+        SELECT MIN(pv.price) AS price
+        FROM product_variants pv
+        WHERE pv.product_id = p.id AND pv.is_active = TRUE
+    ) v
+    WHERE p.deleted_at IS NULL
+      AND p.is_active = TRUE
+      AND ($2::text IS NULL OR p.search_vector @@ to_tsquery('english', $2::text))
+)
+SELECT 'category'::text AS facet, m.category_id::text AS value, c.name AS label, COUNT(*) AS count
+FROM matched m
+JOIN categories c ON c.id = m.category_id
+WHERE m.in_price AND m.in_stock AND m.has_tags AND m.has_size AND m.is_free_from
+GROUP BY m.category_id, c.name
+UNION ALL
+SELECT 'price', width_bucket(m.price, $8::numeric[])::text, NULL, COUNT(*)
+FROM matched m
+WHERE m.price IS NOT NULL AND m.in_category AND m.in_stock AND m.has_tags AND m.has_size AND m.is_free_from
+GROUP BY 2
+UNION ALL
+SELECT 'dietary_tag', t.tag, NULL, COUNT(*)
+FROM matched m
+CROSS JOIN unnest(m.dietary_tags) AS t(tag)
+WHERE m.in_category AND m.in_price AND m.in_stock AND m.has_size AND m.is_free_from
+GROUP BY t.tag
+UNION ALL
+SELECT 'size', s.size, NULL, COUNT(*)
+FROM matched m
+CROSS JOIN LATERAL (
+    SELECT DISTINCT pv.options->>'size' AS size
+    FROM product_variants pv
+    WHERE pv.product_id = m.id AND pv.is_active = TRUE AND pv.options->>'size' IS NOT NULL
+) s
+WHERE m.in_category AND m.in_price AND m.in_stock AND m.has_tags AND m.is_free_from
+GROUP BY s.size
+UNION ALL
+SELECT 'free_from', a.slug, a.name, COUNT(*)
+FROM matched m
+JOIN product_allergens pa ON pa.product_id = m.id AND pa.status = 'free_from'
+JOIN allergens a ON a.id = pa.allergen_id
+WHERE m.in_category AND m.in_price AND m.in_stock AND m.has_tags AND m.has_size
+GROUP BY a.slug, a.name
+ORDER BY 1, 4 DESC, 2;
 
 -- name: UpdateProduct :one
 UPDATE products
-SET name = $2, description = $3, price = $4, image_url = $5,
-    stock_quantity = $6, category_id = $7, is_active = $8, updated_at = NOW()
+SET name = $2, description = $3, image_url = $4, category_id = $5,
+    is_active = $6, personalisation_options = $7, dietary_tags = COALESCE($8::text[], '{}'), updated_at = NOW()
 WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
--- name: SoftDeleteProduct :exec
+-- name: SoftDeleteProduct :one
 UPDATE products
 SET deleted_at = NOW(), updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreProduct :one
+UPDATE products
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: GetProductByIDAdmin :one
+SELECT p.*, c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity
+FROM products p
+LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+WHERE p.id = $1;
+
+-- name: ListProductsAdmin :many
+-- status: NULL (all), 'active', 'inactive' or 'deleted'.
+SELECT p.*, c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity
+FROM products p
+LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+WHERE ($1::text IS NULL
+    OR ($1 = 'active'   AND p.deleted_at IS NULL AND p.is_active = TRUE)
+    OR ($1 = 'inactive' AND p.deleted_at IS NULL AND p.is_active = FALSE)
+    OR ($1 = 'deleted'  AND p.deleted_at IS NOT NULL))
+ORDER BY p.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountProductsAdmin :one
+SELECT COUNT(*) FROM products p
+WHERE ($1::text IS NULL
+    OR ($1 = 'active'   AND p.deleted_at IS NULL AND p.is_active = TRUE)
+    OR ($1 = 'inactive' AND p.deleted_at IS NULL AND p.is_active = FALSE)
+    OR ($1 = 'deleted'  AND p.deleted_at IS NOT NULL));
@@ -0,0 +1,68 @@
+-- name: ListPromotions :many
+SELECT * FROM promotions
+ORDER BY priority DESC, created_at;
+
+-- name: GetPromotionByID :one
+SELECT * FROM promotions
+WHERE id = $1;
+
+-- name: CreatePromotion :one
+INSERT INTO promotions (name, description, kind, priority, exclusive, category_id, product_id,
+                        buy_quantity, get_quantity, percent_off, min_order_amount,
+                        starts_at, ends_at, is_active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING *;
+
+-- name: UpdatePromotion :one
+UPDATE promotions
+SET name = $2, description = $3, kind = $4, priority = $5, exclusive = $6, category_id = $7,
+    product_id = $8, buy_quantity = $9, get_quantity = $10, percent_off = $11,
+    min_order_amount = $12, starts_at = $13, ends_at = $14, is_active = $15,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePromotion :execrows
+DELETE FROM promotions
+WHERE id = $1;
+
+-- name: ListActivePromotions :many
+-- The promotions running at $1, in the order they are applied, with the
+-- name of the category or product they are limited to.
+SELECT pm.*, COALESCE(c.name, p.name) AS scope_name
+FROM promotions pm
+LEFT JOIN categories c ON c.id = pm.category_id
+LEFT JOIN products p ON p.id = pm.product_id
+WHERE pm.is_active
+  AND (pm.starts_at IS NULL OR pm.starts_at <= $1)
+  AND (pm.ends_at IS NULL OR pm.ends_at > $1)
+ORDER BY pm.priority DESC, pm.created_at;
+
+-- name: ListPromotionEligibleProducts :many
+-- Pairs each promotion with those of the given products it applies to:
+-- all of them for a promotion without a category or product, otherwise
+-- the product or those in the category or below it.
+WITH RECURSIVE tree AS (
+    SELECT id AS promotion_id, category_id AS id FROM promotions
+    WHERE id = ANY($1::uuid[]) AND category_id IS NOT NULL
+    UNION
+    SELECT t.promotion_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT pm.id AS promotion_id, p.id AS product_id
+FROM promotions pm
+JOIN products p ON p.id = ANY($2::uuid[])
+WHERE pm.id = ANY($1::uuid[])
+  AND (
+      (pm.category_id IS NULL AND pm.product_id IS NULL)
+      OR pm.product_id = p.id
+      OR EXISTS (SELECT 1 FROM tree t WHERE t.promotion_id = pm.id AND t.id = p.category_id)
+  );
+
+-- name: CreateOrderPromotion :exec
+INSERT INTO order_promotions (order_id, promotion_id, name, detail, discount_amount, position)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListOrderPromotions :many
+SELECT * FROM order_promotions
+WHERE order_id = $1
+ORDER BY position;
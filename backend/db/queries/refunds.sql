@@ -0,0 +1,44 @@
+-- name: CreateRefund :one
+INSERT INTO refunds (order_id, payment_id, amount, reason, restock, actor_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: CreateRefundItem :exec
+INSERT INTO refund_items (refund_id, order_item_id, quantity, amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: UpdateRefundStatus :one
+UPDATE refunds
+SET status = $2, provider_refund_id = $3, failure_reason = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetRefundByIDForUpdate :one
+SELECT * FROM refunds WHERE id = $1 FOR UPDATE;
+
+-- name: ListPendingRefunds :many
+SELECT * FROM refunds
+WHERE status = 'pending' AND updated_at < $1
+ORDER BY created_at ASC
+LIMIT $2;
+
+-- name: ListRefundsByOrderID :many
+SELECT * FROM refunds
+WHERE order_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListRefundItems :many
+SELECT ri.*, oi.product_id, oi.variant_id
+FROM refund_items ri
+JOIN order_items oi ON oi.id = ri.order_item_id
+WHERE ri.refund_id = $1;
+
+-- name: SumOrderRefunds :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC(12, 2)
+FROM refunds
+WHERE order_id = $1 AND status IN ('pending', 'succeeded');
+
+-- name: AddOrderItemRefundedQuantity :execrows
+UPDATE order_items
+SET refunded_quantity = refunded_quantity + $2
+WHERE id = $1 AND refunded_quantity + $2 BETWEEN 0 AND quantity;
@@ -0,0 +1,27 @@
+-- name: UpsertStockReservation :exec
+INSERT INTO stock_reservations (cart_id, variant_id, quantity, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cart_id, variant_id)
+DO UPDATE SET quantity = EXCLUDED.quantity, expires_at = EXCLUDED.expires_at;
+
+-- name: ExtendCartReservations :exec
+UPDATE stock_reservations
+SET expires_at = $2
+WHERE cart_id = $1 AND expires_at > NOW();
+
+-- name: DeleteStockReservation :exec
+DELETE FROM stock_reservations WHERE cart_id = $1 AND variant_id = $2;
+
+-- name: DeleteCartReservations :exec
+DELETE FROM stock_reservations WHERE cart_id = $1;
+
+-- name: DeleteExpiredReservations :execrows
+DELETE FROM stock_reservations WHERE expires_at <= NOW();
+
+-- name: SumActiveReservations :many
+SELECT variant_id, SUM(quantity)::INT AS reserved
+FROM stock_reservations
+WHERE variant_id = ANY($1::uuid[])
+  AND expires_at > NOW()
+  AND cart_id <> $2
+GROUP BY variant_id;
@@ -0,0 +1,24 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, family_id, token_hash, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetSessionByTokenHashForUpdate :one
+SELECT * FROM sessions
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: MarkSessionRotated :exec
+UPDATE sessions
+SET rotated_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
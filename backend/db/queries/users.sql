@@ -25,3 +25,19 @@ RETURNING *;
 UPDATE users
 SET deleted_at = NOW(), updated_at = NOW()
 WHERE id = $1;
+
+-- name: GetUserTokenVersion :one
+SELECT token_version FROM users
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: IncrementUserTokenVersion :one
+UPDATE users
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING token_version;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
@@ -0,0 +1,107 @@
+-- name: ListWishlists :many
+SELECT w.id, w.user_id, w.name, w.share_token, w.created_at, w.updated_at,
+       (SELECT COUNT(*) FROM wishlist_items wi WHERE wi.wishlist_id = w.id) AS item_count
+FROM wishlists w
+WHERE w.user_id = $1
+ORDER BY w.created_at;
+
+-- name: CountWishlists :one
+SELECT COUNT(*) FROM wishlists WHERE user_id = $1;
+
+-- name: LockUserWishlists :exec
+-- Serialises list creation for a user so the list limit holds.
+SELECT id FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: CreateWishlist :one
+INSERT INTO wishlists (user_id, name)
+VALUES ($1, $2)
+RETURNING id, user_id, name, share_token, created_at, updated_at;
+
+-- name: GetWishlist :one
+SELECT id, user_id, name, share_token, created_at, updated_at
+FROM wishlists
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetWishlistForUpdate :one
+SELECT id, user_id, name, share_token, created_at, updated_at
+FROM wishlists
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: GetWishlistByShareToken :one
+SELECT id, user_id, name, share_token, created_at, updated_at
+FROM wishlists
+WHERE share_token = $1;
+
+-- name: RenameWishlist :one
+UPDATE wishlists
+SET name = $3
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, name, share_token, created_at, updated_at;
+
+-- name: SetWishlistShareToken :one
+UPDATE wishlists
+SET share_token = $3
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, name, share_token, created_at, updated_at;
+
+-- name: DeleteWishlist :execrows
+DELETE FROM wishlists WHERE id = $1 AND user_id = $2;
+
+-- name: ListWishlistItems :many
+-- Items of products or variants no longer sold are returned flagged as
+-- unavailable.
+SELECT
+    wi.id,
+    wi.wishlist_id,
+    wi.product_id,
+    wi.variant_id,
+    wi.personalisation,
+    wi.quantity,
+    wi.created_at,
+    p.name                    AS product_name,
+    p.image_url               AS product_image_url,
+    v.price                   AS variant_price,
+    v.sku                     AS variant_sku,
+    v.options                 AS variant_options,
+    p.personalisation_options AS product_personalisation_options,
+    (p.deleted_at IS NOT NULL OR NOT p.is_active OR NOT v.is_active) AS unavailable
+FROM wishlist_items wi
+JOIN products p ON p.id = wi.product_id
+JOIN product_variants v ON v.id = wi.variant_id
+WHERE wi.wishlist_id = $1
+ORDER BY wi.created_at;
+
+-- name: CountWishlistItems :one
+SELECT COUNT(*) FROM wishlist_items WHERE wishlist_id = $1;
+
+-- name: WishlistItemExists :one
+SELECT EXISTS (
+    SELECT 1 FROM wishlist_items
+    WHERE wishlist_id = $1 AND variant_id = $2 AND personalisation = $3
+);
+
+-- name: GetWishlistVariant :one
+-- A variant that can be added to a wishlist: one still sold.
+SELECT v.id, v.product_id, p.personalisation_options
+FROM product_variants v
+JOIN products p ON p.id = v.product_id
+WHERE v.id = $1
+  AND v.is_active = TRUE
+  AND p.deleted_at IS NULL
+  AND p.is_active = TRUE;
+
+-- name: UpsertWishlistItem :one
+INSERT INTO wishlist_items (wishlist_id, product_id, variant_id, personalisation, quantity)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (wishlist_id, variant_id, personalisation)
+DO UPDATE SET quantity = $5
+RETURNING id, wishlist_id, product_id, variant_id, personalisation, quantity, created_at, updated_at;
+
+-- name: GetWishlistItem :one
+SELECT id, wishlist_id, product_id, variant_id, personalisation, quantity, created_at, updated_at
+FROM wishlist_items
+WHERE id = $1 AND wishlist_id = $2;
+
+-- name: DeleteWishlistItem :execrows
+DELETE FROM wishlist_items WHERE id = $1 AND wishlist_id = $2;
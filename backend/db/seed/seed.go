@@ -8,12 +8,15 @@ import (
 	"log"
 	"math/big"
 	"os"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 
 	"github.com/online-cake-shop/backend/internal/config"
+	"github.com/online-cake-shop/backend/internal/domain"
 	"github.com/online-cake-shop/backend/internal/repository/db"
 )
 
@@ -40,6 +43,33 @@ func main() {
 	q := db.New(pool)
 	ctx := context.Background()
 
+	fmt.Println("Seeding admin user...")
+	adminEmail := os.Getenv("SEED_ADMIN_EMAIL")
+	if adminEmail == "" {
+		adminEmail = "admin@cakeshop.com"
+	}
+	admin, err := q.GetUserByEmail(ctx, adminEmail)
+	if err != nil {
+		admin, err = q.CreateUser(ctx, db.CreateUserParams{
+			FirstName:    "Shop",
+			LastName:     "Admin",
+			PhoneNumber:  "+10000000000",
+			EmailAddress: adminEmail,
+		})
+	}
+	if err != nil {
+		log.Printf("  skip admin user %s: %v", adminEmail, err)
+	} else {
+		if _, err := q.MarkUserVerified(ctx, admin.ID); err != nil {
+			log.Printf("  verify admin user: %v", err)
+		}
+		if _, err := q.UpdateUserRole(ctx, db.UpdateUserRoleParams{ID: admin.ID, Role: domain.RoleAdmin}); err != nil {
+			log.Printf("  set admin role: %v", err)
+		} else {
+			fmt.Printf("  admin user: %s (sign in via /auth/login)\n", adminEmail)
+		}
+	}
+
 	fmt.Println("Seeding categories...")
 	cats := []db.CreateCategoryParams{
 		{Name: "Birthday Cakes", Slug: "birthday-cakes"},
@@ -61,97 +91,168 @@ func main() {
 		categoryMap[c.Slug] = cat
 	}
 
+	tiered := db.CreateCategoryParams{
+		Name:     "Tiered Wedding Cakes",
+		Slug:     "tiered-wedding-cakes",
+		ParentID: pgtype.UUID{Bytes: categoryMap["wedding-cakes"].ID, Valid: true},
+	}
+	if cat, err := q.CreateCategory(ctx, tiered); err != nil {
+		log.Printf("  skip category %s (already exists?): %v", tiered.Name, err)
+	} else {
+		fmt.Printf("  created category: %s\n", cat.Name)
+	}
+
 	fmt.Println("Seeding products...")
+	// Products without Variants are sold as a single variant of Price and
+	// StockQuantity.
+	type variant struct {
+		SKU           string
+		Options       string // JSON object
+		Price         string
+		StockQuantity int32
+	}
 	products := []struct {
 		CategorySlug  string
 		Name          string
 		Description   string
-		Price         float64
+		Price         string
 		ImageURL      string
 		StockQuantity int32
+		Variants      []variant
+		// Personalisation is the JSON object of personalisation options;
+		// empty offers none.
+		Personalisation string
+		DietaryTags     []string
+		// Allergens maps allergen slugs to what the product declares.
+		Allergens map[string]string
 	}{
 		{
-			CategorySlug:  "birthday-cakes",
-			Name:          "Classic Chocolate Birthday Cake",
-			Description:   "Rich triple-layer chocolate cake with smooth ganache frosting and colorful sprinkles. Perfect for any birthday celebration.",
-			Price:         45.00,
-			ImageURL:      "https://images.unsplash.com/photo-1578985545062-69928b1d9587?w=800",
-			StockQuantity: 20,
+			CategorySlug: "birthday-cakes",
+			Name:         "Classic Chocolate Birthday Cake",
+			Description:  "Rich triple-layer chocolate cake with smooth ganache frosting and colorful sprinkles. Perfect for any birthday celebration.",
+			ImageURL:     "https://images.unsplash.com/photo-1578985545062-69928b1d9587?w=800",
+			Variants: []variant{
+				{SKU: "CHOC-BDAY-6", Options: `{"size": "6\""}`, Price: "35.00", StockQuantity: 8},
+				{SKU: "CHOC-BDAY-8", Options: `{"size": "8\""}`, Price: "45.00", StockQuantity: 8},
+				{SKU: "CHOC-BDAY-10", Options: `{"size": "10\""}`, Price: "60.00", StockQuantity: 4},
+			},
+			Personalisation: `{"inscription": {"max_length": 30, "price": 3.00}, "candles": {"max": 20, "price_each": 0.25}, "topper": {"choices": [{"name": "Happy Birthday", "price": 4.50}, {"name": "Number", "price": 3.50}]}, "gift_box": {"price": 5.00}}`,
+			Allergens: map[string]string{
+				"eggs": domain.AllergenContains, "gluten": domain.AllergenContains, "milk": domain.AllergenContains, "soya": domain.AllergenContains,
+				"peanuts": domain.AllergenMayContain, "tree-nuts": domain.AllergenMayContain,
+			},
 		},
 		{
-			CategorySlug:  "birthday-cakes",
-			Name:          "Strawberry Dream Birthday Cake",
-			Description:   "Light vanilla sponge layered with fresh strawberries and whipped cream frosting. A summer favorite.",
-			Price:         42.00,
-			ImageURL:      "https://images.unsplash.com/photo-1565958011703-44f9829ba187?w=800",
-			StockQuantity: 15,
+			CategorySlug:    "birthday-cakes",
+			Name:            "Strawberry Dream Birthday Cake",
+			Description:     "Light vanilla sponge layered with fresh strawberries and whipped cream frosting. A summer favorite.",
+			Price:           "42.00",
+			ImageURL:        "https://images.unsplash.com/photo-1565958011703-44f9829ba187?w=800",
+			StockQuantity:   15,
+			Personalisation: `{"inscription": {"max_length": 30, "price": 3.00}, "candles": {"max": 20, "price_each": 0.25}, "topper": {"choices": [{"name": "Happy Birthday", "price": 4.50}, {"name": "Number", "price": 3.50}]}, "gift_box": {"price": 5.00}}`,
+			DietaryTags:     []string{"vegetarian"},
+			Allergens: map[string]string{
+				"eggs": domain.AllergenContains, "gluten": domain.AllergenContains, "milk": domain.AllergenContains,
+			},
 		},
 		{
-			CategorySlug:  "wedding-cakes",
-			Name:          "Elegant White Wedding Cake",
-			Description:   "Five-tier fondant-covered wedding cake with delicate floral decorations and pearl details. Customizable flavors.",
-			Price:         350.00,
-			ImageURL:      "https://images.unsplash.com/photo-1535254973040-607b474cb50d?w=800",
-			StockQuantity: 5,
+			CategorySlug: "wedding-cakes",
+			Name:         "Elegant White Wedding Cake",
+			Description:  "Three- or five-tier fondant-covered wedding cake with delicate floral decorations and pearl details. Customizable flavors.",
+			ImageURL:     "https://images.unsplash.com/photo-1535254973040-607b474cb50d?w=800",
+			Variants: []variant{
+				{SKU: "WHITE-WED-3T-VAN", Options: `{"tiers": "3", "flavour": "vanilla"}`, Price: "250.00", StockQuantity: 3},
+				{SKU: "WHITE-WED-3T-LEM", Options: `{"tiers": "3", "flavour": "lemon"}`, Price: "260.00", StockQuantity: 2},
+				{SKU: "WHITE-WED-5T-VAN", Options: `{"tiers": "5", "flavour": "vanilla"}`, Price: "350.00", StockQuantity: 2},
+			},
+			Personalisation: `{"inscription": {"max_length": 40, "price": 5.00}, "topper": {"choices": [{"name": "Mr & Mrs", "price": 12.00}, {"name": "Floral Heart", "price": 9.00}]}}`,
 		},
 		{
 			CategorySlug:  "wedding-cakes",
 			Name:          "Rustic Naked Wedding Cake",
 			Description:   "Three-tier semi-naked cake with fresh flowers and berries. A boho-chic option for modern weddings.",
-			Price:         280.00,
+			Price:         "280.00",
 			ImageURL:      "https://images.unsplash.com/photo-1519671282429-b44b4b0d9b13?w=800",
 			StockQuantity: 8,
+			DietaryTags:   []string{"vegetarian"},
 		},
 		{
-			CategorySlug:  "custom-cakes",
-			Name:          "Princess Castle Cake",
-			Description:   "A magical multi-tier castle cake with edible towers, a drawbridge, and sparkle dust. Makes dreams come true.",
-			Price:         95.00,
-			ImageURL:      "https://images.unsplash.com/photo-1558636508-e0969431f628?w=800",
-			StockQuantity: 10,
+			CategorySlug:    "custom-cakes",
+			Name:            "Princess Castle Cake",
+			Description:     "A magical multi-tier castle cake with edible towers, a drawbridge, and sparkle dust. Makes dreams come true.",
+			Price:           "95.00",
+			ImageURL:        "https://images.unsplash.com/photo-1558636508-e0969431f628?w=800",
+			StockQuantity:   10,
+			Personalisation: `{"inscription": {"max_length": 25, "price": 3.00}, "candles": {"max": 12, "price_each": 0.25}}`,
 		},
 		{
 			CategorySlug:  "custom-cakes",
 			Name:          "Galaxy Space Cake",
 			Description:   "Stunning galaxy-themed cake with deep blue, purple, and silver swirls. Stars and edible glitter included.",
-			Price:         75.00,
+			Price:         "75.00",
 			ImageURL:      "https://images.unsplash.com/photo-1563729784474-d77dbb933a9e?w=800",
 			StockQuantity: 12,
+			DietaryTags:   []string{"dairy-free", "vegan", "vegetarian"},
+			Allergens: map[string]string{
+				"gluten": domain.AllergenContains, "soya": domain.AllergenContains,
+				"eggs": domain.AllergenFreeFrom, "milk": domain.AllergenFreeFrom,
+			},
 		},
 		{
-			CategorySlug:  "cheesecakes",
-			Name:          "New York Style Cheesecake",
-			Description:   "Classic dense and creamy New York cheesecake with a buttery graham cracker crust. A timeless dessert.",
-			Price:         38.00,
-			ImageURL:      "https://images.unsplash.com/photo-1533134242443-d4fd215305ad?w=800",
-			StockQuantity: 25,
+			CategorySlug:    "cheesecakes",
+			Name:            "New York Style Cheesecake",
+			Description:     "Classic dense and creamy New York cheesecake with a buttery graham cracker crust. A timeless dessert.",
+			Price:           "38.00",
+			ImageURL:        "https://images.unsplash.com/photo-1533134242443-d4fd215305ad?w=800",
+			StockQuantity:   25,
+			Personalisation: `{"gift_box": {"price": 4.00}}`,
 		},
 		{
 			CategorySlug:  "cheesecakes",
 			Name:          "Blueberry Swirl Cheesecake",
 			Description:   "Creamy cheesecake with a gorgeous blueberry compote swirl and fresh blueberry topping.",
-			Price:         42.00,
+			Price:         "42.00",
 			ImageURL:      "https://images.unsplash.com/photo-1571115177098-24ec42ed204d?w=800",
 			StockQuantity: 18,
+			DietaryTags:   []string{"gluten-free", "vegetarian"},
+			Allergens: map[string]string{
+				"eggs": domain.AllergenContains, "milk": domain.AllergenContains,
+				"gluten": domain.AllergenFreeFrom,
+			},
 		},
 		{
 			CategorySlug:  "cupcakes",
 			Name:          "Dozen Assorted Cupcakes",
 			Description:   "A dozen delicious cupcakes in assorted flavors: chocolate, vanilla, red velvet, and lemon. Perfect for parties.",
-			Price:         28.00,
+			Price:         "28.00",
 			ImageURL:      "https://images.unsplash.com/photo-1486427944299-d1955d23e34d?w=800",
 			StockQuantity: 30,
+			DietaryTags:   []string{"nut-free", "vegetarian"},
+			Allergens: map[string]string{
+				"eggs": domain.AllergenContains, "gluten": domain.AllergenContains, "milk": domain.AllergenContains,
+				"peanuts": domain.AllergenFreeFrom, "tree-nuts": domain.AllergenFreeFrom,
+			},
 		},
 		{
 			CategorySlug:  "cupcakes",
 			Name:          "Mini Wedding Cupcake Tower",
 			Description:   "An elegant tower of 36 beautifully decorated mini cupcakes, an alternative to a traditional wedding cake.",
-			Price:         120.00,
+			Price:         "120.00",
 			ImageURL:      "https://images.unsplash.com/photo-1576618148400-f54bed99fcfd?w=800",
 			StockQuantity: 10,
 		},
 	}
 
+	// The allergens themselves are seeded by their migration.
+	allergens, err := q.ListAllergens(ctx)
+	if err != nil {
+		log.Fatalf("list allergens: %v", err)
+	}
+	allergenIDs := make(map[string]uuid.UUID, len(allergens))
+	for _, a := range allergens {
+		allergenIDs[a.Slug] = a.ID
+	}
+
 	for _, p := range products {
 		cat := categoryMap[p.CategorySlug]
 
@@ -160,22 +261,66 @@ func main() {
 		desc := pgtype.Text{String: p.Description, Valid: true}
 		imgURL := pgtype.Text{String: p.ImageURL, Valid: true}
 
-		// Price as NUMERIC
-		scaledPrice := int64(p.Price * 100)
-		priceNumeric := pgtype.Numeric{Int: big.NewInt(scaledPrice), Exp: -2, Valid: true}
+		personalisation := p.Personalisation
+		if personalisation == "" {
+			personalisation = "{}"
+		}
 
 		product, err := q.CreateProduct(ctx, db.CreateProductParams{
-			CategoryID:    catID,
-			Name:          p.Name,
-			Description:   desc,
-			Price:         priceNumeric,
-			ImageUrl:      imgURL,
-			StockQuantity: p.StockQuantity,
+			CategoryID:             catID,
+			Name:                   p.Name,
+			Description:            desc,
+			ImageUrl:               imgURL,
+			IsActive:               true,
+			PersonalisationOptions: []byte(personalisation),
+			DietaryTags:            p.DietaryTags,
 		})
 		if err != nil {
 			log.Printf("  skip product %s: %v", p.Name, err)
-		} else {
-			fmt.Printf("  created product: %s ($%.2f)\n", product.Name, p.Price)
+			continue
+		}
+		fmt.Printf("  created product: %s\n", product.Name)
+
+		for slug, status := range p.Allergens {
+			id, ok := allergenIDs[slug]
+			if !ok {
+				log.Fatalf("  unknown allergen %s for %s", slug, p.Name)
+			}
+			if err := q.CreateProductAllergen(ctx, db.CreateProductAllergenParams{
+				ProductID:  product.ID,
+				AllergenID: id,
+				Status:     status,
+			}); err != nil {
+				log.Printf("    skip allergen %s: %v", slug, err)
+			}
+		}
+
+		variants := p.Variants
+		if len(variants) == 0 {
+			sku := "SKU-" + strings.ToUpper(strings.ReplaceAll(product.ID.String(), "-", "")[:12])
+			variants = []variant{{SKU: sku, Options: "{}", Price: p.Price, StockQuantity: p.StockQuantity}}
+		}
+		for i, v := range variants {
+			// Price as NUMERIC
+			price, err := domain.ParseMoney(v.Price)
+			if err != nil {
+				log.Fatalf("  bad price for %s: %v", v.SKU, err)
+			}
+			priceNumeric := pgtype.Numeric{Int: big.NewInt(price.Cents()), Exp: -2, Valid: true}
+
+			if _, err := q.CreateProductVariant(ctx, db.CreateProductVariantParams{
+				ProductID:     product.ID,
+				Sku:           v.SKU,
+				Options:       []byte(v.Options),
+				Price:         priceNumeric,
+				StockQuantity: v.StockQuantity,
+				Position:      int32(i),
+				IsActive:      true,
+			}); err != nil {
+				log.Printf("    skip variant %s: %v", v.SKU, err)
+			} else {
+				fmt.Printf("    variant %s ($%s)\n", v.SKU, price)
+			}
 		}
 	}
 
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/online-cake-shop/backend/internal/domain"
 )
 
 type Config struct {
@@ -13,6 +15,10 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Email    EmailConfig
+	Payment  PaymentConfig
+	Cart     CartConfig
+	Order    OrderConfig
+	Storage  StorageConfig
 }
 
 type ServerConfig struct {
@@ -45,8 +51,9 @@ func (d DatabaseConfig) DSN() string {
 }
 
 type JWTConfig struct {
-	Secret         string
-	AccessTokenTTL time.Duration
+	Secret          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
 }
 
 type EmailConfig struct {
@@ -58,14 +65,70 @@ type EmailConfig struct {
 	SMTPPass string
 }
 
+type PaymentConfig struct {
+	Provider          string
+	Currency          string
+	MockWebhookSecret string
+}
+
+type CartConfig struct {
+	// ReservationTTL is how long cart lines hold stock after the cart was
+	// last changed.
+	ReservationTTL time.Duration
+	// GuestCartSecret signs the cookie that identifies a guest's cart.
+	GuestCartSecret string
+	// GuestCartTTL is how long a guest cart is kept after it was last used.
+	GuestCartTTL time.Duration
+}
+
+type OrderConfig struct {
+	// DeliveryFee is charged on every order unless a free delivery
+	// promotion waives it.
+	DeliveryFee domain.Money
+}
+
+type StorageConfig struct {
+	// Dir is where uploaded images are kept on local disk.
+	Dir string
+	// ImageBaseURL prefixes image keys to form the URLs they are served at.
+	ImageBaseURL string
+	// MaxImageBytes is the largest image upload accepted.
+	MaxImageBytes int64
+}
+
 func Load() (*Config, error) {
-	jwtTTL, err := time.ParseDuration(getEnv("JWT_ACCESS_TOKEN_TTL", "24h"))
+	jwtTTL, err := time.ParseDuration(getEnv("JWT_ACCESS_TOKEN_TTL", "15m"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT_ACCESS_TOKEN_TTL: %w", err)
 	}
 
+	refreshTTL, err := time.ParseDuration(getEnv("JWT_REFRESH_TOKEN_TTL", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_TOKEN_TTL: %w", err)
+	}
+
+	reservationTTL, err := time.ParseDuration(getEnv("CART_RESERVATION_TTL", "15m"))
+	if err != nil || reservationTTL <= 0 {
+		return nil, fmt.Errorf("invalid CART_RESERVATION_TTL: %q", getEnv("CART_RESERVATION_TTL", "15m"))
+	}
+
+	guestCartTTL, err := time.ParseDuration(getEnv("GUEST_CART_TTL", "720h"))
+	if err != nil || guestCartTTL <= 0 {
+		return nil, fmt.Errorf("invalid GUEST_CART_TTL: %q", getEnv("GUEST_CART_TTL", "720h"))
+	}
+
+	deliveryFee, err := domain.ParseMoney(getEnv("DELIVERY_FEE", "0"))
+	if err != nil || deliveryFee.IsNegative() {
+		return nil, fmt.Errorf("invalid DELIVERY_FEE: %q", getEnv("DELIVERY_FEE", "0"))
+	}
+
 	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
 
+	maxImageMB, err := strconv.Atoi(getEnv("MAX_IMAGE_UPLOAD_MB", "5"))
+	if err != nil || maxImageMB <= 0 {
+		return nil, fmt.Errorf("invalid MAX_IMAGE_UPLOAD_MB: %q", getEnv("MAX_IMAGE_UPLOAD_MB", "5"))
+	}
+
 	originsRaw := getEnv("ALLOWED_ORIGINS", "http://localhost:5173")
 	origins := strings.Split(originsRaw, ",")
 	for i := range origins {
@@ -87,8 +150,9 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:         getEnv("JWT_SECRET", "change-this-secret-in-production-use-at-least-32-chars"),
-			AccessTokenTTL: jwtTTL,
+			Secret:          getEnv("JWT_SECRET", "change-this-secret-in-production-use-at-least-32-chars"),
+			AccessTokenTTL:  jwtTTL,
+			RefreshTokenTTL: refreshTTL,
 		},
 		Email: EmailConfig{
 			Provider: getEnv("EMAIL_PROVIDER", "mock"),
@@ -98,6 +162,24 @@ func Load() (*Config, error) {
 			SMTPUser: getEnv("SMTP_USER", ""),
 			SMTPPass: getEnv("SMTP_PASS", ""),
 		},
+		Payment: PaymentConfig{
+			Provider:          getEnv("PAYMENT_PROVIDER", "mock"),
+			Currency:          strings.ToUpper(getEnv("PAYMENT_CURRENCY", "USD")),
+			MockWebhookSecret: getEnv("MOCK_PAYMENT_WEBHOOK_SECRET", "whsec_mock_change_me"),
+		},
+		Cart: CartConfig{
+			ReservationTTL:  reservationTTL,
+			GuestCartSecret: getEnv("GUEST_CART_SECRET", "change-this-guest-cart-secret-too"),
+			GuestCartTTL:    guestCartTTL,
+		},
+		Order: OrderConfig{
+			DeliveryFee: deliveryFee,
+		},
+		Storage: StorageConfig{
+			Dir:           getEnv("STORAGE_DIR", "./uploads"),
+			ImageBaseURL:  strings.TrimRight(getEnv("IMAGE_BASE_URL", "/api/v1/images"), "/"),
+			MaxImageBytes: int64(maxImageMB) << 20,
+		},
 	}, nil
 }
 
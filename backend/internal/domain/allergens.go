@@ -0,0 +1,19 @@
+package domain
+
+// What a product declares about an allergen. An allergen a product doesn't
+// declare is unknown, not absent.
+const (
+	AllergenContains   = "contains"
+	AllergenMayContain = "may_contain"
+	AllergenFreeFrom   = "free_from"
+)
+
+// IsValidAllergenStatus reports whether status is one of the known
+// declarations.
+func IsValidAllergenStatus(status string) bool {
+	switch status {
+	case AllergenContains, AllergenMayContain, AllergenFreeFrom:
+		return true
+	}
+	return false
+}
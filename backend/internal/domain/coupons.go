@@ -0,0 +1,16 @@
+package domain
+
+// How a coupon discounts the items it applies to.
+const (
+	CouponTypePercentage = "percentage"
+	CouponTypeFixed      = "fixed"
+)
+
+// IsValidCouponType reports whether t is one of the known coupon types.
+func IsValidCouponType(t string) bool {
+	switch t {
+	case CouponTypePercentage, CouponTypeFixed:
+		return true
+	}
+	return false
+}
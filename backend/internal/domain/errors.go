@@ -16,6 +16,9 @@ var (
 	ErrRateLimitExceeded = errors.New("rate limit exceeded, please try again later")
 	ErrInsufficientStock = errors.New("insufficient stock for one or more items")
 	ErrEmptyCart         = errors.New("cart is empty")
+	ErrPayloadTooLarge   = errors.New("request body is too large")
+
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
 )
 
 // AppError wraps a sentinel error with an optional human-readable message.
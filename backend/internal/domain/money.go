@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the shop's currency, held as an integer number of
+// minor units (cents) so that sums and products are exact.
+//
+// Rounding rules:
+//   - Parsing (ParseMoney, JSON) never rounds: input with more than two
+//     significant decimal places is rejected.
+//   - Converting a decimal with more precision (MoneyFromDecimal) and
+//     fractional arithmetic (MulFrac) round half away from zero, so 0.125
+//     becomes 0.13 and -0.125 becomes -0.13.
+//   - Add, Sub and Mul are exact.
+type Money int64
+
+const moneyScale = 100
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// ParseMoney parses a plain decimal such as "12", "12.5" or "-0.99".
+func ParseMoney(s string) (Money, error) {
+	digits := s
+	neg := strings.HasPrefix(digits, "-")
+	if neg {
+		digits = digits[1:]
+	}
+
+	whole, frac, hasDot := strings.Cut(digits, ".")
+	if whole == "" || (hasDot && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("%w: %q has more than two decimal places", ErrInvalidMoney, s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+	}
+	f, _ := strconv.ParseInt(frac, 10, 64)
+	if w > (math.MaxInt64-f)/moneyScale {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+	}
+
+	cents := w*moneyScale + f
+	if neg {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// MoneyFromDecimal converts unscaled × 10^exp (the representation used by
+// SQL NUMERIC values) to Money, rounding half away from zero to cents.
+func MoneyFromDecimal(unscaled *big.Int, exp int32) (Money, error) {
+	if unscaled == nil {
+		return 0, nil
+	}
+
+	n := new(big.Int).Set(unscaled)
+	shift := int64(exp) + 2 // cents are 10^-2
+	if shift >= 0 {
+		n.Mul(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(shift), nil))
+	} else {
+		n = divRoundHalfAway(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(-shift), nil))
+	}
+
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidMoney)
+	}
+	return Money(n.Int64()), nil
+}
+
+// Cents returns the amount in minor units.
+func (m Money) Cents() int64 { return int64(m) }
+
+func (m Money) Add(o Money) Money { return m + o }
+
+func (m Money) Sub(o Money) Money { return m - o }
+
+// Mul multiplies by a whole quantity, e.g. a unit price by a line quantity.
+func (m Money) Mul(n int64) Money { return m * Money(n) }
+
+// MulFrac multiplies by num/den, rounding half away from zero. Use it for
+// percentages: price.MulFrac(15, 100) is 15% of price.
+func (m Money) MulFrac(num, den int64) Money {
+	if den == 0 {
+		panic("domain: Money.MulFrac with zero denominator")
+	}
+	n := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(num))
+	return Money(divRoundHalfAway(n, big.NewInt(den)).Int64())
+}
+
+func (m Money) IsNegative() bool { return m < 0 }
+
+func (m Money) IsZero() bool { return m == 0 }
+
+// Min returns the smaller of m and o.
+func (m Money) Min(o Money) Money {
+	if o < m {
+		return o
+	}
+	return m
+}
+
+// String formats the amount with exactly two decimals, e.g. "12.50".
+func (m Money) String() string {
+	sign := ""
+	u := uint64(m)
+	if m < 0 {
+		sign = "-"
+		u = -u
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, u/moneyScale, u%moneyScale)
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string without going
+// through float64.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if unq, err := strconv.Unquote(s); err == nil {
+		s = unq
+	}
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// divRoundHalfAway divides n by d, rounding half away from zero.
+func divRoundHalfAway(n, d *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	twiceR := new(big.Int).Abs(r)
+	twiceR.Lsh(twiceR, 1)
+	if twiceR.Cmp(new(big.Int).Abs(d)) >= 0 {
+		if n.Sign()*d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
@@ -0,0 +1,181 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    domain.Money
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"12", 1200, false},
+		{"12.5", 1250, false},
+		{"12.50", 1250, false},
+		{"12.500", 1250, false},
+		{"0.01", 1, false},
+		{"-0.99", -99, false},
+		{"99999999.99", 9999999999, false},
+		{"92233720368547758.07", 9223372036854775807, false},
+
+		{"", 0, true},
+		{"12.345", 0, true},
+		{".5", 0, true},
+		{"5.", 0, true},
+		{"1e2", 0, true},
+		{"12,50", 0, true},
+		{"+1", 0, true},
+		{"92233720368547758.08", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := domain.ParseMoney(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    domain.Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1250, "12.50"},
+		{-99, "-0.99"},
+		{-1250, "-12.50"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFromDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		unscaled int64
+		exp      int32
+		want     domain.Money
+	}{
+		{"two decimals", 999, -2, 999},
+		{"integer", 10, 0, 1000},
+		{"positive exponent", 12, 3, 1200000},
+		{"one decimal", 125, -1, 1250},
+		{"round down", 12344, -3, 1234},
+		{"round half up", 12345, -3, 1235},
+		{"round half away from zero (negative)", -12345, -3, -1235},
+		{"round negative down in magnitude", -12344, -3, -1234},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.MoneyFromDecimal(big.NewInt(tt.unscaled), tt.exp)
+			if err != nil {
+				t.Fatalf("MoneyFromDecimal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MoneyFromDecimal(%d, %d) = %d, want %d", tt.unscaled, tt.exp, got, tt.want)
+			}
+		})
+	}
+
+	huge := new(big.Int).Lsh(big.NewInt(1), 70)
+	if _, err := domain.MoneyFromDecimal(huge, 0); err == nil {
+		t.Error("MoneyFromDecimal() with an out-of-range value should fail")
+	}
+}
+
+func TestMoneyMulFrac(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        domain.Money
+		num, den int64
+		want     domain.Money
+	}{
+		{"15% of 20.00", 2000, 15, 100, 300},
+		{"15% of 0.10 rounds half up", 10, 15, 100, 2},
+		{"10% of 0.04 rounds down", 4, 10, 100, 0},
+		{"a third of 1.00", 100, 1, 3, 33},
+		{"two thirds of 1.00", 100, 2, 3, 67},
+		{"negative rounds away from zero", -10, 15, 100, -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.MulFrac(tt.num, tt.den); got != tt.want {
+				t.Errorf("MulFrac() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	type payload struct {
+		Price domain.Money `json:"price"`
+	}
+
+	b, err := json.Marshal(payload{Price: 1999})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(b) != `{"price":19.99}` {
+		t.Errorf("Marshal() = %s, want {\"price\":19.99}", b)
+	}
+
+	for _, in := range []string{`{"price":19.99}`, `{"price":"19.99"}`, `{"price":19.990}`} {
+		var p payload
+		if err := json.Unmarshal([]byte(in), &p); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", in, err)
+		}
+		if p.Price != 1999 {
+			t.Errorf("Unmarshal(%s) = %d, want 1999", in, p.Price)
+		}
+	}
+
+	var p payload
+	if err := json.Unmarshal([]byte(`{"price":19.999}`), &p); err == nil {
+		t.Error("Unmarshal() with three decimals should fail")
+	}
+}
+
+// Summing 0.10 a thousand times drifts in float64 but must be exact here.
+func TestMoneyLargeCartIsExact(t *testing.T) {
+	var total domain.Money
+	for i := 0; i < 1000; i++ {
+		total = total.Add(10)
+	}
+	if total != 10000 {
+		t.Errorf("total = %s, want 100.00", total)
+	}
+
+	// The most expensive product NUMERIC(10,2) allows, in a large quantity.
+	maxPrice := domain.Money(9999999999)
+	line := maxPrice.Mul(100000)
+	if got, want := line.String(), "9999999999000.00"; got != want {
+		t.Errorf("line total = %s, want %s", got, want)
+	}
+
+	var cart domain.Money
+	for i := 0; i < 500; i++ {
+		cart = cart.Add(domain.Money(1999).Mul(int64(i % 7)))
+	}
+	// 500 lines cycle quantities 0..6: 71 full cycles (21 each) + 0+1+2.
+	if want := domain.Money(1999).Mul(71*21 + 3); cart != want {
+		t.Errorf("cart total = %s, want %s", cart, want)
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+// Order statuses. The normal lifecycle is
+//
+//	pending → confirmed → baking → ready → out_for_delivery → delivered
+//
+// An order can be cancelled until it leaves the bakery, and refunded once
+// it has been confirmed; refunding only some of its lines makes it
+// partially_refunded. The rest of a partially refunded order still carries
+// on through the lifecycle from the status it was refunded in, though it
+// can no longer be cancelled. A card order whose payment is declined ends in
+// payment_failed instead of being confirmed.
+const (
+	OrderStatusPending           = "pending"
+	OrderStatusConfirmed         = "confirmed"
+	OrderStatusBaking            = "baking"
+	OrderStatusReady             = "ready"
+	OrderStatusOutForDelivery    = "out_for_delivery"
+	OrderStatusDelivered         = "delivered"
+	OrderStatusCancelled         = "cancelled"
+	OrderStatusRefunded          = "refunded"
+	OrderStatusPaymentFailed     = "payment_failed"
+	OrderStatusPartiallyRefunded = "partially_refunded"
+)
+
+// orderTransitions maps each status to the statuses it may move to.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:           {OrderStatusConfirmed, OrderStatusCancelled, OrderStatusPaymentFailed},
+	OrderStatusConfirmed:         {OrderStatusBaking, OrderStatusCancelled, OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusBaking:            {OrderStatusReady, OrderStatusCancelled, OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusReady:             {OrderStatusOutForDelivery, OrderStatusCancelled, OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusOutForDelivery:    {OrderStatusDelivered, OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusDelivered:         {OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusCancelled:         {OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusRefunded:          {},
+	OrderStatusPaymentFailed:     {},
+	OrderStatusPartiallyRefunded: {OrderStatusBaking, OrderStatusReady, OrderStatusOutForDelivery, OrderStatusDelivered, OrderStatusRefunded},
+}
+
+// IsValidOrderStatus reports whether status is one of the known statuses.
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another.
+func CanTransitionOrder(from, to string) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOrderStatus returns the status that follows from in the normal
+// lifecycle, or false if the order is at the end of it. A partially refunded
+// order continues from the status it was refunded in, so pass that instead.
+func NextOrderStatus(from string) (string, bool) {
+	next := orderTransitions[from]
+	if from == OrderStatusPartiallyRefunded || len(next) == 0 || next[0] == OrderStatusRefunded {
+		return "", false
+	}
+	return next[0], true
+}
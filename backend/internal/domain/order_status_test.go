@@ -0,0 +1,71 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{domain.OrderStatusPending, domain.OrderStatusConfirmed, true},
+		{domain.OrderStatusConfirmed, domain.OrderStatusBaking, true},
+		{domain.OrderStatusBaking, domain.OrderStatusReady, true},
+		{domain.OrderStatusReady, domain.OrderStatusOutForDelivery, true},
+		{domain.OrderStatusOutForDelivery, domain.OrderStatusDelivered, true},
+		{domain.OrderStatusPending, domain.OrderStatusCancelled, true},
+		{domain.OrderStatusDelivered, domain.OrderStatusRefunded, true},
+		{domain.OrderStatusCancelled, domain.OrderStatusRefunded, true},
+		{domain.OrderStatusPending, domain.OrderStatusPaymentFailed, true},
+		{domain.OrderStatusConfirmed, domain.OrderStatusRefunded, true},
+		{domain.OrderStatusBaking, domain.OrderStatusPartiallyRefunded, true},
+		{domain.OrderStatusPartiallyRefunded, domain.OrderStatusRefunded, true},
+		{domain.OrderStatusPartiallyRefunded, domain.OrderStatusReady, true},
+
+		{domain.OrderStatusPending, domain.OrderStatusBaking, false},
+		{domain.OrderStatusPending, domain.OrderStatusRefunded, false},
+		{domain.OrderStatusOutForDelivery, domain.OrderStatusCancelled, false},
+		{domain.OrderStatusDelivered, domain.OrderStatusPending, false},
+		{domain.OrderStatusRefunded, domain.OrderStatusPending, false},
+		{domain.OrderStatusConfirmed, domain.OrderStatusConfirmed, false},
+		{domain.OrderStatusConfirmed, domain.OrderStatusPaymentFailed, false},
+		{domain.OrderStatusPaymentFailed, domain.OrderStatusConfirmed, false},
+		{domain.OrderStatusPending, domain.OrderStatusPartiallyRefunded, false},
+		{domain.OrderStatusPartiallyRefunded, domain.OrderStatusCancelled, false},
+		{"unknown", domain.OrderStatusConfirmed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := domain.CanTransitionOrder(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionOrder(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOrderStatus(t *testing.T) {
+	lifecycle := []string{
+		domain.OrderStatusPending,
+		domain.OrderStatusConfirmed,
+		domain.OrderStatusBaking,
+		domain.OrderStatusReady,
+		domain.OrderStatusOutForDelivery,
+		domain.OrderStatusDelivered,
+	}
+	for i := 0; i < len(lifecycle)-1; i++ {
+		next, ok := domain.NextOrderStatus(lifecycle[i])
+		if !ok || next != lifecycle[i+1] {
+			t.Errorf("NextOrderStatus(%q) = %q, %v; want %q, true", lifecycle[i], next, ok, lifecycle[i+1])
+		}
+	}
+
+	for _, s := range []string{domain.OrderStatusDelivered, domain.OrderStatusCancelled, domain.OrderStatusRefunded, domain.OrderStatusPaymentFailed, domain.OrderStatusPartiallyRefunded} {
+		if next, ok := domain.NextOrderStatus(s); ok {
+			t.Errorf("NextOrderStatus(%q) = %q, true; want no next status", s, next)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package domain
+
+// What an automatic promotion gives.
+const (
+	// PromotionKindBuyXGetY makes the cheapest GetQuantity of every
+	// BuyQuantity + GetQuantity items free.
+	PromotionKindBuyXGetY = "buy_x_get_y"
+	// PromotionKindPercentOff takes a percentage off the items.
+	PromotionKindPercentOff = "percent_off"
+	// PromotionKindFreeDelivery waives the delivery fee.
+	PromotionKindFreeDelivery = "free_delivery"
+)
+
+// IsValidPromotionKind reports whether k is one of the known promotion kinds.
+func IsValidPromotionKind(k string) bool {
+	switch k {
+	case PromotionKindBuyXGetY, PromotionKindPercentOff, PromotionKindFreeDelivery:
+		return true
+	}
+	return false
+}
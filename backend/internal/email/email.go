@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"strings"
 
 	"github.com/online-cake-shop/backend/internal/config"
 )
@@ -12,6 +13,59 @@ import (
 // Sender is the abstract email provider interface.
 type Sender interface {
 	SendOTP(to, firstName, otp string) error
+	SendLoginOTP(to, firstName, otp string) error
+	SendRefund(to, firstName string, refund RefundNotice) error
+	SendOrderConfirmation(to, firstName string, order OrderConfirmation) error
+}
+
+// RefundNotice describes a refund to the customer. Amounts are preformatted.
+type RefundNotice struct {
+	OrderID string
+	Amount  string
+	Full    bool
+	Items   []RefundNoticeItem
+	Reason  string
+}
+
+type RefundNoticeItem struct {
+	Name     string
+	Quantity int32
+	Amount   string
+}
+
+// OrderConfirmation describes a new order to the customer. Amounts are
+// preformatted.
+type OrderConfirmation struct {
+	OrderID         string
+	DeliveryDate    string
+	DeliveryAddress string
+	Total           string
+	Items           []OrderConfirmationItem
+	Promotions      []OrderConfirmationPromotion
+	// CouponCode and Discount are set for orders placed with a coupon.
+	CouponCode string
+	Discount   string
+	// DeliveryFee is empty when delivery was free.
+	DeliveryFee string
+}
+
+// OrderConfirmationPromotion is a promotion applied to the order and what
+// it took off.
+type OrderConfirmationPromotion struct {
+	Name   string
+	Detail string
+	Amount string
+}
+
+// OrderConfirmationItem is an ordered line with the allergen names the
+// product declared when it was ordered.
+type OrderConfirmationItem struct {
+	Name       string
+	Quantity   int32
+	Amount     string
+	Contains   []string
+	MayContain []string
+	FreeFrom   []string
 }
 
 // ─── SMTP Implementation ──────────────────────────────────────────────────────
@@ -25,13 +79,39 @@ func NewSMTPSender(cfg config.EmailConfig) *SMTPSender {
 }
 
 func (s *SMTPSender) SendOTP(to, firstName, otp string) error {
-	subject := "Your Cake Shop Verification Code"
-	body, err := renderOTPTemplate(firstName, otp)
+	body, err := renderOTPTemplate(firstName, otp, "complete your registration")
 	if err != nil {
 		return fmt.Errorf("render otp template: %w", err)
 	}
+	return s.send(to, "Your Cake Shop Verification Code", body)
+}
 
-	msg := buildMIMEMessage(s.cfg.From, to, subject, body)
+func (s *SMTPSender) SendLoginOTP(to, firstName, otp string) error {
+	body, err := renderOTPTemplate(firstName, otp, "sign in to your account")
+	if err != nil {
+		return fmt.Errorf("render otp template: %w", err)
+	}
+	return s.send(to, "Your Cake Shop Sign-in Code", body)
+}
+
+func (s *SMTPSender) SendRefund(to, firstName string, refund RefundNotice) error {
+	body, err := renderRefundTemplate(firstName, refund)
+	if err != nil {
+		return fmt.Errorf("render refund template: %w", err)
+	}
+	return s.send(to, "Your Cake Shop Refund", body)
+}
+
+func (s *SMTPSender) SendOrderConfirmation(to, firstName string, order OrderConfirmation) error {
+	body, err := renderOrderConfirmationTemplate(firstName, order)
+	if err != nil {
+		return fmt.Errorf("render order confirmation template: %w", err)
+	}
+	return s.send(to, "Your Cake Shop Order", body)
+}
+
+func (s *SMTPSender) send(to, subject, htmlBody string) error {
+	msg := buildMIMEMessage(s.cfg.From, to, subject, htmlBody)
 
 	auth := smtp.PlainAuth("", s.cfg.SMTPUser, s.cfg.SMTPPass, s.cfg.SMTPHost)
 	addr := fmt.Sprintf("%s:%d", s.cfg.SMTPHost, s.cfg.SMTPPort)
@@ -70,7 +150,7 @@ const otpEmailTpl = `<!DOCTYPE html>
     <div class="header"><h1>🎂 Cake Shop</h1></div>
     <div class="body">
       <p>Hello <strong>{{.FirstName}}</strong>,</p>
-      <p>Use the verification code below to complete your registration. This code expires in <strong>5 minutes</strong>.</p>
+      <p>Use the verification code below to {{.Action}}. This code expires in <strong>5 minutes</strong>.</p>
       <div class="otp">{{.OTP}}</div>
       <p>If you didn't request this, you can safely ignore this email.</p>
     </div>
@@ -82,15 +162,139 @@ const otpEmailTpl = `<!DOCTYPE html>
 type otpTemplateData struct {
 	FirstName string
 	OTP       string
+	Action    string
 }
 
-func renderOTPTemplate(firstName, otp string) (string, error) {
+func renderOTPTemplate(firstName, otp, action string) (string, error) {
 	tpl, err := template.New("otp").Parse(otpEmailTpl)
 	if err != nil {
 		return "", err
 	}
 	var buf bytes.Buffer
-	if err := tpl.Execute(&buf, otpTemplateData{FirstName: firstName, OTP: otp}); err != nil {
+	if err := tpl.Execute(&buf, otpTemplateData{FirstName: firstName, OTP: otp, Action: action}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ─── Refund Email Template ────────────────────────────────────────────────────
+
+const refundEmailTpl = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1.0"/>
+  <title>Your Refund</title>
+  <style>
+    body { font-family: Arial, sans-serif; background: #f9f9f9; margin: 0; padding: 0; }
+    .container { max-width: 480px; margin: 40px auto; background: #fff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 8px rgba(0,0,0,.08); }
+    .header { background: #c05621; padding: 28px 32px; text-align: center; }
+    .header h1 { color: #fff; margin: 0; font-size: 22px; letter-spacing: .5px; }
+    .body { padding: 32px; }
+    .body p { color: #444; line-height: 1.6; }
+    .amount { font-size: 28px; font-weight: 700; color: #c05621; text-align: center; margin: 24px 0; }
+    table { width: 100%; border-collapse: collapse; color: #444; }
+    td { padding: 6px 0; border-bottom: 1px solid #eee; }
+    td.num { text-align: right; }
+    .footer { background: #f3f3f3; padding: 16px 32px; text-align: center; font-size: 12px; color: #888; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header"><h1>🎂 Cake Shop</h1></div>
+    <div class="body">
+      <p>Hello <strong>{{.FirstName}}</strong>,</p>
+      <p>We've issued a {{if .Full}}full{{else}}partial{{end}} refund for your order <strong>{{.OrderID}}</strong>.</p>
+      <div class="amount">{{.Amount}}</div>
+      <table>
+        {{range .Items}}<tr><td>{{.Quantity}} × {{.Name}}</td><td class="num">{{.Amount}}</td></tr>{{end}}
+      </table>
+      {{if .Reason}}<p>Reason: {{.Reason}}</p>{{end}}
+      <p>Card refunds usually reach your account within 5–10 business days.</p>
+    </div>
+    <div class="footer">© 2024 Cake Shop. All rights reserved.</div>
+  </div>
+</body>
+</html>`
+
+type refundTemplateData struct {
+	FirstName string
+	RefundNotice
+}
+
+func renderRefundTemplate(firstName string, refund RefundNotice) (string, error) {
+	tpl, err := template.New("refund").Parse(refundEmailTpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, refundTemplateData{FirstName: firstName, RefundNotice: refund}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ─── Order Confirmation Email Template ────────────────────────────────────────
+
+const orderConfirmationEmailTpl = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1.0"/>
+  <title>Your Order</title>
+  <style>
+    body { font-family: Arial, sans-serif; background: #f9f9f9; margin: 0; padding: 0; }
+    .container { max-width: 480px; margin: 40px auto; background: #fff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 8px rgba(0,0,0,.08); }
+    .header { background: #c05621; padding: 28px 32px; text-align: center; }
+    .header h1 { color: #fff; margin: 0; font-size: 22px; letter-spacing: .5px; }
+    .body { padding: 32px; }
+    .body p { color: #444; line-height: 1.6; }
+    table { width: 100%; border-collapse: collapse; color: #444; }
+    td { padding: 6px 0; border-bottom: 1px solid #eee; vertical-align: top; }
+    td.num { text-align: right; }
+    .allergens { font-size: 12px; color: #666; margin-top: 4px; }
+    .total td { font-weight: 700; border-bottom: none; }
+    .footer { background: #f3f3f3; padding: 16px 32px; text-align: center; font-size: 12px; color: #888; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header"><h1>🎂 Cake Shop</h1></div>
+    <div class="body">
+      <p>Hello <strong>{{.FirstName}}</strong>,</p>
+      <p>Thank you for your order <strong>{{.OrderID}}</strong>. We'll deliver it to {{.DeliveryAddress}} on {{.DeliveryDate}}.</p>
+      <table>
+        {{range .Items}}<tr><td>{{.Quantity}} × {{.Name}}
+          {{if .Contains}}<div class="allergens"><strong>Contains:</strong> {{join .Contains}}</div>{{end}}
+          {{if .MayContain}}<div class="allergens"><strong>May contain:</strong> {{join .MayContain}}</div>{{end}}
+          {{if .FreeFrom}}<div class="allergens"><strong>Free from:</strong> {{join .FreeFrom}}</div>{{end}}
+        </td><td class="num">{{.Amount}}</td></tr>{{end}}
+        {{range .Promotions}}<tr><td>{{.Name}}<div class="allergens">{{.Detail}}</div></td><td class="num">−{{.Amount}}</td></tr>{{end}}
+        {{if .Discount}}<tr><td>Coupon {{.CouponCode}}</td><td class="num">−{{.Discount}}</td></tr>{{end}}
+        {{if .DeliveryFee}}<tr><td>Delivery</td><td class="num">{{.DeliveryFee}}</td></tr>{{end}}
+        <tr class="total"><td>Total</td><td class="num">{{.Total}}</td></tr>
+      </table>
+      <p>If you have an allergy, please check the allergen information above and contact us before delivery with any questions.</p>
+    </div>
+    <div class="footer">© 2024 Cake Shop. All rights reserved.</div>
+  </div>
+</body>
+</html>`
+
+type orderConfirmationTemplateData struct {
+	FirstName string
+	OrderConfirmation
+}
+
+func renderOrderConfirmationTemplate(firstName string, order OrderConfirmation) (string, error) {
+	tpl, err := template.New("order").Funcs(template.FuncMap{
+		"join": func(s []string) string { return strings.Join(s, ", ") },
+	}).Parse(orderConfirmationEmailTpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, orderConfirmationTemplateData{FirstName: firstName, OrderConfirmation: order}); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
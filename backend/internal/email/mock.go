@@ -19,3 +19,37 @@ func (m *MockSender) SendOTP(to, firstName, otp string) error {
 	)
 	return nil
 }
+
+func (m *MockSender) SendLoginOTP(to, firstName, otp string) error {
+	m.logger.Info("📧 [MOCK EMAIL] login OTP sent",
+		"to", to,
+		"firstName", firstName,
+		"otp", otp,
+	)
+	return nil
+}
+
+func (m *MockSender) SendRefund(to, firstName string, refund RefundNotice) error {
+	m.logger.Info("📧 [MOCK EMAIL] refund notice sent",
+		"to", to,
+		"firstName", firstName,
+		"order", refund.OrderID,
+		"amount", refund.Amount,
+		"full", refund.Full,
+		"items", len(refund.Items),
+	)
+	return nil
+}
+
+func (m *MockSender) SendOrderConfirmation(to, firstName string, order OrderConfirmation) error {
+	m.logger.Info("📧 [MOCK EMAIL] order confirmation sent",
+		"to", to,
+		"firstName", firstName,
+		"order", order.OrderID,
+		"total", order.Total,
+		"coupon", order.CouponCode,
+		"promotions", len(order.Promotions),
+		"items", len(order.Items),
+	)
+	return nil
+}
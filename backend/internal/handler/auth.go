@@ -1,18 +1,33 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
-	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/middleware"
 	"github.com/online-cake-shop/backend/internal/service"
 )
 
+const (
+	authCookieName    = "auth_token"
+	refreshCookieName = "refresh_token"
+	// The refresh cookie is only ever needed by the auth endpoints.
+	refreshCookiePath = "/api/v1/auth"
+)
+
 type AuthHandler struct {
 	authSvc *service.AuthService
+	cartSvc *service.CartService
 }
 
-func NewAuthHandler(authSvc *service.AuthService) *AuthHandler {
-	return &AuthHandler{authSvc: authSvc}
+func NewAuthHandler(authSvc *service.AuthService, cartSvc *service.CartService) *AuthHandler {
+	return &AuthHandler{authSvc: authSvc, cartSvc: cartSvc}
 }
 
 // ─── Register ────────────────────────────────────────────────────────────────
@@ -61,34 +76,149 @@ func (h *AuthHandler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.authSvc.VerifyOTP(r.Context(), service.VerifyOTPInput{
-		Email: req.Email,
-		OTP:   req.OTP,
+		Email:     req.Email,
+		OTP:       req.OTP,
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
 	})
 	if err != nil {
 		writeError(w, r, err)
 		return
 	}
 
-	// Set HTTP-only cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    result.Token,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false, // set true in production with TLS
-		SameSite: http.SameSiteLaxMode,
-		Expires:  time.Now().Add(24 * time.Hour),
+	h.mergeGuestCart(w, r, result.User.ID)
+	writeAuthResult(w, result)
+}
+
+// ─── Login ───────────────────────────────────────────────────────────────────
+
+type loginRequest struct {
+	Email string `json:"email"`
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	if err := h.authSvc.Login(r.Context(), req.Email); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, envelope{
+		"message": "A sign-in code has been sent to your email address.",
+	})
+}
+
+func (h *AuthHandler) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	var req verifyOTPRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	result, err := h.authSvc.VerifyLogin(r.Context(), service.VerifyOTPInput{
+		Email:     req.Email,
+		OTP:       req.OTP,
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.mergeGuestCart(w, r, result.User.ID)
+	writeAuthResult(w, result)
+}
+
+// ─── Refresh ─────────────────────────────────────────────────────────────────
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh accepts the refresh token from the JSON body or, for browser
+// clients, from the refresh_token cookie.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+	if req.RefreshToken == "" {
+		if cookie, err := r.Cookie(refreshCookieName); err == nil {
+			req.RefreshToken = cookie.Value
+		}
+	}
+
+	result, err := h.authSvc.Refresh(r.Context(), service.RefreshInput{
+		RefreshToken: req.RefreshToken,
+		UserAgent:    r.UserAgent(),
+		IPAddress:    clientIP(r),
 	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeAuthResult(w, result)
+}
+
+// ─── Logout ──────────────────────────────────────────────────────────────────
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token := middleware.TokenFromContext(r.Context())
+	if err := h.authSvc.Logout(r.Context(), service.LogoutInput{
+		UserID:    middleware.UserIDFromContext(r.Context()),
+		TokenID:   token.ID,
+		SessionID: token.SessionID,
+		ExpiresAt: token.ExpiresAt,
+	}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	clearAuthCookies(w)
+	writeSuccess(w, http.StatusOK, envelope{"message": "logged out"})
+}
+
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if err := h.authSvc.LogoutAll(r.Context(), middleware.UserIDFromContext(r.Context())); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	clearAuthCookies(w)
+	writeSuccess(w, http.StatusOK, envelope{"message": "logged out of all sessions"})
+}
+
+// ─── Roles (admin) ───────────────────────────────────────────────────────────
+
+type updateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (h *AuthHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	var req updateUserRoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	user, err := h.authSvc.SetUserRole(r.Context(), middleware.UserIDFromContext(r.Context()), chi.URLParam(r, "id"), req.Role)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
 	writeSuccess(w, http.StatusOK, envelope{
-		"token": result.Token,
-		"user": envelope{
-			"id":         result.User.ID.String(),
-			"first_name": result.User.FirstName,
-			"last_name":  result.User.LastName,
-			"email":      result.User.EmailAddress,
-			"phone":      result.User.PhoneNumber,
-		},
+		"id":    user.ID.String(),
+		"email": user.EmailAddress,
+		"role":  user.Role,
 	})
 }
 
@@ -114,3 +244,87 @@ func (h *AuthHandler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 		"message": "A new OTP has been sent to your email address.",
 	})
 }
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+// mergeGuestCart moves the cart the visitor filled as a guest into their
+// own once they have verified an OTP. A failed merge doesn't fail the
+// sign-in: the guest cart and its cookie are kept for the next attempt.
+func (h *AuthHandler) mergeGuestCart(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	if _, err := r.Cookie(guestCartCookieName); err != nil {
+		return
+	}
+	cartID, ok := guestCartID(r, h.cartSvc)
+	if ok {
+		if err := h.cartSvc.MergeGuestCart(r.Context(), cartID, userID); err != nil {
+			slog.Error("merge guest cart", "cart_id", cartID, "user_id", userID, "error", err)
+			return
+		}
+	}
+	clearGuestCartCookie(w)
+}
+
+// writeAuthResult sets the auth cookies and writes the tokens and user profile.
+func writeAuthResult(w http.ResponseWriter, result *service.AuthResult) {
+	// Set HTTP-only cookies
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookieName,
+		Value:    result.Token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   false, // set true in production with TLS
+		SameSite: http.SameSiteLaxMode,
+		Expires:  result.ExpiresAt,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    result.RefreshToken,
+		Path:     refreshCookiePath,
+		HttpOnly: true,
+		Secure:   false, // set true in production with TLS
+		SameSite: http.SameSiteStrictMode,
+		Expires:  result.RefreshExpiresAt,
+	})
+
+	writeSuccess(w, http.StatusOK, envelope{
+		"token":                    result.Token,
+		"expires_at":               result.ExpiresAt,
+		"refresh_token":            result.RefreshToken,
+		"refresh_token_expires_at": result.RefreshExpiresAt,
+		"user": envelope{
+			"id":         result.User.ID.String(),
+			"first_name": result.User.FirstName,
+			"last_name":  result.User.LastName,
+			"email":      result.User.EmailAddress,
+			"phone":      result.User.PhoneNumber,
+			"role":       result.User.Role,
+		},
+	})
+}
+
+func clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     refreshCookiePath,
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+}
+
+// clientIP returns the caller's address without the port. chi's RealIP
+// middleware has already replaced RemoteAddr with the forwarded address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -2,24 +2,33 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
 	"github.com/online-cake-shop/backend/internal/middleware"
 	"github.com/online-cake-shop/backend/internal/service"
 )
 
+// guestCartCookieName holds the signed id of a visitor's guest cart. It is
+// read by the cart endpoints and by OTP verification, which merges it.
+const (
+	guestCartCookieName = "guest_cart"
+	guestCartCookiePath = "/api/v1"
+)
+
 type CartHandler struct {
-	cartSvc *service.CartService
+	cartSvc      *service.CartService
+	guestCartTTL time.Duration
 }
 
-func NewCartHandler(cartSvc *service.CartService) *CartHandler {
-	return &CartHandler{cartSvc: cartSvc}
+func NewCartHandler(cartSvc *service.CartService, guestCartTTL time.Duration) *CartHandler {
+	return &CartHandler{cartSvc: cartSvc, guestCartTTL: guestCartTTL}
 }
 
 func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.UserIDFromContext(r.Context())
-	cart, err := h.cartSvc.GetCart(r.Context(), userID)
+	cart, err := h.cartSvc.GetCart(r.Context(), h.owner(r))
 	if err != nil {
 		writeError(w, r, err)
 		return
@@ -28,12 +37,14 @@ func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 }
 
 type addCartItemRequest struct {
-	ProductID string `json:"product_id"`
-	Quantity  int32  `json:"quantity"`
+	ProductID       string                  `json:"product_id"`
+	VariantID       string                  `json:"variant_id"`
+	Personalisation service.Personalisation `json:"personalisation"`
+	Quantity        int32                   `json:"quantity"`
 }
 
 func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.UserIDFromContext(r.Context())
+	owner := h.owner(r)
 
 	var req addCartItemRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -42,14 +53,18 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cart, err := h.cartSvc.AddItem(r.Context(), service.AddCartItemInput{
-		UserID:    userID,
+		Owner:     owner,
 		ProductID: req.ProductID,
+		VariantID: req.VariantID,
 		Quantity:  req.Quantity,
+
+		Personalisation: req.Personalisation,
 	})
 	if err != nil {
 		writeError(w, r, err)
 		return
 	}
+	h.rememberGuestCart(w, owner, cart)
 	writeSuccess(w, http.StatusOK, cart)
 }
 
@@ -58,7 +73,6 @@ type updateCartItemRequest struct {
 }
 
 func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.UserIDFromContext(r.Context())
 	itemID := chi.URLParam(r, "itemId")
 
 	var req updateCartItemRequest
@@ -68,7 +82,7 @@ func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cart, err := h.cartSvc.UpdateItem(r.Context(), service.UpdateCartItemInput{
-		UserID:     userID,
+		Owner:      h.owner(r),
 		CartItemID: itemID,
 		Quantity:   req.Quantity,
 	})
@@ -80,11 +94,10 @@ func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.UserIDFromContext(r.Context())
 	itemID := chi.URLParam(r, "itemId")
 
 	cart, err := h.cartSvc.RemoveItem(r.Context(), service.RemoveCartItemInput{
-		UserID:     userID,
+		Owner:      h.owner(r),
 		CartItemID: itemID,
 	})
 	if err != nil {
@@ -94,11 +107,81 @@ func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
 	writeSuccess(w, http.StatusOK, cart)
 }
 
+type applyCouponRequest struct {
+	Code string `json:"code"`
+}
+
+// ApplyCoupon applies a coupon code to the cart, or removes the cart's
+// coupon when the code is empty.
+func (h *CartHandler) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	var req applyCouponRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	cart, err := h.cartSvc.ApplyCoupon(r.Context(), h.owner(r), req.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, cart)
+}
+
 func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
-	userID := middleware.UserIDFromContext(r.Context())
-	if err := h.cartSvc.ClearCart(r.Context(), userID); err != nil {
+	if err := h.cartSvc.ClearCart(r.Context(), h.owner(r)); err != nil {
 		writeError(w, r, err)
 		return
 	}
 	writeSuccess(w, http.StatusOK, envelope{"message": "cart cleared"})
 }
+
+// owner is the signed-in user or, for guests, the cart their cookie names.
+func (h *CartHandler) owner(r *http.Request) service.CartOwner {
+	if userID := middleware.UserIDFromContext(r.Context()); userID != uuid.Nil {
+		return service.CartOwner{UserID: userID}
+	}
+	cartID, _ := guestCartID(r, h.cartSvc)
+	return service.CartOwner{GuestCartID: cartID}
+}
+
+// rememberGuestCart sets a guest's cookie to their cart, which AddItem may
+// just have created, and restarts its expiry.
+func (h *CartHandler) rememberGuestCart(w http.ResponseWriter, owner service.CartOwner, cart *service.CartResponse) {
+	if !owner.IsGuest() || cart.ID == "" {
+		return
+	}
+	cartID, err := uuid.Parse(cart.ID)
+	if err != nil {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     guestCartCookieName,
+		Value:    h.cartSvc.GuestCartToken(cartID),
+		Path:     guestCartCookiePath,
+		HttpOnly: true,
+		Secure:   false, // set true in production with TLS
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(h.guestCartTTL.Seconds()),
+	})
+}
+
+// guestCartID returns the guest cart named by the request's cookie, if it
+// carries a valid one.
+func guestCartID(r *http.Request, cartSvc *service.CartService) (uuid.UUID, bool) {
+	cookie, err := r.Cookie(guestCartCookieName)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return cartSvc.ParseGuestCartToken(cookie.Value)
+}
+
+func clearGuestCartCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     guestCartCookieName,
+		Value:    "",
+		Path:     guestCartCookiePath,
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+}
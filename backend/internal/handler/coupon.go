@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+type CouponHandler struct {
+	couponSvc *service.CouponService
+}
+
+func NewCouponHandler(couponSvc *service.CouponService) *CouponHandler {
+	return &CouponHandler{couponSvc: couponSvc}
+}
+
+// couponRequest creates or replaces a coupon. Coupons are active unless
+// is_active is false.
+type couponRequest struct {
+	Code           string       `json:"code"`
+	Description    string       `json:"description"`
+	DiscountType   string       `json:"discount_type"`
+	DiscountValue  domain.Money `json:"discount_value"`
+	MinOrderAmount domain.Money `json:"min_order_amount"`
+	StartsAt       *time.Time   `json:"starts_at"`
+	EndsAt         *time.Time   `json:"ends_at"`
+	MaxUses        *int32       `json:"max_uses"`
+	MaxUsesPerUser *int32       `json:"max_uses_per_user"`
+	IsActive       *bool        `json:"is_active"`
+	CategoryIDs    []string     `json:"category_ids"`
+	ProductIDs     []string     `json:"product_ids"`
+}
+
+func (req couponRequest) toInput() service.CouponInput {
+	return service.CouponInput{
+		Code:           req.Code,
+		Description:    req.Description,
+		DiscountType:   req.DiscountType,
+		DiscountValue:  req.DiscountValue,
+		MinOrderAmount: req.MinOrderAmount,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+		CategoryIDs:    req.CategoryIDs,
+		ProductIDs:     req.ProductIDs,
+	}
+}
+
+func (h *CouponHandler) List(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.couponSvc.ListCoupons(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, coupons)
+}
+
+func (h *CouponHandler) Get(w http.ResponseWriter, r *http.Request) {
+	coupon, err := h.couponSvc.GetCoupon(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req couponRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	coupon, err := h.couponSvc.CreateCoupon(r.Context(), req.toInput())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, coupon)
+}
+
+func (h *CouponHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req couponRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	coupon, err := h.couponSvc.UpdateCoupon(r.Context(), chi.URLParam(r, "id"), req.toInput())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.couponSvc.DeleteCoupon(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "coupon deleted"})
+}
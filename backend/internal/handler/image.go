@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// imageCacheControl lets browsers and CDNs keep images for a year: an
+// upload's URL is never reused for different content.
+const imageCacheControl = "public, max-age=31536000, immutable"
+
+type reorderImagesRequest struct {
+	ImageIDs []string `json:"image_ids"`
+}
+
+// UploadImage takes a multipart/form-data body whose "image" field is the
+// file. The body is streamed; the service limits how much of it is read.
+func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "expected a multipart/form-data body with an image field"})
+		return
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "image field is required"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+			return
+		}
+		if part.FormName() != "image" {
+			continue
+		}
+
+		product, err := h.productSvc.UploadImage(r.Context(), chi.URLParam(r, "id"), part)
+		part.Close()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		writeSuccess(w, http.StatusCreated, product)
+		return
+	}
+}
+
+func (h *ProductHandler) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	var req reorderImagesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	product, err := h.productSvc.ReorderImages(r.Context(), chi.URLParam(r, "id"), req.ImageIDs)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	product, err := h.productSvc.DeleteImage(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "imageId"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, product)
+}
+
+// ServeImage serves an uploaded image or thumbnail. http.ServeContent
+// answers conditional and range requests.
+func (h *ProductHandler) ServeImage(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	obj, contentType, err := h.productSvc.OpenImage(r.Context(), key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer obj.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, obj.ModTime.UnixNano(), obj.Size))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, key, obj.ModTime, obj)
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
@@ -23,6 +25,7 @@ type createOrderRequest struct {
 	DeliveryDate    string `json:"delivery_date"` // RFC3339
 	Notes           string `json:"notes"`
 	PaymentMethod   string `json:"payment_method"`
+	PaymentToken    string `json:"payment_token"`
 }
 
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -49,6 +52,8 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		DeliveryDate:    deliveryDate,
 		Notes:           req.Notes,
 		PaymentMethod:   req.PaymentMethod,
+		PaymentToken:    req.PaymentToken,
+		IdempotencyKey:  r.Header.Get("Idempotency-Key"),
 	})
 	if err != nil {
 		writeError(w, r, err)
@@ -82,3 +87,113 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	}
 	writeSuccess(w, http.StatusOK, order)
 }
+
+type cancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelOrder cancels one of the caller's orders. The body is optional.
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	var req cancelOrderRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	order, err := h.orderSvc.CancelOrder(r.Context(), userID, chi.URLParam(r, "id"), req.Reason)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, order)
+}
+
+// ─── Admin ────────────────────────────────────────────────────────────────────
+
+func (h *OrderHandler) AdminGetOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.orderSvc.GetOrderAdmin(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, order)
+}
+
+type updateOrderStatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type advanceOrderRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *OrderHandler) AdminUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	var req updateOrderStatusRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	actorID := middleware.UserIDFromContext(r.Context())
+	order, err := h.orderSvc.UpdateStatus(r.Context(), actorID, chi.URLParam(r, "id"), req.Status, req.Note)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, order)
+}
+
+// AdminAdvanceStatus moves an order to the next step of its lifecycle. The
+// body is optional.
+func (h *OrderHandler) AdminAdvanceStatus(w http.ResponseWriter, r *http.Request) {
+	var req advanceOrderRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	actorID := middleware.UserIDFromContext(r.Context())
+	order, err := h.orderSvc.AdvanceStatus(r.Context(), actorID, chi.URLParam(r, "id"), req.Note)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, order)
+}
+
+type refundOrderRequest struct {
+	Items []struct {
+		OrderItemID string `json:"order_item_id"`
+		Quantity    int32  `json:"quantity"`
+	} `json:"items"`
+	Restock bool   `json:"restock"`
+	Reason  string `json:"reason"`
+}
+
+// AdminRefund refunds an order. Without items, everything not yet refunded
+// is refunded; the body is optional.
+func (h *OrderHandler) AdminRefund(w http.ResponseWriter, r *http.Request) {
+	var req refundOrderRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	in := service.RefundOrderInput{Restock: req.Restock, Reason: req.Reason}
+	for _, item := range req.Items {
+		in.Items = append(in.Items, service.RefundItemInput{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	actorID := middleware.UserIDFromContext(r.Context())
+	order, err := h.orderSvc.RefundOrder(r.Context(), actorID, chi.URLParam(r, "id"), in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, order)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+// maxWebhookBytes bounds webhook bodies; provider events are a few KB.
+const maxWebhookBytes = 64 << 10
+
+type PaymentHandler struct {
+	paymentSvc *service.PaymentService
+}
+
+func NewPaymentHandler(paymentSvc *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentSvc: paymentSvc}
+}
+
+// Webhook receives payment events. The signature covers the exact bytes
+// sent, so the body is read raw rather than decoded.
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, envelope{"success": false, "error": "request body too large"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	if err := h.paymentSvc.HandleWebhook(r.Context(), chi.URLParam(r, "provider"), payload, r.Header); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "event received"})
+}
+
+type settleMockPaymentRequest struct {
+	// Outcome is "succeeded" (the default) or "failed".
+	Outcome string `json:"outcome"`
+}
+
+// SettleMockPayment settles an order's processing mock payment through a
+// signed webhook. It is only routed in development.
+func (h *PaymentHandler) SettleMockPayment(w http.ResponseWriter, r *http.Request) {
+	var req settleMockPaymentRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	var succeed bool
+	switch strings.ToLower(req.Outcome) {
+	case "", "succeeded":
+		succeed = true
+	case "failed":
+	default:
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "outcome must be succeeded or failed"})
+		return
+	}
+
+	p, err := h.paymentSvc.SettleMockPayment(r.Context(), chi.URLParam(r, "id"), succeed)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, p)
+}
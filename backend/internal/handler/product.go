@@ -3,9 +3,11 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/online-cake-shop/backend/internal/domain"
 	"github.com/online-cake-shop/backend/internal/service"
 )
 
@@ -30,11 +32,20 @@ func (h *ProductHandler) List(w http.ResponseWriter, r *http.Request) {
 		catIDPtr = &categoryID
 	}
 
+	inStock, _ := strconv.ParseBool(q.Get("in_stock"))
+
 	out, err := h.productSvc.List(r.Context(), service.ListProductsInput{
-		CategoryID: catIDPtr,
-		SortBy:     sortBy,
-		Page:       page,
-		Limit:      limit,
+		CategoryID:  catIDPtr,
+		Query:       q.Get("q"),
+		SortBy:      sortBy,
+		Page:        page,
+		Limit:       limit,
+		MinPrice:    q.Get("min_price"),
+		MaxPrice:    q.Get("max_price"),
+		InStock:     inStock,
+		DietaryTags: q["dietary_tag"],
+		Sizes:       q["size"],
+		FreeFrom:    q["free_from"],
 	})
 	if err != nil {
 		writeError(w, r, err)
@@ -63,6 +74,344 @@ func (h *ProductHandler) ListCategories(w http.ResponseWriter, r *http.Request)
 	writeSuccess(w, http.StatusOK, cats)
 }
 
+// ─── Admin ────────────────────────────────────────────────────────────────────
+
+type createProductRequest struct {
+	CategoryID    *string          `json:"category_id"`
+	Name          string           `json:"name"`
+	Description   *string          `json:"description"`
+	Price         domain.Money     `json:"price"`
+	ImageURL      *string          `json:"image_url"`
+	StockQuantity int32            `json:"stock_quantity"`
+	IsActive      *bool            `json:"is_active"`
+	Variants      []variantRequest `json:"variants"`
+
+	PersonalisationOptions *service.PersonalisationOptions `json:"personalisation_options"`
+	DietaryTags            []string                        `json:"dietary_tags"`
+	Allergens              []allergenDeclarationRequest    `json:"allergens"`
+}
+
+type allergenDeclarationRequest struct {
+	AllergenID string `json:"allergen_id"`
+	Status     string `json:"status"`
+}
+
+type variantRequest struct {
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"options"`
+	Price         domain.Money      `json:"price"`
+	StockQuantity int32             `json:"stock_quantity"`
+	Position      *int32            `json:"position"`
+	IsActive      *bool             `json:"is_active"`
+}
+
+type updateVariantRequest struct {
+	SKU           *string           `json:"sku"`
+	Options       map[string]string `json:"options"`
+	Price         *domain.Money     `json:"price"`
+	StockQuantity *int32            `json:"stock_quantity"`
+	Position      *int32            `json:"position"`
+	IsActive      *bool             `json:"is_active"`
+}
+
+type updateProductRequest struct {
+	CategoryID    *string       `json:"category_id"`
+	Name          *string       `json:"name"`
+	Description   *string       `json:"description"`
+	Price         *domain.Money `json:"price"`
+	ImageURL      *string       `json:"image_url"`
+	StockQuantity *int32        `json:"stock_quantity"`
+	IsActive      *bool         `json:"is_active"`
+
+	PersonalisationOptions *service.PersonalisationOptions `json:"personalisation_options"`
+	DietaryTags            *[]string                       `json:"dietary_tags"`
+	Allergens              *[]allergenDeclarationRequest   `json:"allergens"`
+}
+
+func (h *ProductHandler) AdminList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	out, err := h.productSvc.AdminList(r.Context(), service.AdminListProductsInput{
+		Status: q.Get("status"),
+		Page:   queryInt(q.Get("page"), 1),
+		Limit:  queryInt(q.Get("limit"), 20),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, out)
+}
+
+func (h *ProductHandler) AdminGet(w http.ResponseWriter, r *http.Request) {
+	product, err := h.productSvc.AdminGet(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createProductRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	variants := make([]service.VariantInput, 0, len(req.Variants))
+	for _, v := range req.Variants {
+		variants = append(variants, v.toInput())
+	}
+
+	product, err := h.productSvc.Create(r.Context(), service.CreateProductInput{
+		CategoryID:    req.CategoryID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         req.Price,
+		ImageURL:      req.ImageURL,
+		StockQuantity: req.StockQuantity,
+		IsActive:      req.IsActive,
+		Variants:      variants,
+
+		PersonalisationOptions: req.PersonalisationOptions,
+		DietaryTags:            req.DietaryTags,
+		Allergens:              allergenDeclarations(req.Allergens),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, product)
+}
+
+func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req updateProductRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	var allergens *[]service.AllergenDeclaration
+	if req.Allergens != nil {
+		decls := allergenDeclarations(*req.Allergens)
+		allergens = &decls
+	}
+
+	product, err := h.productSvc.Update(r.Context(), chi.URLParam(r, "id"), service.UpdateProductInput{
+		CategoryID:    req.CategoryID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         req.Price,
+		ImageURL:      req.ImageURL,
+		StockQuantity: req.StockQuantity,
+		IsActive:      req.IsActive,
+
+		PersonalisationOptions: req.PersonalisationOptions,
+		DietaryTags:            req.DietaryTags,
+		Allergens:              allergens,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.productSvc.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "product deleted"})
+}
+
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	product, err := h.productSvc.Restore(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) AddVariant(w http.ResponseWriter, r *http.Request) {
+	var req variantRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	product, err := h.productSvc.AddVariant(r.Context(), chi.URLParam(r, "id"), req.toInput())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusCreated, product)
+}
+
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	var req updateVariantRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	product, err := h.productSvc.UpdateVariant(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "variantId"), service.UpdateVariantInput{
+		SKU:           req.SKU,
+		Options:       req.Options,
+		Price:         req.Price,
+		StockQuantity: req.StockQuantity,
+		Position:      req.Position,
+		IsActive:      req.IsActive,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, product)
+}
+
+func (v variantRequest) toInput() service.VariantInput {
+	return service.VariantInput{
+		SKU:           v.SKU,
+		Options:       v.Options,
+		Price:         v.Price,
+		StockQuantity: v.StockQuantity,
+		Position:      v.Position,
+		IsActive:      v.IsActive,
+	}
+}
+
+func allergenDeclarations(reqs []allergenDeclarationRequest) []service.AllergenDeclaration {
+	decls := make([]service.AllergenDeclaration, 0, len(reqs))
+	for _, d := range reqs {
+		decls = append(decls, service.AllergenDeclaration{AllergenID: d.AllergenID, Status: d.Status})
+	}
+	return decls
+}
+
+type createCategoryRequest struct {
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+type updateCategoryRequest struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+func (h *ProductHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	cat, err := h.productSvc.GetCategory(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, cat)
+}
+
+func (h *ProductHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req createCategoryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	cat, err := h.productSvc.CreateCategory(r.Context(), service.CreateCategoryInput{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, cat)
+}
+
+func (h *ProductHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var req updateCategoryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	cat, err := h.productSvc.UpdateCategory(r.Context(), chi.URLParam(r, "id"), service.UpdateCategoryInput{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, cat)
+}
+
+func (h *ProductHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if err := h.productSvc.DeleteCategory(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "category deleted"})
+}
+
+type allergenRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *ProductHandler) ListAllergens(w http.ResponseWriter, r *http.Request) {
+	allergens, err := h.productSvc.ListAllergens(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, allergens)
+}
+
+func (h *ProductHandler) CreateAllergen(w http.ResponseWriter, r *http.Request) {
+	var req allergenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	allergen, err := h.productSvc.CreateAllergen(r.Context(), req.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, allergen)
+}
+
+func (h *ProductHandler) UpdateAllergen(w http.ResponseWriter, r *http.Request) {
+	var req allergenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	allergen, err := h.productSvc.UpdateAllergen(r.Context(), chi.URLParam(r, "id"), req.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, allergen)
+}
+
+func (h *ProductHandler) DeleteAllergen(w http.ResponseWriter, r *http.Request) {
+	if err := h.productSvc.DeleteAllergen(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "allergen deleted"})
+}
+
 func queryInt(s string, defaultVal int) int {
 	if s == "" {
 		return defaultVal
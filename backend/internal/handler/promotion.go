@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+type PromotionHandler struct {
+	promotionSvc *service.PromotionService
+}
+
+func NewPromotionHandler(promotionSvc *service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionSvc: promotionSvc}
+}
+
+// promotionRequest creates or replaces a promotion. Promotions are active
+// unless is_active is false.
+type promotionRequest struct {
+	Name           string       `json:"name"`
+	Description    string       `json:"description"`
+	Kind           string       `json:"kind"`
+	Priority       int32        `json:"priority"`
+	Exclusive      bool         `json:"exclusive"`
+	CategoryID     string       `json:"category_id"`
+	ProductID      string       `json:"product_id"`
+	BuyQuantity    int32        `json:"buy_quantity"`
+	GetQuantity    int32        `json:"get_quantity"`
+	PercentOff     domain.Money `json:"percent_off"`
+	MinOrderAmount domain.Money `json:"min_order_amount"`
+	StartsAt       *time.Time   `json:"starts_at"`
+	EndsAt         *time.Time   `json:"ends_at"`
+	IsActive       *bool        `json:"is_active"`
+}
+
+func (req promotionRequest) toInput() service.PromotionInput {
+	return service.PromotionInput{
+		Name:           req.Name,
+		Description:    req.Description,
+		Kind:           req.Kind,
+		Priority:       req.Priority,
+		Exclusive:      req.Exclusive,
+		CategoryID:     req.CategoryID,
+		ProductID:      req.ProductID,
+		BuyQuantity:    req.BuyQuantity,
+		GetQuantity:    req.GetQuantity,
+		PercentOff:     req.PercentOff,
+		MinOrderAmount: req.MinOrderAmount,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+}
+
+func (h *PromotionHandler) List(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.promotionSvc.ListPromotions(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, promotions)
+}
+
+func (h *PromotionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	promotion, err := h.promotionSvc.GetPromotion(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, promotion)
+}
+
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req promotionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	promotion, err := h.promotionSvc.CreatePromotion(r.Context(), req.toInput())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusCreated, promotion)
+}
+
+func (h *PromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req promotionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	promotion, err := h.promotionSvc.UpdatePromotion(r.Context(), chi.URLParam(r, "id"), req.toInput())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeSuccess(w, http.StatusOK, promotion)
+}
+
+func (h *PromotionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.promotionSvc.DeletePromotion(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "promotion deleted"})
+}
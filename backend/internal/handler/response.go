@@ -50,7 +50,8 @@ func resolveError(err error) (int, string) {
 		return http.StatusConflict, msg
 	case errors.Is(err, domain.ErrUnauthorized):
 		return http.StatusUnauthorized, msg
-	case errors.Is(err, domain.ErrForbidden):
+	case errors.Is(err, domain.ErrForbidden),
+		errors.Is(err, domain.ErrUserNotVerified):
 		return http.StatusForbidden, msg
 	case errors.Is(err, domain.ErrInvalidInput):
 		return http.StatusBadRequest, msg
@@ -64,6 +65,10 @@ func resolveError(err error) (int, string) {
 		return http.StatusConflict, msg
 	case errors.Is(err, domain.ErrEmptyCart):
 		return http.StatusBadRequest, msg
+	case errors.Is(err, domain.ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge, msg
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity, msg
 	default:
 		slog.Error("unhandled error", "error", err)
 		return http.StatusInternalServerError, "an internal error occurred"
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/online-cake-shop/backend/internal/middleware"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+type WishlistHandler struct {
+	wishlistSvc *service.WishlistService
+}
+
+func NewWishlistHandler(wishlistSvc *service.WishlistService) *WishlistHandler {
+	return &WishlistHandler{wishlistSvc: wishlistSvc}
+}
+
+type wishlistRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *WishlistHandler) List(w http.ResponseWriter, r *http.Request) {
+	wishlists, err := h.wishlistSvc.ListWishlists(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlists)
+}
+
+func (h *WishlistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req wishlistRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	wishlist, err := h.wishlistSvc.CreateWishlist(r.Context(), middleware.UserIDFromContext(r.Context()), req.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusCreated, wishlist)
+}
+
+func (h *WishlistHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	wishlist, err := h.wishlistSvc.GetWishlist(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
+
+func (h *WishlistHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	var req wishlistRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	wishlist, err := h.wishlistSvc.RenameWishlist(r.Context(), userID, chi.URLParam(r, "id"), req.Name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
+
+func (h *WishlistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	if err := h.wishlistSvc.DeleteWishlist(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, envelope{"message": "wishlist deleted"})
+}
+
+type addWishlistItemRequest struct {
+	ProductID       string                  `json:"product_id"`
+	VariantID       string                  `json:"variant_id"`
+	Personalisation service.Personalisation `json:"personalisation"`
+	Quantity        *int32                  `json:"quantity"`
+}
+
+// AddItem adds a cake to a list. The quantity defaults to one.
+func (h *WishlistHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var req addWishlistItemRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{"success": false, "error": "invalid request body"})
+		return
+	}
+	quantity := int32(1)
+	if req.Quantity != nil {
+		quantity = *req.Quantity
+	}
+
+	wishlist, err := h.wishlistSvc.AddItem(r.Context(), service.AddWishlistItemInput{
+		UserID:     middleware.UserIDFromContext(r.Context()),
+		WishlistID: chi.URLParam(r, "id"),
+		ProductID:  req.ProductID,
+		VariantID:  req.VariantID,
+		Quantity:   quantity,
+
+		Personalisation: req.Personalisation,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
+
+func (h *WishlistHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	wishlist, err := h.wishlistSvc.RemoveItem(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "itemId"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
+
+// MoveItemToCart moves a list item into the caller's cart and returns the
+// cart.
+func (h *WishlistHandler) MoveItemToCart(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	cart, err := h.wishlistSvc.MoveItemToCart(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "itemId"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, cart)
+}
+
+func (h *WishlistHandler) Share(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	wishlist, err := h.wishlistSvc.ShareWishlist(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
+
+func (h *WishlistHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+
+	wishlist, err := h.wishlistSvc.UnshareWishlist(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
+
+// GetShared returns a shared list to anyone with its link.
+func (h *WishlistHandler) GetShared(w http.ResponseWriter, r *http.Request) {
+	wishlist, err := h.wishlistSvc.GetSharedWishlist(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, wishlist)
+}
@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -13,14 +15,32 @@ import (
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey contextKey = "userID"
+	roleKey   contextKey = "role"
+	tokenKey  contextKey = "token"
+)
+
+// RevocationChecker reports whether an otherwise valid access token has been
+// revoked by logout or logout-all.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, userID, tokenID uuid.UUID, version int32) (bool, error)
+}
+
+// TokenInfo describes the access token that authenticated the request.
+type TokenInfo struct {
+	ID        uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
 
 type AuthMiddleware struct {
-	secret string
+	secret      string
+	revocations RevocationChecker
 }
 
-func NewAuthMiddleware(secret string) *AuthMiddleware {
-	return &AuthMiddleware{secret: secret}
+func NewAuthMiddleware(secret string, revocations RevocationChecker) *AuthMiddleware {
+	return &AuthMiddleware{secret: secret, revocations: revocations}
 }
 
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
@@ -49,17 +69,86 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		info, version, err := tokenInfoFromClaims(claims)
+		if err != nil {
+			writeUnauthorized(w)
+			return
+		}
+
+		revoked, err := m.revocations.IsRevoked(r.Context(), userID, info.ID, version)
+		if err != nil {
+			slog.Error("check token revocation", "error", err)
+			writeUnauthorized(w)
+			return
+		}
+		if revoked {
+			writeUnauthorized(w)
+			return
+		}
+
+		// Tokens issued before roles existed carry no role claim.
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = domain.RoleCustomer
+		}
+
 		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		ctx = context.WithValue(ctx, roleKey, role)
+		ctx = context.WithValue(ctx, tokenKey, info)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// OptionalAuthenticate is Authenticate for routes guests may use too.
+// Requests without a token pass through anonymously; a token that is sent
+// must be valid, so that clients refresh it rather than silently acting as
+// guests.
+func (m *AuthMiddleware) OptionalAuthenticate(next http.Handler) http.Handler {
+	authenticated := m.Authenticate(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, _ := extractToken(r); token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole rejects requests whose authenticated user holds none of the
+// given roles. It must run after Authenticate.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := RoleFromContext(r.Context())
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			writeForbidden(w)
+		})
+	}
+}
+
 // UserIDFromContext extracts the authenticated user's UUID from the request context.
 func UserIDFromContext(ctx context.Context) uuid.UUID {
 	id, _ := ctx.Value(userIDKey).(uuid.UUID)
 	return id
 }
 
+// RoleFromContext returns the authenticated user's role.
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	return role
+}
+
+// TokenFromContext returns the access token that authenticated the request.
+func TokenFromContext(ctx context.Context) TokenInfo {
+	info, _ := ctx.Value(tokenKey).(TokenInfo)
+	return info
+}
+
 func extractToken(r *http.Request) (string, error) {
 	// 1. Try Authorization header
 	authHeader := r.Header.Get("Authorization")
@@ -94,8 +183,42 @@ func parseJWT(tokenStr, secret string) (jwt.MapClaims, error) {
 	return claims, nil
 }
 
+func tokenInfoFromClaims(claims jwt.MapClaims) (TokenInfo, int32, error) {
+	jti, _ := claims["jti"].(string)
+	id, err := uuid.Parse(jti)
+	if err != nil {
+		return TokenInfo{}, 0, domain.ErrUnauthorized
+	}
+
+	// sid is optional: tokens are always issued with one, but logout only
+	// needs it to revoke the refresh side of the session.
+	var sessionID uuid.UUID
+	if sid, ok := claims["sid"].(string); ok {
+		sessionID, _ = uuid.Parse(sid)
+	}
+
+	// JSON numbers decode as float64.
+	ver, ok := claims["ver"].(float64)
+	if !ok {
+		return TokenInfo{}, 0, domain.ErrUnauthorized
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return TokenInfo{}, 0, domain.ErrUnauthorized
+	}
+
+	return TokenInfo{ID: id, SessionID: sessionID, ExpiresAt: exp.Time}, int32(ver), nil
+}
+
 func writeUnauthorized(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
 	w.Write([]byte(`{"success":false,"error":"authentication required"}`))
 }
+
+func writeForbidden(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte(`{"success":false,"error":"you do not have permission to perform this action"}`))
+}
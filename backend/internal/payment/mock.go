@@ -0,0 +1,243 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+)
+
+const (
+	MockProviderName    = "mock"
+	MockSignatureHeader = "Mock-Signature"
+
+	// Card tokens understood by the mock provider. Any other token succeeds.
+	MockTokenSuccess = "tok_success"
+	MockTokenDecline = "tok_decline"
+	MockTokenAsync   = "tok_async"
+
+	mockWebhookTolerance = 5 * time.Minute
+)
+
+// MockProvider simulates a card gateway in memory — for development and
+// testing. The card token decides the outcome: MockTokenDecline is declined,
+// MockTokenAsync stays processing until Settle is called, anything else is
+// authorised and can be captured.
+type MockProvider struct {
+	secret []byte
+	logger *slog.Logger
+
+	mu          sync.Mutex
+	intents     map[string]*Intent
+	byReference map[string]string // payment reference -> intent id
+	references  map[string]string // intent id -> payment reference
+	refunds     map[string]*Refund
+	refunded    map[string]domain.Money // intent id -> total refunded
+}
+
+func NewMockProvider(webhookSecret string, logger *slog.Logger) *MockProvider {
+	return &MockProvider{
+		secret:      []byte(webhookSecret),
+		logger:      logger,
+		intents:     make(map[string]*Intent),
+		byReference: make(map[string]string),
+		references:  make(map[string]string),
+		refunds:     make(map[string]*Refund),
+		refunded:    make(map[string]domain.Money),
+	}
+}
+
+func (m *MockProvider) Name() string { return MockProviderName }
+
+func (m *MockProvider) CreateIntent(_ context.Context, params CreateIntentParams) (*Intent, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("mock payment: amount must be positive")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.byReference[params.Reference]; ok {
+		intent := *m.intents[id]
+		return &intent, nil
+	}
+
+	intent := &Intent{ID: mockID("pi_mock_"), Amount: params.Amount}
+	switch params.Token {
+	case MockTokenDecline:
+		intent.Status = StatusFailed
+		intent.FailureReason = "card_declined"
+	case MockTokenAsync:
+		intent.Status = StatusProcessing
+	default:
+		intent.Status = StatusRequiresCapture
+	}
+
+	m.intents[intent.ID] = intent
+	if params.Reference != "" {
+		m.byReference[params.Reference] = intent.ID
+		m.references[intent.ID] = params.Reference
+	}
+
+	m.logger.Info("💳 [MOCK PAYMENT] intent created",
+		"intent", intent.ID,
+		"amount", params.Amount.String(),
+		"currency", params.Currency,
+		"status", intent.Status,
+	)
+
+	out := *intent
+	return &out, nil
+}
+
+func (m *MockProvider) Capture(_ context.Context, intentID string, amount domain.Money) (*Intent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	intent, ok := m.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	switch intent.Status {
+	case StatusSucceeded:
+		// Capturing twice is a no-op, like a retried request.
+	case StatusRequiresCapture:
+		if amount <= 0 || amount > intent.Amount {
+			return nil, fmt.Errorf("mock payment: can't capture %s of %s", amount, intent.Amount)
+		}
+		intent.Amount = amount
+		intent.Status = StatusSucceeded
+		m.logger.Info("💳 [MOCK PAYMENT] intent captured", "intent", intent.ID, "amount", amount.String())
+	default:
+		return nil, fmt.Errorf("mock payment: can't capture an intent that is %s", intent.Status)
+	}
+
+	out := *intent
+	return &out, nil
+}
+
+func (m *MockProvider) Refund(_ context.Context, intentID string, amount domain.Money, reference string) (*Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.refunds[reference]; ok && reference != "" {
+		out := *r
+		return &out, nil
+	}
+
+	r := &Refund{ID: mockID("re_mock_"), IntentID: intentID, Amount: amount, Status: StatusSucceeded}
+	intent, ok := m.intents[intentID]
+	switch {
+	case !ok:
+		r.Status, r.FailureReason = StatusFailed, "unknown payment"
+	case intent.Status != StatusSucceeded:
+		r.Status, r.FailureReason = StatusFailed, fmt.Sprintf("payment is %s", intent.Status)
+	case amount <= 0 || m.refunded[intentID].Add(amount) > intent.Amount:
+		r.Status, r.FailureReason = StatusFailed, "refund exceeds the captured amount"
+	default:
+		m.refunded[intentID] = m.refunded[intentID].Add(amount)
+	}
+	if reference != "" {
+		m.refunds[reference] = r
+	}
+
+	if r.Status == StatusFailed {
+		m.logger.Info("💳 [MOCK PAYMENT] refund refused", "intent", intentID, "reason", r.FailureReason)
+	} else {
+		m.logger.Info("💳 [MOCK PAYMENT] refund issued", "intent", intentID, "refund", r.ID, "amount", amount.String())
+	}
+
+	out := *r
+	return &out, nil
+}
+
+// mockEvent is the JSON body of mock webhooks.
+type mockEvent struct {
+	ID            string       `json:"id"`
+	Type          EventType    `json:"type"`
+	IntentID      string       `json:"intent_id"`
+	Reference     string       `json:"reference,omitempty"`
+	Amount        domain.Money `json:"amount"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	Created       int64        `json:"created"`
+}
+
+func (m *MockProvider) VerifyWebhook(payload []byte, header http.Header) (*Event, error) {
+	if err := VerifySignature(m.secret, payload, header.Get(MockSignatureHeader), time.Now(), mockWebhookTolerance); err != nil {
+		return nil, err
+	}
+
+	var e mockEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, fmt.Errorf("decode mock webhook: %w", err)
+	}
+	if e.ID == "" || e.IntentID == "" {
+		return nil, fmt.Errorf("decode mock webhook: missing id or intent_id")
+	}
+
+	return &Event{
+		ID:            e.ID,
+		Type:          e.Type,
+		IntentID:      e.IntentID,
+		Reference:     e.Reference,
+		Amount:        e.Amount,
+		FailureReason: e.FailureReason,
+		CreatedAt:     time.Unix(e.Created, 0),
+	}, nil
+}
+
+// Settle resolves a processing intent, as the bank eventually would, and
+// returns the signed webhook the gateway would deliver for it.
+func (m *MockProvider) Settle(intentID string, succeed bool) ([]byte, http.Header, error) {
+	m.mu.Lock()
+	intent, ok := m.intents[intentID]
+	if !ok {
+		m.mu.Unlock()
+		return nil, nil, ErrIntentNotFound
+	}
+	if intent.Status != StatusProcessing {
+		status := intent.Status
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("mock payment: can't settle an intent that is %s", status)
+	}
+
+	e := mockEvent{
+		ID:        mockID("evt_mock_"),
+		IntentID:  intent.ID,
+		Reference: m.references[intent.ID],
+		Amount:    intent.Amount,
+		Created:   time.Now().Unix(),
+	}
+	if succeed {
+		intent.Status = StatusSucceeded
+		e.Type = EventPaymentSucceeded
+	} else {
+		intent.Status = StatusFailed
+		intent.FailureReason = "card_declined"
+		e.Type = EventPaymentFailed
+		e.FailureReason = intent.FailureReason
+	}
+	m.mu.Unlock()
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode mock webhook: %w", err)
+	}
+	header := http.Header{}
+	header.Set(MockSignatureHeader, Sign(m.secret, payload, time.Now()))
+	return payload, header, nil
+}
+
+func mockID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,157 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/payment"
+)
+
+func newMock() *payment.MockProvider {
+	return payment.NewMockProvider("whsec_test", slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestMockProviderSuccess(t *testing.T) {
+	ctx := context.Background()
+	p := newMock()
+
+	intent, err := p.CreateIntent(ctx, payment.CreateIntentParams{
+		Reference: "pay_1", Amount: 4500, Currency: "USD", Token: payment.MockTokenSuccess,
+	})
+	if err != nil {
+		t.Fatalf("CreateIntent() error = %v", err)
+	}
+	if intent.Status != payment.StatusRequiresCapture {
+		t.Fatalf("status = %s, want %s", intent.Status, payment.StatusRequiresCapture)
+	}
+
+	again, err := p.CreateIntent(ctx, payment.CreateIntentParams{Reference: "pay_1", Amount: 4500})
+	if err != nil || again.ID != intent.ID {
+		t.Errorf("retrying CreateIntent with the same reference should return the same intent")
+	}
+
+	captured, err := p.Capture(ctx, intent.ID, 4500)
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	if captured.Status != payment.StatusSucceeded {
+		t.Errorf("status after capture = %s, want %s", captured.Status, payment.StatusSucceeded)
+	}
+
+	if _, err := p.Refund(ctx, intent.ID, 1500, "ref_1"); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if _, err := p.Refund(ctx, intent.ID, 1500, "ref_1"); err != nil {
+		t.Errorf("retrying Refund() with the same reference should succeed, got %v", err)
+	}
+	refused, err := p.Refund(ctx, intent.ID, 3001, "ref_2")
+	if err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if refused.Status != payment.StatusFailed {
+		t.Errorf("refunding more than was captured: status = %s, want %s", refused.Status, payment.StatusFailed)
+	}
+}
+
+func TestMockProviderDecline(t *testing.T) {
+	p := newMock()
+
+	intent, err := p.CreateIntent(context.Background(), payment.CreateIntentParams{
+		Reference: "pay_2", Amount: 4500, Token: payment.MockTokenDecline,
+	})
+	if err != nil {
+		t.Fatalf("CreateIntent() error = %v", err)
+	}
+	if intent.Status != payment.StatusFailed || intent.FailureReason == "" {
+		t.Errorf("intent = %+v, want a failed intent with a reason", intent)
+	}
+	if _, err := p.Capture(context.Background(), intent.ID, 4500); err == nil {
+		t.Error("capturing a declined intent should fail")
+	}
+}
+
+func TestMockProviderAsyncWebhook(t *testing.T) {
+	p := newMock()
+
+	intent, err := p.CreateIntent(context.Background(), payment.CreateIntentParams{
+		Reference: "pay_3", Amount: 4500, Token: payment.MockTokenAsync,
+	})
+	if err != nil {
+		t.Fatalf("CreateIntent() error = %v", err)
+	}
+	if intent.Status != payment.StatusProcessing {
+		t.Fatalf("status = %s, want %s", intent.Status, payment.StatusProcessing)
+	}
+
+	body, header, err := p.Settle(intent.ID, true)
+	if err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+
+	event, err := p.VerifyWebhook(body, header)
+	if err != nil {
+		t.Fatalf("VerifyWebhook() error = %v", err)
+	}
+	if event.Type != payment.EventPaymentSucceeded || event.IntentID != intent.ID ||
+		event.Reference != "pay_3" || event.Amount != domain.Money(4500) {
+		t.Errorf("event = %+v", event)
+	}
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = '9'
+	if _, err := p.VerifyWebhook(tampered, header); !errors.Is(err, payment.ErrInvalidSignature) {
+		t.Errorf("VerifyWebhook(tampered) error = %v, want ErrInvalidSignature", err)
+	}
+
+	if _, _, err := p.Settle(intent.ID, false); err == nil {
+		t.Error("settling an intent twice should fail")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("whsec_test")
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1_700_000_000, 0)
+	header := payment.Sign(secret, body, now)
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		header  string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", secret, header, now, false},
+		{"within tolerance", secret, header, now.Add(4 * time.Minute), false},
+		{"too old", secret, header, now.Add(6 * time.Minute), true},
+		{"wrong secret", []byte("other"), header, now, true},
+		{"malformed", secret, "garbage", now, true},
+		{"missing signature", secret, "t=1700000000", now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := payment.VerifySignature(tt.secret, body, tt.header, tt.now, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	mock := newMock()
+	r := payment.NewRegistry(mock)
+
+	if p, err := r.Get(payment.MockProviderName); err != nil || p != mock {
+		t.Errorf("Get(mock) = %v, %v", p, err)
+	}
+	if _, err := r.Get("nope"); !errors.Is(err, payment.ErrUnknownProvider) {
+		t.Errorf("Get(unknown) error = %v, want ErrUnknownProvider", err)
+	}
+}
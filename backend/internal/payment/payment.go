@@ -0,0 +1,116 @@
+// Package payment abstracts card payment gateways behind the Provider
+// interface so the shop can take card payments without depending on a
+// specific gateway.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+)
+
+// Status is the state of a payment intent at the provider.
+type Status string
+
+const (
+	// StatusRequiresCapture means the amount is authorised but not yet taken.
+	StatusRequiresCapture Status = "requires_capture"
+	// StatusProcessing means the outcome will arrive later via a webhook.
+	StatusProcessing Status = "processing"
+	StatusSucceeded  Status = "succeeded"
+	StatusFailed     Status = "failed"
+)
+
+// Intent is a provider-side attempt to collect one payment.
+type Intent struct {
+	ID            string
+	Status        Status
+	Amount        domain.Money
+	FailureReason string
+}
+
+type CreateIntentParams struct {
+	// Reference is our payment id. Providers use it as an idempotency key,
+	// so retrying CreateIntent never charges twice.
+	Reference string
+	Amount    domain.Money
+	Currency  string
+	// Token identifies the customer's card as tokenised by the provider's
+	// client-side SDK.
+	Token string
+}
+
+// Refund is a provider-side refund of (part of) a captured intent.
+type Refund struct {
+	ID            string
+	IntentID      string
+	Amount        domain.Money
+	Status        Status
+	FailureReason string
+}
+
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+)
+
+// Event is a verified webhook notification.
+type Event struct {
+	ID       string
+	Type     EventType
+	IntentID string
+	// Reference echoes CreateIntentParams.Reference, so an event can be
+	// matched even if it arrives before we've stored the intent id.
+	Reference     string
+	Amount        domain.Money
+	FailureReason string
+	CreatedAt     time.Time
+}
+
+// Provider is implemented by each payment gateway integration.
+//
+// A declined card is not an error: CreateIntent and Capture report it as an
+// Intent with StatusFailed, and Refund reports a refused refund as a Refund
+// with StatusFailed. Errors mean the request itself failed and may be
+// retried.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, params CreateIntentParams) (*Intent, error)
+	Capture(ctx context.Context, intentID string, amount domain.Money) (*Intent, error)
+	Refund(ctx context.Context, intentID string, amount domain.Money, reference string) (*Refund, error)
+	// VerifyWebhook checks the signature of an incoming webhook and parses it.
+	VerifyWebhook(payload []byte, header http.Header) (*Event, error)
+}
+
+var (
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrIntentNotFound   = errors.New("payment intent not found")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Registry looks providers up by name.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
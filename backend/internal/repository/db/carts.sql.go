@@ -16,47 +16,70 @@ import (
 const getOrCreateCart = `-- name: GetOrCreateCart :one
 INSERT INTO carts (user_id) VALUES ($1)
 ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
-RETURNING id, user_id, created_at, updated_at
+RETURNING id, user_id, created_at, updated_at, coupon_id
 `
 
 func (q *Queries) GetOrCreateCart(ctx context.Context, userID uuid.UUID) (Cart, error) {
 	row := q.db.QueryRow(ctx, getOrCreateCart, userID)
 	var c Cart
-	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt)
+	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt, &c.CouponID)
 	return c, err
 }
 
 const getCartByUserID = `-- name: GetCartByUserID :one
-SELECT id, user_id, created_at, updated_at FROM carts WHERE user_id = $1
+SELECT id, user_id, created_at, updated_at, coupon_id FROM carts WHERE user_id = $1
 `
 
 func (q *Queries) GetCartByUserID(ctx context.Context, userID uuid.UUID) (Cart, error) {
 	row := q.db.QueryRow(ctx, getCartByUserID, userID)
 	var c Cart
-	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt)
+	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt, &c.CouponID)
 	return c, err
 }
 
-// GetCartItemsRow joins cart_items with product details.
+// GetCartItemsRow joins cart_items with product and variant details. The
+// price and stock are the variant's.
 type GetCartItemsRow struct {
-	ID              uuid.UUID      `json:"id"`
-	CartID          uuid.UUID      `json:"cart_id"`
-	ProductID       uuid.UUID      `json:"product_id"`
-	Quantity        int32          `json:"quantity"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	ProductName     string         `json:"product_name"`
-	ProductPrice    pgtype.Numeric `json:"product_price"`
-	ProductImageUrl pgtype.Text    `json:"product_image_url"`
-	ProductStock    int32          `json:"product_stock"`
+	ID                            uuid.UUID          `json:"id"`
+	CartID                        uuid.UUID          `json:"cart_id"`
+	ProductID                     uuid.UUID          `json:"product_id"`
+	VariantID                     uuid.UUID          `json:"variant_id"`
+	Quantity                      int32              `json:"quantity"`
+	Personalisation               []byte             `json:"personalisation"`
+	AddedPrice                    pgtype.Numeric     `json:"added_price"`
+	CreatedAt                     time.Time          `json:"created_at"`
+	UpdatedAt                     time.Time          `json:"updated_at"`
+	ProductName                   string             `json:"product_name"`
+	ProductPrice                  pgtype.Numeric     `json:"product_price"`
+	ProductImageUrl               pgtype.Text        `json:"product_image_url"`
+	ProductStock                  int32              `json:"product_stock"`
+	VariantSku                    string             `json:"variant_sku"`
+	VariantOptions                []byte             `json:"variant_options"`
+	ProductPersonalisationOptions []byte             `json:"product_personalisation_options"`
+	ReservedUntil                 pgtype.Timestamptz `json:"reserved_until"`
+	Unavailable                   bool               `json:"unavailable"`
+	AvailableQuantity             int32              `json:"available_quantity"`
 }
 
 const getCartItems = `-- name: GetCartItems :many
-SELECT ci.id, ci.cart_id, ci.product_id, ci.quantity, ci.created_at, ci.updated_at,
-       p.name AS product_name, p.price AS product_price,
-       p.image_url AS product_image_url, p.stock_quantity AS product_stock
+SELECT ci.id, ci.cart_id, ci.product_id, ci.variant_id, ci.quantity, ci.personalisation, ci.added_price, ci.created_at, ci.updated_at,
+       p.name AS product_name, v.price AS product_price,
+       p.image_url AS product_image_url, v.stock_quantity AS product_stock,
+       v.sku AS variant_sku, v.options AS variant_options,
+       p.personalisation_options AS product_personalisation_options,
+       sr.expires_at AS reserved_until,
+       (p.deleted_at IS NOT NULL OR NOT p.is_active OR NOT v.is_active) AS unavailable,
+       GREATEST(v.stock_quantity - COALESCE((
+           SELECT SUM(o.quantity) FROM stock_reservations o
+           WHERE o.variant_id = ci.variant_id
+             AND o.cart_id <> ci.cart_id
+             AND o.expires_at > NOW()
+       ), 0), 0)::INT AS available_quantity
 FROM cart_items ci
 JOIN products p ON p.id = ci.product_id
+JOIN product_variants v ON v.id = ci.variant_id
+LEFT JOIN stock_reservations sr
+       ON sr.cart_id = ci.cart_id AND sr.variant_id = ci.variant_id AND sr.expires_at > NOW()
 WHERE ci.cart_id = $1 ORDER BY ci.created_at ASC
 `
 
@@ -71,9 +94,11 @@ func (q *Queries) GetCartItems(ctx context.Context, cartID uuid.UUID) ([]GetCart
 	for rows.Next() {
 		var i GetCartItemsRow
 		if err := rows.Scan(
-			&i.ID, &i.CartID, &i.ProductID, &i.Quantity,
-			&i.CreatedAt, &i.UpdatedAt,
+			&i.ID, &i.CartID, &i.ProductID, &i.VariantID, &i.Quantity, &i.Personalisation,
+			&i.AddedPrice, &i.CreatedAt, &i.UpdatedAt,
 			&i.ProductName, &i.ProductPrice, &i.ProductImageUrl, &i.ProductStock,
+			&i.VariantSku, &i.VariantOptions, &i.ProductPersonalisationOptions, &i.ReservedUntil,
+			&i.Unavailable, &i.AvailableQuantity,
 		); err != nil {
 			return nil, err
 		}
@@ -83,41 +108,47 @@ func (q *Queries) GetCartItems(ctx context.Context, cartID uuid.UUID) ([]GetCart
 }
 
 const upsertCartItem = `-- name: UpsertCartItem :one
-INSERT INTO cart_items (cart_id, product_id, quantity)
-VALUES ($1, $2, $3)
-ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = $3, updated_at = NOW()
-RETURNING id, cart_id, product_id, quantity, created_at, updated_at
+INSERT INTO cart_items (cart_id, product_id, variant_id, personalisation, quantity, added_price)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (cart_id, variant_id, personalisation) DO UPDATE SET quantity = $5, added_price = $6, updated_at = NOW()
+RETURNING id, cart_id, product_id, quantity, created_at, updated_at, variant_id, personalisation, added_price
 `
 
 type UpsertCartItemParams struct {
-	CartID    uuid.UUID `json:"cart_id"`
-	ProductID uuid.UUID `json:"product_id"`
-	Quantity  int32     `json:"quantity"`
+	CartID          uuid.UUID      `json:"cart_id"`
+	ProductID       uuid.UUID      `json:"product_id"`
+	VariantID       uuid.UUID      `json:"variant_id"`
+	Personalisation []byte         `json:"personalisation"`
+	Quantity        int32          `json:"quantity"`
+	AddedPrice      pgtype.Numeric `json:"added_price"`
 }
 
 func (q *Queries) UpsertCartItem(ctx context.Context, arg UpsertCartItemParams) (CartItem, error) {
-	row := q.db.QueryRow(ctx, upsertCartItem, arg.CartID, arg.ProductID, arg.Quantity)
+	row := q.db.QueryRow(ctx, upsertCartItem,
+		arg.CartID, arg.ProductID, arg.VariantID, arg.Personalisation, arg.Quantity, arg.AddedPrice,
+	)
 	var i CartItem
-	err := row.Scan(&i.ID, &i.CartID, &i.ProductID, &i.Quantity, &i.CreatedAt, &i.UpdatedAt)
+	err := row.Scan(&i.ID, &i.CartID, &i.ProductID, &i.Quantity, &i.CreatedAt, &i.UpdatedAt, &i.VariantID, &i.Personalisation, &i.AddedPrice)
 	return i, err
 }
 
 const updateCartItemQuantity = `-- name: UpdateCartItemQuantity :one
-UPDATE cart_items SET quantity = $2, updated_at = NOW()
+UPDATE cart_items SET quantity = $2, added_price = $4, updated_at = NOW()
 WHERE id = $1 AND cart_id = $3
-RETURNING id, cart_id, product_id, quantity, created_at, updated_at
+RETURNING id, cart_id, product_id, quantity, created_at, updated_at, variant_id, personalisation, added_price
 `
 
 type UpdateCartItemQuantityParams struct {
-	ID       uuid.UUID `json:"id"`
-	Quantity int32     `json:"quantity"`
-	CartID   uuid.UUID `json:"cart_id"`
+	ID         uuid.UUID      `json:"id"`
+	Quantity   int32          `json:"quantity"`
+	CartID     uuid.UUID      `json:"cart_id"`
+	AddedPrice pgtype.Numeric `json:"added_price"`
 }
 
 func (q *Queries) UpdateCartItemQuantity(ctx context.Context, arg UpdateCartItemQuantityParams) (CartItem, error) {
-	row := q.db.QueryRow(ctx, updateCartItemQuantity, arg.ID, arg.Quantity, arg.CartID)
+	row := q.db.QueryRow(ctx, updateCartItemQuantity, arg.ID, arg.Quantity, arg.CartID, arg.AddedPrice)
 	var i CartItem
-	err := row.Scan(&i.ID, &i.CartID, &i.ProductID, &i.Quantity, &i.CreatedAt, &i.UpdatedAt)
+	err := row.Scan(&i.ID, &i.CartID, &i.ProductID, &i.Quantity, &i.CreatedAt, &i.UpdatedAt, &i.VariantID, &i.Personalisation, &i.AddedPrice)
 	return i, err
 }
 
@@ -143,3 +174,152 @@ func (q *Queries) ClearCart(ctx context.Context, cartID uuid.UUID) error {
 	_, err := q.db.Exec(ctx, clearCart, cartID)
 	return err
 }
+
+const getCartItem = `-- name: GetCartItem :one
+SELECT id, cart_id, product_id, quantity, created_at, updated_at, variant_id, personalisation, added_price
+FROM cart_items WHERE id = $1 AND cart_id = $2
+`
+
+func (q *Queries) GetCartItem(ctx context.Context, id, cartID uuid.UUID) (CartItem, error) {
+	row := q.db.QueryRow(ctx, getCartItem, id, cartID)
+	var i CartItem
+	err := row.Scan(&i.ID, &i.CartID, &i.ProductID, &i.Quantity, &i.CreatedAt, &i.UpdatedAt, &i.VariantID, &i.Personalisation, &i.AddedPrice)
+	return i, err
+}
+
+const sumCartVariantQuantity = `-- name: SumCartVariantQuantity :one
+SELECT COALESCE(SUM(quantity), 0)::INT FROM cart_items
+WHERE cart_id = $1 AND variant_id = $2
+`
+
+// SumCartVariantQuantity is the quantity of a variant across a cart's lines,
+// which may differ in personalisation.
+func (q *Queries) SumCartVariantQuantity(ctx context.Context, cartID, variantID uuid.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, sumCartVariantQuantity, cartID, variantID)
+	var quantity int32
+	err := row.Scan(&quantity)
+	return quantity, err
+}
+
+const createGuestCart = `-- name: CreateGuestCart :one
+INSERT INTO carts (user_id) VALUES (NULL)
+RETURNING id, user_id, created_at, updated_at, coupon_id
+`
+
+func (q *Queries) CreateGuestCart(ctx context.Context) (Cart, error) {
+	row := q.db.QueryRow(ctx, createGuestCart)
+	var c Cart
+	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt, &c.CouponID)
+	return c, err
+}
+
+const touchGuestCart = `-- name: TouchGuestCart :one
+UPDATE carts SET updated_at = NOW()
+WHERE id = $1 AND user_id IS NULL
+RETURNING id, user_id, created_at, updated_at, coupon_id
+`
+
+// TouchGuestCart returns a guest cart, marking it as used so it isn't swept.
+func (q *Queries) TouchGuestCart(ctx context.Context, id uuid.UUID) (Cart, error) {
+	row := q.db.QueryRow(ctx, touchGuestCart, id)
+	var c Cart
+	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt, &c.CouponID)
+	return c, err
+}
+
+const lockGuestCart = `-- name: LockGuestCart :one
+SELECT id, user_id, created_at, updated_at, coupon_id FROM carts WHERE id = $1 AND user_id IS NULL FOR UPDATE
+`
+
+func (q *Queries) LockGuestCart(ctx context.Context, id uuid.UUID) (Cart, error) {
+	row := q.db.QueryRow(ctx, lockGuestCart, id)
+	var c Cart
+	err := row.Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt, &c.CouponID)
+	return c, err
+}
+
+const listCartItems = `-- name: ListCartItems :many
+SELECT id, cart_id, product_id, quantity, created_at, updated_at, variant_id, personalisation, added_price
+FROM cart_items WHERE cart_id = $1 ORDER BY created_at ASC
+`
+
+func (q *Queries) ListCartItems(ctx context.Context, cartID uuid.UUID) ([]CartItem, error) {
+	rows, err := q.db.Query(ctx, listCartItems, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []CartItem
+	for rows.Next() {
+		var i CartItem
+		if err := rows.Scan(&i.ID, &i.CartID, &i.ProductID, &i.Quantity, &i.CreatedAt, &i.UpdatedAt, &i.VariantID, &i.Personalisation, &i.AddedPrice); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const getCartLineQuantity = `-- name: GetCartLineQuantity :one
+SELECT COALESCE(MAX(quantity), 0)::INT FROM cart_items
+WHERE cart_id = $1 AND variant_id = $2 AND personalisation = $3
+`
+
+type GetCartLineQuantityParams struct {
+	CartID          uuid.UUID `json:"cart_id"`
+	VariantID       uuid.UUID `json:"variant_id"`
+	Personalisation []byte    `json:"personalisation"`
+}
+
+// GetCartLineQuantity is the quantity of the cart's line for a variant with
+// a personalisation, or 0 if it has none.
+func (q *Queries) GetCartLineQuantity(ctx context.Context, arg GetCartLineQuantityParams) (int32, error) {
+	row := q.db.QueryRow(ctx, getCartLineQuantity, arg.CartID, arg.VariantID, arg.Personalisation)
+	var quantity int32
+	err := row.Scan(&quantity)
+	return quantity, err
+}
+
+const deleteCart = `-- name: DeleteCart :exec
+DELETE FROM carts WHERE id = $1
+`
+
+func (q *Queries) DeleteCart(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCart, id)
+	return err
+}
+
+const deleteIdleGuestCarts = `-- name: DeleteIdleGuestCarts :execrows
+DELETE FROM carts WHERE user_id IS NULL AND updated_at < $1
+`
+
+func (q *Queries) DeleteIdleGuestCarts(ctx context.Context, updatedAt time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteIdleGuestCarts, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setCartCoupon = `-- name: SetCartCoupon :exec
+UPDATE carts SET coupon_id = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SetCartCoupon(ctx context.Context, id uuid.UUID, couponID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, setCartCoupon, id, couponID)
+	return err
+}
+
+const mergeCartCoupon = `-- name: MergeCartCoupon :exec
+UPDATE carts SET coupon_id = COALESCE(coupon_id, $2)
+WHERE id = $1
+`
+
+// MergeCartCoupon gives a cart the coupon of a merged guest cart unless it
+// has its own.
+func (q *Queries) MergeCartCoupon(ctx context.Context, id uuid.UUID, couponID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, mergeCartCoupon, id, couponID)
+	return err
+}
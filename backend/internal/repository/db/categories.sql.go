@@ -9,27 +9,29 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createCategory = `-- name: CreateCategory :one
-INSERT INTO categories (name, slug) VALUES ($1, $2)
-RETURNING id, name, slug, created_at, updated_at
+INSERT INTO categories (name, slug, parent_id) VALUES ($1, $2, $3)
+RETURNING id, name, slug, created_at, updated_at, parent_id
 `
 
 type CreateCategoryParams struct {
-	Name string `json:"name"`
-	Slug string `json:"slug"`
+	Name     string      `json:"name"`
+	Slug     string      `json:"slug"`
+	ParentID pgtype.UUID `json:"parent_id"`
 }
 
 func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
-	row := q.db.QueryRow(ctx, createCategory, arg.Name, arg.Slug)
+	row := q.db.QueryRow(ctx, createCategory, arg.Name, arg.Slug, arg.ParentID)
 	var c Category
-	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt)
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt, &c.ParentID)
 	return c, err
 }
 
 const listCategories = `-- name: ListCategories :many
-SELECT id, name, slug, created_at, updated_at FROM categories ORDER BY name ASC
+SELECT id, name, slug, created_at, updated_at, parent_id FROM categories ORDER BY name ASC
 `
 
 func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
@@ -42,7 +44,7 @@ func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
 	var categories []Category
 	for rows.Next() {
 		var c Category
-		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt, &c.ParentID); err != nil {
 			return nil, err
 		}
 		categories = append(categories, c)
@@ -51,23 +53,125 @@ func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
 }
 
 const getCategoryByID = `-- name: GetCategoryByID :one
-SELECT id, name, slug, created_at, updated_at FROM categories WHERE id = $1
+SELECT id, name, slug, created_at, updated_at, parent_id FROM categories WHERE id = $1
 `
 
 func (q *Queries) GetCategoryByID(ctx context.Context, id uuid.UUID) (Category, error) {
 	row := q.db.QueryRow(ctx, getCategoryByID, id)
 	var c Category
-	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt)
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt, &c.ParentID)
 	return c, err
 }
 
 const getCategoryBySlug = `-- name: GetCategoryBySlug :one
-SELECT id, name, slug, created_at, updated_at FROM categories WHERE slug = $1
+SELECT id, name, slug, created_at, updated_at, parent_id FROM categories WHERE slug = $1
 `
 
 func (q *Queries) GetCategoryBySlug(ctx context.Context, slug string) (Category, error) {
 	row := q.db.QueryRow(ctx, getCategoryBySlug, slug)
 	var c Category
-	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt)
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt, &c.ParentID)
 	return c, err
 }
+
+const updateCategory = `-- name: UpdateCategory :one
+UPDATE categories
+SET name = $2, slug = $3, parent_id = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, slug, created_at, updated_at, parent_id
+`
+
+type UpdateCategoryParams struct {
+	ID       uuid.UUID   `json:"id"`
+	Name     string      `json:"name"`
+	Slug     string      `json:"slug"`
+	ParentID pgtype.UUID `json:"parent_id"`
+}
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error) {
+	row := q.db.QueryRow(ctx, updateCategory, arg.ID, arg.Name, arg.Slug, arg.ParentID)
+	var c Category
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.CreatedAt, &c.UpdatedAt, &c.ParentID)
+	return c, err
+}
+
+const deleteCategory = `-- name: DeleteCategory :execrows
+DELETE FROM categories WHERE id = $1
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCategory, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countCategoryChildren = `-- name: CountCategoryChildren :one
+SELECT COUNT(*) FROM categories WHERE parent_id = $1
+`
+
+func (q *Queries) CountCategoryChildren(ctx context.Context, parentID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countCategoryChildren, parentID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const listCategorySlugsLike = `-- name: ListCategorySlugsLike :many
+SELECT slug FROM categories
+WHERE (slug = $1 OR slug LIKE $1 || '-%')
+  AND ($2::uuid IS NULL OR id <> $2)
+`
+
+type ListCategorySlugsLikeParams struct {
+	Slug      string      `json:"slug"`
+	ExcludeID pgtype.UUID `json:"exclude_id"`
+}
+
+// Slugs equal to $1 or of the form "$1-<suffix>", optionally ignoring one category.
+func (q *Queries) ListCategorySlugsLike(ctx context.Context, arg ListCategorySlugsLikeParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listCategorySlugsLike, arg.Slug, arg.ExcludeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slugs []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		slugs = append(slugs, slug)
+	}
+	return slugs, rows.Err()
+}
+
+const listCategoryDescendantIDs = `-- name: ListCategoryDescendantIDs :many
+WITH RECURSIVE tree AS (
+    SELECT id FROM categories WHERE id = $1
+    UNION ALL
+    SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id FROM tree
+`
+
+// The category itself and every category below it.
+func (q *Queries) ListCategoryDescendantIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listCategoryDescendantIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -21,6 +21,8 @@ type User struct {
 	CreatedAt    time.Time          `json:"created_at"`
 	UpdatedAt    time.Time          `json:"updated_at"`
 	DeletedAt    pgtype.Timestamptz `json:"deleted_at"`
+	TokenVersion int32              `json:"token_version"`
+	Role         string             `json:"role"`
 }
 
 type EmailOtp struct {
@@ -31,9 +33,10 @@ type EmailOtp struct {
 	IsUsed       bool      `json:"is_used"`
 	AttemptCount int32     `json:"attempt_count"`
 	CreatedAt    time.Time `json:"created_at"`
+	Purpose      string    `json:"purpose"`
 }
 
-type Category struct {
+type Allergen struct {
 	ID        uuid.UUID `json:"id"`
 	Name      string    `json:"name"`
 	Slug      string    `json:"slug"`
@@ -41,55 +44,284 @@ type Category struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type Category struct {
+	ID        uuid.UUID   `json:"id"`
+	Name      string      `json:"name"`
+	Slug      string      `json:"slug"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	ParentID  pgtype.UUID `json:"parent_id"`
+}
+
+type ProductAllergen struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	AllergenID uuid.UUID `json:"allergen_id"`
+	Status     string    `json:"status"`
+}
+
 type Product struct {
-	ID            uuid.UUID          `json:"id"`
-	CategoryID    pgtype.UUID        `json:"category_id"`
-	Name          string             `json:"name"`
-	Description   pgtype.Text        `json:"description"`
-	Price         pgtype.Numeric     `json:"price"`
-	ImageUrl      pgtype.Text        `json:"image_url"`
-	StockQuantity int32              `json:"stock_quantity"`
-	IsActive      bool               `json:"is_active"`
-	CreatedAt     time.Time          `json:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
+	ID                     uuid.UUID          `json:"id"`
+	CategoryID             pgtype.UUID        `json:"category_id"`
+	Name                   string             `json:"name"`
+	Description            pgtype.Text        `json:"description"`
+	ImageUrl               pgtype.Text        `json:"image_url"`
+	IsActive               bool               `json:"is_active"`
+	CreatedAt              time.Time          `json:"created_at"`
+	UpdatedAt              time.Time          `json:"updated_at"`
+	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
+	PersonalisationOptions []byte             `json:"personalisation_options"`
+	DietaryTags            []string           `json:"dietary_tags"`
 }
 
-type Cart struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+type ProductImage struct {
+	ID           uuid.UUID `json:"id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	StorageKey   string    `json:"storage_key"`
+	ThumbnailKey string    `json:"thumbnail_key"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Position     int32     `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
-type CartItem struct {
-	ID        uuid.UUID `json:"id"`
-	CartID    uuid.UUID `json:"cart_id"`
-	ProductID uuid.UUID `json:"product_id"`
-	Quantity  int32     `json:"quantity"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+type ProductVariant struct {
+	ID            uuid.UUID      `json:"id"`
+	ProductID     uuid.UUID      `json:"product_id"`
+	Sku           string         `json:"sku"`
+	Options       []byte         `json:"options"`
+	Price         pgtype.Numeric `json:"price"`
+	StockQuantity int32          `json:"stock_quantity"`
+	Position      int32          `json:"position"`
+	IsActive      bool           `json:"is_active"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
 }
 
-type Order struct {
+type Cart struct {
+	ID        uuid.UUID   `json:"id"`
+	UserID    pgtype.UUID `json:"user_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	CouponID  pgtype.UUID `json:"coupon_id"`
+}
+
+type CartItem struct {
 	ID              uuid.UUID      `json:"id"`
-	UserID          uuid.UUID      `json:"user_id"`
-	DeliveryAddress string         `json:"delivery_address"`
-	DeliveryDate    time.Time      `json:"delivery_date"`
-	Notes           pgtype.Text    `json:"notes"`
-	PaymentMethod   string         `json:"payment_method"`
-	Status          string         `json:"status"`
-	TotalAmount     pgtype.Numeric `json:"total_amount"`
+	CartID          uuid.UUID      `json:"cart_id"`
+	ProductID       uuid.UUID      `json:"product_id"`
+	Quantity        int32          `json:"quantity"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
+	VariantID       uuid.UUID      `json:"variant_id"`
+	Personalisation []byte         `json:"personalisation"`
+	AddedPrice      pgtype.Numeric `json:"added_price"`
+}
+
+type Order struct {
+	ID                uuid.UUID      `json:"id"`
+	UserID            uuid.UUID      `json:"user_id"`
+	DeliveryAddress   string         `json:"delivery_address"`
+	DeliveryDate      time.Time      `json:"delivery_date"`
+	Notes             pgtype.Text    `json:"notes"`
+	PaymentMethod     string         `json:"payment_method"`
+	Status            string         `json:"status"`
+	TotalAmount       pgtype.Numeric `json:"total_amount"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	RefundedAmount    pgtype.Numeric `json:"refunded_amount"`
+	CouponID          pgtype.UUID    `json:"coupon_id"`
+	CouponCode        pgtype.Text    `json:"coupon_code"`
+	DiscountAmount    pgtype.Numeric `json:"discount_amount"`
+	PromotionDiscount pgtype.Numeric `json:"promotion_discount"`
+	DeliveryFee       pgtype.Numeric `json:"delivery_fee"`
+}
+
+type OrderStatusHistory struct {
+	ID         uuid.UUID   `json:"id"`
+	OrderID    uuid.UUID   `json:"order_id"`
+	FromStatus pgtype.Text `json:"from_status"`
+	ToStatus   string      `json:"to_status"`
+	ActorID    pgtype.UUID `json:"actor_id"`
+	Note       pgtype.Text `json:"note"`
+	CreatedAt  time.Time   `json:"created_at"`
 }
 
 type OrderItem struct {
-	ID         uuid.UUID      `json:"id"`
-	OrderID    uuid.UUID      `json:"order_id"`
-	ProductID  uuid.UUID      `json:"product_id"`
-	Quantity   int32          `json:"quantity"`
-	UnitPrice  pgtype.Numeric `json:"unit_price"`
-	TotalPrice pgtype.Numeric `json:"total_price"`
-	CreatedAt  time.Time      `json:"created_at"`
+	ID                   uuid.UUID      `json:"id"`
+	OrderID              uuid.UUID      `json:"order_id"`
+	ProductID            uuid.UUID      `json:"product_id"`
+	Quantity             int32          `json:"quantity"`
+	UnitPrice            pgtype.Numeric `json:"unit_price"`
+	TotalPrice           pgtype.Numeric `json:"total_price"`
+	CreatedAt            time.Time      `json:"created_at"`
+	RefundedQuantity     int32          `json:"refunded_quantity"`
+	VariantID            uuid.UUID      `json:"variant_id"`
+	Personalisation      []byte         `json:"personalisation"`
+	PersonalisationPrice pgtype.Numeric `json:"personalisation_price"`
+	Allergens            []byte         `json:"allergens"`
+}
+
+type Session struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	FamilyID  uuid.UUID          `json:"family_id"`
+	TokenHash string             `json:"token_hash"`
+	UserAgent pgtype.Text        `json:"user_agent"`
+	IpAddress pgtype.Text        `json:"ip_address"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	RotatedAt pgtype.Timestamptz `json:"rotated_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RevokedToken struct {
+	Jti       uuid.UUID `json:"jti"`
+	UserID    uuid.UUID `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type IdempotencyKey struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Key          string    `json:"key"`
+	Fingerprint  string    `json:"fingerprint"`
+	ResponseBody []byte    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type Payment struct {
+	ID               uuid.UUID      `json:"id"`
+	OrderID          uuid.UUID      `json:"order_id"`
+	Provider         string         `json:"provider"`
+	ProviderIntentID pgtype.Text    `json:"provider_intent_id"`
+	Status           string         `json:"status"`
+	Amount           pgtype.Numeric `json:"amount"`
+	Currency         string         `json:"currency"`
+	FailureReason    pgtype.Text    `json:"failure_reason"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+type PaymentEvent struct {
+	ID          uuid.UUID          `json:"id"`
+	Provider    string             `json:"provider"`
+	EventID     string             `json:"event_id"`
+	EventType   string             `json:"event_type"`
+	PaymentID   pgtype.UUID        `json:"payment_id"`
+	Payload     []byte             `json:"payload"`
+	Outcome     pgtype.Text        `json:"outcome"`
+	OccurredAt  pgtype.Timestamptz `json:"occurred_at"`
+	ReceivedAt  time.Time          `json:"received_at"`
+	ProcessedAt pgtype.Timestamptz `json:"processed_at"`
+}
+
+type Refund struct {
+	ID               uuid.UUID      `json:"id"`
+	OrderID          uuid.UUID      `json:"order_id"`
+	PaymentID        pgtype.UUID    `json:"payment_id"`
+	ProviderRefundID pgtype.Text    `json:"provider_refund_id"`
+	Status           string         `json:"status"`
+	Amount           pgtype.Numeric `json:"amount"`
+	Reason           pgtype.Text    `json:"reason"`
+	Restock          bool           `json:"restock"`
+	ActorID          pgtype.UUID    `json:"actor_id"`
+	FailureReason    pgtype.Text    `json:"failure_reason"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+type RefundItem struct {
+	RefundID    uuid.UUID      `json:"refund_id"`
+	OrderItemID uuid.UUID      `json:"order_item_id"`
+	Quantity    int32          `json:"quantity"`
+	Amount      pgtype.Numeric `json:"amount"`
+}
+
+type Coupon struct {
+	ID             uuid.UUID          `json:"id"`
+	Code           string             `json:"code"`
+	Description    pgtype.Text        `json:"description"`
+	DiscountType   string             `json:"discount_type"`
+	DiscountValue  pgtype.Numeric     `json:"discount_value"`
+	MinOrderAmount pgtype.Numeric     `json:"min_order_amount"`
+	StartsAt       pgtype.Timestamptz `json:"starts_at"`
+	EndsAt         pgtype.Timestamptz `json:"ends_at"`
+	MaxUses        pgtype.Int4        `json:"max_uses"`
+	MaxUsesPerUser pgtype.Int4        `json:"max_uses_per_user"`
+	UsedCount      int32              `json:"used_count"`
+	IsActive       bool               `json:"is_active"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type CouponCategory struct {
+	CouponID   uuid.UUID `json:"coupon_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+}
+
+type CouponProduct struct {
+	CouponID  uuid.UUID `json:"coupon_id"`
+	ProductID uuid.UUID `json:"product_id"`
+}
+
+type CouponRedemption struct {
+	ID             uuid.UUID      `json:"id"`
+	CouponID       uuid.UUID      `json:"coupon_id"`
+	UserID         uuid.UUID      `json:"user_id"`
+	OrderID        uuid.UUID      `json:"order_id"`
+	DiscountAmount pgtype.Numeric `json:"discount_amount"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type Promotion struct {
+	ID             uuid.UUID          `json:"id"`
+	Name           string             `json:"name"`
+	Description    pgtype.Text        `json:"description"`
+	Kind           string             `json:"kind"`
+	Priority       int32              `json:"priority"`
+	Exclusive      bool               `json:"exclusive"`
+	CategoryID     pgtype.UUID        `json:"category_id"`
+	ProductID      pgtype.UUID        `json:"product_id"`
+	BuyQuantity    pgtype.Int4        `json:"buy_quantity"`
+	GetQuantity    pgtype.Int4        `json:"get_quantity"`
+	PercentOff     pgtype.Numeric     `json:"percent_off"`
+	MinOrderAmount pgtype.Numeric     `json:"min_order_amount"`
+	StartsAt       pgtype.Timestamptz `json:"starts_at"`
+	EndsAt         pgtype.Timestamptz `json:"ends_at"`
+	IsActive       bool               `json:"is_active"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type OrderPromotion struct {
+	ID             uuid.UUID      `json:"id"`
+	OrderID        uuid.UUID      `json:"order_id"`
+	PromotionID    pgtype.UUID    `json:"promotion_id"`
+	Name           string         `json:"name"`
+	Detail         string         `json:"detail"`
+	DiscountAmount pgtype.Numeric `json:"discount_amount"`
+	Position       int32          `json:"position"`
+}
+
+type Wishlist struct {
+	ID         uuid.UUID   `json:"id"`
+	UserID     uuid.UUID   `json:"user_id"`
+	Name       string      `json:"name"`
+	ShareToken pgtype.Text `json:"share_token"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type WishlistItem struct {
+	ID              uuid.UUID `json:"id"`
+	WishlistID      uuid.UUID `json:"wishlist_id"`
+	ProductID       uuid.UUID `json:"product_id"`
+	VariantID       uuid.UUID `json:"variant_id"`
+	Personalisation []byte    `json:"personalisation"`
+	Quantity        int32     `json:"quantity"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
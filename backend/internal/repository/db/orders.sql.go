@@ -14,62 +14,81 @@ import (
 )
 
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (user_id, delivery_address, delivery_date, notes, payment_method, total_amount)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at
+INSERT INTO orders (user_id, delivery_address, delivery_date, notes, payment_method, total_amount,
+                    coupon_id, coupon_code, discount_amount, promotion_discount,
+                    delivery_fee)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+          coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
 `
 
 type CreateOrderParams struct {
-	UserID          uuid.UUID      `json:"user_id"`
-	DeliveryAddress string         `json:"delivery_address"`
-	DeliveryDate    time.Time      `json:"delivery_date"`
-	Notes           pgtype.Text    `json:"notes"`
-	PaymentMethod   string         `json:"payment_method"`
-	TotalAmount     pgtype.Numeric `json:"total_amount"`
+	UserID            uuid.UUID      `json:"user_id"`
+	DeliveryAddress   string         `json:"delivery_address"`
+	DeliveryDate      time.Time      `json:"delivery_date"`
+	Notes             pgtype.Text    `json:"notes"`
+	PaymentMethod     string         `json:"payment_method"`
+	TotalAmount       pgtype.Numeric `json:"total_amount"`
+	CouponID          pgtype.UUID    `json:"coupon_id"`
+	CouponCode        pgtype.Text    `json:"coupon_code"`
+	DiscountAmount    pgtype.Numeric `json:"discount_amount"`
+	PromotionDiscount pgtype.Numeric `json:"promotion_discount"`
+	DeliveryFee       pgtype.Numeric `json:"delivery_fee"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
 	row := q.db.QueryRow(ctx, createOrder,
 		arg.UserID, arg.DeliveryAddress, arg.DeliveryDate,
 		arg.Notes, arg.PaymentMethod, arg.TotalAmount,
+		arg.CouponID, arg.CouponCode, arg.DiscountAmount, arg.PromotionDiscount, arg.DeliveryFee,
 	)
 	var o Order
 	err := row.Scan(
 		&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
 		&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
-		&o.CreatedAt, &o.UpdatedAt,
+		&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+		&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
 	)
 	return o, err
 }
 
 const createOrderItem = `-- name: CreateOrderItem :one
-INSERT INTO order_items (order_id, product_id, quantity, unit_price, total_price)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, order_id, product_id, quantity, unit_price, total_price, created_at
+INSERT INTO order_items (order_id, product_id, variant_id, quantity, unit_price, total_price,
+                         personalisation, personalisation_price, allergens)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, order_id, product_id, quantity, unit_price, total_price, created_at, refunded_quantity, variant_id,
+          personalisation, personalisation_price, allergens
 `
 
 type CreateOrderItemParams struct {
-	OrderID    uuid.UUID      `json:"order_id"`
-	ProductID  uuid.UUID      `json:"product_id"`
-	Quantity   int32          `json:"quantity"`
-	UnitPrice  pgtype.Numeric `json:"unit_price"`
-	TotalPrice pgtype.Numeric `json:"total_price"`
+	OrderID              uuid.UUID      `json:"order_id"`
+	ProductID            uuid.UUID      `json:"product_id"`
+	VariantID            uuid.UUID      `json:"variant_id"`
+	Quantity             int32          `json:"quantity"`
+	UnitPrice            pgtype.Numeric `json:"unit_price"`
+	TotalPrice           pgtype.Numeric `json:"total_price"`
+	Personalisation      []byte         `json:"personalisation"`
+	PersonalisationPrice pgtype.Numeric `json:"personalisation_price"`
+	Allergens            []byte         `json:"allergens"`
 }
 
 func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error) {
 	row := q.db.QueryRow(ctx, createOrderItem,
-		arg.OrderID, arg.ProductID, arg.Quantity, arg.UnitPrice, arg.TotalPrice,
+		arg.OrderID, arg.ProductID, arg.VariantID, arg.Quantity, arg.UnitPrice, arg.TotalPrice,
+		arg.Personalisation, arg.PersonalisationPrice, arg.Allergens,
 	)
 	var i OrderItem
 	err := row.Scan(
 		&i.ID, &i.OrderID, &i.ProductID, &i.Quantity,
-		&i.UnitPrice, &i.TotalPrice, &i.CreatedAt,
+		&i.UnitPrice, &i.TotalPrice, &i.CreatedAt, &i.RefundedQuantity, &i.VariantID,
+		&i.Personalisation, &i.PersonalisationPrice, &i.Allergens,
 	)
 	return i, err
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at
+SELECT id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+       coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
 FROM orders WHERE id = $1 AND user_id = $2
 `
 
@@ -79,13 +98,33 @@ func (q *Queries) GetOrderByID(ctx context.Context, id, userID uuid.UUID) (Order
 	err := row.Scan(
 		&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
 		&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
-		&o.CreatedAt, &o.UpdatedAt,
+		&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+		&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
+	)
+	return o, err
+}
+
+const getOrderByIDAdmin = `-- name: GetOrderByIDAdmin :one
+SELECT id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+       coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
+FROM orders WHERE id = $1
+`
+
+func (q *Queries) GetOrderByIDAdmin(ctx context.Context, id uuid.UUID) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderByIDAdmin, id)
+	var o Order
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
+		&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
+		&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+		&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
 	)
 	return o, err
 }
 
 const listOrdersByUserID = `-- name: ListOrdersByUserID :many
-SELECT id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at
+SELECT id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+       coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
 FROM orders WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
@@ -102,7 +141,8 @@ func (q *Queries) ListOrdersByUserID(ctx context.Context, userID uuid.UUID, limi
 		if err := rows.Scan(
 			&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
 			&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
-			&o.CreatedAt, &o.UpdatedAt,
+			&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+			&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
 		); err != nil {
 			return nil, err
 		}
@@ -122,17 +162,24 @@ func (q *Queries) CountOrdersByUserID(ctx context.Context, userID uuid.UUID) (in
 	return count, err
 }
 
-// GetOrderItemsRow joins order_items with product name and image.
+// GetOrderItemsRow joins order_items with product name and image and the
+// variant's SKU and options.
 type GetOrderItemsRow struct {
 	OrderItem
 	ProductName     string      `json:"product_name"`
 	ProductImageUrl pgtype.Text `json:"product_image_url"`
+	VariantSku      string      `json:"variant_sku"`
+	VariantOptions  []byte      `json:"variant_options"`
 }
 
 const getOrderItems = `-- name: GetOrderItems :many
-SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.unit_price, oi.total_price, oi.created_at,
-       p.name AS product_name, p.image_url AS product_image_url
-FROM order_items oi JOIN products p ON p.id = oi.product_id
+SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.unit_price, oi.total_price, oi.created_at, oi.refunded_quantity, oi.variant_id,
+       oi.personalisation, oi.personalisation_price, oi.allergens,
+       p.name AS product_name, p.image_url AS product_image_url,
+       v.sku AS variant_sku, v.options AS variant_options
+FROM order_items oi
+JOIN products p ON p.id = oi.product_id
+JOIN product_variants v ON v.id = oi.variant_id
 WHERE oi.order_id = $1
 `
 
@@ -148,8 +195,9 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]GetOr
 		var i GetOrderItemsRow
 		if err := rows.Scan(
 			&i.ID, &i.OrderID, &i.ProductID, &i.Quantity,
-			&i.UnitPrice, &i.TotalPrice, &i.CreatedAt,
-			&i.ProductName, &i.ProductImageUrl,
+			&i.UnitPrice, &i.TotalPrice, &i.CreatedAt, &i.RefundedQuantity, &i.VariantID,
+			&i.Personalisation, &i.PersonalisationPrice, &i.Allergens,
+			&i.ProductName, &i.ProductImageUrl, &i.VariantSku, &i.VariantOptions,
 		); err != nil {
 			return nil, err
 		}
@@ -160,7 +208,8 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]GetOr
 
 const updateOrderStatus = `-- name: UpdateOrderStatus :one
 UPDATE orders SET status = $2, updated_at = NOW() WHERE id = $1
-RETURNING id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at
+RETURNING id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+          coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
 `
 
 func (q *Queries) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string) (Order, error) {
@@ -169,7 +218,110 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status st
 	err := row.Scan(
 		&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
 		&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
-		&o.CreatedAt, &o.UpdatedAt,
+		&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+		&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
+	)
+	return o, err
+}
+
+const addOrderRefundedAmount = `-- name: AddOrderRefundedAmount :one
+UPDATE orders SET refunded_amount = refunded_amount + $2, updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+          coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
+`
+
+func (q *Queries) AddOrderRefundedAmount(ctx context.Context, id uuid.UUID, amount pgtype.Numeric) (Order, error) {
+	row := q.db.QueryRow(ctx, addOrderRefundedAmount, id, amount)
+	var o Order
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
+		&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
+		&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+		&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
+	)
+	return o, err
+}
+
+const getOrderByIDForUpdate = `-- name: GetOrderByIDForUpdate :one
+SELECT id, user_id, delivery_address, delivery_date, notes, payment_method, status, total_amount, created_at, updated_at, refunded_amount,
+       coupon_id, coupon_code, discount_amount, promotion_discount, delivery_fee
+FROM orders WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetOrderByIDForUpdate(ctx context.Context, id uuid.UUID) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderByIDForUpdate, id)
+	var o Order
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.DeliveryAddress, &o.DeliveryDate,
+		&o.Notes, &o.PaymentMethod, &o.Status, &o.TotalAmount,
+		&o.CreatedAt, &o.UpdatedAt, &o.RefundedAmount,
+		&o.CouponID, &o.CouponCode, &o.DiscountAmount, &o.PromotionDiscount, &o.DeliveryFee,
 	)
 	return o, err
 }
+
+const createOrderStatusHistory = `-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, order_id, from_status, to_status, actor_id, note, created_at
+`
+
+type CreateOrderStatusHistoryParams struct {
+	OrderID    uuid.UUID   `json:"order_id"`
+	FromStatus pgtype.Text `json:"from_status"`
+	ToStatus   string      `json:"to_status"`
+	ActorID    pgtype.UUID `json:"actor_id"`
+	Note       pgtype.Text `json:"note"`
+}
+
+func (q *Queries) CreateOrderStatusHistory(ctx context.Context, arg CreateOrderStatusHistoryParams) (OrderStatusHistory, error) {
+	row := q.db.QueryRow(ctx, createOrderStatusHistory,
+		arg.OrderID, arg.FromStatus, arg.ToStatus, arg.ActorID, arg.Note,
+	)
+	var h OrderStatusHistory
+	err := row.Scan(
+		&h.ID, &h.OrderID, &h.FromStatus, &h.ToStatus,
+		&h.ActorID, &h.Note, &h.CreatedAt,
+	)
+	return h, err
+}
+
+const listOrderStatusHistory = `-- name: ListOrderStatusHistory :many
+SELECT id, order_id, from_status, to_status, actor_id, note, created_at
+FROM order_status_history WHERE order_id = $1 ORDER BY created_at ASC
+`
+
+func (q *Queries) ListOrderStatusHistory(ctx context.Context, orderID uuid.UUID) ([]OrderStatusHistory, error) {
+	rows, err := q.db.Query(ctx, listOrderStatusHistory, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []OrderStatusHistory
+	for rows.Next() {
+		var h OrderStatusHistory
+		if err := rows.Scan(
+			&h.ID, &h.OrderID, &h.FromStatus, &h.ToStatus,
+			&h.ActorID, &h.Note, &h.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+const getStatusBeforePartialRefund = `-- name: GetStatusBeforePartialRefund :one
+SELECT from_status FROM order_status_history
+WHERE order_id = $1 AND to_status = 'partially_refunded'
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetStatusBeforePartialRefund(ctx context.Context, orderID uuid.UUID) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getStatusBeforePartialRefund, orderID)
+	var fromStatus pgtype.Text
+	err := row.Scan(&fromStatus)
+	return fromStatus, err
+}
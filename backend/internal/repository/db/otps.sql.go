@@ -13,33 +13,39 @@ import (
 )
 
 const createOTP = `-- name: CreateOTP :one
-INSERT INTO email_otps (user_id, otp_hash, expires_at)
-VALUES ($1, $2, $3)
-RETURNING id, user_id, otp_hash, expires_at, is_used, attempt_count, created_at
+INSERT INTO email_otps (user_id, otp_hash, expires_at, purpose)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, otp_hash, expires_at, is_used, attempt_count, created_at, purpose
 `
 
 type CreateOTPParams struct {
 	UserID    uuid.UUID `json:"user_id"`
 	OtpHash   string    `json:"otp_hash"`
 	ExpiresAt time.Time `json:"expires_at"`
+	Purpose   string    `json:"purpose"`
 }
 
 func (q *Queries) CreateOTP(ctx context.Context, arg CreateOTPParams) (EmailOtp, error) {
-	row := q.db.QueryRow(ctx, createOTP, arg.UserID, arg.OtpHash, arg.ExpiresAt)
+	row := q.db.QueryRow(ctx, createOTP, arg.UserID, arg.OtpHash, arg.ExpiresAt, arg.Purpose)
 	var o EmailOtp
-	err := row.Scan(&o.ID, &o.UserID, &o.OtpHash, &o.ExpiresAt, &o.IsUsed, &o.AttemptCount, &o.CreatedAt)
+	err := row.Scan(&o.ID, &o.UserID, &o.OtpHash, &o.ExpiresAt, &o.IsUsed, &o.AttemptCount, &o.CreatedAt, &o.Purpose)
 	return o, err
 }
 
 const getLatestOTPByUserID = `-- name: GetLatestOTPByUserID :one
-SELECT id, user_id, otp_hash, expires_at, is_used, attempt_count, created_at
-FROM email_otps WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+SELECT id, user_id, otp_hash, expires_at, is_used, attempt_count, created_at, purpose
+FROM email_otps WHERE user_id = $1 AND purpose = $2 ORDER BY created_at DESC LIMIT 1
 `
 
-func (q *Queries) GetLatestOTPByUserID(ctx context.Context, userID uuid.UUID) (EmailOtp, error) {
-	row := q.db.QueryRow(ctx, getLatestOTPByUserID, userID)
+type GetLatestOTPByUserIDParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Purpose string    `json:"purpose"`
+}
+
+func (q *Queries) GetLatestOTPByUserID(ctx context.Context, arg GetLatestOTPByUserIDParams) (EmailOtp, error) {
+	row := q.db.QueryRow(ctx, getLatestOTPByUserID, arg.UserID, arg.Purpose)
 	var o EmailOtp
-	err := row.Scan(&o.ID, &o.UserID, &o.OtpHash, &o.ExpiresAt, &o.IsUsed, &o.AttemptCount, &o.CreatedAt)
+	err := row.Scan(&o.ID, &o.UserID, &o.OtpHash, &o.ExpiresAt, &o.IsUsed, &o.AttemptCount, &o.CreatedAt, &o.Purpose)
 	return o, err
 }
 
@@ -54,13 +60,13 @@ func (q *Queries) MarkOTPUsed(ctx context.Context, id uuid.UUID) error {
 
 const incrementOTPAttempts = `-- name: IncrementOTPAttempts :one
 UPDATE email_otps SET attempt_count = attempt_count + 1
-WHERE id = $1 RETURNING id, user_id, otp_hash, expires_at, is_used, attempt_count, created_at
+WHERE id = $1 RETURNING id, user_id, otp_hash, expires_at, is_used, attempt_count, created_at, purpose
 `
 
 func (q *Queries) IncrementOTPAttempts(ctx context.Context, id uuid.UUID) (EmailOtp, error) {
 	row := q.db.QueryRow(ctx, incrementOTPAttempts, id)
 	var o EmailOtp
-	err := row.Scan(&o.ID, &o.UserID, &o.OtpHash, &o.ExpiresAt, &o.IsUsed, &o.AttemptCount, &o.CreatedAt)
+	err := row.Scan(&o.ID, &o.UserID, &o.OtpHash, &o.ExpiresAt, &o.IsUsed, &o.AttemptCount, &o.CreatedAt, &o.Purpose)
 	return o, err
 }
 
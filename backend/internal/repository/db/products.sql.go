@@ -12,46 +12,103 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// GetProductByIDRow is returned by GetProductByID, joining category fields.
+// GetProductByIDRow is returned by GetProductByID, joining category fields and the
+// lowest price and total stock of the active variants.
 type GetProductByIDRow struct {
 	Product
-	CategoryName pgtype.Text `json:"category_name"`
-	CategorySlug pgtype.Text `json:"category_slug"`
+	CategoryName  pgtype.Text    `json:"category_name"`
+	CategorySlug  pgtype.Text    `json:"category_slug"`
+	Price         pgtype.Numeric `json:"price"`
+	StockQuantity int32          `json:"stock_quantity"`
 }
 
-// ListProductsRow is returned by ListProducts, joining category fields.
+// ListProductsRow is returned by ListProducts, joining category fields and the
+// lowest price and total stock of the active variants. The headlines are only
+// set when searching.
 type ListProductsRow struct {
 	Product
-	CategoryName pgtype.Text `json:"category_name"`
-	CategorySlug pgtype.Text `json:"category_slug"`
+	CategoryName        pgtype.Text    `json:"category_name"`
+	CategorySlug        pgtype.Text    `json:"category_slug"`
+	Price               pgtype.Numeric `json:"price"`
+	StockQuantity       int32          `json:"stock_quantity"`
+	NameHeadline        pgtype.Text    `json:"name_headline"`
+	DescriptionHeadline pgtype.Text    `json:"description_headline"`
 }
 
 type ListProductsParams struct {
-	CategoryID pgtype.UUID `json:"category_id"`
-	SortBy     pgtype.Text `json:"sort_by"`
-	Limit      int32       `json:"limit"`
-	Offset     int32       `json:"offset"`
+	CategoryID pgtype.UUID    `json:"category_id"`
+	SortBy     pgtype.Text    `json:"sort_by"`
+	Limit      int32          `json:"limit"`
+	Offset     int32          `json:"offset"`
+	Search     pgtype.Text    `json:"search"`
+	MinPrice   pgtype.Numeric `json:"min_price"`
+	MaxPrice   pgtype.Numeric `json:"max_price"`
+	InStock    bool           `json:"in_stock"`
+	Tags       []string       `json:"tags"`
+	Sizes      []string       `json:"sizes"`
+	FreeFrom   []string       `json:"free_from"`
 }
 
 const listProducts = `-- name: ListProducts :many
-SELECT p.id, p.category_id, p.name, p.description, p.price, p.image_url,
-       p.stock_quantity, p.is_active, p.created_at, p.updated_at, p.deleted_at,
-       c.name AS category_name, c.slug AS category_slug
+SELECT p.id, p.category_id, p.name, p.description, p.image_url,
+       p.is_active, p.created_at, p.updated_at, p.deleted_at, p.personalisation_options, p.dietary_tags,
+       c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity,
+       ts_headline('english', p.name, s.query,
+                   'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS name_headline,
+       ts_headline('english', p.description, s.query,
+                   'MaxFragments=2, MaxWords=20, MinWords=8, FragmentDelimiter=" … ", StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS description_headline
 FROM products p
 LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+LEFT JOIN LATERAL (SELECT to_tsquery('english', $5::text) AS query) s ON $5::text IS NOT NULL
 WHERE p.deleted_at IS NULL
   AND p.is_active = TRUE
-  AND ($1::uuid IS NULL OR p.category_id = $1)
+  AND ($1::uuid IS NULL OR p.category_id IN (
+      WITH RECURSIVE tree AS (
+          SELECT id FROM categories WHERE id = $1
+          UNION ALL
+          SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+      )
+      SELECT id FROM tree
+  ))
+  AND ($5::text IS NULL OR p.search_vector @@ s.query)
+  AND ($6::numeric IS NULL OR v.price >= $6)
+  AND ($7::numeric IS NULL OR v.price <= $7)
+  AND (NOT $8::bool OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.stock_quantity > COALESCE((
+            SELECT SUM(r.quantity) FROM stock_reservations r
+            WHERE r.variant_id = sv.id AND r.expires_at > NOW()
+        ), 0)
+  ))
+  AND (COALESCE(cardinality($9::text[]), 0) = 0 OR p.dietary_tags @> $9)
+  AND (COALESCE(cardinality($10::text[]), 0) = 0 OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.options->>'size' = ANY($10)
+  ))
+  AND (COALESCE(cardinality($11::text[]), 0) = 0 OR (
+      SELECT COUNT(*) FROM product_allergens pa
+      JOIN allergens a ON a.id = pa.allergen_id
+      WHERE pa.product_id = p.id AND pa.status = 'free_from' AND a.slug = ANY($11)
+  ) = cardinality($11))
 ORDER BY
-  CASE WHEN $2::text = 'price_asc'  THEN p.price END ASC,
-  CASE WHEN $2::text = 'price_desc' THEN p.price END DESC,
+  CASE WHEN $2::text = 'price_asc'  THEN v.price END ASC,
+  CASE WHEN $2::text = 'price_desc' THEN v.price END DESC,
+  ts_rank_cd(p.search_vector, s.query) DESC NULLS LAST,
   p.created_at DESC
 LIMIT $3 OFFSET $4
 `
 
 func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]ListProductsRow, error) {
 	rows, err := q.db.Query(ctx, listProducts,
-		arg.CategoryID, arg.SortBy, arg.Limit, arg.Offset,
+		arg.CategoryID, arg.SortBy, arg.Limit, arg.Offset, arg.Search,
+		arg.MinPrice, arg.MaxPrice, arg.InStock, arg.Tags, arg.Sizes, arg.FreeFrom,
 	)
 	if err != nil {
 		return nil, err
@@ -62,9 +119,10 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]L
 	for rows.Next() {
 		var p ListProductsRow
 		if err := rows.Scan(
-			&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price,
-			&p.ImageUrl, &p.StockQuantity, &p.IsActive, &p.CreatedAt,
-			&p.UpdatedAt, &p.DeletedAt, &p.CategoryName, &p.CategorySlug,
+			&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+			&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
+			&p.CategoryName, &p.CategorySlug, &p.Price, &p.StockQuantity,
+			&p.NameHeadline, &p.DescriptionHeadline,
 		); err != nil {
 			return nil, err
 		}
@@ -74,11 +132,16 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]L
 }
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT p.id, p.category_id, p.name, p.description, p.price, p.image_url,
-       p.stock_quantity, p.is_active, p.created_at, p.updated_at, p.deleted_at,
-       c.name AS category_name, c.slug AS category_slug
+SELECT p.id, p.category_id, p.name, p.description, p.image_url,
+       p.is_active, p.created_at, p.updated_at, p.deleted_at, p.personalisation_options, p.dietary_tags,
+       c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity
 FROM products p
 LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
 WHERE p.id = $1 AND p.deleted_at IS NULL
 `
 
@@ -86,94 +149,393 @@ func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (GetProductB
 	row := q.db.QueryRow(ctx, getProductByID, id)
 	var p GetProductByIDRow
 	err := row.Scan(
-		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price,
-		&p.ImageUrl, &p.StockQuantity, &p.IsActive, &p.CreatedAt,
-		&p.UpdatedAt, &p.DeletedAt, &p.CategoryName, &p.CategorySlug,
+		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
+		&p.CategoryName, &p.CategorySlug, &p.Price, &p.StockQuantity,
 	)
 	return p, err
 }
 
 const countProducts = `-- name: CountProducts :one
-SELECT COUNT(*) FROM products
-WHERE deleted_at IS NULL AND is_active = TRUE
-AND ($1::uuid IS NULL OR category_id = $1)
+SELECT COUNT(*)
+FROM products p
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+WHERE p.deleted_at IS NULL
+  AND p.is_active = TRUE
+  AND ($1::uuid IS NULL OR p.category_id IN (
+      WITH RECURSIVE tree AS (
+          SELECT id FROM categories WHERE id = $1
+          UNION ALL
+          SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+      )
+      SELECT id FROM tree
+  ))
+  AND ($2::text IS NULL OR p.search_vector @@ to_tsquery('english', $2::text))
+  AND ($3::numeric IS NULL OR v.price >= $3)
+  AND ($4::numeric IS NULL OR v.price <= $4)
+  AND (NOT $5::bool OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.stock_quantity > COALESCE((
+            SELECT SUM(r.quantity) FROM stock_reservations r
+            WHERE r.variant_id = sv.id AND r.expires_at > NOW()
+        ), 0)
+  ))
+  AND (COALESCE(cardinality($6::text[]), 0) = 0 OR p.dietary_tags @> $6)
+  AND (COALESCE(cardinality($7::text[]), 0) = 0 OR EXISTS (
+      SELECT 1 FROM product_variants sv
+      WHERE sv.product_id = p.id AND sv.is_active = TRUE
+        AND sv.options->>'size' = ANY($7)
+  ))
+  AND (COALESCE(cardinality($8::text[]), 0) = 0 OR (
+      SELECT COUNT(*) FROM product_allergens pa
+      JOIN allergens a ON a.id = pa.allergen_id
+      WHERE pa.product_id = p.id AND pa.status = 'free_from' AND a.slug = ANY($8)
+  ) = cardinality($8))
 `
 
-func (q *Queries) CountProducts(ctx context.Context, categoryID pgtype.UUID) (int64, error) {
-	row := q.db.QueryRow(ctx, countProducts, categoryID)
+type CountProductsParams struct {
+	CategoryID pgtype.UUID    `json:"category_id"`
+	Search     pgtype.Text    `json:"search"`
+	MinPrice   pgtype.Numeric `json:"min_price"`
+	MaxPrice   pgtype.Numeric `json:"max_price"`
+	InStock    bool           `json:"in_stock"`
+	Tags       []string       `json:"tags"`
+	Sizes      []string       `json:"sizes"`
+	FreeFrom   []string       `json:"free_from"`
+}
+
+func (q *Queries) CountProducts(ctx context.Context, arg CountProductsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countProducts,
+		arg.CategoryID, arg.Search, arg.MinPrice, arg.MaxPrice, arg.InStock,
+		arg.Tags, arg.Sizes, arg.FreeFrom,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
-const deductProductStock = `-- name: DeductProductStock :exec
-UPDATE products SET stock_quantity = stock_quantity - $2, updated_at = NOW()
-WHERE id = $1 AND stock_quantity >= $2
+const productFacets = `-- name: ProductFacets :many
+WITH matched AS (
+    SELECT p.id, p.category_id, p.dietary_tags, v.price,
+           ($1::uuid IS NULL OR p.category_id IN (
+               WITH RECURSIVE tree AS (
+                   SELECT id FROM categories WHERE id = $1
+                   UNION ALL
+                   SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+               )
+               SELECT id FROM tree
+           )) AS in_category,
+           (($3::numeric IS NULL OR v.price >= $3)
+               AND ($4::numeric IS NULL OR v.price <= $4)) AS in_price,
+           (NOT $5::bool OR EXISTS (
+               SELECT 1 FROM product_variants sv
+               WHERE sv.product_id = p.id AND sv.is_active = TRUE
+                 AND sv.stock_quantity > COALESCE((
+                     SELECT SUM(r.quantity) FROM stock_reservations r
+                     WHERE r.variant_id = sv.id AND r.expires_at > NOW()
+                 ), 0)
+           )) AS in_stock,
+           (COALESCE(cardinality($6::text[]), 0) = 0 OR p.dietary_tags @> $6) AS has_tags,
+           (COALESCE(cardinality($7::text[]), 0) = 0 OR EXISTS (
+               SELECT 1 FROM product_variants sv
+               WHERE sv.product_id = p.id AND sv.is_active = TRUE
+                 AND sv.options->>'size' = ANY($7)
+           )) AS has_size,
+           (COALESCE(cardinality($9::text[]), 0) = 0 OR (
+               SELECT COUNT(*) FROM product_allergens pa
+               JOIN allergens a ON a.id = pa.allergen_id
+               WHERE pa.product_id = p.id AND pa.status = 'free_from' AND a.slug = ANY($9)
+           ) = cardinality($9)) AS is_free_from
+    FROM products p
+    CROSS JOIN LATERAL (
+        SELECT MIN(pv.price) AS price
+        FROM product_variants pv
+        WHERE pv.product_id = p.id AND pv.is_active = TRUE
+    ) v
+    WHERE p.deleted_at IS NULL
+      AND p.is_active = TRUE
+      AND ($2::text IS NULL OR p.search_vector @@ to_tsquery('english', $2::text))
+)
+SELECT 'category'::text AS facet, m.category_id::text AS value, c.name AS label, COUNT(*) AS count
+FROM matched m
+JOIN categories c ON c.id = m.category_id
+WHERE m.in_price AND m.in_stock AND m.has_tags AND m.has_size AND m.is_free_from
+GROUP BY m.category_id, c.name
+UNION ALL
+SELECT 'price', width_bucket(m.price, $8::numeric[])::text, NULL, COUNT(*)
+FROM matched m
+WHERE m.price IS NOT NULL AND m.in_category AND m.in_stock AND m.has_tags AND m.has_size AND m.is_free_from
+GROUP BY 2
+UNION ALL
+SELECT 'dietary_tag', t.tag, NULL, COUNT(*)
+FROM matched m
+CROSS JOIN unnest(m.dietary_tags) AS t(tag)
+WHERE m.in_category AND m.in_price AND m.in_stock AND m.has_size AND m.is_free_from
+GROUP BY t.tag
+UNION ALL
+SELECT 'size', s.size, NULL, COUNT(*)
+FROM matched m
+CROSS JOIN LATERAL (
+    SELECT DISTINCT pv.options->>'size' AS size
+    FROM product_variants pv
+    WHERE pv.product_id = m.id AND pv.is_active = TRUE AND pv.options->>'size' IS NOT NULL
+) s
+WHERE m.in_category AND m.in_price AND m.in_stock AND m.has_tags AND m.is_free_from
+GROUP BY s.size
+UNION ALL
+SELECT 'free_from', a.slug, a.name, COUNT(*)
+FROM matched m
+JOIN product_allergens pa ON pa.product_id = m.id AND pa.status = 'free_from'
+JOIN allergens a ON a.id = pa.allergen_id
+WHERE m.in_category AND m.in_price AND m.in_stock AND m.has_tags AND m.has_size
+GROUP BY a.slug, a.name
+ORDER BY 1, 4 DESC, 2
 `
 
-type DeductProductStockParams struct {
-	ID       uuid.UUID `json:"id"`
-	Quantity int32     `json:"quantity"`
+type ProductFacetsParams struct {
+	CategoryID  pgtype.UUID      `json:"category_id"`
+	Search      pgtype.Text      `json:"search"`
+	MinPrice    pgtype.Numeric   `json:"min_price"`
+	MaxPrice    pgtype.Numeric   `json:"max_price"`
+	InStock     bool             `json:"in_stock"`
+	Tags        []string         `json:"tags"`
+	Sizes       []string         `json:"sizes"`
+	PriceBounds []pgtype.Numeric `json:"price_bounds"`
+	FreeFrom    []string         `json:"free_from"`
 }
 
-func (q *Queries) DeductProductStock(ctx context.Context, arg DeductProductStockParams) error {
-	_, err := q.db.Exec(ctx, deductProductStock, arg.ID, arg.Quantity)
-	return err
-}
-
-const getProductsForOrder = `-- name: GetProductsForOrder :many
-SELECT id, name, price, stock_quantity
-FROM products WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL AND is_active = TRUE
-`
-
-type GetProductsForOrderRow struct {
-	ID            uuid.UUID      `json:"id"`
-	Name          string         `json:"name"`
-	Price         pgtype.Numeric `json:"price"`
-	StockQuantity int32          `json:"stock_quantity"`
+// ProductFacetsRow is one facet value and the number of products with it.
+// Facet is 'category', 'price', 'dietary_tag', 'size' or 'free_from'; Label
+// is set for categories and allergens.
+type ProductFacetsRow struct {
+	Facet string      `json:"facet"`
+	Value string      `json:"value"`
+	Label pgtype.Text `json:"label"`
+	Count int64       `json:"count"`
 }
 
-func (q *Queries) GetProductsForOrder(ctx context.Context, ids []uuid.UUID) ([]GetProductsForOrderRow, error) {
-	rows, err := q.db.Query(ctx, getProductsForOrder, ids)
+func (q *Queries) ProductFacets(ctx context.Context, arg ProductFacetsParams) ([]ProductFacetsRow, error) {
+	rows, err := q.db.Query(ctx, productFacets,
+		arg.CategoryID, arg.Search, arg.MinPrice, arg.MaxPrice, arg.InStock,
+		arg.Tags, arg.Sizes, arg.PriceBounds, arg.FreeFrom,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var products []GetProductsForOrderRow
+	var items []ProductFacetsRow
 	for rows.Next() {
-		var p GetProductsForOrderRow
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.StockQuantity); err != nil {
+		var i ProductFacetsRow
+		if err := rows.Scan(&i.Facet, &i.Value, &i.Label, &i.Count); err != nil {
 			return nil, err
 		}
-		products = append(products, p)
+		items = append(items, i)
 	}
-	return products, rows.Err()
+	return items, rows.Err()
 }
 
 type CreateProductParams struct {
-	CategoryID    pgtype.UUID    `json:"category_id"`
-	Name          string         `json:"name"`
-	Description   pgtype.Text    `json:"description"`
-	Price         pgtype.Numeric `json:"price"`
-	ImageUrl      pgtype.Text    `json:"image_url"`
-	StockQuantity int32          `json:"stock_quantity"`
+	CategoryID             pgtype.UUID `json:"category_id"`
+	Name                   string      `json:"name"`
+	Description            pgtype.Text `json:"description"`
+	ImageUrl               pgtype.Text `json:"image_url"`
+	IsActive               bool        `json:"is_active"`
+	PersonalisationOptions []byte      `json:"personalisation_options"`
+	DietaryTags            []string    `json:"dietary_tags"`
 }
 
 const createProduct = `-- name: CreateProduct :one
-INSERT INTO products (category_id, name, description, price, image_url, stock_quantity)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, category_id, name, description, price, image_url, stock_quantity, is_active, created_at, updated_at, deleted_at
+INSERT INTO products (category_id, name, description, image_url, is_active, personalisation_options, dietary_tags)
+VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7::text[], '{}'))
+RETURNING id, category_id, name, description, image_url, is_active, created_at, updated_at, deleted_at, personalisation_options, dietary_tags
 `
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
 	row := q.db.QueryRow(ctx, createProduct,
-		arg.CategoryID, arg.Name, arg.Description, arg.Price, arg.ImageUrl, arg.StockQuantity,
+		arg.CategoryID, arg.Name, arg.Description, arg.ImageUrl, arg.IsActive,
+		arg.PersonalisationOptions, arg.DietaryTags,
+	)
+	var p Product
+	err := row.Scan(
+		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
 	)
+	return p, err
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+SET name = $2, description = $3, image_url = $4, category_id = $5,
+    is_active = $6, personalisation_options = $7, dietary_tags = COALESCE($8::text[], '{}'), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, category_id, name, description, image_url, is_active, created_at, updated_at, deleted_at, personalisation_options, dietary_tags
+`
+
+type UpdateProductParams struct {
+	ID                     uuid.UUID   `json:"id"`
+	Name                   string      `json:"name"`
+	Description            pgtype.Text `json:"description"`
+	ImageUrl               pgtype.Text `json:"image_url"`
+	CategoryID             pgtype.UUID `json:"category_id"`
+	IsActive               bool        `json:"is_active"`
+	PersonalisationOptions []byte      `json:"personalisation_options"`
+	DietaryTags            []string    `json:"dietary_tags"`
+}
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProduct,
+		arg.ID, arg.Name, arg.Description, arg.ImageUrl,
+		arg.CategoryID, arg.IsActive, arg.PersonalisationOptions, arg.DietaryTags,
+	)
+	var p Product
+	err := row.Scan(
+		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
+	)
+	return p, err
+}
+
+const softDeleteProduct = `-- name: SoftDeleteProduct :one
+UPDATE products SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, category_id, name, description, image_url, is_active, created_at, updated_at, deleted_at, personalisation_options, dietary_tags
+`
+
+func (q *Queries) SoftDeleteProduct(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, softDeleteProduct, id)
+	var p Product
+	err := row.Scan(
+		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
+	)
+	return p, err
+}
+
+const restoreProduct = `-- name: RestoreProduct :one
+UPDATE products SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, category_id, name, description, image_url, is_active, created_at, updated_at, deleted_at, personalisation_options, dietary_tags
+`
+
+func (q *Queries) RestoreProduct(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, restoreProduct, id)
 	var p Product
 	err := row.Scan(
-		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price,
-		&p.ImageUrl, &p.StockQuantity, &p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt,
+		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
 	)
 	return p, err
 }
+
+// GetProductByIDAdminRow is returned by GetProductByIDAdmin, joining category fields and the
+// lowest price and total stock of the active variants.
+type GetProductByIDAdminRow struct {
+	Product
+	CategoryName  pgtype.Text    `json:"category_name"`
+	CategorySlug  pgtype.Text    `json:"category_slug"`
+	Price         pgtype.Numeric `json:"price"`
+	StockQuantity int32          `json:"stock_quantity"`
+}
+
+const getProductByIDAdmin = `-- name: GetProductByIDAdmin :one
+SELECT p.id, p.category_id, p.name, p.description, p.image_url,
+       p.is_active, p.created_at, p.updated_at, p.deleted_at, p.personalisation_options, p.dietary_tags,
+       c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity
+FROM products p
+LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+WHERE p.id = $1
+`
+
+func (q *Queries) GetProductByIDAdmin(ctx context.Context, id uuid.UUID) (GetProductByIDAdminRow, error) {
+	row := q.db.QueryRow(ctx, getProductByIDAdmin, id)
+	var p GetProductByIDAdminRow
+	err := row.Scan(
+		&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
+		&p.CategoryName, &p.CategorySlug, &p.Price, &p.StockQuantity,
+	)
+	return p, err
+}
+
+// ListProductsAdminRow is returned by ListProductsAdmin, joining category fields and the
+// lowest price and total stock of the active variants.
+type ListProductsAdminRow struct {
+	Product
+	CategoryName  pgtype.Text    `json:"category_name"`
+	CategorySlug  pgtype.Text    `json:"category_slug"`
+	Price         pgtype.Numeric `json:"price"`
+	StockQuantity int32          `json:"stock_quantity"`
+}
+
+type ListProductsAdminParams struct {
+	Status pgtype.Text `json:"status"`
+	Limit  int32       `json:"limit"`
+	Offset int32       `json:"offset"`
+}
+
+const listProductsAdmin = `-- name: ListProductsAdmin :many
+SELECT p.id, p.category_id, p.name, p.description, p.image_url,
+       p.is_active, p.created_at, p.updated_at, p.deleted_at, p.personalisation_options, p.dietary_tags,
+       c.name AS category_name, c.slug AS category_slug, v.price, v.stock_quantity
+FROM products p
+LEFT JOIN categories c ON c.id = p.category_id
+CROSS JOIN LATERAL (
+    SELECT MIN(pv.price) AS price, COALESCE(SUM(pv.stock_quantity), 0)::INT AS stock_quantity
+    FROM product_variants pv
+    WHERE pv.product_id = p.id AND pv.is_active = TRUE
+) v
+WHERE ($1::text IS NULL
+    OR ($1 = 'active'   AND p.deleted_at IS NULL AND p.is_active = TRUE)
+    OR ($1 = 'inactive' AND p.deleted_at IS NULL AND p.is_active = FALSE)
+    OR ($1 = 'deleted'  AND p.deleted_at IS NOT NULL))
+ORDER BY p.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+func (q *Queries) ListProductsAdmin(ctx context.Context, arg ListProductsAdminParams) ([]ListProductsAdminRow, error) {
+	rows, err := q.db.Query(ctx, listProductsAdmin, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []ListProductsAdminRow
+	for rows.Next() {
+		var p ListProductsAdminRow
+		if err := rows.Scan(
+			&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.ImageUrl,
+			&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PersonalisationOptions, &p.DietaryTags,
+			&p.CategoryName, &p.CategorySlug, &p.Price, &p.StockQuantity,
+		); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+const countProductsAdmin = `-- name: CountProductsAdmin :one
+SELECT COUNT(*) FROM products p
+WHERE ($1::text IS NULL
+    OR ($1 = 'active'   AND p.deleted_at IS NULL AND p.is_active = TRUE)
+    OR ($1 = 'inactive' AND p.deleted_at IS NULL AND p.is_active = FALSE)
+    OR ($1 = 'deleted'  AND p.deleted_at IS NOT NULL))
+`
+
+func (q *Queries) CountProductsAdmin(ctx context.Context, status pgtype.Text) (int64, error) {
+	row := q.db.QueryRow(ctx, countProductsAdmin, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
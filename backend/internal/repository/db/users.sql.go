@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (first_name, last_name, phone_number, email_address)
 VALUES ($1, $2, $3, $4)
-RETURNING id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at
+RETURNING id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at, token_version, role
 `
 
 type CreateUserParams struct {
@@ -32,12 +32,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 	err := row.Scan(
 		&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber,
 		&u.EmailAddress, &u.IsVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+		&u.TokenVersion, &u.Role,
 	)
 	return u, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at
+SELECT id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at, token_version, role
 FROM users WHERE id = $1 AND deleted_at IS NULL
 `
 
@@ -47,12 +48,13 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 	err := row.Scan(
 		&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber,
 		&u.EmailAddress, &u.IsVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+		&u.TokenVersion, &u.Role,
 	)
 	return u, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at
+SELECT id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at, token_version, role
 FROM users WHERE email_address = $1 AND deleted_at IS NULL
 `
 
@@ -62,12 +64,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, emailAddress string) (User
 	err := row.Scan(
 		&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber,
 		&u.EmailAddress, &u.IsVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+		&u.TokenVersion, &u.Role,
 	)
 	return u, err
 }
 
 const getUserByPhone = `-- name: GetUserByPhone :one
-SELECT id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at
+SELECT id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at, token_version, role
 FROM users WHERE phone_number = $1 AND deleted_at IS NULL
 `
 
@@ -77,6 +80,7 @@ func (q *Queries) GetUserByPhone(ctx context.Context, phoneNumber string) (User,
 	err := row.Scan(
 		&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber,
 		&u.EmailAddress, &u.IsVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+		&u.TokenVersion, &u.Role,
 	)
 	return u, err
 }
@@ -84,7 +88,7 @@ func (q *Queries) GetUserByPhone(ctx context.Context, phoneNumber string) (User,
 const markUserVerified = `-- name: MarkUserVerified :one
 UPDATE users SET is_verified = TRUE, updated_at = NOW()
 WHERE id = $1
-RETURNING id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at
+RETURNING id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at, token_version, role
 `
 
 func (q *Queries) MarkUserVerified(ctx context.Context, id uuid.UUID) (User, error) {
@@ -93,6 +97,7 @@ func (q *Queries) MarkUserVerified(ctx context.Context, id uuid.UUID) (User, err
 	err := row.Scan(
 		&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber,
 		&u.EmailAddress, &u.IsVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+		&u.TokenVersion, &u.Role,
 	)
 	return u, err
 }
@@ -105,3 +110,49 @@ func (q *Queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.Exec(ctx, softDeleteUser, id)
 	return err
 }
+
+const getUserTokenVersion = `-- name: GetUserTokenVersion :one
+SELECT token_version FROM users WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, getUserTokenVersion, id)
+	var tokenVersion int32
+	err := row.Scan(&tokenVersion)
+	return tokenVersion, err
+}
+
+const incrementUserTokenVersion = `-- name: IncrementUserTokenVersion :one
+UPDATE users SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING token_version
+`
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementUserTokenVersion, id)
+	var tokenVersion int32
+	err := row.Scan(&tokenVersion)
+	return tokenVersion, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users SET role = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, first_name, last_name, phone_number, email_address, is_verified, created_at, updated_at, deleted_at, token_version, role
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.ID, arg.Role)
+	var u User
+	err := row.Scan(
+		&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber,
+		&u.EmailAddress, &u.IsVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+		&u.TokenVersion, &u.Role,
+	)
+	return u, err
+}
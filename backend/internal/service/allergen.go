@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Products declare, per allergen, that they contain it, may contain traces
+// of it or are free from it. The declarations are shown with the product,
+// can be filtered on ("free from milk and eggs") and are copied onto order
+// lines so the confirmation shows what was true when the cake was ordered.
+
+const (
+	maxAllergenNameLength = 100
+	maxFilterAllergens    = 20
+)
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+// ProductAllergen is one of a product's declarations. ID is the allergen's.
+type ProductAllergen struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Slug   string `json:"slug"`
+	Status string `json:"status"`
+}
+
+// AllergenDeclaration sets what a product declares about an allergen.
+type AllergenDeclaration struct {
+	AllergenID string
+	Status     string
+}
+
+// OrderedAllergen is a declaration as copied onto an order line.
+type OrderedAllergen struct {
+	Name   string `json:"name"`
+	Slug   string `json:"slug"`
+	Status string `json:"status"`
+}
+
+// ─── Allergens ────────────────────────────────────────────────────────────────
+
+func (s *ProductService) ListAllergens(ctx context.Context) ([]db.Allergen, error) {
+	allergens, err := s.q.ListAllergens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list allergens: %w", err)
+	}
+	if allergens == nil {
+		allergens = []db.Allergen{}
+	}
+	return allergens, nil
+}
+
+// CreateAllergen adds an allergen; its slug, used to filter on it, is
+// derived from the name.
+func (s *ProductService) CreateAllergen(ctx context.Context, name string) (*db.Allergen, error) {
+	name, slug, err := validateAllergenName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := s.q.CreateAllergen(ctx, db.CreateAllergenParams{Name: name, Slug: slug})
+	if err != nil {
+		return nil, mapAllergenWriteError(err)
+	}
+	return &a, nil
+}
+
+// UpdateAllergen renames an allergen, which changes its slug. Orders keep
+// the name they were placed with.
+func (s *ProductService) UpdateAllergen(ctx context.Context, id, name string) (*db.Allergen, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid allergen id"}
+	}
+	name, slug, err := validateAllergenName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := s.q.UpdateAllergen(ctx, db.UpdateAllergenParams{ID: uid, Name: name, Slug: slug})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, mapAllergenWriteError(err)
+	}
+	return &a, nil
+}
+
+// DeleteAllergen removes an allergen no product declares.
+func (s *ProductService) DeleteAllergen(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid allergen id"}
+	}
+
+	n, err := s.q.DeleteAllergen(ctx, uid)
+	if err != nil {
+		return mapAllergenWriteError(err)
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ─── Declarations ─────────────────────────────────────────────────────────────
+
+// attachAllergens fills in the declarations of products.
+func (s *ProductService) attachAllergens(ctx context.Context, products []ProductResponse) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, uuid.MustParse(p.ID))
+	}
+
+	rows, err := s.q.ListProductAllergens(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("list product allergens: %w", err)
+	}
+
+	byProduct := make(map[uuid.UUID][]ProductAllergen, len(products))
+	for _, r := range rows {
+		byProduct[r.ProductID] = append(byProduct[r.ProductID], ProductAllergen{
+			ID:     r.AllergenID.String(),
+			Name:   r.Name,
+			Slug:   r.Slug,
+			Status: r.Status,
+		})
+	}
+	for i := range products {
+		products[i].Allergens = byProduct[ids[i]]
+		if products[i].Allergens == nil {
+			products[i].Allergens = []ProductAllergen{}
+		}
+	}
+	return nil
+}
+
+// replaceProductAllergens sets the declarations of a product within the
+// caller's transaction.
+func replaceProductAllergens(ctx context.Context, qtx *db.Queries, productID uuid.UUID, decls []db.CreateProductAllergenParams) error {
+	if err := qtx.DeleteProductAllergens(ctx, productID); err != nil {
+		return fmt.Errorf("clear product allergens: %w", err)
+	}
+	for _, d := range decls {
+		d.ProductID = productID
+		if err := qtx.CreateProductAllergen(ctx, d); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("allergen %s does not exist", d.AllergenID)}
+			}
+			return fmt.Errorf("create product allergen: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateAllergenDeclarations checks a product's declarations as set by an
+// admin. Each allergen may be declared once.
+func validateAllergenDeclarations(decls []AllergenDeclaration) ([]db.CreateProductAllergenParams, error) {
+	out := make([]db.CreateProductAllergenParams, 0, len(decls))
+	seen := make(map[uuid.UUID]bool, len(decls))
+	for _, d := range decls {
+		id, err := uuid.Parse(d.AllergenID)
+		if err != nil {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid allergen_id"}
+		}
+		if !domain.IsValidAllergenStatus(d.Status) {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "allergen status must be one of contains, may_contain, free_from"}
+		}
+		if seen[id] {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("allergen %s is declared twice", id)}
+		}
+		seen[id] = true
+		out = append(out, db.CreateProductAllergenParams{AllergenID: id, Status: d.Status})
+	}
+	return out, nil
+}
+
+// normalizeAllergenSlugs cleans up the free_from filter: slugs are
+// lower-cased, deduplicated and sorted.
+func normalizeAllergenSlugs(slugs []string) ([]string, error) {
+	seen := make(map[string]bool, len(slugs))
+	out := make([]string, 0, len(slugs))
+	for _, s := range slugs {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	if len(out) > maxFilterAllergens {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("at most %d allergens can be selected", maxFilterAllergens)}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// ─── Orders ───────────────────────────────────────────────────────────────────
+
+// orderedAllergens loads the declarations of the given products for copying
+// onto order lines.
+func orderedAllergens(ctx context.Context, q *db.Queries, productIDs []uuid.UUID) (map[uuid.UUID][]OrderedAllergen, error) {
+	rows, err := q.ListProductAllergens(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list product allergens: %w", err)
+	}
+	out := make(map[uuid.UUID][]OrderedAllergen, len(productIDs))
+	for _, r := range rows {
+		out[r.ProductID] = append(out[r.ProductID], OrderedAllergen{Name: r.Name, Slug: r.Slug, Status: r.Status})
+	}
+	return out, nil
+}
+
+// The order_items column is never NULL, so nothing encodes as "[]".
+func encodeOrderedAllergens(a []OrderedAllergen) []byte {
+	if len(a) == 0 {
+		return []byte("[]")
+	}
+	b, _ := json.Marshal(a)
+	return b
+}
+
+func decodeOrderedAllergens(b []byte) []OrderedAllergen {
+	var a []OrderedAllergen
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, &a)
+	}
+	return a
+}
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+func validateAllergenName(name string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "name is required"}
+	}
+	if len(name) > maxAllergenNameLength {
+		return "", "", &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("name must be at most %d characters", maxAllergenNameLength)}
+	}
+	slug := slugify(name)
+	if slug == "" {
+		return "", "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "name must contain at least one letter or digit"}
+	}
+	return name, slug, nil
+}
+
+func mapAllergenWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation on the slug
+			return &domain.AppError{Err: domain.ErrConflict, Message: "an allergen with this name already exists"}
+		case "23503": // foreign_key_violation: products declare it
+			return &domain.AppError{Err: domain.ErrConflict, Message: "allergen is declared by products, remove it from them first"}
+		}
+	}
+	return fmt.Errorf("write allergen: %w", err)
+}
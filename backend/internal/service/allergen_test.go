@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestValidateAllergenDeclarations(t *testing.T) {
+	milk, eggs := uuid.New(), uuid.New()
+
+	got, err := service.ValidateAllergenDeclarations([]service.AllergenDeclaration{
+		{AllergenID: milk.String(), Status: domain.AllergenContains},
+		{AllergenID: eggs.String(), Status: domain.AllergenFreeFrom},
+	})
+	if err != nil {
+		t.Fatalf("ValidateAllergenDeclarations() error = %v", err)
+	}
+	if len(got) != 2 || got[0].AllergenID != milk || got[0].Status != "contains" || got[1].AllergenID != eggs || got[1].Status != "free_from" {
+		t.Errorf("ValidateAllergenDeclarations() = %+v", got)
+	}
+
+	tests := []struct {
+		name  string
+		decls []service.AllergenDeclaration
+	}{
+		{"bad id", []service.AllergenDeclaration{{AllergenID: "milk", Status: domain.AllergenContains}}},
+		{"bad status", []service.AllergenDeclaration{{AllergenID: milk.String(), Status: "traces"}}},
+		{"declared twice", []service.AllergenDeclaration{
+			{AllergenID: milk.String(), Status: domain.AllergenContains},
+			{AllergenID: milk.String(), Status: domain.AllergenMayContain},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.ValidateAllergenDeclarations(tt.decls); err == nil {
+				t.Error("ValidateAllergenDeclarations() accepted invalid declarations")
+			}
+		})
+	}
+}
+
+func TestNormalizeAllergenSlugs(t *testing.T) {
+	got, err := service.NormalizeAllergenSlugs([]string{" Milk", "eggs", "", "milk"})
+	if err != nil {
+		t.Fatalf("NormalizeAllergenSlugs() error = %v", err)
+	}
+	if want := []string{"eggs", "milk"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeAllergenSlugs() = %v, want %v", got, want)
+	}
+
+	many := make([]string, 21)
+	for i := range many {
+		many[i] = uuid.NewString()
+	}
+	if _, err := service.NormalizeAllergenSlugs(many); err == nil {
+		t.Error("NormalizeAllergenSlugs() accepted too many slugs")
+	}
+}
+
+func TestOrderedAllergensRoundTrip(t *testing.T) {
+	if got := string(service.EncodeOrderedAllergens(nil)); got != "[]" {
+		t.Errorf("EncodeOrderedAllergens(nil) = %s, want []", got)
+	}
+
+	in := []service.OrderedAllergen{{Name: "Milk", Slug: "milk", Status: domain.AllergenContains}}
+	if got := service.DecodeOrderedAllergens(service.EncodeOrderedAllergens(in)); !reflect.DeepEqual(got, in) {
+		t.Errorf("round trip = %+v, want %+v", got, in)
+	}
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
@@ -13,6 +16,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/online-cake-shop/backend/internal/config"
@@ -28,18 +33,31 @@ var (
 
 const (
 	otpLength      = 6
+	otpTTL         = 5 * time.Minute
 	maxOTPAttempts = 5
 	maxOTPPerHour  = 3
+
+	refreshTokenBytes = 32
 )
 
+// OTP purposes. A code is only redeemable by the flow that issued it.
+const (
+	otpPurposeRegistration = "registration"
+	otpPurposeLogin        = "login"
+)
+
+var errInvalidRefreshToken = &domain.AppError{Err: domain.ErrUnauthorized, Message: "invalid or expired refresh token"}
+
 type AuthService struct {
-	q         *db.Queries
-	emailSvc  email.Sender
-	jwtConfig config.JWTConfig
+	pool        *pgxpool.Pool
+	q           *db.Queries
+	emailSvc    email.Sender
+	revocations *RevocationStore
+	jwtConfig   config.JWTConfig
 }
 
-func NewAuthService(q *db.Queries, emailSvc email.Sender, jwtConfig config.JWTConfig) *AuthService {
-	return &AuthService{q: q, emailSvc: emailSvc, jwtConfig: jwtConfig}
+func NewAuthService(pool *pgxpool.Pool, q *db.Queries, emailSvc email.Sender, revocations *RevocationStore, jwtConfig config.JWTConfig) *AuthService {
+	return &AuthService{pool: pool, q: q, emailSvc: emailSvc, revocations: revocations, jwtConfig: jwtConfig}
 }
 
 // ─── DTOs ────────────────────────────────────────────────────────────────────
@@ -52,13 +70,32 @@ type RegisterInput struct {
 }
 
 type VerifyOTPInput struct {
-	Email string
-	OTP   string
+	Email     string
+	OTP       string
+	UserAgent string
+	IPAddress string
+}
+
+type RefreshInput struct {
+	RefreshToken string
+	UserAgent    string
+	IPAddress    string
+}
+
+// LogoutInput identifies the access token presented on logout.
+type LogoutInput struct {
+	UserID    uuid.UUID
+	TokenID   uuid.UUID
+	SessionID uuid.UUID
+	ExpiresAt time.Time
 }
 
 type AuthResult struct {
-	Token string
-	User  db.User
+	Token            string
+	ExpiresAt        time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	User             db.User
 }
 
 // ─── Register ────────────────────────────────────────────────────────────────
@@ -100,85 +137,212 @@ func (s *AuthService) Register(ctx context.Context, in RegisterInput) error {
 		}
 	}
 
-	return s.sendOTP(ctx, user)
+	return s.sendOTP(ctx, user, otpPurposeRegistration)
 }
 
 // ─── Verify OTP ──────────────────────────────────────────────────────────────
 
 func (s *AuthService) VerifyOTP(ctx context.Context, in VerifyOTPInput) (*AuthResult, error) {
-	user, err := s.q.GetUserByEmail(ctx, strings.ToLower(in.Email))
+	user, err := s.getUserByEmail(ctx, in.Email)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, &domain.AppError{Err: domain.ErrNotFound, Message: "no account found with this email"}
-		}
-		return nil, fmt.Errorf("get user: %w", err)
+		return nil, err
 	}
 
-	otp, err := s.q.GetLatestOTPByUserID(ctx, user.ID)
+	if err := s.redeemOTP(ctx, user.ID, otpPurposeRegistration, in.OTP); err != nil {
+		return nil, err
+	}
+
+	// Mark user verified
+	verifiedUser, err := s.q.MarkUserVerified(ctx, user.ID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, &domain.AppError{Err: domain.ErrOTPInvalid, Message: "no OTP found, please request a new one"}
-		}
-		return nil, fmt.Errorf("get otp: %w", err)
+		return nil, fmt.Errorf("mark user verified: %w", err)
 	}
 
-	if otp.IsUsed {
-		return nil, domain.ErrOTPAlreadyUsed
+	return s.issueTokens(ctx, s.q, verifiedUser, uuid.New(), in.UserAgent, in.IPAddress)
+}
+
+// ─── Login ───────────────────────────────────────────────────────────────────
+
+// Login sends a sign-in OTP to an already-verified account.
+func (s *AuthService) Login(ctx context.Context, email string) error {
+	user, err := s.getUserByEmail(ctx, email)
+	if err != nil {
+		return err
 	}
-	if time.Now().After(otp.ExpiresAt) {
-		return nil, domain.ErrOTPExpired
+	if !user.IsVerified {
+		return &domain.AppError{Err: domain.ErrUserNotVerified, Message: "account is not verified, please complete registration first"}
 	}
-	if otp.AttemptCount >= maxOTPAttempts {
-		return nil, &domain.AppError{Err: domain.ErrOTPInvalid, Message: "too many failed attempts, please request a new OTP"}
+
+	return s.sendOTP(ctx, user, otpPurposeLogin)
+}
+
+// VerifyLogin redeems a sign-in OTP and issues a new access token.
+func (s *AuthService) VerifyLogin(ctx context.Context, in VerifyOTPInput) (*AuthResult, error) {
+	user, err := s.getUserByEmail(ctx, in.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsVerified {
+		return nil, &domain.AppError{Err: domain.ErrUserNotVerified, Message: "account is not verified, please complete registration first"}
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(otp.OtpHash), []byte(in.OTP)); err != nil {
-		// Increment attempt counter but don't reveal the mismatch in error detail
-		if _, incErr := s.q.IncrementOTPAttempts(ctx, otp.ID); incErr != nil {
-			return nil, fmt.Errorf("increment otp attempts: %w", incErr)
+	if err := s.redeemOTP(ctx, user.ID, otpPurposeLogin, in.OTP); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, s.q, user, uuid.New(), in.UserAgent, in.IPAddress)
+}
+
+// ─── Refresh ─────────────────────────────────────────────────────────────────
+
+// Refresh exchanges a refresh token for a new access/refresh token pair.
+// Every refresh token is single-use: presenting one that has already been
+// rotated revokes its whole session family.
+func (s *AuthService) Refresh(ctx context.Context, in RefreshInput) (*AuthResult, error) {
+	if in.RefreshToken == "" {
+		return nil, &domain.AppError{Err: domain.ErrUnauthorized, Message: "refresh token is required"}
+	}
+
+	var result *AuthResult
+	var reused bool
+
+	err := pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		sess, err := qtx.GetSessionByTokenHashForUpdate(ctx, hashToken(in.RefreshToken))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errInvalidRefreshToken
+			}
+			return fmt.Errorf("get session: %w", err)
+		}
+
+		if sess.RevokedAt.Valid {
+			return errInvalidRefreshToken
 		}
-		return nil, domain.ErrOTPInvalid
+		if sess.RotatedAt.Valid {
+			// Replay of an already-rotated token: assume it leaked and kill
+			// the family. Commit the revocation, then report the failure.
+			reused = true
+			if err := qtx.RevokeSessionFamily(ctx, sess.FamilyID); err != nil {
+				return fmt.Errorf("revoke session family: %w", err)
+			}
+			return nil
+		}
+		if time.Now().After(sess.ExpiresAt) {
+			return errInvalidRefreshToken
+		}
+
+		if err := qtx.MarkSessionRotated(ctx, sess.ID); err != nil {
+			return fmt.Errorf("mark session rotated: %w", err)
+		}
+
+		user, err := qtx.GetUserByID(ctx, sess.UserID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errInvalidRefreshToken
+			}
+			return fmt.Errorf("get user: %w", err)
+		}
+
+		result, err = s.issueTokens(ctx, qtx, user, sess.FamilyID, in.UserAgent, in.IPAddress)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, &domain.AppError{Err: domain.ErrUnauthorized, Message: "refresh token reuse detected, please sign in again"}
 	}
 
-	// Mark OTP used
-	if err := s.q.MarkOTPUsed(ctx, otp.ID); err != nil {
-		return nil, fmt.Errorf("mark otp used: %w", err)
+	return result, nil
+}
+
+// ─── Logout ──────────────────────────────────────────────────────────────────
+
+// Logout revokes the presented access token and the refresh tokens of the
+// session it belongs to.
+func (s *AuthService) Logout(ctx context.Context, in LogoutInput) error {
+	if err := s.revocations.RevokeToken(ctx, in.UserID, in.TokenID, in.ExpiresAt); err != nil {
+		return err
 	}
+	if in.SessionID != uuid.Nil {
+		if err := s.q.RevokeSessionFamily(ctx, in.SessionID); err != nil {
+			return fmt.Errorf("revoke session: %w", err)
+		}
+	}
+	return nil
+}
 
-	// Mark user verified
-	verifiedUser, err := s.q.MarkUserVerified(ctx, user.ID)
+// LogoutAll revokes every access and refresh token issued to the user.
+func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.q.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// ─── Roles ───────────────────────────────────────────────────────────────────
+
+// SetUserRole changes a user's role. Existing tokens carry the old role in
+// their claims, so they are revoked and the user must sign in again.
+func (s *AuthService) SetUserRole(ctx context.Context, actorID uuid.UUID, userID, role string) (db.User, error) {
+	uid, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, fmt.Errorf("mark user verified: %w", err)
+		return db.User{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid user id"}
+	}
+	if !domain.IsValidRole(role) {
+		return db.User{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "role must be one of customer, staff, admin"}
+	}
+	if uid == actorID {
+		return db.User{}, &domain.AppError{Err: domain.ErrForbidden, Message: "you cannot change your own role"}
 	}
 
-	token, err := s.generateJWT(verifiedUser.ID)
+	user, err := s.q.UpdateUserRole(ctx, db.UpdateUserRoleParams{ID: uid, Role: role})
 	if err != nil {
-		return nil, fmt.Errorf("generate jwt: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, domain.ErrNotFound
+		}
+		return db.User{}, fmt.Errorf("update user role: %w", err)
 	}
 
-	return &AuthResult{Token: token, User: verifiedUser}, nil
+	if err := s.LogoutAll(ctx, user.ID); err != nil {
+		return db.User{}, err
+	}
+	return user, nil
 }
 
 // ─── Resend OTP ──────────────────────────────────────────────────────────────
 
 func (s *AuthService) ResendOTP(ctx context.Context, email string) error {
-	user, err := s.q.GetUserByEmail(ctx, strings.ToLower(email))
+	user, err := s.getUserByEmail(ctx, email)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &domain.AppError{Err: domain.ErrNotFound, Message: "no account found with this email"}
-		}
-		return fmt.Errorf("get user: %w", err)
+		return err
 	}
 	if user.IsVerified {
 		return &domain.AppError{Err: domain.ErrConflict, Message: "account is already verified"}
 	}
 
-	return s.sendOTP(ctx, user)
+	return s.sendOTP(ctx, user, otpPurposeRegistration)
 }
 
 // ─── Internal helpers ─────────────────────────────────────────────────────────
 
-func (s *AuthService) sendOTP(ctx context.Context, user db.User) error {
+func (s *AuthService) getUserByEmail(ctx context.Context, email string) (db.User, error) {
+	user, err := s.q.GetUserByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, &domain.AppError{Err: domain.ErrNotFound, Message: "no account found with this email"}
+		}
+		return db.User{}, fmt.Errorf("get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *AuthService) sendOTP(ctx context.Context, user db.User, purpose string) error {
 	// Rate limit: max 3 OTPs per hour per user
 	count, err := s.q.CountRecentOTPsByUserID(ctx, user.ID)
 	if err != nil {
@@ -209,23 +373,132 @@ func (s *AuthService) sendOTP(ctx context.Context, user db.User) error {
 	if _, err := s.q.CreateOTP(ctx, db.CreateOTPParams{
 		UserID:    user.ID,
 		OtpHash:   string(hash),
-		ExpiresAt: time.Now().Add(5 * time.Minute),
+		ExpiresAt: time.Now().Add(otpTTL),
+		Purpose:   purpose,
 	}); err != nil {
 		return fmt.Errorf("store otp: %w", err)
 	}
 
 	// Send email
+	if purpose == otpPurposeLogin {
+		return s.emailSvc.SendLoginOTP(user.EmailAddress, user.FirstName, rawOTP)
+	}
 	return s.emailSvc.SendOTP(user.EmailAddress, user.FirstName, rawOTP)
 }
 
-func (s *AuthService) generateJWT(userID uuid.UUID) (string, error) {
+// redeemOTP checks the user's latest OTP for the given purpose and marks it
+// used on success. Failed comparisons count towards maxOTPAttempts.
+func (s *AuthService) redeemOTP(ctx context.Context, userID uuid.UUID, purpose, code string) error {
+	otp, err := s.q.GetLatestOTPByUserID(ctx, db.GetLatestOTPByUserIDParams{
+		UserID:  userID,
+		Purpose: purpose,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &domain.AppError{Err: domain.ErrOTPInvalid, Message: "no OTP found, please request a new one"}
+		}
+		return fmt.Errorf("get otp: %w", err)
+	}
+
+	if err := checkOTP(otp, purpose, time.Now()); err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(otp.OtpHash), []byte(code)); err != nil {
+		// Increment attempt counter but don't reveal the mismatch in error detail
+		if _, incErr := s.q.IncrementOTPAttempts(ctx, otp.ID); incErr != nil {
+			return fmt.Errorf("increment otp attempts: %w", incErr)
+		}
+		return domain.ErrOTPInvalid
+	}
+
+	if err := s.q.MarkOTPUsed(ctx, otp.ID); err != nil {
+		return fmt.Errorf("mark otp used: %w", err)
+	}
+	return nil
+}
+
+// checkOTP validates an OTP's state before its hash is compared.
+func checkOTP(otp db.EmailOtp, purpose string, now time.Time) error {
+	if otp.Purpose != purpose {
+		return domain.ErrOTPInvalid
+	}
+	if otp.IsUsed {
+		return domain.ErrOTPAlreadyUsed
+	}
+	if now.After(otp.ExpiresAt) {
+		return domain.ErrOTPExpired
+	}
+	if otp.AttemptCount >= maxOTPAttempts {
+		return &domain.AppError{Err: domain.ErrOTPInvalid, Message: "too many failed attempts, please request a new OTP"}
+	}
+	return nil
+}
+
+// issueTokens stores a new refresh token in the given session family and
+// signs a matching access token.
+func (s *AuthService) issueTokens(ctx context.Context, q *db.Queries, user db.User, familyID uuid.UUID, userAgent, ipAddress string) (*AuthResult, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	sess, err := q.CreateSession(ctx, db.CreateSessionParams{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		UserAgent: pgtype.Text{String: userAgent, Valid: userAgent != ""},
+		IpAddress: pgtype.Text{String: ipAddress, Valid: ipAddress != ""},
+		ExpiresAt: time.Now().Add(s.jwtConfig.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+
+	token, expiresAt, err := s.generateJWT(user, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("generate jwt: %w", err)
+	}
+
+	return &AuthResult{
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: sess.ExpiresAt,
+		User:             user,
+	}, nil
+}
+
+func (s *AuthService) generateJWT(user db.User, sessionID uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.jwtConfig.AccessTokenTTL)
 	claims := jwt.MapClaims{
-		"sub": userID.String(),
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(s.jwtConfig.AccessTokenTTL).Unix(),
+		"sub":  user.ID.String(),
+		"jti":  uuid.NewString(),
+		"sid":  sessionID.String(),
+		"ver":  user.TokenVersion,
+		"role": user.Role,
+		"iat":  now.Unix(),
+		"exp":  expiresAt.Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtConfig.Secret))
+	signed, err := token.SignedString([]byte(s.jwtConfig.Secret))
+	return signed, expiresAt, err
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of an opaque token. Refresh tokens carry
+// 256 bits of entropy, so a fast unsalted hash is sufficient for lookup.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func generateOTP(length int) (string, error) {
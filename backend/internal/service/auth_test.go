@@ -1,8 +1,12 @@
 package service_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
 	"github.com/online-cake-shop/backend/internal/service"
 )
 
@@ -100,3 +104,70 @@ func TestValidateRegisterInput(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckOTP(t *testing.T) {
+	now := time.Now()
+	valid := db.EmailOtp{
+		Purpose:   "login",
+		ExpiresAt: now.Add(time.Minute),
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(o *db.EmailOtp)
+		purpose string
+		wantErr error
+	}{
+		{name: "valid", mutate: func(o *db.EmailOtp) {}, purpose: "login"},
+		{name: "wrong purpose", mutate: func(o *db.EmailOtp) {}, purpose: "registration", wantErr: domain.ErrOTPInvalid},
+		{name: "already used", mutate: func(o *db.EmailOtp) { o.IsUsed = true }, purpose: "login", wantErr: domain.ErrOTPAlreadyUsed},
+		{name: "expired", mutate: func(o *db.EmailOtp) { o.ExpiresAt = now.Add(-time.Second) }, purpose: "login", wantErr: domain.ErrOTPExpired},
+		{name: "too many attempts", mutate: func(o *db.EmailOtp) { o.AttemptCount = 5 }, purpose: "login", wantErr: domain.ErrOTPInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otp := valid
+			tt.mutate(&otp)
+			err := service.CheckOTP(otp, tt.purpose, now)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got err=%v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateRefreshToken(t *testing.T) {
+	a, err := service.GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := service.GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a == b {
+		t.Error("expected two refresh tokens to differ")
+	}
+	// 32 random bytes, unpadded base64url
+	if len(a) != 43 {
+		t.Errorf("expected length 43, got %d", len(a))
+	}
+
+	h := service.HashToken(a)
+	if len(h) != 64 {
+		t.Errorf("expected 64 hex chars, got %d", len(h))
+	}
+	if h != service.HashToken(a) {
+		t.Error("expected hash to be deterministic")
+	}
+	if h == service.HashToken(b) {
+		t.Error("expected different tokens to hash differently")
+	}
+}
@@ -4,63 +4,162 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/online-cake-shop/backend/internal/config"
 	"github.com/online-cake-shop/backend/internal/domain"
 	"github.com/online-cake-shop/backend/internal/repository/db"
 )
 
 type CartService struct {
-	q *db.Queries
+	pool            *pgxpool.Pool
+	q               *db.Queries
+	promotions      *PromotionEngine
+	reservationTTL  time.Duration
+	guestCartSecret []byte
 }
 
-func NewCartService(q *db.Queries) *CartService {
-	return &CartService{q: q}
+func NewCartService(pool *pgxpool.Pool, q *db.Queries, promotions *PromotionEngine, cfg config.CartConfig) *CartService {
+	return &CartService{
+		pool:            pool,
+		q:               q,
+		promotions:      promotions,
+		reservationTTL:  cfg.ReservationTTL,
+		guestCartSecret: []byte(cfg.GuestCartSecret),
+	}
 }
 
 // ─── DTOs ────────────────────────────────────────────────────────────────────
 
 type CartItemResponse struct {
-	ID           string  `json:"id"`
-	ProductID    string  `json:"product_id"`
-	ProductName  string  `json:"product_name"`
-	ProductImage *string `json:"product_image_url"`
-	Price        float64 `json:"price"`
-	Quantity     int32   `json:"quantity"`
-	Subtotal     float64 `json:"subtotal"`
+	ID           string            `json:"id"`
+	ProductID    string            `json:"product_id"`
+	VariantID    string            `json:"variant_id"`
+	ProductName  string            `json:"product_name"`
+	ProductImage *string           `json:"product_image_url"`
+	SKU          string            `json:"sku"`
+	Options      map[string]string `json:"options"`
+	// Price is per cake, including PersonalisationPrice.
+	Price                domain.Money     `json:"price"`
+	Personalisation      *Personalisation `json:"personalisation,omitempty"`
+	PersonalisationPrice domain.Money     `json:"personalisation_price"`
+	Quantity             int32            `json:"quantity"`
+	Subtotal             domain.Money     `json:"subtotal"`
+	// Discount is what promotions take off Subtotal.
+	Discount domain.Money `json:"discount"`
+	// ReservedUntil is when the stock held for this line is released. It is
+	// omitted once the hold has lapsed.
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+	// PreviousPrice is the per-cake price the customer was shown when they
+	// added the line or last changed its quantity, if it has changed since.
+	PreviousPrice *domain.Money `json:"previous_price,omitempty"`
+	// Available is false once the product or variant is no longer sold.
+	// Such lines are left out of the total and block checkout.
+	Available bool `json:"available"`
+	// AvailableQuantity is the variant's stock not held by other carts.
+	AvailableQuantity int32         `json:"available_quantity"`
+	Warnings          []CartWarning `json:"warnings"`
+}
+
+// Cart warning codes.
+const (
+	CartWarningPriceChanged      = "price_changed"
+	CartWarningUnavailable       = "unavailable"
+	CartWarningInsufficientStock = "insufficient_stock"
+	CartWarningCouponUnusable    = "coupon_unusable"
+)
+
+// CartWarning tells the customer what changed about a line since it was
+// added. Unavailable and insufficient stock warnings block checkout until
+// the line is removed or its quantity lowered.
+type CartWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// CartOwner is whose cart to work on: the signed-in user's or, without a
+// UserID, the guest cart the visitor's cookie names. GuestCartID is
+// uuid.Nil for guests who have no cart yet.
+type CartOwner struct {
+	UserID      uuid.UUID
+	GuestCartID uuid.UUID
+}
+
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == uuid.Nil
 }
 
 type CartResponse struct {
+	// ID is empty for guests who haven't added anything yet.
 	ID    string             `json:"id"`
 	Items []CartItemResponse `json:"items"`
-	Total float64            `json:"total"`
+	// Subtotal is the sum of the lines that can be ordered. Promotions take
+	// PromotionDiscount off it and the coupon Discount; Total is what is
+	// left plus DeliveryFee.
+	Subtotal          domain.Money       `json:"subtotal"`
+	Promotions        []AppliedPromotion `json:"promotions"`
+	PromotionDiscount domain.Money       `json:"promotion_discount"`
+	Discount          domain.Money       `json:"discount"`
+	DeliveryFee       domain.Money       `json:"delivery_fee"`
+	Total             domain.Money       `json:"total"`
+	Coupon            *CartCoupon        `json:"coupon,omitempty"`
+	// CanCheckout is false for an empty cart or one with lines or a coupon
+	// that can't be ordered as they are.
+	CanCheckout bool `json:"can_checkout"`
+}
+
+// CartCoupon is the coupon applied to a cart. Warning says why it can't be
+// used on the cart as it is, in which case it takes nothing off.
+type CartCoupon struct {
+	Code        string       `json:"code"`
+	Description *string      `json:"description,omitempty"`
+	Warning     *CartWarning `json:"warning,omitempty"`
 }
 
+// AddCartItemInput adds a variant of a product. VariantID may be left empty
+// for products that come in a single variant. The same variant with another
+// Personalisation becomes a separate line.
 type AddCartItemInput struct {
-	UserID    uuid.UUID
-	ProductID string
-	Quantity  int32
+	Owner           CartOwner
+	ProductID       string
+	VariantID       string
+	Personalisation Personalisation
+	Quantity        int32
 }
 
 type UpdateCartItemInput struct {
-	UserID     uuid.UUID
+	Owner      CartOwner
 	CartItemID string
 	Quantity   int32
 }
 
 type RemoveCartItemInput struct {
-	UserID     uuid.UUID
+	Owner      CartOwner
 	CartItemID string
 }
 
 // ─── Get Cart ─────────────────────────────────────────────────────────────────
 
-func (s *CartService) GetCart(ctx context.Context, userID uuid.UUID) (*CartResponse, error) {
-	cart, err := s.q.GetOrCreateCart(ctx, userID)
+// GetCart returns the owner's cart. Guests without a cart get an empty one
+// that isn't stored until they add to it.
+func (s *CartService) GetCart(ctx context.Context, owner CartOwner) (*CartResponse, error) {
+	var cart db.Cart
+	var err error
+	if owner.IsGuest() {
+		cart, err = s.findCart(ctx, owner)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &CartResponse{Items: []CartItemResponse{}, Promotions: []AppliedPromotion{}}, nil
+		}
+	} else {
+		cart, err = s.getOrCreateCart(ctx, owner)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("get or create cart: %w", err)
+		return nil, err
 	}
 
 	items, err := s.q.GetCartItems(ctx, cart.ID)
@@ -68,7 +167,16 @@ func (s *CartService) GetCart(ctx context.Context, userID uuid.UUID) (*CartRespo
 		return nil, fmt.Errorf("get cart items: %w", err)
 	}
 
-	return buildCartResponse(cart, items), nil
+	resp := buildCartResponse(cart, items)
+	if err := s.applyCartPromotions(ctx, resp); err != nil {
+		return nil, err
+	}
+	if cart.CouponID.Valid {
+		if err := s.priceCartCoupon(ctx, owner, cart.CouponID.Bytes, resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
 // ─── Add Item ─────────────────────────────────────────────────────────────────
@@ -82,33 +190,69 @@ func (s *CartService) AddItem(ctx context.Context, in AddCartItemInput) (*CartRe
 	if err != nil {
 		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
 	}
+	var variantID uuid.UUID
+	if in.VariantID != "" {
+		if variantID, err = uuid.Parse(in.VariantID); err != nil {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid variant id"}
+		}
+	}
 
-	// Verify product exists and has stock
-	product, err := s.q.GetProductByID(ctx, productID)
+	cart, err := s.getOrCreateCart(ctx, in.Owner)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrNotFound
-		}
-		return nil, fmt.Errorf("get product: %w", err)
+		return nil, err
 	}
-	if product.StockQuantity < in.Quantity {
-		return nil, domain.ErrInsufficientStock
+	if in.Owner.IsGuest() {
+		in.Owner.GuestCartID = cart.ID // it may just have been created
 	}
 
-	cart, err := s.q.GetOrCreateCart(ctx, in.UserID)
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		return s.addItem(ctx, s.q.WithTx(tx), cart.ID, productID, variantID, in.Personalisation, in.Quantity)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("get or create cart: %w", err)
+		return nil, err
 	}
 
-	if _, err := s.q.UpsertCartItem(ctx, db.UpsertCartItemParams{
-		CartID:    cart.ID,
-		ProductID: productID,
-		Quantity:  in.Quantity,
-	}); err != nil {
-		return nil, fmt.Errorf("upsert cart item: %w", err)
+	return s.GetCart(ctx, in.Owner)
+}
+
+// addItem adds quantity of a variant to a cart within the caller's
+// transaction and holds the stock for it. A nil variantID picks the
+// product's only variant.
+func (s *CartService) addItem(ctx context.Context, qtx *db.Queries, cartID, productID, variantID uuid.UUID, requested Personalisation, quantity int32) error {
+	if quantity < 1 {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "quantity must be at least 1"}
+	}
+	if variantID == uuid.Nil {
+		only, err := onlyVariant(ctx, qtx, productID)
+		if err != nil {
+			return err
+		}
+		variantID = only
+	}
+	variant, err := lockVariant(ctx, qtx, variantID)
+	if err != nil {
+		return err
+	}
+	if variant.ProductID != productID {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "variant does not belong to the product"}
+	}
+	options := decodePersonalisationOptions(variant.PersonalisationOptions)
+	personalisation, err := checkPersonalisation(options, requested)
+	if err != nil {
+		return err
 	}
 
-	return s.GetCart(ctx, in.UserID)
+	if _, err := qtx.UpsertCartItem(ctx, db.UpsertCartItemParams{
+		CartID:          cartID,
+		ProductID:       productID,
+		VariantID:       variantID,
+		Personalisation: encodePersonalisation(personalisation),
+		Quantity:        quantity,
+		AddedPrice:      moneyToNumeric(numericToMoney(variant.Price).Add(personalisationPrice(options, personalisation))),
+	}); err != nil {
+		return fmt.Errorf("upsert cart item: %w", err)
+	}
+	return s.reserve(ctx, qtx, cartID, variant)
 }
 
 // ─── Update Item ──────────────────────────────────────────────────────────────
@@ -123,7 +267,7 @@ func (s *CartService) UpdateItem(ctx context.Context, in UpdateCartItemInput) (*
 		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid item id"}
 	}
 
-	cart, err := s.q.GetCartByUserID(ctx, in.UserID)
+	cart, err := s.findCart(ctx, in.Owner)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrNotFound
@@ -131,18 +275,40 @@ func (s *CartService) UpdateItem(ctx context.Context, in UpdateCartItemInput) (*
 		return nil, fmt.Errorf("get cart: %w", err)
 	}
 
-	if _, err := s.q.UpdateCartItemQuantity(ctx, db.UpdateCartItemQuantityParams{
-		ID:       itemID,
-		Quantity: in.Quantity,
-		CartID:   cart.ID,
-	}); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrNotFound
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		item, err := qtx.GetCartItem(ctx, itemID, cart.ID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get cart item: %w", err)
+		}
+
+		variant, err := lockVariant(ctx, qtx, item.VariantID)
+		if err != nil {
+			return err
+		}
+		// The customer is looking at the current price while changing the
+		// quantity, so it becomes the one changes are reported against.
+		options := decodePersonalisationOptions(variant.PersonalisationOptions)
+		price := numericToMoney(variant.Price).Add(personalisationPrice(options, decodePersonalisation(item.Personalisation)))
+		if _, err := qtx.UpdateCartItemQuantity(ctx, db.UpdateCartItemQuantityParams{
+			ID:         itemID,
+			Quantity:   in.Quantity,
+			CartID:     cart.ID,
+			AddedPrice: moneyToNumeric(price),
+		}); err != nil {
+			return fmt.Errorf("update cart item: %w", err)
 		}
-		return nil, fmt.Errorf("update cart item: %w", err)
+		return s.reserve(ctx, qtx, cart.ID, variant)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.GetCart(ctx, in.UserID)
+	return s.GetCart(ctx, in.Owner)
 }
 
 // ─── Remove Item ──────────────────────────────────────────────────────────────
@@ -153,7 +319,7 @@ func (s *CartService) RemoveItem(ctx context.Context, in RemoveCartItemInput) (*
 		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid item id"}
 	}
 
-	cart, err := s.q.GetCartByUserID(ctx, in.UserID)
+	cart, err := s.findCart(ctx, in.Owner)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrNotFound
@@ -161,55 +327,259 @@ func (s *CartService) RemoveItem(ctx context.Context, in RemoveCartItemInput) (*
 		return nil, fmt.Errorf("get cart: %w", err)
 	}
 
-	if err := s.q.DeleteCartItem(ctx, db.DeleteCartItemParams{
-		ID:     itemID,
-		CartID: cart.ID,
-	}); err != nil {
-		return nil, fmt.Errorf("delete cart item: %w", err)
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		item, err := qtx.GetCartItem(ctx, itemID, cart.ID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil // already gone
+			}
+			return fmt.Errorf("get cart item: %w", err)
+		}
+
+		if err := qtx.DeleteCartItem(ctx, db.DeleteCartItemParams{
+			ID:     itemID,
+			CartID: cart.ID,
+		}); err != nil {
+			return fmt.Errorf("delete cart item: %w", err)
+		}
+
+		// Other lines of the variant keep their share of the hold.
+		remaining, err := qtx.SumCartVariantQuantity(ctx, cart.ID, item.VariantID)
+		if err != nil {
+			return fmt.Errorf("sum cart quantity: %w", err)
+		}
+		if remaining == 0 {
+			if err := qtx.DeleteStockReservation(ctx, cart.ID, item.VariantID); err != nil {
+				return fmt.Errorf("release reservation: %w", err)
+			}
+			return nil
+		}
+		if err := qtx.UpsertStockReservation(ctx, db.UpsertStockReservationParams{
+			CartID:    cart.ID,
+			VariantID: item.VariantID,
+			Quantity:  remaining,
+			ExpiresAt: time.Now().Add(s.reservationTTL),
+		}); err != nil {
+			return fmt.Errorf("reduce reservation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.GetCart(ctx, in.UserID)
+	return s.GetCart(ctx, in.Owner)
 }
 
 // ─── Clear Cart ───────────────────────────────────────────────────────────────
 
-func (s *CartService) ClearCart(ctx context.Context, userID uuid.UUID) error {
-	cart, err := s.q.GetCartByUserID(ctx, userID)
+// ClearCart empties the owner's cart and removes its coupon.
+func (s *CartService) ClearCart(ctx context.Context, owner CartOwner) error {
+	cart, err := s.findCart(ctx, owner)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil // nothing to clear
 		}
 		return fmt.Errorf("get cart: %w", err)
 	}
-	return s.q.ClearCart(ctx, cart.ID)
+	return pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		if err := qtx.ClearCart(ctx, cart.ID); err != nil {
+			return fmt.Errorf("clear cart: %w", err)
+		}
+		if err := qtx.DeleteCartReservations(ctx, cart.ID); err != nil {
+			return fmt.Errorf("release reservations: %w", err)
+		}
+		if err := qtx.SetCartCoupon(ctx, cart.ID, pgtype.UUID{}); err != nil {
+			return fmt.Errorf("remove cart coupon: %w", err)
+		}
+		return nil
+	})
 }
 
 // ─── Helpers ──────────────────────────────────────────────────────────────────
 
+// findCart returns the owner's cart, or pgx.ErrNoRows if it has none.
+func (s *CartService) findCart(ctx context.Context, owner CartOwner) (db.Cart, error) {
+	if !owner.IsGuest() {
+		return s.q.GetCartByUserID(ctx, owner.UserID)
+	}
+	if owner.GuestCartID == uuid.Nil {
+		return db.Cart{}, pgx.ErrNoRows
+	}
+	return s.q.TouchGuestCart(ctx, owner.GuestCartID)
+}
+
+// getOrCreateCart returns the owner's cart, creating it if needed. A guest
+// whose cart has been swept gets a new one.
+func (s *CartService) getOrCreateCart(ctx context.Context, owner CartOwner) (db.Cart, error) {
+	var cart db.Cart
+	var err error
+	if owner.IsGuest() {
+		cart, err = s.findCart(ctx, owner)
+		if errors.Is(err, pgx.ErrNoRows) {
+			cart, err = s.q.CreateGuestCart(ctx)
+		}
+	} else {
+		cart, err = s.q.GetOrCreateCart(ctx, owner.UserID)
+	}
+	if err != nil {
+		return db.Cart{}, fmt.Errorf("get or create cart: %w", err)
+	}
+	return cart, nil
+}
+
+// lockVariant locks a purchasable variant's row, which serialises holds on
+// its stock.
+func lockVariant(ctx context.Context, qtx *db.Queries, variantID uuid.UUID) (db.GetVariantStockForUpdateRow, error) {
+	variant, err := qtx.GetVariantStockForUpdate(ctx, variantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.GetVariantStockForUpdateRow{}, domain.ErrNotFound
+		}
+		return db.GetVariantStockForUpdateRow{}, fmt.Errorf("get variant: %w", err)
+	}
+	return variant, nil
+}
+
+// onlyVariant returns the variant of a product that is sold in just one, so
+// that clients needn't pick it.
+func onlyVariant(ctx context.Context, qtx *db.Queries, productID uuid.UUID) (uuid.UUID, error) {
+	variants, err := qtx.ListProductVariants(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("list variants: %w", err)
+	}
+	var active []db.ProductVariant
+	for _, v := range variants {
+		if v.IsActive {
+			active = append(active, v)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return uuid.Nil, domain.ErrNotFound
+	case 1:
+		return active[0].ID, nil
+	default:
+		return uuid.Nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "variant_id is required for products with several variants"}
+	}
+}
+
+// reserve holds the cart's quantity of a locked variant, across all its
+// lines, failing if other carts' holds leave too little stock. It runs after
+// the cart lines are written. Every change to the cart also keeps its other
+// unexpired holds alive.
+func (s *CartService) reserve(ctx context.Context, qtx *db.Queries, cartID uuid.UUID, variant db.GetVariantStockForUpdateRow) error {
+	quantity, err := qtx.SumCartVariantQuantity(ctx, cartID, variant.ID)
+	if err != nil {
+		return fmt.Errorf("sum cart quantity: %w", err)
+	}
+	reserved, err := reservedStock(ctx, qtx, []uuid.UUID{variant.ID}, cartID)
+	if err != nil {
+		return err
+	}
+	if available := availableToPromise(variant.StockQuantity, reserved[variant.ID]); quantity > available {
+		return &domain.AppError{
+			Err:     domain.ErrInsufficientStock,
+			Message: fmt.Sprintf("only %d of '%s' available", available, variantName(variant.Name, variant.Options)),
+		}
+	}
+
+	expiresAt := time.Now().Add(s.reservationTTL)
+	if err := qtx.UpsertStockReservation(ctx, db.UpsertStockReservationParams{
+		CartID:    cartID,
+		VariantID: variant.ID,
+		Quantity:  quantity,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return fmt.Errorf("reserve stock: %w", err)
+	}
+	if err := qtx.ExtendCartReservations(ctx, cartID, expiresAt); err != nil {
+		return fmt.Errorf("extend reservations: %w", err)
+	}
+	return nil
+}
+
+// buildCartResponse prices the cart's lines and flags the ones that changed
+// since they were added or can't be ordered as they are.
 func buildCartResponse(cart db.Cart, items []db.GetCartItemsRow) *CartResponse {
 	resp := &CartResponse{
-		ID:    cart.ID.String(),
-		Items: make([]CartItemResponse, 0, len(items)),
+		ID:          cart.ID.String(),
+		Items:       make([]CartItemResponse, 0, len(items)),
+		Promotions:  []AppliedPromotion{},
+		CanCheckout: len(items) > 0,
+	}
+
+	// Lines of a variant with different personalisations share its stock.
+	wanted := make(map[uuid.UUID]int32, len(items))
+	for _, item := range items {
+		wanted[item.VariantID] += item.Quantity
 	}
-	var total float64
+
+	var total domain.Money
 	for _, item := range items {
-		price := numericToFloat(item.ProductPrice)
-		subtotal := price * float64(item.Quantity)
-		total += subtotal
+		personalisation := decodePersonalisation(item.Personalisation)
+		surcharge := personalisationPrice(decodePersonalisationOptions(item.ProductPersonalisationOptions), personalisation)
+		price := numericToMoney(item.ProductPrice).Add(surcharge)
+		subtotal := price.Mul(int64(item.Quantity))
 
 		ci := CartItemResponse{
-			ID:          item.ID.String(),
-			ProductID:   item.ProductID.String(),
-			ProductName: item.ProductName,
-			Price:       price,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
+			ID:                   item.ID.String(),
+			ProductID:            item.ProductID.String(),
+			VariantID:            item.VariantID.String(),
+			ProductName:          item.ProductName,
+			SKU:                  item.VariantSku,
+			Options:              decodeVariantOptions(item.VariantOptions),
+			Price:                price,
+			PersonalisationPrice: surcharge,
+			Quantity:             item.Quantity,
+			Subtotal:             subtotal,
+			Available:            !item.Unavailable,
+			AvailableQuantity:    item.AvailableQuantity,
+			Warnings:             []CartWarning{},
+		}
+		if !personalisation.isZero() {
+			ci.Personalisation = &personalisation
 		}
 		if item.ProductImageUrl.Valid {
 			ci.ProductImage = &item.ProductImageUrl.String
 		}
+		if item.ReservedUntil.Valid {
+			ci.ReservedUntil = &item.ReservedUntil.Time
+		}
+
+		switch {
+		case item.Unavailable:
+			ci.AvailableQuantity = 0
+			ci.Warnings = append(ci.Warnings, CartWarning{Code: CartWarningUnavailable, Message: "no longer available"})
+			resp.CanCheckout = false
+		case wanted[item.VariantID] > item.AvailableQuantity:
+			msg := fmt.Sprintf("only %d available", item.AvailableQuantity)
+			if item.AvailableQuantity == 0 {
+				msg = "out of stock"
+			}
+			ci.Warnings = append(ci.Warnings, CartWarning{Code: CartWarningInsufficientStock, Message: msg})
+			resp.CanCheckout = false
+		}
+		if item.AddedPrice.Valid && !item.Unavailable {
+			if previous := numericToMoney(item.AddedPrice); previous != price {
+				ci.PreviousPrice = &previous
+				ci.Warnings = append(ci.Warnings, CartWarning{
+					Code:    CartWarningPriceChanged,
+					Message: fmt.Sprintf("price changed from %s to %s", previous, price),
+				})
+			}
+		}
+
+		if !item.Unavailable {
+			total = total.Add(subtotal)
+		}
 		resp.Items = append(resp.Items, ci)
 	}
+	resp.Subtotal = total
 	resp.Total = total
 	return resp
 }
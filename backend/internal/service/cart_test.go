@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestBuildCartResponseLargeCart(t *testing.T) {
+	// Prices like 0.10 and 0.70 aren't representable in float64; a cart
+	// full of them must still add up to the exact cent.
+	prices := []int64{10, 70, 1999, 33}
+
+	var items []db.GetCartItemsRow
+	var want domain.Money
+	for i := 0; i < 400; i++ {
+		cents := prices[i%len(prices)]
+		qty := int32(i%9 + 1)
+		items = append(items, db.GetCartItemsRow{
+			ID:           uuid.New(),
+			ProductID:    uuid.New(),
+			Quantity:     qty,
+			ProductName:  "Cake",
+			ProductPrice: pgtype.Numeric{Int: big.NewInt(cents), Exp: -2, Valid: true},
+		})
+		want += domain.Money(cents * int64(qty))
+	}
+
+	resp := service.BuildCartResponse(db.Cart{ID: uuid.New()}, items)
+
+	if resp.Total != want {
+		t.Errorf("Total = %s, want %s", resp.Total, want)
+	}
+
+	var sum domain.Money
+	for _, it := range resp.Items {
+		if it.Subtotal != it.Price.Mul(int64(it.Quantity)) {
+			t.Errorf("item subtotal %s != %s × %d", it.Subtotal, it.Price, it.Quantity)
+		}
+		sum = sum.Add(it.Subtotal)
+	}
+	if sum != resp.Total {
+		t.Errorf("sum of subtotals = %s, want total %s", sum, resp.Total)
+	}
+}
+
+func TestBuildCartResponsePersonalisation(t *testing.T) {
+	options := []byte(`{"inscription": {"max_length": 30, "price": 3.00}, "candles": {"max": 20, "price_each": 0.25}}`)
+	items := []db.GetCartItemsRow{
+		{
+			ID:                            uuid.New(),
+			Quantity:                      2,
+			ProductPrice:                  pgtype.Numeric{Int: big.NewInt(4500), Exp: -2, Valid: true},
+			Personalisation:               []byte(`{"inscription": "Happy Birthday Sara", "candles": 7}`),
+			ProductPersonalisationOptions: options,
+		},
+		{
+			ID:                            uuid.New(),
+			Quantity:                      1,
+			ProductPrice:                  pgtype.Numeric{Int: big.NewInt(4500), Exp: -2, Valid: true},
+			Personalisation:               []byte(`{}`),
+			ProductPersonalisationOptions: options,
+		},
+	}
+
+	resp := service.BuildCartResponse(db.Cart{ID: uuid.New()}, items)
+
+	personalised := resp.Items[0]
+	if personalised.PersonalisationPrice != 475 || personalised.Price != 4975 || personalised.Subtotal != 9950 {
+		t.Errorf("personalised line = %s + %s each, subtotal %s; want 49.75 each incl. 4.75, subtotal 99.50",
+			personalised.Price, personalised.PersonalisationPrice, personalised.Subtotal)
+	}
+	if personalised.Personalisation == nil || personalised.Personalisation.Candles != 7 {
+		t.Errorf("Personalisation = %+v", personalised.Personalisation)
+	}
+	if plain := resp.Items[1]; plain.Personalisation != nil || plain.Price != 4500 {
+		t.Errorf("plain line = %+v", plain)
+	}
+	if resp.Total != 14450 {
+		t.Errorf("Total = %s, want 144.50", resp.Total)
+	}
+}
+
+func TestBuildCartResponseWarnings(t *testing.T) {
+	price := func(cents int64) pgtype.Numeric {
+		return pgtype.Numeric{Int: big.NewInt(cents), Exp: -2, Valid: true}
+	}
+	shared := uuid.New()
+	items := []db.GetCartItemsRow{
+		{ // fine as added
+			ID: uuid.New(), VariantID: uuid.New(), Quantity: 1,
+			ProductPrice: price(2000), AddedPrice: price(2000), AvailableQuantity: 5,
+		},
+		{ // repriced since it was added
+			ID: uuid.New(), VariantID: uuid.New(), Quantity: 1,
+			ProductPrice: price(2200), AddedPrice: price(2000), AvailableQuantity: 5,
+		},
+		{ // withdrawn
+			ID: uuid.New(), VariantID: uuid.New(), Quantity: 1,
+			ProductPrice: price(3000), AddedPrice: price(2500), AvailableQuantity: 5, Unavailable: true,
+		},
+		{ // two personalisations of a variant with 3 left between them
+			ID: uuid.New(), VariantID: shared, Quantity: 2,
+			ProductPrice: price(1000), AvailableQuantity: 3,
+			Personalisation: []byte(`{"candles": 2}`),
+		},
+		{
+			ID: uuid.New(), VariantID: shared, Quantity: 2,
+			ProductPrice: price(1000), AvailableQuantity: 3,
+		},
+	}
+
+	resp := service.BuildCartResponse(db.Cart{ID: uuid.New()}, items)
+
+	codes := func(i int) []string {
+		var out []string
+		for _, w := range resp.Items[i].Warnings {
+			out = append(out, w.Code)
+		}
+		return out
+	}
+	if got := codes(0); len(got) != 0 {
+		t.Errorf("unchanged line warnings = %v, want none", got)
+	}
+	if got := codes(1); len(got) != 1 || got[0] != service.CartWarningPriceChanged {
+		t.Errorf("repriced line warnings = %v, want [price_changed]", got)
+	}
+	if prev := resp.Items[1].PreviousPrice; prev == nil || *prev != 2000 {
+		t.Errorf("repriced line PreviousPrice = %v, want 20.00", prev)
+	}
+	if got := codes(2); len(got) != 1 || got[0] != service.CartWarningUnavailable {
+		t.Errorf("withdrawn line warnings = %v, want [unavailable]", got)
+	}
+	if resp.Items[2].Available || resp.Items[2].AvailableQuantity != 0 {
+		t.Errorf("withdrawn line Available = %v, AvailableQuantity = %d", resp.Items[2].Available, resp.Items[2].AvailableQuantity)
+	}
+	for _, i := range []int{3, 4} {
+		if got := codes(i); len(got) != 1 || got[0] != service.CartWarningInsufficientStock {
+			t.Errorf("line %d warnings = %v, want [insufficient_stock]", i, got)
+		}
+	}
+
+	if resp.CanCheckout {
+		t.Error("CanCheckout = true with flagged lines")
+	}
+	// The withdrawn line can't be bought, so it isn't counted.
+	if resp.Total != 2000+2200+2000+2000 {
+		t.Errorf("Total = %s, want 82.00", resp.Total)
+	}
+
+	if ok := service.BuildCartResponse(db.Cart{ID: uuid.New()}, items[:2]); !ok.CanCheckout {
+		t.Error("CanCheckout = false with only a price change")
+	}
+	if empty := service.BuildCartResponse(db.Cart{ID: uuid.New()}, nil); empty.CanCheckout {
+		t.Error("CanCheckout = true for an empty cart")
+	}
+}
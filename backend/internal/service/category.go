@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+const maxCategoryNameLength = 100
+
+type CreateCategoryInput struct {
+	Name     string
+	ParentID *string
+}
+
+// UpdateCategoryInput is a partial update: nil fields are left unchanged.
+// An empty ParentID moves the category to the top level.
+type UpdateCategoryInput struct {
+	Name     *string
+	ParentID *string
+}
+
+// ─── Admin: Get Category ──────────────────────────────────────────────────────
+
+func (s *ProductService) GetCategory(ctx context.Context, id string) (*db.Category, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid category id"}
+	}
+
+	cat, err := s.q.GetCategoryByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get category: %w", err)
+	}
+	return &cat, nil
+}
+
+// ─── Admin: Create Category ───────────────────────────────────────────────────
+
+// CreateCategory adds a category. Its slug is derived from the name and
+// suffixed with -2, -3, ... if already taken.
+func (s *ProductService) CreateCategory(ctx context.Context, in CreateCategoryInput) (*db.Category, error) {
+	name, err := validateCategoryName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID, err := s.resolveParentID(ctx, uuid.Nil, in.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	slug, err := s.uniqueCategorySlug(ctx, name, pgtype.UUID{})
+	if err != nil {
+		return nil, err
+	}
+
+	cat, err := s.q.CreateCategory(ctx, db.CreateCategoryParams{
+		Name:     name,
+		Slug:     slug,
+		ParentID: parentID,
+	})
+	if err != nil {
+		return nil, mapCategoryWriteError(err)
+	}
+	return &cat, nil
+}
+
+// ─── Admin: Update Category ───────────────────────────────────────────────────
+
+// UpdateCategory renames and/or moves a category. Renaming regenerates the
+// slug; a category can't be moved underneath itself.
+func (s *ProductService) UpdateCategory(ctx context.Context, id string, in UpdateCategoryInput) (*db.Category, error) {
+	existing, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	params := db.UpdateCategoryParams{
+		ID:       existing.ID,
+		Name:     existing.Name,
+		Slug:     existing.Slug,
+		ParentID: existing.ParentID,
+	}
+
+	if in.Name != nil {
+		if params.Name, err = validateCategoryName(*in.Name); err != nil {
+			return nil, err
+		}
+		if params.Name != existing.Name {
+			exclude := pgtype.UUID{Bytes: existing.ID, Valid: true}
+			if params.Slug, err = s.uniqueCategorySlug(ctx, params.Name, exclude); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if in.ParentID != nil {
+		if params.ParentID, err = s.resolveParentID(ctx, existing.ID, in.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	cat, err := s.q.UpdateCategory(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, mapCategoryWriteError(err)
+	}
+	return &cat, nil
+}
+
+// ─── Admin: Delete Category ───────────────────────────────────────────────────
+
+// DeleteCategory removes a category that has no subcategories. Its products
+// become uncategorised.
+func (s *ProductService) DeleteCategory(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid category id"}
+	}
+
+	children, err := s.q.CountCategoryChildren(ctx, pgtype.UUID{Bytes: uid, Valid: true})
+	if err != nil {
+		return fmt.Errorf("count subcategories: %w", err)
+	}
+	if children > 0 {
+		return &domain.AppError{Err: domain.ErrConflict, Message: "category has subcategories, move or delete them first"}
+	}
+
+	n, err := s.q.DeleteCategory(ctx, uid)
+	if err != nil {
+		return mapCategoryWriteError(err)
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+// resolveParentID validates a requested parent for the category id (uuid.Nil
+// when creating). Nil or empty means "top level".
+func (s *ProductService) resolveParentID(ctx context.Context, id uuid.UUID, parent *string) (pgtype.UUID, error) {
+	if parent == nil || *parent == "" {
+		return pgtype.UUID{}, nil
+	}
+
+	pid, err := uuid.Parse(*parent)
+	if err != nil {
+		return pgtype.UUID{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid parent_id"}
+	}
+
+	if _, err := s.q.GetCategoryByID(ctx, pid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgtype.UUID{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "parent category does not exist"}
+		}
+		return pgtype.UUID{}, fmt.Errorf("get parent category: %w", err)
+	}
+
+	if id != uuid.Nil {
+		subtree, err := s.q.ListCategoryDescendantIDs(ctx, id)
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("list subcategories: %w", err)
+		}
+		for _, d := range subtree {
+			if d == pid {
+				return pgtype.UUID{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "a category can't be moved under itself or its subcategories"}
+			}
+		}
+	}
+
+	return pgtype.UUID{Bytes: pid, Valid: true}, nil
+}
+
+func (s *ProductService) uniqueCategorySlug(ctx context.Context, name string, exclude pgtype.UUID) (string, error) {
+	base := slugify(name)
+	if base == "" {
+		return "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "name must contain at least one letter or digit"}
+	}
+
+	taken, err := s.q.ListCategorySlugsLike(ctx, db.ListCategorySlugsLikeParams{
+		Slug:      base,
+		ExcludeID: exclude,
+	})
+	if err != nil {
+		return "", fmt.Errorf("list category slugs: %w", err)
+	}
+	return nextFreeSlug(base, taken), nil
+}
+
+func validateCategoryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "name is required"}
+	}
+	if len(name) > maxCategoryNameLength {
+		return "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "name must be at most 100 characters"}
+	}
+	return name, nil
+}
+
+// slugify lowercases s and joins its runs of ASCII letters and digits with
+// single hyphens: "Wedding Cakes > Tiered" becomes "wedding-cakes-tiered".
+func slugify(s string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			pendingDash = false
+			continue
+		}
+		pendingDash = true
+	}
+
+	slug := b.String()
+	if len(slug) > maxCategoryNameLength-10 {
+		// Leave room for a numeric suffix within the column limit.
+		slug = strings.TrimRight(slug[:maxCategoryNameLength-10], "-")
+	}
+	return slug
+}
+
+// nextFreeSlug returns base if it is not in taken, otherwise the first
+// base-N (N >= 2) that is not.
+func nextFreeSlug(base string, taken []string) string {
+	used := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		used[t] = true
+	}
+	if !used[base] {
+		return base
+	}
+	for n := 2; ; n++ {
+		candidate := base + "-" + strconv.Itoa(n)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+func mapCategoryWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation: a concurrent write took the slug
+			return &domain.AppError{Err: domain.ErrConflict, Message: "a category with this slug already exists, please retry"}
+		case "23503": // foreign_key_violation: parent removed concurrently
+			return &domain.AppError{Err: domain.ErrConflict, Message: "category hierarchy changed, please retry"}
+		}
+	}
+	return fmt.Errorf("write category: %w", err)
+}
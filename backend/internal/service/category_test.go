@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Birthday Cakes", "birthday-cakes"},
+		{"Wedding Cakes > Tiered", "wedding-cakes-tiered"},
+		{"  Gluten-free & Vegan!  ", "gluten-free-vegan"},
+		{"Crème Brûlée", "cr-me-br-l-e"},
+		{"100% Chocolate", "100-chocolate"},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := service.Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyLeavesRoomForSuffix(t *testing.T) {
+	got := service.Slugify(strings.Repeat("a", 100))
+	if len(got) > 90 {
+		t.Errorf("Slugify() length = %d, want <= 90", len(got))
+	}
+}
+
+func TestNextFreeSlug(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{"free", nil, "cupcakes"},
+		{"taken once", []string{"cupcakes"}, "cupcakes-2"},
+		{"fills first gap", []string{"cupcakes", "cupcakes-2", "cupcakes-4"}, "cupcakes-3"},
+		{"unrelated suffixes ignored", []string{"cupcakes-mini"}, "cupcakes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.NextFreeSlug("cupcakes", tt.taken); got != tt.want {
+				t.Errorf("NextFreeSlug() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
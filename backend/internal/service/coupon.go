@@ -0,0 +1,667 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Coupons are discount codes customers apply to their cart. A coupon takes
+// a percentage or a fixed amount off the items it applies to, which are all
+// of them unless it is restricted to some products or categories. It can
+// require a minimum order, be valid only for a window and be limited in how
+// often it is used, overall and by each customer.
+//
+// The cart prices its coupon each time it is read and flags one that can't
+// be used. Placing the order checks it again with the coupon row locked and
+// counts the use in the same transaction, so limits hold under concurrent
+// checkouts. Cancelled and unpaid orders give their use back.
+
+const (
+	minCouponCodeLength        = 3
+	maxCouponCodeLength        = 50
+	maxCouponDescriptionLength = 500
+	maxCouponRestrictions      = 100
+)
+
+type CouponService struct {
+	pool *pgxpool.Pool
+	q    *db.Queries
+}
+
+func NewCouponService(pool *pgxpool.Pool, q *db.Queries) *CouponService {
+	return &CouponService{pool: pool, q: q}
+}
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+// CouponInput creates or replaces a coupon. For percentage coupons
+// DiscountValue is the percentage: 12.5 takes 12.5% off. Nil limits and
+// validity bounds mean none. Without CategoryIDs or ProductIDs the coupon
+// applies to every item.
+type CouponInput struct {
+	Code           string
+	Description    string
+	DiscountType   string
+	DiscountValue  domain.Money
+	MinOrderAmount domain.Money
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	MaxUses        *int32
+	MaxUsesPerUser *int32
+	IsActive       bool
+	CategoryIDs    []string
+	ProductIDs     []string
+}
+
+type CouponResponse struct {
+	ID             string       `json:"id"`
+	Code           string       `json:"code"`
+	Description    *string      `json:"description"`
+	DiscountType   string       `json:"discount_type"`
+	DiscountValue  domain.Money `json:"discount_value"`
+	MinOrderAmount domain.Money `json:"min_order_amount"`
+	StartsAt       *time.Time   `json:"starts_at"`
+	EndsAt         *time.Time   `json:"ends_at"`
+	MaxUses        *int32       `json:"max_uses"`
+	MaxUsesPerUser *int32       `json:"max_uses_per_user"`
+	// UsedCount is how many orders have been placed with the coupon, not
+	// counting cancelled or unpaid ones.
+	UsedCount   int32     `json:"used_count"`
+	IsActive    bool      `json:"is_active"`
+	CategoryIDs []string  `json:"category_ids"`
+	ProductIDs  []string  `json:"product_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// couponRestrictions are the validated category and product ids a coupon
+// is restricted to.
+type couponRestrictions struct {
+	categoryIDs []uuid.UUID
+	productIDs  []uuid.UUID
+}
+
+// couponLine is an order line as far as coupons are concerned.
+type couponLine struct {
+	productID uuid.UUID
+	amount    domain.Money
+}
+
+// ─── Admin ────────────────────────────────────────────────────────────────────
+
+func (s *CouponService) ListCoupons(ctx context.Context) ([]CouponResponse, error) {
+	coupons, err := s.q.ListCoupons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons: %w", err)
+	}
+	return s.couponResponses(ctx, coupons)
+}
+
+func (s *CouponService) GetCoupon(ctx context.Context, id string) (*CouponResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid coupon id"}
+	}
+
+	c, err := s.q.GetCouponByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get coupon: %w", err)
+	}
+	return s.couponResponse(ctx, c)
+}
+
+func (s *CouponService) CreateCoupon(ctx context.Context, in CouponInput) (*CouponResponse, error) {
+	params, restrictions, err := validateCouponInput(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var c db.Coupon
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		c, err = qtx.CreateCoupon(ctx, params)
+		if err != nil {
+			return mapCouponWriteError(err)
+		}
+		return replaceCouponRestrictions(ctx, qtx, c.ID, restrictions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.couponResponse(ctx, c)
+}
+
+// UpdateCoupon replaces a coupon's settings and restrictions. Its use count
+// is kept; lowering MaxUses below it ends the coupon. Carts it is applied
+// to are priced with the new settings.
+func (s *CouponService) UpdateCoupon(ctx context.Context, id string, in CouponInput) (*CouponResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid coupon id"}
+	}
+	params, restrictions, err := validateCouponInput(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var c db.Coupon
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		c, err = qtx.UpdateCoupon(ctx, db.UpdateCouponParams{
+			ID:             uid,
+			Code:           params.Code,
+			Description:    params.Description,
+			DiscountType:   params.DiscountType,
+			DiscountValue:  params.DiscountValue,
+			MinOrderAmount: params.MinOrderAmount,
+			StartsAt:       params.StartsAt,
+			EndsAt:         params.EndsAt,
+			MaxUses:        params.MaxUses,
+			MaxUsesPerUser: params.MaxUsesPerUser,
+			IsActive:       params.IsActive,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return mapCouponWriteError(err)
+		}
+		return replaceCouponRestrictions(ctx, qtx, c.ID, restrictions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.couponResponse(ctx, c)
+}
+
+// DeleteCoupon removes a coupon no order has been placed with; used ones
+// can only be deactivated. Carts it was applied to lose it.
+func (s *CouponService) DeleteCoupon(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid coupon id"}
+	}
+
+	n, err := s.q.DeleteCoupon(ctx, uid)
+	if err != nil {
+		return mapCouponWriteError(err)
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (s *CouponService) couponResponse(ctx context.Context, c db.Coupon) (*CouponResponse, error) {
+	out, err := s.couponResponses(ctx, []db.Coupon{c})
+	if err != nil {
+		return nil, err
+	}
+	return &out[0], nil
+}
+
+// couponResponses maps coupons with their restrictions.
+func (s *CouponService) couponResponses(ctx context.Context, coupons []db.Coupon) ([]CouponResponse, error) {
+	out := make([]CouponResponse, 0, len(coupons))
+	if len(coupons) == 0 {
+		return out, nil
+	}
+	ids := make([]uuid.UUID, 0, len(coupons))
+	for _, c := range coupons {
+		ids = append(ids, c.ID)
+	}
+
+	categories, err := s.q.ListCouponCategories(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list coupon categories: %w", err)
+	}
+	products, err := s.q.ListCouponProducts(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list coupon products: %w", err)
+	}
+	categoryIDs := make(map[uuid.UUID][]string, len(coupons))
+	for _, r := range categories {
+		categoryIDs[r.CouponID] = append(categoryIDs[r.CouponID], r.CategoryID.String())
+	}
+	productIDs := make(map[uuid.UUID][]string, len(coupons))
+	for _, r := range products {
+		productIDs[r.CouponID] = append(productIDs[r.CouponID], r.ProductID.String())
+	}
+
+	for _, c := range coupons {
+		resp := mapCouponResponse(c)
+		if ids := categoryIDs[c.ID]; ids != nil {
+			resp.CategoryIDs = ids
+		}
+		if ids := productIDs[c.ID]; ids != nil {
+			resp.ProductIDs = ids
+		}
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// replaceCouponRestrictions sets the categories and products a coupon is
+// restricted to within the caller's transaction.
+func replaceCouponRestrictions(ctx context.Context, qtx *db.Queries, couponID uuid.UUID, r couponRestrictions) error {
+	if err := qtx.DeleteCouponCategories(ctx, couponID); err != nil {
+		return fmt.Errorf("clear coupon categories: %w", err)
+	}
+	if err := qtx.DeleteCouponProducts(ctx, couponID); err != nil {
+		return fmt.Errorf("clear coupon products: %w", err)
+	}
+	for _, id := range r.categoryIDs {
+		if err := qtx.CreateCouponCategory(ctx, couponID, id); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("category %s does not exist", id)}
+			}
+			return fmt.Errorf("create coupon category: %w", err)
+		}
+	}
+	for _, id := range r.productIDs {
+		if err := qtx.CreateCouponProduct(ctx, couponID, id); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("product %s does not exist", id)}
+			}
+			return fmt.Errorf("create coupon product: %w", err)
+		}
+	}
+	return nil
+}
+
+// ─── Cart ─────────────────────────────────────────────────────────────────────
+
+// ApplyCoupon applies a coupon code to the owner's cart, in place of any
+// other, if it can be used on the cart as it is. An empty code removes the
+// cart's coupon.
+func (s *CartService) ApplyCoupon(ctx context.Context, owner CartOwner, code string) (*CartResponse, error) {
+	code = normalizeCouponCode(code)
+
+	cart, err := s.findCart(ctx, owner)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if code == "" {
+				return s.GetCart(ctx, owner)
+			}
+			return nil, domain.ErrEmptyCart
+		}
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+
+	if code == "" {
+		if err := s.q.SetCartCoupon(ctx, cart.ID, pgtype.UUID{}); err != nil {
+			return nil, fmt.Errorf("remove cart coupon: %w", err)
+		}
+		return s.GetCart(ctx, owner)
+	}
+
+	coupon, err := s.q.GetCouponByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &domain.AppError{Err: domain.ErrNotFound, Message: "coupon not found"}
+		}
+		return nil, fmt.Errorf("get coupon: %w", err)
+	}
+
+	items, err := s.q.GetCartItems(ctx, cart.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get cart items: %w", err)
+	}
+	resp := buildCartResponse(cart, items)
+	if err := s.applyCartPromotions(ctx, resp); err != nil {
+		return nil, err
+	}
+	lines := cartCouponLines(resp)
+	eligible, uses, err := couponUsage(ctx, s.q, coupon, owner.UserID, lines)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := couponDiscount(coupon, lines, eligible, uses, time.Now()); err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: couponUnusableMessage(coupon, err)}
+	}
+
+	if err := s.q.SetCartCoupon(ctx, cart.ID, pgtype.UUID{Bytes: coupon.ID, Valid: true}); err != nil {
+		return nil, fmt.Errorf("set cart coupon: %w", err)
+	}
+	return s.GetCart(ctx, owner)
+}
+
+// priceCartCoupon applies the cart's coupon to resp, or flags it if it
+// can't be used on the cart as it is.
+func (s *CartService) priceCartCoupon(ctx context.Context, owner CartOwner, couponID uuid.UUID, resp *CartResponse) error {
+	coupon, err := s.q.GetCouponByID(ctx, couponID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil // deleted since it was read with the cart
+		}
+		return fmt.Errorf("get coupon: %w", err)
+	}
+	lines := cartCouponLines(resp)
+	eligible, uses, err := couponUsage(ctx, s.q, coupon, owner.UserID, lines)
+	if err != nil {
+		return err
+	}
+	applyCartCoupon(resp, coupon, eligible, uses, time.Now())
+	return nil
+}
+
+// applyCartCoupon takes what the coupon is worth off the cart's total, after
+// promotions. A coupon that can't be used takes nothing off and blocks
+// checkout until it is removed or the cart is changed to suit it.
+func applyCartCoupon(resp *CartResponse, c db.Coupon, eligible map[uuid.UUID]bool, uses int64, now time.Time) {
+	resp.Coupon = &CartCoupon{Code: c.Code}
+	if c.Description.Valid {
+		resp.Coupon.Description = &c.Description.String
+	}
+
+	discount, err := couponDiscount(c, cartCouponLines(resp), eligible, uses, now)
+	if err != nil {
+		resp.Coupon.Warning = &CartWarning{Code: CartWarningCouponUnusable, Message: couponUnusableMessage(c, err)}
+		resp.CanCheckout = false
+		return
+	}
+	resp.Discount = discount
+	resp.Total = resp.Total.Sub(discount)
+}
+
+// cartCouponLines are the cart's lines that can be ordered, at what
+// promotions left of them.
+func cartCouponLines(resp *CartResponse) []couponLine {
+	lines := make([]couponLine, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		if item.Available {
+			lines = append(lines, couponLine{productID: uuid.MustParse(item.ProductID), amount: item.Subtotal.Sub(item.Discount)})
+		}
+	}
+	return lines
+}
+
+// ─── Orders ───────────────────────────────────────────────────────────────────
+
+// claimCoupon locks the coupon applied to a cart being ordered and works out
+// its discount on lines, failing with a conflict if it can no longer be
+// used. The caller records the use with recordCouponUse before committing.
+func claimCoupon(ctx context.Context, qtx *db.Queries, couponID, userID uuid.UUID, lines []couponLine) (db.Coupon, domain.Money, error) {
+	coupon, err := qtx.GetCouponForUpdate(ctx, couponID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Coupon{}, 0, &domain.AppError{Err: domain.ErrConflict, Message: "the coupon in your cart no longer exists, remove it to check out"}
+		}
+		return db.Coupon{}, 0, fmt.Errorf("lock coupon: %w", err)
+	}
+
+	eligible, uses, err := couponUsage(ctx, qtx, coupon, userID, lines)
+	if err != nil {
+		return db.Coupon{}, 0, err
+	}
+	discount, err := couponDiscount(coupon, lines, eligible, uses, time.Now())
+	if err != nil {
+		return db.Coupon{}, 0, &domain.AppError{Err: domain.ErrConflict, Message: couponUnusableMessage(coupon, err)}
+	}
+	return coupon, discount, nil
+}
+
+// recordCouponUse counts an order's use of the coupon claimed for it.
+func recordCouponUse(ctx context.Context, qtx *db.Queries, couponID, userID, orderID uuid.UUID, discount domain.Money) error {
+	if err := qtx.IncrementCouponUse(ctx, couponID); err != nil {
+		return fmt.Errorf("count coupon use: %w", err)
+	}
+	if err := qtx.CreateCouponRedemption(ctx, db.CreateCouponRedemptionParams{
+		CouponID:       couponID,
+		UserID:         userID,
+		OrderID:        orderID,
+		DiscountAmount: moneyToNumeric(discount),
+	}); err != nil {
+		return fmt.Errorf("record coupon use: %w", err)
+	}
+	return nil
+}
+
+// ─── Pricing ──────────────────────────────────────────────────────────────────
+
+// couponUsage loads which of the lines' products the coupon applies to and,
+// for a signed-in user, how often they have used it. Guests count as not
+// having used it; they are checked again when they order.
+func couponUsage(ctx context.Context, q *db.Queries, c db.Coupon, userID uuid.UUID, lines []couponLine) (map[uuid.UUID]bool, int64, error) {
+	eligible := make(map[uuid.UUID]bool, len(lines))
+	if len(lines) > 0 {
+		productIDs := make([]uuid.UUID, 0, len(lines))
+		for _, l := range lines {
+			productIDs = append(productIDs, l.productID)
+		}
+		ids, err := q.ListCouponEligibleProducts(ctx, c.ID, productIDs)
+		if err != nil {
+			return nil, 0, fmt.Errorf("list coupon products: %w", err)
+		}
+		for _, id := range ids {
+			eligible[id] = true
+		}
+	}
+
+	var uses int64
+	if userID != uuid.Nil && c.MaxUsesPerUser.Valid {
+		n, err := q.CountUserCouponRedemptions(ctx, c.ID, userID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count coupon uses: %w", err)
+		}
+		uses = n
+	}
+	return eligible, uses, nil
+}
+
+// couponDiscount is what a coupon takes off an order of lines, given the
+// products it applies to and how often the customer has used it. The
+// minimum order is measured against all lines; the discount only against
+// eligible ones, and never exceeds them. It fails with the reason if the
+// coupon can't be used.
+func couponDiscount(c db.Coupon, lines []couponLine, eligible map[uuid.UUID]bool, uses int64, now time.Time) (domain.Money, error) {
+	switch {
+	case !c.IsActive:
+		return 0, errors.New("it is no longer available")
+	case c.StartsAt.Valid && now.Before(c.StartsAt.Time):
+		return 0, fmt.Errorf("it is valid from %s", c.StartsAt.Time.Format("2 January 2006"))
+	case c.EndsAt.Valid && !now.Before(c.EndsAt.Time):
+		return 0, errors.New("it has expired")
+	case c.MaxUses.Valid && c.UsedCount >= c.MaxUses.Int32:
+		return 0, errors.New("it has been used up")
+	case c.MaxUsesPerUser.Valid && uses >= int64(c.MaxUsesPerUser.Int32):
+		return 0, errors.New("you have already used it")
+	}
+
+	var subtotal, base domain.Money
+	for _, l := range lines {
+		subtotal = subtotal.Add(l.amount)
+		if eligible[l.productID] {
+			base = base.Add(l.amount)
+		}
+	}
+	if minimum := numericToMoney(c.MinOrderAmount); subtotal < minimum {
+		return 0, fmt.Errorf("orders must come to at least %s", minimum)
+	}
+	if base == 0 {
+		return 0, errors.New("it doesn't apply to anything in your cart")
+	}
+
+	value := numericToMoney(c.DiscountValue)
+	if c.DiscountType == domain.CouponTypePercentage {
+		// value is the percentage in hundredths, e.g. 1250 for 12.5%.
+		return base.MulFrac(value.Cents(), 100*100), nil
+	}
+	return value.Min(base), nil
+}
+
+func couponUnusableMessage(c db.Coupon, reason error) string {
+	return fmt.Sprintf("coupon %s can't be used: %v", c.Code, reason)
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+// normalizeCouponCode makes codes case-insensitive; they are stored
+// upper-case.
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validateCouponInput checks a coupon as set by an admin.
+func validateCouponInput(in CouponInput) (db.CreateCouponParams, couponRestrictions, error) {
+	invalid := func(msg string) (db.CreateCouponParams, couponRestrictions, error) {
+		return db.CreateCouponParams{}, couponRestrictions{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: msg}
+	}
+
+	code := normalizeCouponCode(in.Code)
+	if len(code) < minCouponCodeLength || len(code) > maxCouponCodeLength {
+		return invalid(fmt.Sprintf("code must be %d to %d characters", minCouponCodeLength, maxCouponCodeLength))
+	}
+	for _, r := range code {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return invalid("code may only contain letters, digits, '-' and '_'")
+		}
+	}
+	description := strings.TrimSpace(in.Description)
+	if len(description) > maxCouponDescriptionLength {
+		return invalid(fmt.Sprintf("description must be at most %d characters", maxCouponDescriptionLength))
+	}
+
+	if !domain.IsValidCouponType(in.DiscountType) {
+		return invalid("discount_type must be percentage or fixed")
+	}
+	if in.DiscountValue <= 0 {
+		return invalid("discount_value must be greater than 0")
+	}
+	if in.DiscountType == domain.CouponTypePercentage && in.DiscountValue > 100*100 {
+		return invalid("a percentage discount_value can be at most 100")
+	}
+	if in.DiscountValue > maxStoredAmount {
+		return invalid("discount_value is too large")
+	}
+	if in.MinOrderAmount < 0 || in.MinOrderAmount > maxStoredAmount {
+		return invalid("min_order_amount must be between 0 and " + maxStoredAmount.String())
+	}
+
+	if in.StartsAt != nil && in.EndsAt != nil && !in.StartsAt.Before(*in.EndsAt) {
+		return invalid("ends_at must be after starts_at")
+	}
+	if in.MaxUses != nil && *in.MaxUses < 1 {
+		return invalid("max_uses must be at least 1")
+	}
+	if in.MaxUsesPerUser != nil && *in.MaxUsesPerUser < 1 {
+		return invalid("max_uses_per_user must be at least 1")
+	}
+
+	if len(in.CategoryIDs)+len(in.ProductIDs) > maxCouponRestrictions {
+		return invalid(fmt.Sprintf("a coupon can be restricted to at most %d categories and products", maxCouponRestrictions))
+	}
+	categoryIDs, err := parseCouponRestriction(in.CategoryIDs)
+	if err != nil {
+		return invalid("invalid category id")
+	}
+	productIDs, err := parseCouponRestriction(in.ProductIDs)
+	if err != nil {
+		return invalid("invalid product id")
+	}
+
+	params := db.CreateCouponParams{
+		Code:           code,
+		DiscountType:   in.DiscountType,
+		DiscountValue:  moneyToNumeric(in.DiscountValue),
+		MinOrderAmount: moneyToNumeric(in.MinOrderAmount),
+		IsActive:       in.IsActive,
+	}
+	if description != "" {
+		params.Description = pgtype.Text{String: description, Valid: true}
+	}
+	if in.StartsAt != nil {
+		params.StartsAt = pgtype.Timestamptz{Time: *in.StartsAt, Valid: true}
+	}
+	if in.EndsAt != nil {
+		params.EndsAt = pgtype.Timestamptz{Time: *in.EndsAt, Valid: true}
+	}
+	if in.MaxUses != nil {
+		params.MaxUses = pgtype.Int4{Int32: *in.MaxUses, Valid: true}
+	}
+	if in.MaxUsesPerUser != nil {
+		params.MaxUsesPerUser = pgtype.Int4{Int32: *in.MaxUsesPerUser, Valid: true}
+	}
+	return params, couponRestrictions{categoryIDs: categoryIDs, productIDs: productIDs}, nil
+}
+
+// parseCouponRestriction parses ids, dropping duplicates.
+func parseCouponRestriction(ids []string) ([]uuid.UUID, error) {
+	out := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, s := range ids {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func mapCouponResponse(c db.Coupon) CouponResponse {
+	resp := CouponResponse{
+		ID:             c.ID.String(),
+		Code:           c.Code,
+		DiscountType:   c.DiscountType,
+		DiscountValue:  numericToMoney(c.DiscountValue),
+		MinOrderAmount: numericToMoney(c.MinOrderAmount),
+		UsedCount:      c.UsedCount,
+		IsActive:       c.IsActive,
+		CategoryIDs:    []string{},
+		ProductIDs:     []string{},
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+	}
+	if c.Description.Valid {
+		resp.Description = &c.Description.String
+	}
+	if c.StartsAt.Valid {
+		resp.StartsAt = &c.StartsAt.Time
+	}
+	if c.EndsAt.Valid {
+		resp.EndsAt = &c.EndsAt.Time
+	}
+	if c.MaxUses.Valid {
+		resp.MaxUses = &c.MaxUses.Int32
+	}
+	if c.MaxUsesPerUser.Valid {
+		resp.MaxUsesPerUser = &c.MaxUsesPerUser.Int32
+	}
+	return resp
+}
+
+func mapCouponWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation on the code
+			return &domain.AppError{Err: domain.ErrConflict, Message: "a coupon with this code already exists"}
+		case "23503": // foreign_key_violation: orders were placed with it
+			return &domain.AppError{Err: domain.ErrConflict, Message: "coupon has been used, deactivate it instead"}
+		}
+	}
+	return fmt.Errorf("write coupon: %w", err)
+}
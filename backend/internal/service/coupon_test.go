@@ -0,0 +1,199 @@
+package service_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func cents(n int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(n), Exp: -2, Valid: true}
+}
+
+func TestCouponDiscount(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	cake, candle := uuid.New(), uuid.New()
+	lines := []service.CouponLine{
+		{ProductID: cake, Amount: 3333},  // 33.33
+		{ProductID: candle, Amount: 500}, // 5.00
+	}
+	both := map[uuid.UUID]bool{cake: true, candle: true}
+	cakeOnly := map[uuid.UUID]bool{cake: true}
+
+	percent := func(hundredths int64) db.Coupon {
+		return db.Coupon{Code: "SAVE", DiscountType: domain.CouponTypePercentage, DiscountValue: cents(hundredths), MinOrderAmount: cents(0), IsActive: true}
+	}
+	fixed := func(amount int64) db.Coupon {
+		return db.Coupon{Code: "SAVE", DiscountType: domain.CouponTypeFixed, DiscountValue: cents(amount), MinOrderAmount: cents(0), IsActive: true}
+	}
+
+	tests := []struct {
+		name     string
+		coupon   db.Coupon
+		eligible map[uuid.UUID]bool
+		uses     int64
+		want     domain.Money
+		wantErr  bool
+	}{
+		{"percentage of everything", percent(1000), both, 0, 383, false},
+		// 12.5% of 33.33 is 4.16625, rounded to the cent.
+		{"percentage rounds", percent(1250), cakeOnly, 0, 417, false},
+		{"hundred percent", percent(10000), both, 0, 3833, false},
+		{"fixed", fixed(1000), both, 0, 1000, false},
+		{"fixed capped at eligible lines", fixed(1000), map[uuid.UUID]bool{candle: true}, 0, 500, false},
+		{"nothing eligible", percent(1000), map[uuid.UUID]bool{}, 0, 0, true},
+		{"inactive", func() db.Coupon { c := fixed(100); c.IsActive = false; return c }(), both, 0, 0, true},
+		{"not started", func() db.Coupon {
+			c := fixed(100)
+			c.StartsAt = pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true}
+			return c
+		}(), both, 0, 0, true},
+		{"ended", func() db.Coupon {
+			c := fixed(100)
+			c.EndsAt = pgtype.Timestamptz{Time: now, Valid: true}
+			return c
+		}(), both, 0, 0, true},
+		{"within window", func() db.Coupon {
+			c := fixed(100)
+			c.StartsAt = pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}
+			c.EndsAt = pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true}
+			return c
+		}(), both, 0, 100, false},
+		{"used up", func() db.Coupon {
+			c := fixed(100)
+			c.MaxUses = pgtype.Int4{Int32: 5, Valid: true}
+			c.UsedCount = 5
+			return c
+		}(), both, 0, 0, true},
+		{"uses left", func() db.Coupon {
+			c := fixed(100)
+			c.MaxUses = pgtype.Int4{Int32: 5, Valid: true}
+			c.UsedCount = 4
+			return c
+		}(), both, 0, 100, false},
+		{"per-user limit reached", func() db.Coupon {
+			c := fixed(100)
+			c.MaxUsesPerUser = pgtype.Int4{Int32: 1, Valid: true}
+			return c
+		}(), both, 1, 0, true},
+		// The minimum counts the whole order, not just eligible lines.
+		{"minimum met by all lines", func() db.Coupon {
+			c := fixed(100)
+			c.MinOrderAmount = cents(3800)
+			return c
+		}(), cakeOnly, 0, 100, false},
+		{"under minimum", func() db.Coupon {
+			c := fixed(100)
+			c.MinOrderAmount = cents(4000)
+			return c
+		}(), both, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.CouponDiscount(tt.coupon, lines, tt.eligible, tt.uses, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CouponDiscount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CouponDiscount() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCartCoupon(t *testing.T) {
+	now := time.Now()
+	product := uuid.New()
+	cart := func() *service.CartResponse {
+		return &service.CartResponse{
+			Items: []service.CartItemResponse{
+				{ProductID: product.String(), Subtotal: 2000, Available: true},
+				{ProductID: uuid.NewString(), Subtotal: 900, Available: false},
+			},
+			Subtotal:    2900,
+			Total:       2900,
+			CanCheckout: true,
+		}
+	}
+	coupon := db.Coupon{Code: "TENOFF", DiscountType: domain.CouponTypePercentage, DiscountValue: cents(1000), MinOrderAmount: cents(0), IsActive: true}
+
+	resp := cart()
+	service.ApplyCartCoupon(resp, coupon, map[uuid.UUID]bool{product: true}, 0, now)
+	if resp.Discount != 200 || resp.Total != 2700 || !resp.CanCheckout {
+		t.Errorf("discount = %s, total = %s, can checkout = %v; want 2.00, 27.00, true", resp.Discount, resp.Total, resp.CanCheckout)
+	}
+	if resp.Coupon == nil || resp.Coupon.Code != "TENOFF" || resp.Coupon.Warning != nil {
+		t.Errorf("Coupon = %+v, want TENOFF without a warning", resp.Coupon)
+	}
+
+	// Unavailable lines can't be ordered, so they don't count towards the
+	// minimum.
+	coupon.MinOrderAmount = cents(2500)
+	resp = cart()
+	service.ApplyCartCoupon(resp, coupon, map[uuid.UUID]bool{product: true}, 0, now)
+	if resp.Discount != 0 || resp.Total != 2900 || resp.CanCheckout {
+		t.Errorf("discount = %s, total = %s, can checkout = %v; want 0.00, 29.00, false", resp.Discount, resp.Total, resp.CanCheckout)
+	}
+	if resp.Coupon == nil || resp.Coupon.Warning == nil || resp.Coupon.Warning.Code != service.CartWarningCouponUnusable {
+		t.Errorf("Coupon = %+v, want a coupon_unusable warning", resp.Coupon)
+	}
+}
+
+func TestValidateCouponInput(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	zero := int32(0)
+	valid := func() service.CouponInput {
+		return service.CouponInput{
+			Code:          " summer-25 ",
+			DiscountType:  domain.CouponTypePercentage,
+			DiscountValue: 2500,
+			StartsAt:      &start,
+			EndsAt:        &end,
+			IsActive:      true,
+			CategoryIDs:   []string{"6a1f5c1e-8a8e-4c55-9f0e-0d6d2b1d7a10"},
+		}
+	}
+
+	params, _, err := service.ValidateCouponInput(valid())
+	if err != nil {
+		t.Fatalf("ValidateCouponInput() error = %v", err)
+	}
+	if params.Code != "SUMMER-25" {
+		t.Errorf("Code = %q, want SUMMER-25", params.Code)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*service.CouponInput)
+	}{
+		{"short code", func(in *service.CouponInput) { in.Code = "AB" }},
+		{"bad characters", func(in *service.CouponInput) { in.Code = "SAVE 10" }},
+		{"unknown type", func(in *service.CouponInput) { in.DiscountType = "bogo" }},
+		{"zero value", func(in *service.CouponInput) { in.DiscountValue = 0 }},
+		{"over 100%", func(in *service.CouponInput) { in.DiscountValue = 10001 }},
+		{"negative minimum", func(in *service.CouponInput) { in.MinOrderAmount = -1 }},
+		{"ends before start", func(in *service.CouponInput) { in.EndsAt = &start; in.StartsAt = &end }},
+		{"zero max uses", func(in *service.CouponInput) { in.MaxUses = &zero }},
+		{"zero per-user uses", func(in *service.CouponInput) { in.MaxUsesPerUser = &zero }},
+		{"bad category id", func(in *service.CouponInput) { in.CategoryIDs = []string{"nope"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid()
+			tt.modify(&in)
+			_, _, err := service.ValidateCouponInput(in)
+			if !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("ValidateCouponInput() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
@@ -1,7 +1,116 @@
 package service
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
 // Export internal functions for whitebox testing from _test packages.
 
 var GenerateOTP = generateOTP
 var ValidateRegisterInput = validateRegisterInput
-var NumericToFloat = numericToFloat
+var NumericToMoney = numericToMoney
+var MoneyToNumeric = moneyToNumeric
+var BuildCartResponse = buildCartResponse
+var CheckOTP = checkOTP
+var GenerateRefreshToken = generateRefreshToken
+var HashToken = hashToken
+var ValidateProductFields = validateProductFields
+var Slugify = slugify
+var NextFreeSlug = nextFreeSlug
+var CheckCustomerCancellable = checkCustomerCancellable
+var CheckStaffTransition = checkStaffTransition
+var OrderFingerprint = orderFingerprint
+var ValidateIdempotencyKey = validateIdempotencyKey
+var CanUpdatePayment = canUpdatePayment
+var UnprocessedIntent = unprocessedIntent
+var RefundedOrderStatus = refundedOrderStatus
+var RefundsOnCancel = refundsOnCancel
+var CheckRefundable = checkRefundable
+var AvailableToPromise = availableToPromise
+var NormalizeVariantOptions = normalizeVariantOptions
+var ValidateVariantFields = validateVariantFields
+var VariantName = variantName
+var DefaultVariantSKU = defaultVariantSKU
+var ValidatePersonalisationOptions = validatePersonalisationOptions
+var CheckPersonalisation = checkPersonalisation
+var PersonalisationPrice = personalisationPrice
+var SearchTSQuery = searchTSQuery
+var RenderHeadline = renderHeadline
+var NormalizeDietaryTags = normalizeDietaryTags
+var BuildFacets = buildFacets
+var ValidateAllergenDeclarations = validateAllergenDeclarations
+var NormalizeAllergenSlugs = normalizeAllergenSlugs
+var EncodeOrderedAllergens = encodeOrderedAllergens
+var DecodeOrderedAllergens = decodeOrderedAllergens
+var DecodeImage = decodeImage
+var ResizeToFit = resizeToFit
+var CheckImageOrder = checkImageOrder
+var MergedQuantity = mergedQuantity
+var ValidateCouponInput = validateCouponInput
+var ApplyCartCoupon = applyCartCoupon
+var ValidatePromotionInput = validatePromotionInput
+var FormatPercent = formatPercent
+var ValidateWishlistName = validateWishlistName
+var MapWishlistItem = mapWishlistItem
+var GenerateShareToken = generateShareToken
+
+// PlanRefund returns the planned quantity and amount per order item id,
+// and the delivery fee refunded with them.
+func PlanRefund(order db.Order, items []db.GetOrderItemsRow, requested []RefundItemInput) (map[uuid.UUID]PlannedRefundLine, domain.Money, error) {
+	lines, deliveryFee, err := planRefund(order, items, requested)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make(map[uuid.UUID]PlannedRefundLine, len(lines))
+	for _, l := range lines {
+		out[l.item.ID] = PlannedRefundLine{Quantity: l.quantity, Amount: l.amount}
+	}
+	return out, deliveryFee, nil
+}
+
+type PlannedRefundLine struct {
+	Quantity int32
+	Amount   domain.Money
+}
+
+type CouponLine struct {
+	ProductID uuid.UUID
+	Amount    domain.Money
+}
+
+// CouponDiscount is couponDiscount over exported lines.
+func CouponDiscount(c db.Coupon, lines []CouponLine, eligible map[uuid.UUID]bool, uses int64, now time.Time) (domain.Money, error) {
+	in := make([]couponLine, 0, len(lines))
+	for _, l := range lines {
+		in = append(in, couponLine{productID: l.ProductID, amount: l.Amount})
+	}
+	return couponDiscount(c, in, eligible, uses, now)
+}
+
+type PromotionLine struct {
+	ProductID uuid.UUID
+	Quantity  int32
+	Amount    domain.Money
+}
+
+type PromotionResult struct {
+	Applied       []AppliedPromotion
+	LineDiscounts []domain.Money
+	Discount      domain.Money
+	DeliveryFee   domain.Money
+}
+
+// ApplyPromotions is applyPromotions over exported lines.
+func ApplyPromotions(promotions []db.ListActivePromotionsRow, eligible map[uuid.UUID]map[uuid.UUID]bool, lines []PromotionLine, deliveryFee domain.Money) PromotionResult {
+	in := make([]promotionLine, 0, len(lines))
+	for _, l := range lines {
+		in = append(in, promotionLine{productID: l.ProductID, quantity: l.Quantity, amount: l.Amount})
+	}
+	res := applyPromotions(promotions, eligible, in, deliveryFee)
+	return PromotionResult{Applied: res.applied, LineDiscounts: res.lineDiscounts, Discount: res.discount, DeliveryFee: res.deliveryFee}
+}
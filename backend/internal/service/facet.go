@@ -0,0 +1,208 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// The catalogue can be narrowed by price, availability, dietary tags, size
+// and allergens, and the listing says how many products each choice would leave.
+// Facet counts apply every filter except the facet's own, so choosing a
+// second tag or another price range never hides the alternatives.
+
+const (
+	maxDietaryTags      = 10
+	maxDietaryTagLength = 30
+	maxFilterSizes      = 10
+)
+
+// priceBucketBounds split the catalogue into price ranges: under 25.00,
+// 25.00 to 49.99, and so on up to 200.00 and over.
+var priceBucketBounds = []domain.Money{25_00, 50_00, 100_00, 200_00}
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+// ProductFacets lists the values shoppers can filter on with the number of
+// products each would match. Categories count products in the category
+// itself, not its subcategories.
+type ProductFacets struct {
+	Categories  []FacetCount      `json:"categories"`
+	PriceRanges []PriceRangeFacet `json:"price_ranges"`
+	DietaryTags []FacetCount      `json:"dietary_tags"`
+	Sizes       []FacetCount      `json:"sizes"`
+	// FreeFrom counts products declared free from each allergen; Value is
+	// the allergen's slug.
+	FreeFrom []FacetCount `json:"free_from"`
+}
+
+// FacetCount is a filter value and its product count. Label is the
+// category name for categories, whose Value is the category id, and the
+// allergen name for allergens.
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeFacet is a price range that can be passed back as min_price and
+// max_price; both ends are inclusive and a nil end is open.
+type PriceRangeFacet struct {
+	Min   *domain.Money `json:"min"`
+	Max   *domain.Money `json:"max"`
+	Count int64         `json:"count"`
+}
+
+// productFilters are the parsed filters of ListProductsInput, in the form
+// the queries take them.
+type productFilters struct {
+	minPrice pgtype.Numeric
+	maxPrice pgtype.Numeric
+	inStock  bool
+	tags     []string
+	sizes    []string
+	freeFrom []string
+}
+
+// parseProductFilters validates the filters of a catalogue listing.
+func parseProductFilters(in ListProductsInput) (productFilters, error) {
+	var f productFilters
+	minPrice, err := parsePriceFilter("min_price", in.MinPrice)
+	if err != nil {
+		return productFilters{}, err
+	}
+	maxPrice, err := parsePriceFilter("max_price", in.MaxPrice)
+	if err != nil {
+		return productFilters{}, err
+	}
+	if minPrice != nil && maxPrice != nil && *minPrice > *maxPrice {
+		return productFilters{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "min_price must not be above max_price"}
+	}
+	if minPrice != nil {
+		f.minPrice = moneyToNumeric(*minPrice)
+	}
+	if maxPrice != nil {
+		f.maxPrice = moneyToNumeric(*maxPrice)
+	}
+
+	f.inStock = in.InStock
+
+	tags, err := normalizeDietaryTags(in.DietaryTags)
+	if err != nil {
+		return productFilters{}, err
+	}
+	f.tags = tags
+
+	if len(in.Sizes) > maxFilterSizes {
+		return productFilters{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("at most %d sizes can be selected", maxFilterSizes)}
+	}
+	f.sizes = make([]string, 0, len(in.Sizes))
+	for _, s := range in.Sizes {
+		if s = strings.TrimSpace(s); s != "" {
+			f.sizes = append(f.sizes, s)
+		}
+	}
+
+	if f.freeFrom, err = normalizeAllergenSlugs(in.FreeFrom); err != nil {
+		return productFilters{}, err
+	}
+	return f, nil
+}
+
+// parsePriceFilter parses an optional price bound; "" is no bound.
+func parsePriceFilter(name, s string) (*domain.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m, err := domain.ParseMoney(s)
+	if err != nil || m.IsNegative() {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("%s must be a non-negative amount", name)}
+	}
+	return &m, nil
+}
+
+// normalizeDietaryTags lower-cases tags and turns spaces and underscores
+// into hyphens, so "Gluten Free" is "gluten-free". The result is sorted and
+// has no duplicates.
+func normalizeDietaryTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		t = strings.Join(strings.FieldsFunc(t, func(r rune) bool {
+			return r == ' ' || r == '_' || r == '-'
+		}), "-")
+		if t == "" || seen[t] {
+			continue
+		}
+		if len(t) > maxDietaryTagLength {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("dietary tags must be at most %d characters", maxDietaryTagLength)}
+		}
+		for _, r := range t {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("dietary tag %q may only contain letters, digits and hyphens", t)}
+			}
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxDietaryTags {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("at most %d dietary tags", maxDietaryTags)}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// buildFacets turns ProductFacets rows into the response. Every price range
+// is listed, empty or not, so the sidebar doesn't shift as filters change.
+func buildFacets(rows []db.ProductFacetsRow) ProductFacets {
+	facets := ProductFacets{
+		Categories:  []FacetCount{},
+		PriceRanges: make([]PriceRangeFacet, len(priceBucketBounds)+1),
+		DietaryTags: []FacetCount{},
+		Sizes:       []FacetCount{},
+		FreeFrom:    []FacetCount{},
+	}
+	for i := range facets.PriceRanges {
+		if i > 0 {
+			lo := priceBucketBounds[i-1]
+			facets.PriceRanges[i].Min = &lo
+		}
+		if i < len(priceBucketBounds) {
+			hi := priceBucketBounds[i] - 1
+			facets.PriceRanges[i].Max = &hi
+		}
+	}
+
+	for _, r := range rows {
+		switch r.Facet {
+		case "category":
+			facets.Categories = append(facets.Categories, FacetCount{Value: r.Value, Label: r.Label.String, Count: r.Count})
+		case "price":
+			if i, err := strconv.Atoi(r.Value); err == nil && i >= 0 && i < len(facets.PriceRanges) {
+				facets.PriceRanges[i].Count = r.Count
+			}
+		case "dietary_tag":
+			facets.DietaryTags = append(facets.DietaryTags, FacetCount{Value: r.Value, Count: r.Count})
+		case "size":
+			facets.Sizes = append(facets.Sizes, FacetCount{Value: r.Value, Count: r.Count})
+		case "free_from":
+			facets.FreeFrom = append(facets.FreeFrom, FacetCount{Value: r.Value, Label: r.Label.String, Count: r.Count})
+		}
+	}
+	return facets
+}
+
+func priceBucketParams() []pgtype.Numeric {
+	bounds := make([]pgtype.Numeric, 0, len(priceBucketBounds))
+	for _, b := range priceBucketBounds {
+		bounds = append(bounds, moneyToNumeric(b))
+	}
+	return bounds
+}
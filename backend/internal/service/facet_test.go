@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestNormalizeDietaryTags(t *testing.T) {
+	got, err := service.NormalizeDietaryTags([]string{" Vegan", "Gluten Free", "gluten_free", "nut--free", "", "vegan"})
+	if err != nil {
+		t.Fatalf("NormalizeDietaryTags() error = %v", err)
+	}
+	want := []string{"gluten-free", "nut-free", "vegan"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeDietaryTags() = %v, want %v", got, want)
+	}
+
+	for _, tags := range [][]string{
+		{"dairy-free!"},
+		{strings.Repeat("a", 31)},
+		{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+	} {
+		if _, err := service.NormalizeDietaryTags(tags); err == nil {
+			t.Errorf("NormalizeDietaryTags(%v) accepted invalid tags", tags)
+		}
+	}
+}
+
+func TestBuildFacets(t *testing.T) {
+	facets := service.BuildFacets([]db.ProductFacetsRow{
+		{Facet: "category", Value: "c1", Label: pgtype.Text{String: "Birthday Cakes", Valid: true}, Count: 4},
+		{Facet: "price", Value: "1", Count: 3},
+		{Facet: "price", Value: "4", Count: 1},
+		{Facet: "dietary_tag", Value: "vegan", Count: 2},
+		{Facet: "size", Value: "8\"", Count: 5},
+		{Facet: "free_from", Value: "milk", Label: pgtype.Text{String: "Milk", Valid: true}, Count: 1},
+	})
+
+	if want := []service.FacetCount{{Value: "c1", Label: "Birthday Cakes", Count: 4}}; !reflect.DeepEqual(facets.Categories, want) {
+		t.Errorf("Categories = %v, want %v", facets.Categories, want)
+	}
+	if want := []service.FacetCount{{Value: "vegan", Count: 2}}; !reflect.DeepEqual(facets.DietaryTags, want) {
+		t.Errorf("DietaryTags = %v, want %v", facets.DietaryTags, want)
+	}
+	if want := []service.FacetCount{{Value: "8\"", Count: 5}}; !reflect.DeepEqual(facets.Sizes, want) {
+		t.Errorf("Sizes = %v, want %v", facets.Sizes, want)
+	}
+	if want := []service.FacetCount{{Value: "milk", Label: "Milk", Count: 1}}; !reflect.DeepEqual(facets.FreeFrom, want) {
+		t.Errorf("FreeFrom = %v, want %v", facets.FreeFrom, want)
+	}
+
+	money := func(m domain.Money) *domain.Money { return &m }
+	wantRanges := []service.PriceRangeFacet{
+		{Min: nil, Max: money(24_99), Count: 0},
+		{Min: money(25_00), Max: money(49_99), Count: 3},
+		{Min: money(50_00), Max: money(99_99), Count: 0},
+		{Min: money(100_00), Max: money(199_99), Count: 0},
+		{Min: money(200_00), Max: nil, Count: 1},
+	}
+	if !reflect.DeepEqual(facets.PriceRanges, wantRanges) {
+		t.Errorf("PriceRanges = %+v, want %+v", facets.PriceRanges, wantRanges)
+	}
+
+	empty := service.BuildFacets(nil)
+	if empty.Categories == nil || empty.DietaryTags == nil || empty.Sizes == nil || empty.FreeFrom == nil {
+		t.Error("BuildFacets(nil) left facets nil; they should encode as []")
+	}
+}
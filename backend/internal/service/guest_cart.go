@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Visitors can fill a cart before they register or sign in. Their cart has
+// no user and is found through a cookie holding its id, signed so that ids
+// can't be guessed into someone else's cart. Guest lines hold stock like
+// any other. When the visitor completes OTP verification the guest cart is
+// merged into their own, coupon included, and deleted; carts left idle are
+// swept.
+
+// ─── Cookie token ─────────────────────────────────────────────────────────────
+
+// GuestCartToken is the cookie value identifying a guest cart: its id and
+// an HMAC of it.
+func (s *CartService) GuestCartToken(cartID uuid.UUID) string {
+	return cartID.String() + "." + base64.RawURLEncoding.EncodeToString(s.guestCartMAC(cartID))
+}
+
+// ParseGuestCartToken returns the cart a token was issued for, or false if
+// it is malformed or wasn't signed by us.
+func (s *CartService) ParseGuestCartToken(token string) (uuid.UUID, bool) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, false
+	}
+	cartID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, s.guestCartMAC(cartID)) {
+		return uuid.Nil, false
+	}
+	return cartID, true
+}
+
+func (s *CartService) guestCartMAC(cartID uuid.UUID) []byte {
+	h := hmac.New(sha256.New, s.guestCartSecret)
+	h.Write([]byte("guest-cart:"))
+	h.Write(cartID[:])
+	return h.Sum(nil)
+}
+
+// ─── Merge ────────────────────────────────────────────────────────────────────
+
+// MergeGuestCart moves a guest cart's lines into the user's cart and
+// deletes the guest cart. A line the user's cart already has takes the
+// sum of both quantities. Quantities are capped at the stock available to
+// the user, and lines of products no longer sold are dropped. Merging a
+// cart that no longer exists does nothing.
+func (s *CartService) MergeGuestCart(ctx context.Context, guestCartID, userID uuid.UUID) error {
+	cart, err := s.q.GetOrCreateCart(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get or create cart: %w", err)
+	}
+
+	return pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		// Concurrent sign-ins with the same cookie merge one at a time;
+		// the later ones find the cart gone.
+		guest, err := qtx.LockGuestCart(ctx, guestCartID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("lock guest cart: %w", err)
+		}
+
+		lines, err := qtx.ListCartItems(ctx, guestCartID)
+		if err != nil {
+			return fmt.Errorf("list guest cart items: %w", err)
+		}
+		// Without the guest's holds, their stock is available to the user.
+		if err := qtx.DeleteCartReservations(ctx, guestCartID); err != nil {
+			return fmt.Errorf("release guest reservations: %w", err)
+		}
+
+		// Variants are locked in id order so concurrent merges can't
+		// deadlock.
+		sort.SliceStable(lines, func(i, j int) bool {
+			return bytes.Compare(lines[i].VariantID[:], lines[j].VariantID[:]) < 0
+		})
+		for _, line := range lines {
+			if err := s.mergeLine(ctx, qtx, cart.ID, line); err != nil {
+				return err
+			}
+		}
+
+		// The user's own coupon, if they applied one, wins.
+		if guest.CouponID.Valid {
+			if err := qtx.MergeCartCoupon(ctx, cart.ID, guest.CouponID); err != nil {
+				return fmt.Errorf("merge cart coupon: %w", err)
+			}
+		}
+
+		if err := qtx.DeleteCart(ctx, guestCartID); err != nil {
+			return fmt.Errorf("delete guest cart: %w", err)
+		}
+		return nil
+	})
+}
+
+// mergeLine adds a guest cart line to the user's cart, as much of it as
+// stock allows.
+func (s *CartService) mergeLine(ctx context.Context, qtx *db.Queries, cartID uuid.UUID, line db.CartItem) error {
+	variant, err := lockVariant(ctx, qtx, line.VariantID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil // no longer sold
+	}
+	if err != nil {
+		return err
+	}
+
+	inCart, err := qtx.SumCartVariantQuantity(ctx, cartID, variant.ID)
+	if err != nil {
+		return fmt.Errorf("sum cart quantity: %w", err)
+	}
+	reserved, err := reservedStock(ctx, qtx, []uuid.UUID{variant.ID}, cartID)
+	if err != nil {
+		return err
+	}
+	add := mergedQuantity(line.Quantity, inCart, availableToPromise(variant.StockQuantity, reserved[variant.ID]))
+	if add == 0 {
+		return nil
+	}
+
+	existing, err := qtx.GetCartLineQuantity(ctx, db.GetCartLineQuantityParams{
+		CartID:          cartID,
+		VariantID:       variant.ID,
+		Personalisation: line.Personalisation,
+	})
+	if err != nil {
+		return fmt.Errorf("get cart line: %w", err)
+	}
+	if _, err := qtx.UpsertCartItem(ctx, db.UpsertCartItemParams{
+		CartID:          cartID,
+		ProductID:       line.ProductID,
+		VariantID:       variant.ID,
+		Personalisation: line.Personalisation,
+		Quantity:        existing + add,
+		AddedPrice:      line.AddedPrice, // what the guest was shown
+	}); err != nil {
+		return fmt.Errorf("upsert cart item: %w", err)
+	}
+	return s.reserve(ctx, qtx, cartID, variant)
+}
+
+// mergedQuantity is how much of a guest line of quantity guest fits in a
+// cart that already has inCart of the variant, when available can be
+// promised to it.
+func mergedQuantity(guest, inCart, available int32) int32 {
+	return max(0, min(guest, available-inCart))
+}
+
+// ─── Sweeper ──────────────────────────────────────────────────────────────────
+
+// GuestCartSweeper periodically deletes guest carts that haven't been used
+// for ttl, with their lines and holds.
+type GuestCartSweeper struct {
+	q        *db.Queries
+	ttl      time.Duration
+	interval time.Duration
+}
+
+func NewGuestCartSweeper(q *db.Queries, ttl, interval time.Duration) *GuestCartSweeper {
+	return &GuestCartSweeper{q: q, ttl: ttl, interval: interval}
+}
+
+// Run sweeps idle guest carts until ctx is cancelled.
+func (s *GuestCartSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.q.DeleteIdleGuestCarts(ctx, time.Now().Add(-s.ttl))
+			if err != nil {
+				slog.Error("sweep guest carts", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("deleted idle guest carts", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/config"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestGuestCartToken(t *testing.T) {
+	svc := service.NewCartService(nil, nil, nil, config.CartConfig{GuestCartSecret: "secret"})
+	cartID := uuid.New()
+
+	token := svc.GuestCartToken(cartID)
+	got, ok := svc.ParseGuestCartToken(token)
+	if !ok || got != cartID {
+		t.Fatalf("ParseGuestCartToken(GuestCartToken(id)) = %v, %v; want %v, true", got, ok, cartID)
+	}
+
+	id, sig, _ := strings.Cut(token, ".")
+	other := service.NewCartService(nil, nil, nil, config.CartConfig{GuestCartSecret: "other"})
+	for name, bad := range map[string]string{
+		"empty":        "",
+		"id only":      id,
+		"no id":        "." + sig,
+		"other cart":   uuid.NewString() + "." + sig,
+		"bad base64":   id + ".!!!",
+		"truncated":    token[:len(token)-2],
+		"other secret": other.GuestCartToken(cartID),
+	} {
+		if _, ok := svc.ParseGuestCartToken(bad); ok {
+			t.Errorf("ParseGuestCartToken(%s) ok = true, want false", name)
+		}
+	}
+}
+
+func TestMergedQuantity(t *testing.T) {
+	tests := []struct {
+		name                     string
+		guest, inCart, available int32
+		want                     int32
+	}{
+		{"plenty of stock", 2, 1, 10, 2},
+		{"capped at stock", 5, 1, 4, 3},
+		{"cart already holds all", 2, 4, 4, 0},
+		{"cart holds more than available", 2, 5, 3, 0},
+		{"no stock", 1, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := service.MergedQuantity(tt.guest, tt.inCart, tt.available); got != tt.want {
+			t.Errorf("%s: MergedQuantity(%d, %d, %d) = %d, want %d", tt.name, tt.guest, tt.inCart, tt.available, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+const (
+	// idempotencyKeyTTL is how long a key replays its original response.
+	idempotencyKeyTTL       = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+)
+
+// errIdempotencyKeyTaken aborts a transaction whose key was completed by a
+// concurrent request; the caller then replays that request's response.
+var errIdempotencyKeyTaken = errors.New("idempotency key already claimed")
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "Idempotency-Key must be at most 255 characters"}
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return &domain.AppError{Err: domain.ErrInvalidInput, Message: "Idempotency-Key must be printable ASCII"}
+		}
+	}
+	return nil
+}
+
+// orderFingerprint identifies the parameters of a create-order request, so
+// that a key reused for a different request can be told apart from a retry.
+func orderFingerprint(in CreateOrderInput) string {
+	b, _ := json.Marshal(struct {
+		DeliveryAddress string    `json:"delivery_address"`
+		DeliveryDate    time.Time `json:"delivery_date"`
+		Notes           string    `json:"notes"`
+		PaymentMethod   string    `json:"payment_method"`
+	}{in.DeliveryAddress, in.DeliveryDate.UTC(), in.Notes, in.PaymentMethod})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayOrder returns the stored response for an unexpired key, or nil if
+// the key hasn't been used yet.
+func (s *OrderService) replayOrder(ctx context.Context, userID uuid.UUID, key, fingerprint string) (*OrderResponse, error) {
+	stored, err := s.q.GetIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+
+	var resp OrderResponse
+	if err := json.Unmarshal(stored.ResponseBody, &resp); err != nil {
+		return nil, fmt.Errorf("decode stored response: %w", err)
+	}
+	return &resp, nil
+}
+
+// claimIdempotencyKey records the key inside the order transaction. It
+// blocks while a concurrent request holds the same key and fails with
+// errIdempotencyKeyTaken if that request committed.
+func claimIdempotencyKey(ctx context.Context, qtx *db.Queries, userID uuid.UUID, key, fingerprint string) error {
+	if err := qtx.DeleteExpiredIdempotencyKeys(ctx, userID); err != nil {
+		return fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+
+	if _, err := qtx.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(idempotencyKeyTTL),
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errIdempotencyKeyTaken
+		}
+		return fmt.Errorf("claim idempotency key: %w", err)
+	}
+	return nil
+}
+
+func saveIdempotentResponse(ctx context.Context, qtx *db.Queries, userID uuid.UUID, key string, resp *OrderResponse) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encode response: %w", err)
+	}
+	if err := qtx.SaveIdempotencyResponse(ctx, userID, key, body); err != nil {
+		return fmt.Errorf("save idempotency response: %w", err)
+	}
+	return nil
+}
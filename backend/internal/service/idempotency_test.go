@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestOrderFingerprint(t *testing.T) {
+	base := service.CreateOrderInput{
+		UserID:          uuid.New(),
+		DeliveryAddress: "123 Main St",
+		DeliveryDate:    time.Date(2024, 12, 25, 10, 0, 0, 0, time.UTC),
+		Notes:           "Happy Birthday!",
+		PaymentMethod:   "cash_on_delivery",
+		IdempotencyKey:  "abc",
+	}
+
+	same := base
+	same.IdempotencyKey = "other-key"
+	same.DeliveryDate = base.DeliveryDate.In(time.FixedZone("UTC+2", 2*60*60))
+	if service.OrderFingerprint(base) != service.OrderFingerprint(same) {
+		t.Error("fingerprint should ignore the key and the time zone of the delivery date")
+	}
+
+	changed := base
+	changed.Notes = "Happy Anniversary!"
+	if service.OrderFingerprint(base) == service.OrderFingerprint(changed) {
+		t.Error("fingerprint should change when the request body changes")
+	}
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"uuid", uuid.NewString(), false},
+		{"max length", strings.Repeat("k", 255), false},
+		{"too long", strings.Repeat("k", 256), true},
+		{"control character", "abc\n", true},
+		{"non-ascii", "clé", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateIdempotencyKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIdempotencyKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
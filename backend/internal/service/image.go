@@ -0,0 +1,445 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/storage"
+)
+
+// Admins upload JPEG and PNG photos of a product. Each upload is stored as
+// is, next to a thumbnail that fits in thumbnailSize pixels, under keys
+// that are never reused, so both can be cached forever. The product's
+// image_url follows its first image.
+
+const (
+	maxProductImages  = 10
+	maxImageDimension = 5000
+	thumbnailSize     = 400
+	thumbnailQuality  = 85
+)
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+type ProductImage struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+	Position     int32  `json:"position"`
+}
+
+// ─── Admin: Upload Image ──────────────────────────────────────────────────────
+
+// UploadImage adds an image read from r after the product's other images.
+func (s *ProductService) UploadImage(ctx context.Context, productID string, r io.Reader) (*ProductResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.storageCfg.MaxImageBytes+1))
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "could not read the image"}
+	}
+	if int64(len(data)) > s.storageCfg.MaxImageBytes {
+		return nil, &domain.AppError{
+			Err:     domain.ErrPayloadTooLarge,
+			Message: fmt.Sprintf("images must be at most %d MB", s.storageCfg.MaxImageBytes>>20),
+		}
+	}
+
+	img, contentType, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := encodeImage(resizeToFit(img, thumbnailSize), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+
+	imageID := uuid.New()
+	ext := imageExtension(contentType)
+	key := fmt.Sprintf("products/%s/%s%s", pid, imageID, ext)
+	thumbKey := fmt.Sprintf("products/%s/%s-thumb%s", pid, imageID, ext)
+
+	// The files go first; if the row can't be written they are removed.
+	if err := s.storage.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("store image: %w", err)
+	}
+	if err := s.storage.Put(ctx, thumbKey, bytes.NewReader(thumb)); err != nil {
+		s.deleteImageFiles(ctx, key)
+		return nil, fmt.Errorf("store thumbnail: %w", err)
+	}
+
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		current, err := lockProductForImages(ctx, qtx, pid)
+		if err != nil {
+			return err
+		}
+		n, err := qtx.CountProductImages(ctx, pid)
+		if err != nil {
+			return fmt.Errorf("count images: %w", err)
+		}
+		if n >= maxProductImages {
+			return &domain.AppError{Err: domain.ErrConflict, Message: fmt.Sprintf("a product can have at most %d images", maxProductImages)}
+		}
+
+		bounds := img.Bounds()
+		if _, err := qtx.CreateProductImage(ctx, db.CreateProductImageParams{
+			ID:           imageID,
+			ProductID:    pid,
+			StorageKey:   key,
+			ThumbnailKey: thumbKey,
+			ContentType:  contentType,
+			Width:        int32(bounds.Dx()),
+			Height:       int32(bounds.Dy()),
+			SizeBytes:    int64(len(data)),
+		}); err != nil {
+			return fmt.Errorf("create image: %w", err)
+		}
+		return s.syncImageURL(ctx, qtx, pid, current)
+	})
+	if err != nil {
+		s.deleteImageFiles(ctx, key, thumbKey)
+		return nil, err
+	}
+
+	return s.adminGet(ctx, pid)
+}
+
+// ─── Admin: Delete Image ──────────────────────────────────────────────────────
+
+func (s *ProductService) DeleteImage(ctx context.Context, productID, imageID string) (*ProductResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+	iid, err := uuid.Parse(imageID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid image id"}
+	}
+
+	var removed db.ProductImage
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		current, err := lockProductForImages(ctx, qtx, pid)
+		if err != nil {
+			return err
+		}
+		if removed, err = qtx.GetProductImage(ctx, iid, pid); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get image: %w", err)
+		}
+		if _, err := qtx.DeleteProductImage(ctx, iid); err != nil {
+			return fmt.Errorf("delete image: %w", err)
+		}
+		return s.syncImageURL(ctx, qtx, pid, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The row is gone, so the files can't be served any more; a leftover
+	// file is only wasted space.
+	s.deleteImageFiles(ctx, removed.StorageKey, removed.ThumbnailKey)
+
+	return s.adminGet(ctx, pid)
+}
+
+// ─── Admin: Reorder Images ────────────────────────────────────────────────────
+
+// ReorderImages puts a product's images in the given order. imageIDs must
+// list each of its images exactly once.
+func (s *ProductService) ReorderImages(ctx context.Context, productID string, imageIDs []string) (*ProductResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+
+	order := make([]uuid.UUID, 0, len(imageIDs))
+	for _, id := range imageIDs {
+		iid, err := uuid.Parse(id)
+		if err != nil {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid image id"}
+		}
+		order = append(order, iid)
+	}
+
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		current, err := lockProductForImages(ctx, qtx, pid)
+		if err != nil {
+			return err
+		}
+		images, err := qtx.ListProductImages(ctx, []uuid.UUID{pid})
+		if err != nil {
+			return fmt.Errorf("list images: %w", err)
+		}
+		if err := checkImageOrder(images, order); err != nil {
+			return err
+		}
+		for i, id := range order {
+			if err := qtx.UpdateProductImagePosition(ctx, id, int32(i)); err != nil {
+				return fmt.Errorf("update image position: %w", err)
+			}
+		}
+		return s.syncImageURL(ctx, qtx, pid, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adminGet(ctx, pid)
+}
+
+// ─── Serve Image ──────────────────────────────────────────────────────────────
+
+// OpenImage opens a stored image by key for serving, with its content type.
+func (s *ProductService) OpenImage(ctx context.Context, key string) (*storage.Object, string, error) {
+	contentType := imageContentType(key)
+	if contentType == "" || !strings.HasPrefix(key, "products/") || !storage.ValidKey(key) {
+		return nil, "", domain.ErrNotFound
+	}
+	obj, err := s.storage.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, "", domain.ErrNotFound
+		}
+		return nil, "", fmt.Errorf("open image: %w", err)
+	}
+	return obj, contentType, nil
+}
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+// attachImages fills in the images of products.
+func (s *ProductService) attachImages(ctx context.Context, products []ProductResponse) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, uuid.MustParse(p.ID))
+	}
+
+	images, err := s.q.ListProductImages(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("list images: %w", err)
+	}
+
+	byProduct := make(map[uuid.UUID][]ProductImage, len(products))
+	for _, img := range images {
+		byProduct[img.ProductID] = append(byProduct[img.ProductID], ProductImage{
+			ID:           img.ID.String(),
+			URL:          s.imageURL(img.StorageKey),
+			ThumbnailURL: s.imageURL(img.ThumbnailKey),
+			ContentType:  img.ContentType,
+			Width:        img.Width,
+			Height:       img.Height,
+			Position:     img.Position,
+		})
+	}
+	for i := range products {
+		products[i].Images = byProduct[ids[i]]
+		if products[i].Images == nil {
+			products[i].Images = []ProductImage{}
+		}
+	}
+	return nil
+}
+
+// syncImageURL points the product's image_url at its first image. Without
+// images, an image_url of a removed upload is cleared and any other is left
+// alone.
+func (s *ProductService) syncImageURL(ctx context.Context, qtx *db.Queries, productID uuid.UUID, current pgtype.Text) error {
+	images, err := qtx.ListProductImages(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return fmt.Errorf("list images: %w", err)
+	}
+
+	want := current
+	if len(images) > 0 {
+		want = pgtype.Text{String: s.imageURL(images[0].StorageKey), Valid: true}
+	} else if current.Valid && strings.HasPrefix(current.String, s.storageCfg.ImageBaseURL+"/") {
+		want = pgtype.Text{}
+	}
+	if want == current {
+		return nil
+	}
+	if err := qtx.SetProductImageURL(ctx, productID, want); err != nil {
+		return fmt.Errorf("set image url: %w", err)
+	}
+	return nil
+}
+
+func (s *ProductService) imageURL(key string) string {
+	return s.storageCfg.ImageBaseURL + "/" + key
+}
+
+func (s *ProductService) deleteImageFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			slog.Error("delete image file", "key", key, "error", err)
+		}
+	}
+}
+
+// lockProductForImages locks a live product for changing its images and
+// returns its image_url.
+func lockProductForImages(ctx context.Context, qtx *db.Queries, productID uuid.UUID) (pgtype.Text, error) {
+	current, err := qtx.GetProductImageURLForUpdate(ctx, productID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgtype.Text{}, domain.ErrNotFound
+		}
+		return pgtype.Text{}, fmt.Errorf("lock product: %w", err)
+	}
+	return current, nil
+}
+
+// checkImageOrder reports whether order lists each of images exactly once.
+func checkImageOrder(images []db.ProductImage, order []uuid.UUID) error {
+	invalid := &domain.AppError{Err: domain.ErrInvalidInput, Message: "image_ids must list each of the product's images once"}
+	if len(order) != len(images) {
+		return invalid
+	}
+	remaining := make(map[uuid.UUID]bool, len(images))
+	for _, img := range images {
+		remaining[img.ID] = true
+	}
+	for _, id := range order {
+		if !remaining[id] {
+			return invalid
+		}
+		delete(remaining, id)
+	}
+	return nil
+}
+
+// decodeImage checks that data is a JPEG or PNG of acceptable dimensions,
+// going by its contents rather than anything the client claims, and
+// decodes it.
+func decodeImage(data []byte) (image.Image, string, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return nil, "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "images must be JPEG or PNG"}
+	}
+
+	// Check the size before decoding so a small file can't claim a huge
+	// canvas and exhaust memory.
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return nil, "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "image is damaged or not a JPEG or PNG"}
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, "", &domain.AppError{
+			Err:     domain.ErrInvalidInput,
+			Message: fmt.Sprintf("images must be at most %d×%d pixels", maxImageDimension, maxImageDimension),
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", &domain.AppError{Err: domain.ErrInvalidInput, Message: "image is damaged or not a JPEG or PNG"}
+	}
+	return img, contentType, nil
+}
+
+// resizeToFit scales img down to fit in a size×size square, keeping its
+// aspect ratio, by averaging the source pixels each output pixel covers.
+// Images that already fit are returned as they are.
+func resizeToFit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, size
+	if w >= h {
+		th = max(1, h*size/w)
+	} else {
+		tw = max(1, w*size/h)
+	}
+
+	// Work on premultiplied RGBA so transparent pixels don't bleed colour.
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, max((x+1)*w/tw, x*w/tw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			o := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+func encodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbnailQuality})
+	}
+	return buf.Bytes(), err
+}
+
+func imageExtension(contentType string) string {
+	if contentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+func imageContentType(key string) string {
+	switch path.Ext(key) {
+	case ".jpg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	}
+	return ""
+}
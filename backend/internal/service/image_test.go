@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func encodedImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encode %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeImage(t *testing.T) {
+	for _, tt := range []struct {
+		format, contentType string
+	}{
+		{"png", "image/png"},
+		{"jpeg", "image/jpeg"},
+	} {
+		img, contentType, err := service.DecodeImage(encodedImage(t, tt.format, 30, 20))
+		if err != nil {
+			t.Fatalf("DecodeImage(%s) error = %v", tt.format, err)
+		}
+		if contentType != tt.contentType || img.Bounds().Dx() != 30 || img.Bounds().Dy() != 20 {
+			t.Errorf("DecodeImage(%s) = %v, %q", tt.format, img.Bounds(), contentType)
+		}
+	}
+
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	if _, _, err := service.DecodeImage(gif); err == nil {
+		t.Error("DecodeImage(gif) error = nil, want error")
+	}
+	if _, _, err := service.DecodeImage([]byte("<svg></svg>")); err == nil {
+		t.Error("DecodeImage(svg) error = nil, want error")
+	}
+
+	truncated := encodedImage(t, "png", 30, 20)[:40]
+	if _, _, err := service.DecodeImage(truncated); err == nil {
+		t.Error("DecodeImage(truncated png) error = nil, want error")
+	}
+
+	if _, _, err := service.DecodeImage(encodedImage(t, "png", 5001, 1)); err == nil {
+		t.Error("DecodeImage(5001×1) error = nil, want error")
+	}
+}
+
+func TestResizeToFit(t *testing.T) {
+	tests := []struct {
+		w, h, size   int
+		wantW, wantH int
+	}{
+		{800, 400, 400, 400, 200},
+		{300, 1200, 400, 100, 400},
+		{1000, 1000, 400, 400, 400},
+		{4000, 2, 400, 400, 1},
+		{300, 200, 400, 300, 200},
+	}
+	for _, tt := range tests {
+		src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+		got := service.ResizeToFit(src, tt.size).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("ResizeToFit(%d×%d, %d) = %d×%d, want %d×%d", tt.w, tt.h, tt.size, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+
+	// Averaging keeps a uniform colour uniform.
+	src := image.NewRGBA(image.Rect(0, 0, 90, 60))
+	fill := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	for y := 0; y < 60; y++ {
+		for x := 0; x < 90; x++ {
+			src.SetRGBA(x, y, fill)
+		}
+	}
+	dst := service.ResizeToFit(src, 40).(*image.RGBA)
+	if got := dst.RGBAAt(20, 13); got != fill {
+		t.Errorf("ResizeToFit() pixel = %v, want %v", got, fill)
+	}
+}
+
+func TestCheckImageOrder(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	images := []db.ProductImage{{ID: a}, {ID: b}}
+
+	if err := service.CheckImageOrder(images, []uuid.UUID{b, a}); err != nil {
+		t.Errorf("CheckImageOrder(b, a) error = %v", err)
+	}
+	for name, order := range map[string][]uuid.UUID{
+		"missing":   {a},
+		"duplicate": {a, a},
+		"unknown":   {a, uuid.New()},
+		"extra":     {a, b, b},
+	} {
+		if err := service.CheckImageOrder(images, order); err == nil {
+			t.Errorf("CheckImageOrder(%s) error = nil, want error", name)
+		}
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/big"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,16 +13,23 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/email"
 	"github.com/online-cake-shop/backend/internal/repository/db"
 )
 
+// orderCancelCutoff is how long before delivery customers can still cancel.
+const orderCancelCutoff = 24 * time.Hour
+
 type OrderService struct {
-	pool *pgxpool.Pool
-	q    *db.Queries
+	pool       *pgxpool.Pool
+	q          *db.Queries
+	promotions *PromotionEngine
+	payments   *PaymentService
+	emailSvc   email.Sender
 }
 
-func NewOrderService(pool *pgxpool.Pool, q *db.Queries) *OrderService {
-	return &OrderService{pool: pool, q: q}
+func NewOrderService(pool *pgxpool.Pool, q *db.Queries, promotions *PromotionEngine, payments *PaymentService, emailSvc email.Sender) *OrderService {
+	return &OrderService{pool: pool, q: q, promotions: promotions, payments: payments, emailSvc: emailSvc}
 }
 
 // ─── DTOs ────────────────────────────────────────────────────────────────────
@@ -33,28 +40,67 @@ type CreateOrderInput struct {
 	DeliveryDate    time.Time
 	Notes           string
 	PaymentMethod   string
+	// PaymentToken is the card token from the payment provider's client SDK;
+	// only used when PaymentMethod is "card".
+	PaymentToken string
+	// IdempotencyKey, if set, makes retries of the same request return the
+	// original order instead of creating another.
+	IdempotencyKey string
 }
 
 type OrderItemResponse struct {
-	ID          string  `json:"id"`
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	ImageURL    *string `json:"image_url"`
-	Quantity    int32   `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
-	TotalPrice  float64 `json:"total_price"`
+	ID          string            `json:"id"`
+	ProductID   string            `json:"product_id"`
+	VariantID   string            `json:"variant_id"`
+	ProductName string            `json:"product_name"`
+	ImageURL    *string           `json:"image_url"`
+	SKU         string            `json:"sku"`
+	Options     map[string]string `json:"options"`
+	Quantity    int32             `json:"quantity"`
+	UnitPrice   domain.Money      `json:"unit_price"`
+	TotalPrice  domain.Money      `json:"total_price"`
+	// RefundedQuantity is how many of Quantity have been refunded.
+	RefundedQuantity int32 `json:"refunded_quantity"`
+	// PersonalisationPrice is the part of UnitPrice paid for Personalisation.
+	Personalisation      *Personalisation `json:"personalisation,omitempty"`
+	PersonalisationPrice domain.Money     `json:"personalisation_price"`
+	// Allergens are the product's declarations when it was ordered.
+	Allergens []OrderedAllergen `json:"allergens"`
 }
 
 type OrderResponse struct {
-	ID              string              `json:"id"`
-	DeliveryAddress string              `json:"delivery_address"`
-	DeliveryDate    time.Time           `json:"delivery_date"`
-	Notes           *string             `json:"notes"`
-	PaymentMethod   string              `json:"payment_method"`
-	Status          string              `json:"status"`
-	TotalAmount     float64             `json:"total_amount"`
-	Items           []OrderItemResponse `json:"items"`
-	CreatedAt       time.Time           `json:"created_at"`
+	ID              string    `json:"id"`
+	UserID          string    `json:"user_id"`
+	DeliveryAddress string    `json:"delivery_address"`
+	DeliveryDate    time.Time `json:"delivery_date"`
+	Notes           *string   `json:"notes"`
+	PaymentMethod   string    `json:"payment_method"`
+	Status          string    `json:"status"`
+	// TotalAmount is what the items came to less PromotionDiscount and
+	// DiscountAmount, what CouponCode took off, plus DeliveryFee.
+	TotalAmount       domain.Money `json:"total_amount"`
+	PromotionDiscount domain.Money `json:"promotion_discount"`
+	DiscountAmount    domain.Money `json:"discount_amount"`
+	CouponCode        *string      `json:"coupon_code,omitempty"`
+	DeliveryFee       domain.Money `json:"delivery_fee"`
+	// RefundedAmount is what has been refunded so far, partial refunds
+	// included.
+	RefundedAmount domain.Money `json:"refunded_amount"`
+	// Promotions explains the promotions the order was placed with.
+	Promotions    []AppliedPromotion  `json:"promotions,omitempty"`
+	Items         []OrderItemResponse `json:"items"`
+	Payment       *PaymentResponse    `json:"payment,omitempty"`
+	Refunds       []RefundResponse    `json:"refunds,omitempty"`
+	StatusHistory []OrderStatusChange `json:"status_history,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+type OrderStatusChange struct {
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    *string   `json:"actor_id"`
+	Note       *string   `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type ListOrdersOutput struct {
@@ -67,16 +113,49 @@ type ListOrdersOutput struct {
 
 // ─── Create Order (transactional) ────────────────────────────────────────────
 
+// orderLine is a cart line priced for ordering.
+type orderLine struct {
+	item            db.GetCartItemsRow
+	personalisation Personalisation
+	surcharge       domain.Money
+	unitPrice       domain.Money
+}
+
 func (s *OrderService) CreateOrder(ctx context.Context, in CreateOrderInput) (*OrderResponse, error) {
+	if in.PaymentMethod == "" {
+		in.PaymentMethod = paymentMethodCashOnDelivery
+	}
 	if err := validateOrderInput(in); err != nil {
 		return nil, err
 	}
 
+	var fingerprint string
+	if in.IdempotencyKey != "" {
+		if err := validateIdempotencyKey(in.IdempotencyKey); err != nil {
+			return nil, err
+		}
+		fingerprint = orderFingerprint(in)
+		if resp, err := s.replayOrder(ctx, in.UserID, in.IdempotencyKey, fingerprint); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	// An empty cart may mean a retry raced with the original request, which
+	// has since checked out the cart; replay it if so.
+	emptyCart := func() (*OrderResponse, error) {
+		if in.IdempotencyKey != "" {
+			if resp, err := s.replayOrder(ctx, in.UserID, in.IdempotencyKey, fingerprint); resp != nil || err != nil {
+				return resp, err
+			}
+		}
+		return nil, domain.ErrEmptyCart
+	}
+
 	// Load cart items outside the transaction first
 	cart, err := s.q.GetCartByUserID(ctx, in.UserID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrEmptyCart
+			return emptyCart()
 		}
 		return nil, fmt.Errorf("get cart: %w", err)
 	}
@@ -86,52 +165,135 @@ func (s *OrderService) CreateOrder(ctx context.Context, in CreateOrderInput) (*O
 		return nil, fmt.Errorf("get cart items: %w", err)
 	}
 	if len(cartItems) == 0 {
-		return nil, domain.ErrEmptyCart
+		return emptyCart()
+	}
+	for _, ci := range cartItems {
+		if ci.Unavailable {
+			return nil, noLongerAvailable(ci.ProductName)
+		}
 	}
 
-	// Collect product IDs to lock for stock check
-	productIDs := make([]uuid.UUID, 0, len(cartItems))
+	// Collect variant IDs to lock for stock check
+	variantIDs := make([]uuid.UUID, 0, len(cartItems))
 	for _, ci := range cartItems {
-		productIDs = append(productIDs, ci.ProductID)
+		variantIDs = append(variantIDs, ci.VariantID)
 	}
 
-	var order db.Order
-	var orderItems []db.GetOrderItemsRow
+	var resp *OrderResponse
+	var pendingPayment *db.Payment
+	var placedItems []db.GetOrderItemsRow
 
 	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
 		qtx := s.q.WithTx(tx)
 
-		// Fetch and lock products
-		products, err := qtx.GetProductsForOrder(ctx, productIDs)
+		if in.IdempotencyKey != "" {
+			if err := claimIdempotencyKey(ctx, qtx, in.UserID, in.IdempotencyKey, fingerprint); err != nil {
+				return err
+			}
+		}
+
+		// Fetch and lock variants
+		variants, err := qtx.GetVariantsForOrder(ctx, variantIDs)
 		if err != nil {
-			return fmt.Errorf("fetch products: %w", err)
+			return fmt.Errorf("fetch variants: %w", err)
 		}
 
-		productMap := make(map[uuid.UUID]db.GetProductsForOrderRow, len(products))
-		for _, p := range products {
-			productMap[p.ID] = p
+		variantMap := make(map[uuid.UUID]db.GetVariantsForOrderRow, len(variants))
+		for _, v := range variants {
+			variantMap[v.ID] = v
 		}
 
-		// Validate stock
+		// Validate stock against what other carts aren't holding. The
+		// cart's own holds, even lapsed ones, don't count against it.
+		// A variant can be on several lines with different personalisations.
+		reserved, err := reservedStock(ctx, qtx, variantIDs, cart.ID)
+		if err != nil {
+			return err
+		}
+		wanted := make(map[uuid.UUID]int32, len(variantMap))
 		for _, ci := range cartItems {
-			p, ok := productMap[ci.ProductID]
-			if !ok {
-				return fmt.Errorf("product %s not found", ci.ProductID)
+			if _, ok := variantMap[ci.VariantID]; !ok {
+				return noLongerAvailable(ci.ProductName) // withdrawn since the cart was read
 			}
-			if p.StockQuantity < ci.Quantity {
+			wanted[ci.VariantID] += ci.Quantity
+		}
+		for id, quantity := range wanted {
+			v := variantMap[id]
+			if availableToPromise(v.StockQuantity, reserved[v.ID]) < quantity {
 				return &domain.AppError{
 					Err:     domain.ErrInsufficientStock,
-					Message: fmt.Sprintf("not enough stock for '%s'", p.Name),
+					Message: fmt.Sprintf("not enough stock for '%s'", variantName(v.Name, v.Options)),
+				}
+			}
+		}
+
+		// Price the lines against the current personalisation options,
+		// which may have changed since the cakes were added.
+		lines := make([]orderLine, 0, len(cartItems))
+		var totalAmount domain.Money
+		for _, ci := range cartItems {
+			v := variantMap[ci.VariantID]
+			options := decodePersonalisationOptions(v.PersonalisationOptions)
+			personalisation, err := checkPersonalisation(options, decodePersonalisation(ci.Personalisation))
+			if err != nil {
+				return &domain.AppError{
+					Err:     domain.ErrInvalidInput,
+					Message: fmt.Sprintf("'%s' needs updating: %v", variantName(v.Name, v.Options), err),
 				}
 			}
+			surcharge := personalisationPrice(options, personalisation)
+			line := orderLine{
+				item:            ci,
+				personalisation: personalisation,
+				surcharge:       surcharge,
+				unitPrice:       numericToMoney(v.Price).Add(surcharge),
+			}
+			lines = append(lines, line)
+			totalAmount = totalAmount.Add(line.unitPrice.Mul(int64(ci.Quantity)))
+		}
+		if totalAmount > maxStoredAmount {
+			return &domain.AppError{Err: domain.ErrInvalidInput, Message: "order total is too large"}
 		}
 
-		// Compute total
-		var totalAmount float64
+		// Promotions are worked out again as they stand now.
+		promotionLines := make([]promotionLine, 0, len(lines))
+		for _, line := range lines {
+			promotionLines = append(promotionLines, promotionLine{
+				productID: line.item.ProductID,
+				quantity:  line.item.Quantity,
+				amount:    line.unitPrice.Mul(int64(line.item.Quantity)),
+			})
+		}
+		promotions, err := s.promotions.price(ctx, qtx, promotionLines, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// The cart's coupon is checked again, and its use counted, with
+		// the coupon locked so concurrent orders can't exceed its limits.
+		var coupon db.Coupon
+		var discount domain.Money
+		if cart.CouponID.Valid {
+			couponLines := make([]couponLine, 0, len(lines))
+			for i, l := range promotionLines {
+				couponLines = append(couponLines, couponLine{
+					productID: l.productID,
+					amount:    l.amount.Sub(promotions.lineDiscounts[i]),
+				})
+			}
+			if coupon, discount, err = claimCoupon(ctx, qtx, cart.CouponID.Bytes, in.UserID, couponLines); err != nil {
+				return err
+			}
+		}
+		totalAmount = totalAmount.Sub(promotions.discount).Sub(discount).Add(promotions.deliveryFee)
+
+		productIDs := make([]uuid.UUID, 0, len(cartItems))
 		for _, ci := range cartItems {
-			p := productMap[ci.ProductID]
-			price := numericToFloat(p.Price)
-			totalAmount += price * float64(ci.Quantity)
+			productIDs = append(productIDs, ci.ProductID)
+		}
+		allergens, err := orderedAllergens(ctx, qtx, productIDs)
+		if err != nil {
+			return err
 		}
 
 		// Create order
@@ -140,80 +302,204 @@ func (s *OrderService) CreateOrder(ctx context.Context, in CreateOrderInput) (*O
 			notes = pgtype.Text{String: in.Notes, Valid: true}
 		}
 
-		totalNumeric, err := floatToNumeric(totalAmount)
-		if err != nil {
-			return fmt.Errorf("convert total: %w", err)
+		params := db.CreateOrderParams{
+			UserID:            in.UserID,
+			DeliveryAddress:   in.DeliveryAddress,
+			DeliveryDate:      in.DeliveryDate,
+			Notes:             notes,
+			PaymentMethod:     in.PaymentMethod,
+			TotalAmount:       moneyToNumeric(totalAmount),
+			DiscountAmount:    moneyToNumeric(discount),
+			PromotionDiscount: moneyToNumeric(promotions.discount),
+			DeliveryFee:       moneyToNumeric(promotions.deliveryFee),
 		}
-
-		paymentMethod := in.PaymentMethod
-		if paymentMethod == "" {
-			paymentMethod = "cash_on_delivery"
+		if cart.CouponID.Valid {
+			params.CouponID = pgtype.UUID{Bytes: coupon.ID, Valid: true}
+			params.CouponCode = pgtype.Text{String: coupon.Code, Valid: true}
 		}
-
-		order, err = qtx.CreateOrder(ctx, db.CreateOrderParams{
-			UserID:          in.UserID,
-			DeliveryAddress: in.DeliveryAddress,
-			DeliveryDate:    in.DeliveryDate,
-			Notes:           notes,
-			PaymentMethod:   paymentMethod,
-			TotalAmount:     totalNumeric,
-		})
+		order, err := qtx.CreateOrder(ctx, params)
 		if err != nil {
 			return fmt.Errorf("create order: %w", err)
 		}
-
-		// Create order items and deduct stock
-		for _, ci := range cartItems {
-			p := productMap[ci.ProductID]
-			unitPrice := numericToFloat(p.Price)
-			totalPrice := unitPrice * float64(ci.Quantity)
-
-			unitPriceNumeric, err := floatToNumeric(unitPrice)
-			if err != nil {
-				return err
-			}
-			totalPriceNumeric, err := floatToNumeric(totalPrice)
-			if err != nil {
+		if cart.CouponID.Valid {
+			if err := recordCouponUse(ctx, qtx, coupon.ID, in.UserID, order.ID, discount); err != nil {
 				return err
 			}
+		}
+		if err := recordOrderPromotions(ctx, qtx, order.ID, promotions.applied); err != nil {
+			return err
+		}
+
+		if _, err := qtx.CreateOrderStatusHistory(ctx, db.CreateOrderStatusHistoryParams{
+			OrderID:  order.ID,
+			ToStatus: order.Status,
+			ActorID:  pgtype.UUID{Bytes: in.UserID, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("record order status: %w", err)
+		}
 
+		// Create order items and deduct stock
+		for _, line := range lines {
+			ci := line.item
 			if _, err := qtx.CreateOrderItem(ctx, db.CreateOrderItemParams{
-				OrderID:    order.ID,
-				ProductID:  ci.ProductID,
-				Quantity:   ci.Quantity,
-				UnitPrice:  unitPriceNumeric,
-				TotalPrice: totalPriceNumeric,
+				OrderID:              order.ID,
+				ProductID:            ci.ProductID,
+				VariantID:            ci.VariantID,
+				Quantity:             ci.Quantity,
+				UnitPrice:            moneyToNumeric(line.unitPrice),
+				TotalPrice:           moneyToNumeric(line.unitPrice.Mul(int64(ci.Quantity))),
+				Personalisation:      encodePersonalisation(line.personalisation),
+				PersonalisationPrice: moneyToNumeric(line.surcharge),
+				Allergens:            encodeOrderedAllergens(allergens[ci.ProductID]),
 			}); err != nil {
 				return fmt.Errorf("create order item: %w", err)
 			}
 
 			// Deduct stock
-			if err := qtx.DeductProductStock(ctx, db.DeductProductStockParams{
-				ID:       ci.ProductID,
+			if err := qtx.DeductVariantStock(ctx, db.DeductVariantStockParams{
+				ID:       ci.VariantID,
 				Quantity: ci.Quantity,
 			}); err != nil {
 				return fmt.Errorf("deduct stock: %w", err)
 			}
 		}
 
-		// Clear cart
+		// Clear cart; its holds are now covered by the stock deduction.
 		if err := qtx.ClearCart(ctx, cart.ID); err != nil {
 			return fmt.Errorf("clear cart: %w", err)
 		}
+		if err := qtx.DeleteCartReservations(ctx, cart.ID); err != nil {
+			return fmt.Errorf("release reservations: %w", err)
+		}
+		if err := qtx.SetCartCoupon(ctx, cart.ID, pgtype.UUID{}); err != nil {
+			return fmt.Errorf("remove cart coupon: %w", err)
+		}
 
+		orderItems, err := qtx.GetOrderItems(ctx, order.ID)
+		if err != nil {
+			return fmt.Errorf("get order items: %w", err)
+		}
+		resp = mapOrderResponse(order, orderItems)
+		resp.Promotions = promotions.applied
+		placedItems = orderItems
+
+		if in.PaymentMethod == paymentMethodCard {
+			p, err := s.payments.createPending(ctx, qtx, order.ID, totalAmount)
+			if err != nil {
+				return err
+			}
+			pendingPayment = &p
+			resp.Payment = mapPaymentResponse(p)
+		}
+
+		if in.IdempotencyKey != "" {
+			return saveIdempotentResponse(ctx, qtx, in.UserID, in.IdempotencyKey, resp)
+		}
 		return nil
 	})
+	if errors.Is(err, errIdempotencyKeyTaken) {
+		resp, err = s.replayOrder(ctx, in.UserID, in.IdempotencyKey, fingerprint)
+		if err == nil && resp == nil {
+			err = fmt.Errorf("idempotency key %q vanished after a concurrent claim", in.IdempotencyKey)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Load order items for response (outside transaction)
-	orderItems, err = s.q.GetOrderItems(ctx, order.ID)
+	if pendingPayment != nil {
+		s.chargeOrder(ctx, in, resp, *pendingPayment)
+	}
+	// Replays have placedItems nil and were confirmed the first time.
+	if placedItems != nil && resp.Status != domain.OrderStatusPaymentFailed {
+		s.sendOrderConfirmation(ctx, in.UserID, resp, placedItems)
+	}
+
+	return resp, nil
+}
+
+// sendOrderConfirmation emails the customer their new order with the
+// allergens of each line. The order has already been placed, so failures
+// are only logged.
+func (s *OrderService) sendOrderConfirmation(ctx context.Context, userID uuid.UUID, resp *OrderResponse, items []db.GetOrderItemsRow) {
+	user, err := s.q.GetUserByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("get order items: %w", err)
+		slog.Error("get user for order confirmation", "order_id", resp.ID, "error", err)
+		return
 	}
 
-	return mapOrderResponse(order, orderItems), nil
+	confirmation := email.OrderConfirmation{
+		OrderID:         resp.ID,
+		DeliveryDate:    resp.DeliveryDate.Format("Monday 2 January 2006"),
+		DeliveryAddress: resp.DeliveryAddress,
+		Total:           s.payments.formatAmount(resp.TotalAmount),
+		Items:           make([]email.OrderConfirmationItem, 0, len(items)),
+	}
+	for _, p := range resp.Promotions {
+		confirmation.Promotions = append(confirmation.Promotions, email.OrderConfirmationPromotion{
+			Name:   p.Name,
+			Detail: p.Detail,
+			Amount: s.payments.formatAmount(p.Discount),
+		})
+	}
+	if resp.CouponCode != nil {
+		confirmation.CouponCode = *resp.CouponCode
+		confirmation.Discount = s.payments.formatAmount(resp.DiscountAmount)
+	}
+	if resp.DeliveryFee > 0 {
+		confirmation.DeliveryFee = s.payments.formatAmount(resp.DeliveryFee)
+	}
+	for _, item := range items {
+		line := email.OrderConfirmationItem{
+			Name:     variantName(item.ProductName, item.VariantOptions),
+			Quantity: item.Quantity,
+			Amount:   s.payments.formatAmount(numericToMoney(item.TotalPrice)),
+		}
+		for _, a := range decodeOrderedAllergens(item.Allergens) {
+			switch a.Status {
+			case domain.AllergenContains:
+				line.Contains = append(line.Contains, a.Name)
+			case domain.AllergenMayContain:
+				line.MayContain = append(line.MayContain, a.Name)
+			case domain.AllergenFreeFrom:
+				line.FreeFrom = append(line.FreeFrom, a.Name)
+			}
+		}
+		confirmation.Items = append(confirmation.Items, line)
+	}
+
+	if err := s.emailSvc.SendOrderConfirmation(user.EmailAddress, user.FirstName, confirmation); err != nil {
+		slog.Error("send order confirmation", "order_id", resp.ID, "error", err)
+	}
+}
+
+// chargeOrder charges a new card order and reflects the outcome in resp.
+// The order already exists, so a payment the provider couldn't process
+// doesn't fail the request. It's failed like a declined one instead, which
+// moves the order to payment_failed and releases its stock and coupon use.
+func (s *OrderService) chargeOrder(ctx context.Context, in CreateOrderInput, resp *OrderResponse, pending db.Payment) {
+	p, err := s.payments.process(ctx, pending, in.PaymentToken)
+	if err != nil {
+		slog.Error("process payment", "order_id", resp.ID, "payment_id", pending.ID, "error", err)
+		if p, err = s.payments.settle(ctx, pending.ID, unprocessedIntent(p)); err != nil {
+			slog.Error("fail unprocessed payment", "order_id", resp.ID, "payment_id", pending.ID, "error", err)
+			return
+		}
+	}
+	resp.Payment = mapPaymentResponse(p)
+
+	// A final payment outcome confirms or fails the order.
+	if order, err := s.q.GetOrderByID(ctx, pending.OrderID, in.UserID); err == nil {
+		resp.Status = order.Status
+	} else {
+		slog.Error("reload order after payment", "order_id", resp.ID, "error", err)
+	}
+
+	if in.IdempotencyKey != "" {
+		if err := saveIdempotentResponse(ctx, s.q, in.UserID, in.IdempotencyKey, resp); err != nil {
+			slog.Error("update idempotent response", "order_id", resp.ID, "error", err)
+		}
+	}
 }
 
 // ─── List Orders ──────────────────────────────────────────────────────────────
@@ -276,16 +562,187 @@ func (s *OrderService) GetOrder(ctx context.Context, userID uuid.UUID, orderID s
 		return nil, fmt.Errorf("get order: %w", err)
 	}
 
-	items, err := s.q.GetOrderItems(ctx, order.ID)
+	return s.orderDetails(ctx, order)
+}
+
+// ─── Cancel Order ─────────────────────────────────────────────────────────────
+
+// CancelOrder lets a customer cancel their own order while it hasn't gone
+// into the oven and delivery is at least orderCancelCutoff away. Stock taken
+// by the order is put back, and its coupon use freed, in the same
+// transaction. A card payment that was already captured is refunded in full.
+func (s *OrderService) CancelOrder(ctx context.Context, userID uuid.UUID, orderID, reason string) (*OrderResponse, error) {
+	oid, err := uuid.Parse(orderID)
 	if err != nil {
-		return nil, fmt.Errorf("get order items: %w", err)
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order id"}
+	}
+
+	var (
+		order  db.Order
+		refund *reservedRefund
+	)
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		current, err := qtx.GetOrderByIDForUpdate(ctx, oid)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get order: %w", err)
+		}
+		if current.UserID != userID {
+			return domain.ErrNotFound
+		}
+
+		if err := checkCustomerCancellable(current.Status, current.DeliveryDate, time.Now()); err != nil {
+			return err
+		}
+
+		order, refund, err = cancelOrder(ctx, qtx, current, userID, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if refund != nil {
+		if order, err = s.refundCancelledOrder(ctx, userID, *refund); err != nil {
+			return nil, err
+		}
 	}
 
-	return mapOrderResponse(order, items), nil
+	return s.orderDetails(ctx, order)
+}
+
+// ─── Admin ────────────────────────────────────────────────────────────────────
+
+// GetOrderAdmin loads any user's order.
+func (s *OrderService) GetOrderAdmin(ctx context.Context, orderID string) (*OrderResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order id"}
+	}
+
+	order, err := s.q.GetOrderByIDAdmin(ctx, oid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+
+	return s.orderDetails(ctx, order)
+}
+
+// UpdateStatus moves any user's order to the given status, if the lifecycle
+// allows it, and records who did it. Cancelling returns the items to stock,
+// frees the coupon use and refunds a captured card payment.
+// Refund statuses are only reached through RefundOrder.
+func (s *OrderService) UpdateStatus(ctx context.Context, actorID uuid.UUID, orderID, status, note string) (*OrderResponse, error) {
+	if !domain.IsValidOrderStatus(status) {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order status"}
+	}
+	if status == domain.OrderStatusRefunded || status == domain.OrderStatusPartiallyRefunded {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "use the refund endpoint to refund an order"}
+	}
+	return s.changeStatus(ctx, actorID, orderID, note, func(string) (string, error) {
+		return status, nil
+	})
+}
+
+// AdvanceStatus moves any user's order to the next step of the normal
+// lifecycle (e.g. confirmed → baking). Pending card orders can't be
+// advanced; they wait for their payment. A partially refunded order moves
+// on from the status it was refunded in.
+func (s *OrderService) AdvanceStatus(ctx context.Context, actorID uuid.UUID, orderID, note string) (*OrderResponse, error) {
+	return s.changeStatus(ctx, actorID, orderID, note, func(current string) (string, error) {
+		next, ok := domain.NextOrderStatus(current)
+		if !ok {
+			return "", &domain.AppError{
+				Err:     domain.ErrConflict,
+				Message: fmt.Sprintf("order is %s and can't be advanced", current),
+			}
+		}
+		return next, nil
+	})
+}
+
+// changeStatus locks the order, asks target for the status to move to from
+// where the order stands in the lifecycle, and applies the transition in
+// one transaction.
+func (s *OrderService) changeStatus(ctx context.Context, actorID uuid.UUID, orderID, note string, target func(current string) (string, error)) (*OrderResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order id"}
+	}
+
+	var (
+		order  db.Order
+		refund *reservedRefund
+	)
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		current, err := qtx.GetOrderByIDForUpdate(ctx, oid)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get order: %w", err)
+		}
+
+		from, err := lifecycleStatus(ctx, qtx, current)
+		if err != nil {
+			return err
+		}
+		to, err := target(from)
+		if err != nil {
+			return err
+		}
+		if err := checkStaffTransition(current.PaymentMethod, from, to); err != nil {
+			return err
+		}
+		// The rest of a partially refunded order carries on from the
+		// status it was refunded in.
+		if from != current.Status && !domain.CanTransitionOrder(from, to) {
+			return &domain.AppError{
+				Err:     domain.ErrConflict,
+				Message: fmt.Sprintf("partially refunded order can't move from %s to %s", from, to),
+			}
+		}
+
+		if to == domain.OrderStatusCancelled {
+			order, refund, err = cancelOrder(ctx, qtx, current, actorID, note)
+			return err
+		}
+		order, err = transitionOrder(ctx, qtx, current, to, actorID, note)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if refund != nil {
+		if order, err = s.refundCancelledOrder(ctx, actorID, *refund); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.orderDetails(ctx, order)
 }
 
 // ─── Helpers ──────────────────────────────────────────────────────────────────
 
+// noLongerAvailable rejects a checkout whose cart has a line of a product
+// or variant that has been withdrawn; the cart flags it for removal.
+func noLongerAvailable(productName string) error {
+	return &domain.AppError{
+		Err:     domain.ErrConflict,
+		Message: fmt.Sprintf("'%s' is no longer available, remove it from your cart", productName),
+	}
+}
+
 func validateOrderInput(in CreateOrderInput) error {
 	if in.DeliveryAddress == "" {
 		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "delivery address is required"}
@@ -293,44 +750,236 @@ func validateOrderInput(in CreateOrderInput) error {
 	if in.DeliveryDate.IsZero() || in.DeliveryDate.Before(time.Now()) {
 		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "delivery date must be in the future"}
 	}
+	return validatePaymentMethod(in.PaymentMethod)
+}
+
+// transitionOrder moves an order that is locked by the caller's transaction
+// to a new status and appends the change to its history.
+func transitionOrder(ctx context.Context, qtx *db.Queries, order db.Order, to string, actorID uuid.UUID, note string) (db.Order, error) {
+	if !domain.CanTransitionOrder(order.Status, to) {
+		return db.Order{}, &domain.AppError{
+			Err:     domain.ErrConflict,
+			Message: fmt.Sprintf("order can't move from %s to %s", order.Status, to),
+		}
+	}
+
+	updated, err := qtx.UpdateOrderStatus(ctx, order.ID, to)
+	if err != nil {
+		return db.Order{}, fmt.Errorf("update order status: %w", err)
+	}
+
+	notes := pgtype.Text{}
+	if note != "" {
+		notes = pgtype.Text{String: note, Valid: true}
+	}
+	if _, err := qtx.CreateOrderStatusHistory(ctx, db.CreateOrderStatusHistoryParams{
+		OrderID:    order.ID,
+		FromStatus: pgtype.Text{String: order.Status, Valid: true},
+		ToStatus:   to,
+		ActorID:    pgtype.UUID{Bytes: actorID, Valid: actorID != uuid.Nil},
+		Note:       notes,
+	}); err != nil {
+		return db.Order{}, fmt.Errorf("record order status: %w", err)
+	}
+
+	return updated, nil
+}
+
+// cancelOrder cancels a locked order and gives back what it took. If its
+// card payment was already captured it also reserves a full refund, which
+// the caller settles with refundCancelledOrder once the transaction commits.
+func cancelOrder(ctx context.Context, qtx *db.Queries, current db.Order, actorID uuid.UUID, note string) (db.Order, *reservedRefund, error) {
+	order, err := transitionOrder(ctx, qtx, current, domain.OrderStatusCancelled, actorID, note)
+	if err != nil {
+		return db.Order{}, nil, err
+	}
+	if err := releaseOrder(ctx, qtx, order.ID); err != nil {
+		return db.Order{}, nil, err
+	}
+	refund, err := reserveCancelRefund(ctx, qtx, actorID, order, "order cancelled")
+	if err != nil {
+		return db.Order{}, nil, err
+	}
+	return order, refund, nil
+}
+
+// refundCancelledOrder settles the refund reserved by cancelOrder. The
+// cancellation stands either way: a refund the provider refuses stays on
+// the order as failed, for staff to retry from the refund endpoint, and one
+// it can't be reached for stays pending for the RefundSweeper.
+func (s *OrderService) refundCancelledOrder(ctx context.Context, actorID uuid.UUID, r reservedRefund) (db.Order, error) {
+	order, _, err := s.payments.settleRefund(ctx, actorID, r)
+	return order, err
+}
+
+// lifecycleStatus is where an order stands in the lifecycle: its status, or
+// for a partially refunded order the status it was refunded in.
+func lifecycleStatus(ctx context.Context, qtx *db.Queries, order db.Order) (string, error) {
+	if order.Status != domain.OrderStatusPartiallyRefunded {
+		return order.Status, nil
+	}
+	from, err := qtx.GetStatusBeforePartialRefund(ctx, order.ID)
+	if err != nil {
+		return "", fmt.Errorf("get status before refund: %w", err)
+	}
+	return from.String, nil
+}
+
+// releaseOrder gives back what a cancelled or unpaid order took: its
+// quantities return to stock and its coupon use is freed.
+func releaseOrder(ctx context.Context, qtx *db.Queries, orderID uuid.UUID) error {
+	if err := qtx.ReleaseCouponRedemption(ctx, orderID); err != nil {
+		return fmt.Errorf("release coupon use: %w", err)
+	}
+
+	items, err := qtx.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order items: %w", err)
+	}
+	for _, item := range items {
+		if err := qtx.RestoreVariantStock(ctx, db.RestoreVariantStockParams{
+			ID:       item.VariantID,
+			Quantity: item.Quantity,
+		}); err != nil {
+			return fmt.Errorf("restore stock: %w", err)
+		}
+	}
 	return nil
 }
 
-func floatToNumeric(f float64) (pgtype.Numeric, error) {
-	bf := new(big.Float).SetFloat64(f)
-	// Use 2 decimal places
-	scaled := new(big.Float).Mul(bf, new(big.Float).SetFloat64(100))
-	intVal, _ := new(big.Int).SetString(scaled.Text('f', 0), 10)
-	return pgtype.Numeric{Int: intVal, Exp: -2, Valid: true}, nil
+// orderDetails loads an order's items, promotions, payment, refunds and
+// status history for a single-order response.
+func (s *OrderService) orderDetails(ctx context.Context, order db.Order) (*OrderResponse, error) {
+	items, err := s.q.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get order items: %w", err)
+	}
+
+	history, err := s.q.ListOrderStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get order status history: %w", err)
+	}
+
+	refunds, err := s.q.ListRefundsByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get refunds: %w", err)
+	}
+
+	promotions, err := s.q.ListOrderPromotions(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get order promotions: %w", err)
+	}
+
+	resp := mapOrderResponse(order, items)
+	for _, p := range promotions {
+		resp.Promotions = append(resp.Promotions, mapOrderPromotion(p))
+	}
+	if resp.Payment, err = s.payments.latestForOrder(ctx, order.ID); err != nil {
+		return nil, err
+	}
+	for _, r := range refunds {
+		resp.Refunds = append(resp.Refunds, mapRefundResponse(r))
+	}
+	resp.StatusHistory = make([]OrderStatusChange, 0, len(history))
+	for _, h := range history {
+		c := OrderStatusChange{ToStatus: h.ToStatus, CreatedAt: h.CreatedAt}
+		if h.FromStatus.Valid {
+			c.FromStatus = &h.FromStatus.String
+		}
+		if h.ActorID.Valid {
+			id := uuid.UUID(h.ActorID.Bytes).String()
+			c.ActorID = &id
+		}
+		if h.Note.Valid {
+			c.Note = &h.Note.String
+		}
+		resp.StatusHistory = append(resp.StatusHistory, c)
+	}
+	return resp, nil
+}
+
+// checkStaffTransition reports why staff may not move an order to a status
+// the lifecycle would otherwise allow. A card order is only confirmed once
+// its payment has been captured, which the payment settling does itself.
+func checkStaffTransition(paymentMethod, from, to string) error {
+	if paymentMethod == paymentMethodCard && from == domain.OrderStatusPending && to == domain.OrderStatusConfirmed {
+		return &domain.AppError{
+			Err:     domain.ErrConflict,
+			Message: "card orders are confirmed when their payment succeeds",
+		}
+	}
+	return nil
+}
+
+// checkCustomerCancellable reports why a customer may not cancel an order in
+// the given status and delivery date, or nil if they may.
+func checkCustomerCancellable(status string, deliveryDate, now time.Time) error {
+	switch status {
+	case domain.OrderStatusPending, domain.OrderStatusConfirmed:
+	default:
+		return &domain.AppError{
+			Err:     domain.ErrConflict,
+			Message: fmt.Sprintf("order is %s and can no longer be cancelled", status),
+		}
+	}
+
+	if deliveryDate.Sub(now) < orderCancelCutoff {
+		return &domain.AppError{
+			Err:     domain.ErrConflict,
+			Message: fmt.Sprintf("orders can only be cancelled up to %d hours before delivery", int(orderCancelCutoff.Hours())),
+		}
+	}
+	return nil
 }
 
 func mapOrderResponse(o db.Order, items []db.GetOrderItemsRow) *OrderResponse {
 	resp := &OrderResponse{
-		ID:              o.ID.String(),
-		DeliveryAddress: o.DeliveryAddress,
-		DeliveryDate:    o.DeliveryDate,
-		PaymentMethod:   o.PaymentMethod,
-		Status:          o.Status,
-		TotalAmount:     numericToFloat(o.TotalAmount),
-		Items:           make([]OrderItemResponse, 0, len(items)),
-		CreatedAt:       o.CreatedAt,
+		ID:                o.ID.String(),
+		UserID:            o.UserID.String(),
+		DeliveryAddress:   o.DeliveryAddress,
+		DeliveryDate:      o.DeliveryDate,
+		PaymentMethod:     o.PaymentMethod,
+		Status:            o.Status,
+		TotalAmount:       numericToMoney(o.TotalAmount),
+		PromotionDiscount: numericToMoney(o.PromotionDiscount),
+		DiscountAmount:    numericToMoney(o.DiscountAmount),
+		DeliveryFee:       numericToMoney(o.DeliveryFee),
+		RefundedAmount:    numericToMoney(o.RefundedAmount),
+		Items:             make([]OrderItemResponse, 0, len(items)),
+		CreatedAt:         o.CreatedAt,
 	}
 	if o.Notes.Valid {
 		resp.Notes = &o.Notes.String
 	}
+	if o.CouponCode.Valid {
+		resp.CouponCode = &o.CouponCode.String
+	}
 
 	for _, item := range items {
 		oi := OrderItemResponse{
 			ID:          item.ID.String(),
 			ProductID:   item.ProductID.String(),
+			VariantID:   item.VariantID.String(),
 			ProductName: item.ProductName,
+			SKU:         item.VariantSku,
+			Options:     decodeVariantOptions(item.VariantOptions),
 			Quantity:    item.Quantity,
-			UnitPrice:   numericToFloat(item.UnitPrice),
-			TotalPrice:  numericToFloat(item.TotalPrice),
+			UnitPrice:   numericToMoney(item.UnitPrice),
+			TotalPrice:  numericToMoney(item.TotalPrice),
+
+			RefundedQuantity:     item.RefundedQuantity,
+			PersonalisationPrice: numericToMoney(item.PersonalisationPrice),
+			Allergens:            decodeOrderedAllergens(item.Allergens),
+		}
+		if oi.Allergens == nil {
+			oi.Allergens = []OrderedAllergen{}
 		}
 		if item.ProductImageUrl.Valid {
 			oi.ImageURL = &item.ProductImageUrl.String
 		}
+		if p := decodePersonalisation(item.Personalisation); !p.isZero() {
+			oi.Personalisation = &p
+		}
 		resp.Items = append(resp.Items, oi)
 	}
 	return resp
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestCheckCustomerCancellable(t *testing.T) {
+	now := time.Date(2024, 12, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		status   string
+		delivery time.Time
+		wantErr  bool
+	}{
+		{"pending, days ahead", domain.OrderStatusPending, now.Add(72 * time.Hour), false},
+		{"confirmed, exactly at cutoff", domain.OrderStatusConfirmed, now.Add(24 * time.Hour), false},
+		{"pending, inside cutoff", domain.OrderStatusPending, now.Add(23 * time.Hour), true},
+		{"baking", domain.OrderStatusBaking, now.Add(72 * time.Hour), true},
+		{"delivered", domain.OrderStatusDelivered, now.Add(-time.Hour), true},
+		{"already cancelled", domain.OrderStatusCancelled, now.Add(72 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.CheckCustomerCancellable(tt.status, tt.delivery, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckCustomerCancellable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefundsOnCancel(t *testing.T) {
+	tests := []struct {
+		method, paymentStatus string
+		want                  bool
+	}{
+		{"card", "succeeded", true},
+		{"card", "processing", false},
+		{"card", "failed", false},
+		{"cash_on_delivery", "succeeded", false},
+	}
+
+	for _, tt := range tests {
+		if got := service.RefundsOnCancel(tt.method, tt.paymentStatus); got != tt.want {
+			t.Errorf("RefundsOnCancel(%q, %q) = %v, want %v", tt.method, tt.paymentStatus, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStaffTransition(t *testing.T) {
+	tests := []struct {
+		method, from, to string
+		wantErr          bool
+	}{
+		{"card", domain.OrderStatusPending, domain.OrderStatusConfirmed, true},
+		{"card", domain.OrderStatusPending, domain.OrderStatusCancelled, false},
+		{"card", domain.OrderStatusConfirmed, domain.OrderStatusBaking, false},
+		{"cash_on_delivery", domain.OrderStatusPending, domain.OrderStatusConfirmed, false},
+	}
+
+	for _, tt := range tests {
+		err := service.CheckStaffTransition(tt.method, tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckStaffTransition(%q, %q, %q) error = %v, wantErr %v", tt.method, tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/online-cake-shop/backend/internal/config"
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/email"
+	"github.com/online-cake-shop/backend/internal/payment"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Payment methods accepted on orders.
+const (
+	paymentMethodCashOnDelivery = "cash_on_delivery"
+	paymentMethodCard           = "card"
+)
+
+// Payment row statuses. Besides the provider statuses, a payment is
+// "pending" between being recorded and reaching the provider.
+const paymentStatusPending = "pending"
+
+// PaymentService takes card payments for orders through the configured
+// payment.Provider and keeps the payments table in step with it. It also
+// settles refunds, so a payment captured for a cancelled order can be
+// given back as soon as it's reported.
+type PaymentService struct {
+	pool      *pgxpool.Pool
+	q         *db.Queries
+	providers *payment.Registry
+	emailSvc  email.Sender
+	provider  string
+	currency  string
+}
+
+func NewPaymentService(pool *pgxpool.Pool, q *db.Queries, providers *payment.Registry, emailSvc email.Sender, cfg config.PaymentConfig) *PaymentService {
+	return &PaymentService{
+		pool:      pool,
+		q:         q,
+		providers: providers,
+		emailSvc:  emailSvc,
+		provider:  cfg.Provider,
+		currency:  cfg.Currency,
+	}
+}
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+type PaymentResponse struct {
+	ID            string       `json:"id"`
+	Provider      string       `json:"provider"`
+	Status        string       `json:"status"`
+	Amount        domain.Money `json:"amount"`
+	Currency      string       `json:"currency"`
+	FailureReason *string      `json:"failure_reason,omitempty"`
+}
+
+// ─── Create / Process ─────────────────────────────────────────────────────────
+
+// createPending records a card payment for a new order. It runs inside the
+// order's transaction; the provider is only contacted once that commits.
+func (s *PaymentService) createPending(ctx context.Context, qtx *db.Queries, orderID uuid.UUID, amount domain.Money) (db.Payment, error) {
+	p, err := qtx.CreatePayment(ctx, db.CreatePaymentParams{
+		OrderID:  orderID,
+		Provider: s.provider,
+		Amount:   moneyToNumeric(amount),
+		Currency: s.currency,
+	})
+	if err != nil {
+		return db.Payment{}, fmt.Errorf("create payment: %w", err)
+	}
+	return p, nil
+}
+
+// process charges a pending payment: it creates an intent with the provider
+// and captures it straight away if the card was authorised. Declines are
+// recorded on the payment, not returned as errors. Final outcomes confirm or
+// fail the order just as the matching webhook would.
+func (s *PaymentService) process(ctx context.Context, p db.Payment, token string) (db.Payment, error) {
+	provider, err := s.providers.Get(p.Provider)
+	if err != nil {
+		return p, err
+	}
+
+	amount := numericToMoney(p.Amount)
+	intent, err := provider.CreateIntent(ctx, payment.CreateIntentParams{
+		Reference: p.ID.String(),
+		Amount:    amount,
+		Currency:  p.Currency,
+		Token:     token,
+	})
+	if err != nil {
+		return p, fmt.Errorf("create payment intent: %w", err)
+	}
+
+	if p, err = s.settle(ctx, p.ID, intent); err != nil {
+		return p, err
+	}
+
+	if intent.Status == payment.StatusRequiresCapture {
+		captured, err := provider.Capture(ctx, intent.ID, amount)
+		if err != nil {
+			return p, fmt.Errorf("capture payment: %w", err)
+		}
+		return s.settle(ctx, p.ID, captured)
+	}
+	return p, nil
+}
+
+// settle records the provider's view of an intent on its payment.
+func (s *PaymentService) settle(ctx context.Context, paymentID uuid.UUID, intent *payment.Intent) (db.Payment, error) {
+	var (
+		p      db.Payment
+		refund *reservedRefund
+	)
+	err := pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		locked, err := qtx.GetPaymentByIDForUpdate(ctx, paymentID)
+		if err != nil {
+			return fmt.Errorf("get payment: %w", err)
+		}
+		p, _, refund, err = applyPaymentStatus(ctx, qtx, locked, intent.ID, intent.Status, intent.FailureReason)
+		return err
+	})
+	if err != nil {
+		return p, err
+	}
+	s.refundLatePayment(ctx, refund)
+	return p, nil
+}
+
+// unprocessedIntent is the outcome recorded for a payment the provider
+// couldn't process: it fails, keeping whatever intent it already had.
+func unprocessedIntent(p db.Payment) *payment.Intent {
+	return &payment.Intent{
+		ID:            p.ProviderIntentID.String,
+		Status:        payment.StatusFailed,
+		Amount:        numericToMoney(p.Amount),
+		FailureReason: "the payment could not be processed",
+	}
+}
+
+// ─── Webhooks ─────────────────────────────────────────────────────────────────
+
+// Outcomes recorded on stored webhook events.
+const (
+	eventOutcomeApplied        = "applied"
+	eventOutcomeStale          = "stale"
+	eventOutcomeUnmatched      = "unmatched"
+	eventOutcomeAmountMismatch = "amount_mismatch"
+	eventOutcomeUnsupported    = "unsupported"
+)
+
+// HandleWebhook verifies and applies a webhook delivery from the named
+// provider. Deliveries are stored before they're applied and deduplicated on
+// the provider's event id, so replays are acknowledged without effect.
+// Payments only ever move forward, so events arriving out of order can't
+// undo a final outcome.
+func (s *PaymentService) HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrNotFound, Message: "unknown payment provider"}
+	}
+
+	event, err := provider.VerifyWebhook(payload, header)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			return &domain.AppError{Err: domain.ErrUnauthorized, Message: "invalid webhook signature"}
+		}
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid webhook payload"}
+	}
+
+	var refund *reservedRefund
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		stored, err := qtx.CreatePaymentEvent(ctx, db.CreatePaymentEventParams{
+			Provider:   providerName,
+			EventID:    event.ID,
+			EventType:  string(event.Type),
+			Payload:    payload,
+			OccurredAt: pgtype.Timestamptz{Time: event.CreatedAt, Valid: !event.CreatedAt.IsZero()},
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				slog.Info("duplicate payment webhook", "provider", providerName, "event_id", event.ID)
+				return nil
+			}
+			return fmt.Errorf("store payment event: %w", err)
+		}
+
+		paymentID, outcome, r, err := applyPaymentEvent(ctx, qtx, providerName, event)
+		if err != nil {
+			return err
+		}
+		if outcome != eventOutcomeApplied {
+			slog.Warn("payment webhook not applied",
+				"provider", providerName, "event_id", event.ID, "outcome", outcome)
+		}
+
+		if err := qtx.MarkPaymentEventProcessed(ctx, db.MarkPaymentEventProcessedParams{
+			ID:        stored.ID,
+			PaymentID: paymentID,
+			Outcome:   outcome,
+		}); err != nil {
+			return fmt.Errorf("mark payment event: %w", err)
+		}
+		refund = r
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.refundLatePayment(ctx, refund)
+	return nil
+}
+
+// refundLatePayment gives back a payment that was captured after its order
+// had been cancelled, reserved as refund when the payment was settled. The
+// webhook or payment has been recorded by then, so failures are only
+// logged; a refused refund stays on the order as failed for staff to retry,
+// and one the provider can't be reached for is left to the RefundSweeper.
+func (s *PaymentService) refundLatePayment(ctx context.Context, refund *reservedRefund) {
+	if refund == nil {
+		return
+	}
+	if _, _, err := s.settleRefund(ctx, uuid.Nil, *refund); err != nil {
+		slog.Error("refund payment for cancelled order", "order_id", refund.refund.OrderID, "refund_id", refund.refund.ID, "error", err)
+	}
+}
+
+// applyPaymentEvent finds and locks the payment an event is about and
+// applies it, reporting what happened and any refund it reserved.
+func applyPaymentEvent(ctx context.Context, qtx *db.Queries, providerName string, event *payment.Event) (pgtype.UUID, string, *reservedRefund, error) {
+	var status payment.Status
+	switch event.Type {
+	case payment.EventPaymentSucceeded:
+		status = payment.StatusSucceeded
+	case payment.EventPaymentFailed:
+		status = payment.StatusFailed
+	default:
+		return pgtype.UUID{}, eventOutcomeUnsupported, nil, nil
+	}
+
+	p, err := findPaymentForEvent(ctx, qtx, providerName, event)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgtype.UUID{}, eventOutcomeUnmatched, nil, nil
+		}
+		return pgtype.UUID{}, "", nil, err
+	}
+	paymentID := pgtype.UUID{Bytes: p.ID, Valid: true}
+
+	if status == payment.StatusSucceeded && event.Amount != numericToMoney(p.Amount) {
+		return paymentID, eventOutcomeAmountMismatch, nil, nil
+	}
+
+	_, applied, refund, err := applyPaymentStatus(ctx, qtx, p, event.IntentID, status, event.FailureReason)
+	if err != nil {
+		return paymentID, "", nil, err
+	}
+	if !applied {
+		return paymentID, eventOutcomeStale, nil, nil
+	}
+	return paymentID, eventOutcomeApplied, refund, nil
+}
+
+// findPaymentForEvent locks the payment an event refers to, preferring our
+// own reference so that events racing ahead of the intent id being stored
+// still match.
+func findPaymentForEvent(ctx context.Context, qtx *db.Queries, providerName string, event *payment.Event) (db.Payment, error) {
+	if id, err := uuid.Parse(event.Reference); err == nil {
+		p, err := qtx.GetPaymentByIDForUpdate(ctx, id)
+		if err == nil && p.Provider == providerName {
+			return p, nil
+		}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return db.Payment{}, fmt.Errorf("get payment: %w", err)
+		}
+	}
+
+	p, err := qtx.GetPaymentByProviderIntentForUpdate(ctx, providerName, event.IntentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Payment{}, err
+		}
+		return db.Payment{}, fmt.Errorf("get payment: %w", err)
+	}
+	return p, nil
+}
+
+// applyPaymentStatus moves a payment locked by the caller's transaction to
+// a new status, and confirms or fails its order once the outcome is final.
+// It reports false, changing nothing, if the payment has already moved on:
+// a final outcome is never replaced and an earlier stage never overwrites a
+// later one. The returned refund, if any, must be settled once the caller's
+// transaction commits.
+func applyPaymentStatus(ctx context.Context, qtx *db.Queries, p db.Payment, intentID string, status payment.Status, failureReason string) (db.Payment, bool, *reservedRefund, error) {
+	if !canUpdatePayment(p.Status, string(status)) {
+		return p, false, nil, nil
+	}
+
+	failure := pgtype.Text{}
+	if status == payment.StatusFailed {
+		failure = pgtype.Text{String: failureReason, Valid: failureReason != ""}
+	}
+	intent := p.ProviderIntentID
+	if intentID != "" {
+		intent = pgtype.Text{String: intentID, Valid: true}
+	}
+
+	updated, err := qtx.UpdatePaymentIntent(ctx, db.UpdatePaymentIntentParams{
+		ID:               p.ID,
+		ProviderIntentID: intent,
+		Status:           string(status),
+		FailureReason:    failure,
+	})
+	if err != nil {
+		return p, false, nil, fmt.Errorf("update payment: %w", err)
+	}
+
+	var refund *reservedRefund
+	if isFinalPaymentStatus(updated.Status) {
+		if refund, err = settleOrderPayment(ctx, qtx, updated); err != nil {
+			return p, false, nil, err
+		}
+	}
+	return updated, true, refund, nil
+}
+
+// settleOrderPayment confirms a pending order whose payment succeeded, or
+// moves it to payment_failed and releases its stock and coupon use if the
+// payment failed. An order cancelled while its payment was processing gets
+// a full refund reserved, to be settled once the transaction commits; other
+// orders that have already left pending are left alone.
+func settleOrderPayment(ctx context.Context, qtx *db.Queries, p db.Payment) (*reservedRefund, error) {
+	order, err := qtx.GetOrderByIDForUpdate(ctx, p.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+	if order.Status == domain.OrderStatusCancelled && p.Status == string(payment.StatusSucceeded) {
+		slog.Info("refunding payment captured after the order was cancelled", "order_id", order.ID, "payment_id", p.ID)
+		return reserveCancelRefund(ctx, qtx, uuid.Nil, order, "payment received after the order was cancelled")
+	}
+	if order.Status != domain.OrderStatusPending {
+		slog.Warn("payment settled for an order that is no longer pending",
+			"order_id", order.ID, "order_status", order.Status, "payment_id", p.ID, "payment_status", p.Status)
+		return nil, nil
+	}
+
+	if p.Status == string(payment.StatusSucceeded) {
+		_, err := transitionOrder(ctx, qtx, order, domain.OrderStatusConfirmed, uuid.Nil, "payment succeeded")
+		return nil, err
+	}
+
+	note := "payment failed"
+	if p.FailureReason.Valid {
+		note += ": " + p.FailureReason.String
+	}
+	if _, err := transitionOrder(ctx, qtx, order, domain.OrderStatusPaymentFailed, uuid.Nil, note); err != nil {
+		return nil, err
+	}
+	return nil, releaseOrder(ctx, qtx, order.ID)
+}
+
+// ─── Refund ───────────────────────────────────────────────────────────────────
+
+// capturedPayment returns an order's payment if it has succeeded, locked by
+// the caller's transaction.
+func capturedPayment(ctx context.Context, qtx *db.Queries, orderID uuid.UUID) (db.Payment, error) {
+	latest, err := qtx.GetLatestPaymentByOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Payment{}, &domain.AppError{Err: domain.ErrConflict, Message: "order has no captured payment to refund"}
+		}
+		return db.Payment{}, fmt.Errorf("get payment: %w", err)
+	}
+	p, err := qtx.GetPaymentByIDForUpdate(ctx, latest.ID)
+	if err != nil {
+		return db.Payment{}, fmt.Errorf("get payment: %w", err)
+	}
+	if p.Status != string(payment.StatusSucceeded) {
+		return db.Payment{}, &domain.AppError{Err: domain.ErrConflict, Message: "order has no captured payment to refund"}
+	}
+	return p, nil
+}
+
+// refund returns part or all of a captured payment. reference is our refund
+// id; providers deduplicate on it, so a retry never refunds twice.
+func (s *PaymentService) refund(ctx context.Context, p db.Payment, amount domain.Money, reference string) (*payment.Refund, error) {
+	provider, err := s.providers.Get(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if !p.ProviderIntentID.Valid {
+		return nil, fmt.Errorf("payment %s has no provider intent", p.ID)
+	}
+	r, err := provider.Refund(ctx, p.ProviderIntentID.String, amount, reference)
+	if err != nil {
+		return nil, fmt.Errorf("refund payment: %w", err)
+	}
+	return r, nil
+}
+
+// ─── Get ──────────────────────────────────────────────────────────────────────
+
+// latestForOrder returns the most recent payment for an order, or nil if
+// the order isn't paid by card.
+func (s *PaymentService) latestForOrder(ctx context.Context, orderID uuid.UUID) (*PaymentResponse, error) {
+	p, err := s.q.GetLatestPaymentByOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+	return mapPaymentResponse(p), nil
+}
+
+// ─── Development ──────────────────────────────────────────────────────────────
+
+// SettleMockPayment resolves an order's processing mock payment, as the bank
+// eventually would, and applies the signed webhook the mock gateway produces
+// for it, exactly as if it had been delivered. It stands in for the gateway
+// in development, where nothing else would ever settle a tok_async payment.
+func (s *PaymentService) SettleMockPayment(ctx context.Context, orderID string, succeed bool) (*PaymentResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order id"}
+	}
+
+	p, err := s.q.GetLatestPaymentByOrderID(ctx, oid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+	if p.Provider != payment.MockProviderName || !p.ProviderIntentID.Valid {
+		return nil, &domain.AppError{Err: domain.ErrConflict, Message: "order has no mock payment to settle"}
+	}
+
+	provider, err := s.providers.Get(payment.MockProviderName)
+	if err != nil {
+		return nil, err
+	}
+	mock, ok := provider.(*payment.MockProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %q is not the mock provider", payment.MockProviderName)
+	}
+	payload, header, err := mock.Settle(p.ProviderIntentID.String, succeed)
+	if err != nil {
+		if errors.Is(err, payment.ErrIntentNotFound) {
+			return nil, &domain.AppError{Err: domain.ErrConflict, Message: "the mock gateway has lost this payment; it keeps payments in memory until restarted"}
+		}
+		return nil, &domain.AppError{Err: domain.ErrConflict, Message: "payment is no longer processing"}
+	}
+
+	if err := s.HandleWebhook(ctx, payment.MockProviderName, payload, header); err != nil {
+		return nil, err
+	}
+	return s.latestForOrder(ctx, oid)
+}
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+// paymentStage orders payment statuses so that updates only move forward.
+func paymentStage(status string) int {
+	switch payment.Status(status) {
+	case payment.StatusRequiresCapture, payment.StatusProcessing:
+		return 1
+	case payment.StatusSucceeded, payment.StatusFailed:
+		return 2
+	}
+	return 0 // pending
+}
+
+func isFinalPaymentStatus(status string) bool {
+	return paymentStage(status) == 2
+}
+
+// canUpdatePayment reports whether a payment may move from one status to
+// another: never out of a final status, and never back to an earlier stage.
+func canUpdatePayment(from, to string) bool {
+	return !isFinalPaymentStatus(from) && paymentStage(to) >= paymentStage(from)
+}
+
+// formatAmount formats an amount in the shop's currency, e.g. "USD 12.50".
+func (s *PaymentService) formatAmount(m domain.Money) string {
+	return s.currency + " " + m.String()
+}
+
+func validatePaymentMethod(method string) error {
+	switch method {
+	case paymentMethodCashOnDelivery, paymentMethodCard:
+		return nil
+	}
+	return &domain.AppError{Err: domain.ErrInvalidInput, Message: "payment_method must be cash_on_delivery or card"}
+}
+
+func mapPaymentResponse(p db.Payment) *PaymentResponse {
+	resp := &PaymentResponse{
+		ID:       p.ID.String(),
+		Provider: p.Provider,
+		Status:   p.Status,
+		Amount:   numericToMoney(p.Amount),
+		Currency: p.Currency,
+	}
+	if p.FailureReason.Valid {
+		resp.FailureReason = &p.FailureReason.String
+	}
+	return resp
+}
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/payment"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestCanUpdatePayment(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"pending", "requires_capture", true},
+		{"pending", "processing", true},
+		{"pending", "failed", true},
+		{"requires_capture", "succeeded", true},
+		{"processing", "succeeded", true},
+		{"processing", "failed", true},
+		{"processing", "processing", true},
+
+		// Late or replayed events must not undo an outcome.
+		{"succeeded", "failed", false},
+		{"failed", "succeeded", false},
+		{"succeeded", "succeeded", false},
+		{"processing", "pending", false},
+		{"succeeded", "processing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := service.CanUpdatePayment(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanUpdatePayment(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnprocessedIntent(t *testing.T) {
+	tests := []struct {
+		name   string
+		p      db.Payment
+		wantID string
+	}{
+		{"before the intent was created", db.Payment{Status: "pending"}, ""},
+		{"after the intent was created", db.Payment{
+			Status:           "requires_capture",
+			ProviderIntentID: pgtype.Text{String: "pi_1", Valid: true},
+		}, "pi_1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intent := service.UnprocessedIntent(tt.p)
+			if intent.Status != payment.StatusFailed {
+				t.Errorf("status = %s, want %s", intent.Status, payment.StatusFailed)
+			}
+			if intent.FailureReason == "" {
+				t.Error("failure reason is empty")
+			}
+			if intent.ID != tt.wantID {
+				t.Errorf("intent id = %q, want %q", intent.ID, tt.wantID)
+			}
+			if !service.CanUpdatePayment(tt.p.Status, string(intent.Status)) {
+				t.Errorf("a %s payment can't be failed", tt.p.Status)
+			}
+		})
+	}
+}
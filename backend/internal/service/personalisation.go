@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+)
+
+// Cakes can be personalised with an inscription, candles, a topper and a gift
+// box. Each product says which of these it offers and what they cost; the
+// surcharge is per cake and is added to the unit price of the cart or order
+// line.
+
+const (
+	maxInscriptionLength = 100
+	maxCandles           = 100
+	maxTopperChoices     = 20
+	maxTopperNameLength  = 50
+)
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+// PersonalisationOptions is what a product can be personalised with. Nil
+// fields aren't offered.
+type PersonalisationOptions struct {
+	Inscription *InscriptionOption `json:"inscription,omitempty"`
+	Candles     *CandlesOption     `json:"candles,omitempty"`
+	Topper      *TopperOption      `json:"topper,omitempty"`
+	GiftBox     *GiftBoxOption     `json:"gift_box,omitempty"`
+}
+
+type InscriptionOption struct {
+	MaxLength int          `json:"max_length"`
+	Price     domain.Money `json:"price"`
+}
+
+type CandlesOption struct {
+	Max       int32        `json:"max"`
+	PriceEach domain.Money `json:"price_each"`
+}
+
+type TopperOption struct {
+	Choices []TopperChoice `json:"choices"`
+}
+
+type TopperChoice struct {
+	Name  string       `json:"name"`
+	Price domain.Money `json:"price"`
+}
+
+type GiftBoxOption struct {
+	Price domain.Money `json:"price"`
+}
+
+// Personalisation is a customer's choice for one cart or order line. The
+// zero value is an unpersonalised cake.
+type Personalisation struct {
+	Inscription string `json:"inscription,omitempty"`
+	Candles     int32  `json:"candles,omitempty"`
+	Topper      string `json:"topper,omitempty"`
+	GiftBox     bool   `json:"gift_box,omitempty"`
+}
+
+func (p Personalisation) isZero() bool {
+	return p == Personalisation{}
+}
+
+// ─── Validation ───────────────────────────────────────────────────────────────
+
+// validatePersonalisationOptions checks a product's options as set by an
+// admin, trimming topper names.
+func validatePersonalisationOptions(o PersonalisationOptions) (PersonalisationOptions, error) {
+	invalid := func(msg string) (PersonalisationOptions, error) {
+		return PersonalisationOptions{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: msg}
+	}
+
+	var prices []domain.Money
+	if o.Inscription != nil {
+		if o.Inscription.MaxLength < 1 || o.Inscription.MaxLength > maxInscriptionLength {
+			return invalid(fmt.Sprintf("inscription max_length must be between 1 and %d", maxInscriptionLength))
+		}
+		prices = append(prices, o.Inscription.Price)
+	}
+	if o.Candles != nil {
+		if o.Candles.Max < 1 || o.Candles.Max > maxCandles {
+			return invalid(fmt.Sprintf("candles max must be between 1 and %d", maxCandles))
+		}
+		prices = append(prices, o.Candles.PriceEach)
+	}
+	if o.Topper != nil {
+		if len(o.Topper.Choices) == 0 || len(o.Topper.Choices) > maxTopperChoices {
+			return invalid(fmt.Sprintf("a topper needs between 1 and %d choices", maxTopperChoices))
+		}
+		choices := make([]TopperChoice, 0, len(o.Topper.Choices))
+		seen := make(map[string]bool, len(o.Topper.Choices))
+		for _, c := range o.Topper.Choices {
+			c.Name = strings.TrimSpace(c.Name)
+			if c.Name == "" || len(c.Name) > maxTopperNameLength {
+				return invalid(fmt.Sprintf("topper names must be 1 to %d characters", maxTopperNameLength))
+			}
+			if seen[strings.ToLower(c.Name)] {
+				return invalid(fmt.Sprintf("topper %q is given twice", c.Name))
+			}
+			seen[strings.ToLower(c.Name)] = true
+			choices = append(choices, c)
+			prices = append(prices, c.Price)
+		}
+		o.Topper = &TopperOption{Choices: choices}
+	}
+	if o.GiftBox != nil {
+		prices = append(prices, o.GiftBox.Price)
+	}
+
+	for _, p := range prices {
+		if p.IsNegative() {
+			return invalid("personalisation prices must not be negative")
+		}
+		if p > maxStoredAmount {
+			return invalid("personalisation price is too large")
+		}
+	}
+	return o, nil
+}
+
+// checkPersonalisation validates a customer's personalisation against what
+// the product offers. The inscription and topper are trimmed, and the topper
+// takes the spelling of the matching choice.
+func checkPersonalisation(o PersonalisationOptions, p Personalisation) (Personalisation, error) {
+	invalid := func(msg string) (Personalisation, error) {
+		return Personalisation{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: msg}
+	}
+
+	p.Inscription = strings.TrimSpace(p.Inscription)
+	if p.Inscription != "" {
+		if o.Inscription == nil {
+			return invalid("this cake can't have an inscription")
+		}
+		if n := utf8.RuneCountInString(p.Inscription); n > o.Inscription.MaxLength {
+			return invalid(fmt.Sprintf("inscription must be at most %d characters", o.Inscription.MaxLength))
+		}
+		for _, r := range p.Inscription {
+			if unicode.IsControl(r) || r == utf8.RuneError {
+				return invalid("inscription must be a single line of text")
+			}
+		}
+	}
+
+	if p.Candles < 0 {
+		return invalid("candles must not be negative")
+	}
+	if p.Candles > 0 {
+		if o.Candles == nil {
+			return invalid("this cake doesn't come with candles")
+		}
+		if p.Candles > o.Candles.Max {
+			return invalid(fmt.Sprintf("at most %d candles", o.Candles.Max))
+		}
+	}
+
+	p.Topper = strings.TrimSpace(p.Topper)
+	if p.Topper != "" {
+		choice, ok := o.topperChoice(p.Topper)
+		if !ok {
+			return invalid(fmt.Sprintf("topper %q isn't available for this cake", p.Topper))
+		}
+		p.Topper = choice.Name
+	}
+
+	if p.GiftBox && o.GiftBox == nil {
+		return invalid("this cake can't be gift boxed")
+	}
+	return p, nil
+}
+
+// personalisationPrice is the per-cake surcharge for p. Choices the product
+// no longer offers cost nothing; checkPersonalisation rejects them.
+func personalisationPrice(o PersonalisationOptions, p Personalisation) domain.Money {
+	var price domain.Money
+	if p.Inscription != "" && o.Inscription != nil {
+		price = price.Add(o.Inscription.Price)
+	}
+	if p.Candles > 0 && o.Candles != nil {
+		price = price.Add(o.Candles.PriceEach.Mul(int64(p.Candles)))
+	}
+	if p.Topper != "" {
+		if choice, ok := o.topperChoice(p.Topper); ok {
+			price = price.Add(choice.Price)
+		}
+	}
+	if p.GiftBox && o.GiftBox != nil {
+		price = price.Add(o.GiftBox.Price)
+	}
+	return price
+}
+
+func (o PersonalisationOptions) topperChoice(name string) (TopperChoice, bool) {
+	if o.Topper == nil {
+		return TopperChoice{}, false
+	}
+	for _, c := range o.Topper.Choices {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return TopperChoice{}, false
+}
+
+// ─── Encoding ─────────────────────────────────────────────────────────────────
+
+// The JSONB columns are never NULL, so both encoders write "{}" for nothing.
+
+func encodePersonalisationOptions(o PersonalisationOptions) []byte {
+	b, _ := json.Marshal(o)
+	return b
+}
+
+// decodePersonalisationOptions reads a product's options. Anything
+// unreadable is treated as offering nothing.
+func decodePersonalisationOptions(b []byte) PersonalisationOptions {
+	var o PersonalisationOptions
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, &o)
+	}
+	return o
+}
+
+func encodePersonalisation(p Personalisation) []byte {
+	b, _ := json.Marshal(p)
+	return b
+}
+
+func decodePersonalisation(b []byte) Personalisation {
+	var p Personalisation
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, &p)
+	}
+	return p
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func birthdayOptions() service.PersonalisationOptions {
+	return service.PersonalisationOptions{
+		Inscription: &service.InscriptionOption{MaxLength: 20, Price: 300},
+		Candles:     &service.CandlesOption{Max: 10, PriceEach: 25},
+		Topper: &service.TopperOption{Choices: []service.TopperChoice{
+			{Name: "Happy Birthday", Price: 450},
+			{Name: "Unicorn", Price: 650},
+		}},
+		GiftBox: &service.GiftBoxOption{Price: 500},
+	}
+}
+
+func TestValidatePersonalisationOptions(t *testing.T) {
+	got, err := service.ValidatePersonalisationOptions(service.PersonalisationOptions{
+		Topper: &service.TopperOption{Choices: []service.TopperChoice{{Name: "  Unicorn ", Price: 650}}},
+	})
+	if err != nil {
+		t.Fatalf("ValidatePersonalisationOptions() error = %v", err)
+	}
+	if got.Topper.Choices[0].Name != "Unicorn" {
+		t.Errorf("topper name = %q, want it trimmed", got.Topper.Choices[0].Name)
+	}
+
+	if _, err := service.ValidatePersonalisationOptions(service.PersonalisationOptions{}); err != nil {
+		t.Errorf("empty options: error = %v", err)
+	}
+
+	bad := map[string]service.PersonalisationOptions{
+		"zero inscription length": {Inscription: &service.InscriptionOption{MaxLength: 0}},
+		"long inscription":        {Inscription: &service.InscriptionOption{MaxLength: 101}},
+		"negative price":          {Inscription: &service.InscriptionOption{MaxLength: 20, Price: -1}},
+		"no candles":              {Candles: &service.CandlesOption{Max: 0}},
+		"no topper choices":       {Topper: &service.TopperOption{}},
+		"duplicate topper": {Topper: &service.TopperOption{Choices: []service.TopperChoice{
+			{Name: "Unicorn"}, {Name: "unicorn"},
+		}}},
+		"blank topper":      {Topper: &service.TopperOption{Choices: []service.TopperChoice{{Name: " "}}}},
+		"huge gift box fee": {GiftBox: &service.GiftBoxOption{Price: domain.Money(1_000_000_000_00)}},
+	}
+	for name, o := range bad {
+		if _, err := service.ValidatePersonalisationOptions(o); err == nil {
+			t.Errorf("%s: ValidatePersonalisationOptions() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestCheckPersonalisation(t *testing.T) {
+	got, err := service.CheckPersonalisation(birthdayOptions(), service.Personalisation{
+		Inscription: "  Happy Birthday Sára ",
+		Candles:     7,
+		Topper:      "unicorn",
+		GiftBox:     true,
+	})
+	if err != nil {
+		t.Fatalf("CheckPersonalisation() error = %v", err)
+	}
+	want := service.Personalisation{Inscription: "Happy Birthday Sára", Candles: 7, Topper: "Unicorn", GiftBox: true}
+	if got != want {
+		t.Errorf("CheckPersonalisation() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		options service.PersonalisationOptions
+		p       service.Personalisation
+	}{
+		{"inscription too long", birthdayOptions(), service.Personalisation{Inscription: strings.Repeat("é", 21)}},
+		{"multi-line inscription", birthdayOptions(), service.Personalisation{Inscription: "Happy\nBirthday"}},
+		{"too many candles", birthdayOptions(), service.Personalisation{Candles: 11}},
+		{"negative candles", birthdayOptions(), service.Personalisation{Candles: -1}},
+		{"unknown topper", birthdayOptions(), service.Personalisation{Topper: "Dragon"}},
+		{"inscription not offered", service.PersonalisationOptions{}, service.Personalisation{Inscription: "Hi"}},
+		{"candles not offered", service.PersonalisationOptions{}, service.Personalisation{Candles: 1}},
+		{"gift box not offered", service.PersonalisationOptions{}, service.Personalisation{GiftBox: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CheckPersonalisation(tt.options, tt.p); err == nil {
+				t.Errorf("CheckPersonalisation(%+v) succeeded, want an error", tt.p)
+			}
+		})
+	}
+
+	// Exactly at the limit, counted in characters rather than bytes.
+	if _, err := service.CheckPersonalisation(birthdayOptions(), service.Personalisation{Inscription: strings.Repeat("é", 20)}); err != nil {
+		t.Errorf("20-character inscription: error = %v", err)
+	}
+}
+
+func TestPersonalisationPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		p    service.Personalisation
+		want domain.Money
+	}{
+		{"none", service.Personalisation{}, 0},
+		{"inscription", service.Personalisation{Inscription: "Hi"}, 300},
+		{"candles", service.Personalisation{Candles: 7}, 175},
+		{"everything", service.Personalisation{Inscription: "Hi", Candles: 4, Topper: "Unicorn", GiftBox: true}, 300 + 100 + 650 + 500},
+		{"withdrawn topper", service.Personalisation{Topper: "Dragon"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.PersonalisationPrice(birthdayOptions(), tt.p); got != tt.want {
+				t.Errorf("PersonalisationPrice() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
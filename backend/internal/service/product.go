@@ -5,43 +5,123 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/online-cake-shop/backend/internal/config"
 	"github.com/online-cake-shop/backend/internal/domain"
 	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/storage"
 )
 
 type ProductService struct {
-	q *db.Queries
+	pool       *pgxpool.Pool
+	q          *db.Queries
+	storage    storage.Storage
+	storageCfg config.StorageConfig
 }
 
-func NewProductService(q *db.Queries) *ProductService {
-	return &ProductService{q: q}
+func NewProductService(pool *pgxpool.Pool, q *db.Queries, store storage.Storage, storageCfg config.StorageConfig) *ProductService {
+	return &ProductService{pool: pool, q: q, storage: store, storageCfg: storageCfg}
 }
 
 // ─── DTOs ────────────────────────────────────────────────────────────────────
 
 type ProductResponse struct {
-	ID            string   `json:"id"`
-	CategoryID    *string  `json:"category_id"`
-	CategoryName  *string  `json:"category_name"`
-	CategorySlug  *string  `json:"category_slug"`
-	Name          string   `json:"name"`
-	Description   *string  `json:"description"`
-	Price         float64  `json:"price"`
-	ImageURL      *string  `json:"image_url"`
-	StockQuantity int32    `json:"stock_quantity"`
-	IsActive      bool     `json:"is_active"`
+	ID           string       `json:"id"`
+	CategoryID   *string      `json:"category_id"`
+	CategoryName *string      `json:"category_name"`
+	CategorySlug *string      `json:"category_slug"`
+	Name         string       `json:"name"`
+	Description  *string      `json:"description"`
+	Price        domain.Money `json:"price"`
+	// ImageURL is the first of Images, or an image hosted elsewhere.
+	ImageURL *string        `json:"image_url"`
+	Images   []ProductImage `json:"images"`
+	// Price is the lowest price and StockQuantity the total stock of the
+	// active variants.
+	StockQuantity int32 `json:"stock_quantity"`
+	// AvailableQuantity is the stock not held by customers' carts.
+	AvailableQuantity      int32                  `json:"available_quantity"`
+	Variants               []VariantResponse      `json:"variants"`
+	PersonalisationOptions PersonalisationOptions `json:"personalisation_options"`
+	DietaryTags            []string               `json:"dietary_tags"`
+	Allergens              []ProductAllergen      `json:"allergens"`
+	IsActive               bool                   `json:"is_active"`
+	DeletedAt              *time.Time             `json:"deleted_at,omitempty"`
+	// Highlight is only set on search results.
+	Highlight *SearchHighlight `json:"highlight,omitempty"`
 }
 
+// ListProductsInput filters and pages the catalogue. A non-empty Query
+// searches it; results come best match first unless SortBy orders them by
+// price.
+//
+// MinPrice and MaxPrice bound the lowest price of a product, inclusively;
+// "" is no bound. InStock keeps products that can be added to a cart.
+// A product must have all of DietaryTags and any of Sizes, and be declared
+// free from every allergen slug in FreeFrom.
 type ListProductsInput struct {
-	CategoryID *string
-	SortBy     string
-	Page       int
-	Limit      int
+	CategoryID  *string
+	Query       string
+	SortBy      string
+	Page        int
+	Limit       int
+	MinPrice    string
+	MaxPrice    string
+	InStock     bool
+	DietaryTags []string
+	Sizes       []string
+	FreeFrom    []string
+}
+
+type AdminListProductsInput struct {
+	Status string // "", "active", "inactive" or "deleted"
+	Page   int
+	Limit  int
+}
+
+// CreateProductInput creates a product with the given variants, or with a
+// single option-less variant of Price and StockQuantity if there are none.
+type CreateProductInput struct {
+	CategoryID    *string
+	Name          string
+	Description   *string
+	Price         domain.Money
+	ImageURL      *string
+	StockQuantity int32
+	IsActive      *bool
+	Variants      []VariantInput
+	// PersonalisationOptions is what the cakes can be personalised with;
+	// nil offers nothing.
+	PersonalisationOptions *PersonalisationOptions
+	DietaryTags            []string
+	Allergens              []AllergenDeclaration
+}
+
+// UpdateProductInput is a partial update: nil fields are left unchanged.
+// For the nullable fields, an empty string clears the value. Price and
+// StockQuantity can only be set on a product with a single variant.
+type UpdateProductInput struct {
+	CategoryID    *string
+	Name          *string
+	Description   *string
+	Price         *domain.Money
+	ImageURL      *string
+	StockQuantity *int32
+	IsActive      *bool
+	// PersonalisationOptions replaces the product's options as a whole.
+	PersonalisationOptions *PersonalisationOptions
+	// DietaryTags replaces the product's tags; an empty list clears them.
+	DietaryTags *[]string
+	// Allergens replaces the product's declarations; an empty list clears
+	// them.
+	Allergens *[]AllergenDeclaration
 }
 
 type ListProductsOutput struct {
@@ -50,6 +130,8 @@ type ListProductsOutput struct {
 	Page       int               `json:"page"`
 	Limit      int               `json:"limit"`
 	TotalPages int               `json:"total_pages"`
+	// Facets is only set on the public catalogue listing.
+	Facets *ProductFacets `json:"facets,omitempty"`
 }
 
 // ─── List Products ────────────────────────────────────────────────────────────
@@ -76,6 +158,20 @@ func (s *ProductService) List(ctx context.Context, in ListProductsInput) (*ListP
 		sortBy = pgtype.Text{String: in.SortBy, Valid: true}
 	}
 
+	search := pgtype.Text{}
+	tsq, err := searchTSQuery(in.Query)
+	if err != nil {
+		return nil, err
+	}
+	if tsq != "" {
+		search = pgtype.Text{String: tsq, Valid: true}
+	}
+
+	filters, err := parseProductFilters(in)
+	if err != nil {
+		return nil, err
+	}
+
 	offset := int32((in.Page - 1) * in.Limit)
 
 	rows, err := s.q.ListProducts(ctx, db.ListProductsParams{
@@ -83,20 +179,55 @@ func (s *ProductService) List(ctx context.Context, in ListProductsInput) (*ListP
 		SortBy:     sortBy,
 		Limit:      int32(in.Limit),
 		Offset:     offset,
+		Search:     search,
+		MinPrice:   filters.minPrice,
+		MaxPrice:   filters.maxPrice,
+		InStock:    filters.inStock,
+		Tags:       filters.tags,
+		Sizes:      filters.sizes,
+		FreeFrom:   filters.freeFrom,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("list products: %w", err)
 	}
 
-	total, err := s.q.CountProducts(ctx, catID)
+	total, err := s.q.CountProducts(ctx, db.CountProductsParams{
+		CategoryID: catID,
+		Search:     search,
+		MinPrice:   filters.minPrice,
+		MaxPrice:   filters.maxPrice,
+		InStock:    filters.inStock,
+		Tags:       filters.tags,
+		Sizes:      filters.sizes,
+		FreeFrom:   filters.freeFrom,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("count products: %w", err)
 	}
 
+	facetRows, err := s.q.ProductFacets(ctx, db.ProductFacetsParams{
+		CategoryID:  catID,
+		Search:      search,
+		MinPrice:    filters.minPrice,
+		MaxPrice:    filters.maxPrice,
+		InStock:     filters.inStock,
+		Tags:        filters.tags,
+		Sizes:       filters.sizes,
+		PriceBounds: priceBucketParams(),
+		FreeFrom:    filters.freeFrom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("product facets: %w", err)
+	}
+	facets := buildFacets(facetRows)
+
 	products := make([]ProductResponse, 0, len(rows))
 	for _, r := range rows {
 		products = append(products, mapListProductRow(r))
 	}
+	if err := s.attachDetails(ctx, products, false); err != nil {
+		return nil, err
+	}
 
 	totalPages := int(total) / in.Limit
 	if int(total)%in.Limit > 0 {
@@ -109,6 +240,7 @@ func (s *ProductService) List(ctx context.Context, in ListProductsInput) (*ListP
 		Page:       in.Page,
 		Limit:      in.Limit,
 		TotalPages: totalPages,
+		Facets:     &facets,
 	}, nil
 }
 
@@ -128,8 +260,11 @@ func (s *ProductService) GetByID(ctx context.Context, id string) (*ProductRespon
 		return nil, fmt.Errorf("get product: %w", err)
 	}
 
-	resp := mapGetProductRow(row)
-	return &resp, nil
+	products := []ProductResponse{mapGetProductRow(row)}
+	if err := s.attachDetails(ctx, products, false); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
 }
 
 // ─── List Categories ─────────────────────────────────────────────────────────
@@ -142,78 +277,470 @@ func (s *ProductService) ListCategories(ctx context.Context) ([]db.Category, err
 	return cats, nil
 }
 
-// ─── Mappers ─────────────────────────────────────────────────────────────────
+// ─── Admin: List Products ─────────────────────────────────────────────────────
 
-func numericToFloat(n pgtype.Numeric) float64 {
-	if !n.Valid || n.Int == nil {
-		return 0
+// AdminList lists products regardless of whether they are active or deleted.
+func (s *ProductService) AdminList(ctx context.Context, in AdminListProductsInput) (*ListProductsOutput, error) {
+	if in.Page < 1 {
+		in.Page = 1
+	}
+	if in.Limit < 1 || in.Limit > 100 {
+		in.Limit = 20
+	}
+
+	status := pgtype.Text{}
+	switch in.Status {
+	case "":
+	case "active", "inactive", "deleted":
+		status = pgtype.Text{String: in.Status, Valid: true}
+	default:
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "status must be one of active, inactive, deleted"}
+	}
+
+	rows, err := s.q.ListProductsAdmin(ctx, db.ListProductsAdminParams{
+		Status: status,
+		Limit:  int32(in.Limit),
+		Offset: int32((in.Page - 1) * in.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list products: %w", err)
+	}
+
+	total, err := s.q.CountProductsAdmin(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("count products: %w", err)
+	}
+
+	products := make([]ProductResponse, 0, len(rows))
+	for _, r := range rows {
+		products = append(products, mapProduct(r.Product, r.CategoryName, r.CategorySlug, r.Price, r.StockQuantity))
+	}
+	if err := s.attachDetails(ctx, products, true); err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / in.Limit
+	if int(total)%in.Limit > 0 {
+		totalPages++
+	}
+
+	return &ListProductsOutput{
+		Products:   products,
+		Total:      total,
+		Page:       in.Page,
+		Limit:      in.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ─── Admin: Get Product ───────────────────────────────────────────────────────
+
+// AdminGet loads a product even if it is inactive or soft-deleted.
+func (s *ProductService) AdminGet(ctx context.Context, id string) (*ProductResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+	return s.adminGet(ctx, uid)
+}
+
+// ─── Admin: Create Product ────────────────────────────────────────────────────
+
+func (s *ProductService) Create(ctx context.Context, in CreateProductInput) (*ProductResponse, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if err := validateProductFields(in.Name, in.Price, in.StockQuantity); err != nil {
+		return nil, err
+	}
+
+	variants := make([]db.CreateProductVariantParams, 0, len(in.Variants))
+	for i, v := range in.Variants {
+		if v.Position == nil {
+			pos := int32(i)
+			v.Position = &pos
+		}
+		params, err := newVariantParams(uuid.Nil, v)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, params)
+	}
+
+	var personalisation PersonalisationOptions
+	if in.PersonalisationOptions != nil {
+		options, err := validatePersonalisationOptions(*in.PersonalisationOptions)
+		if err != nil {
+			return nil, err
+		}
+		personalisation = options
+	}
+
+	tags, err := normalizeDietaryTags(in.DietaryTags)
+	if err != nil {
+		return nil, err
+	}
+
+	allergens, err := validateAllergenDeclarations(in.Allergens)
+	if err != nil {
+		return nil, err
+	}
+
+	catID, err := s.resolveCategoryID(ctx, in.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if in.IsActive != nil {
+		isActive = *in.IsActive
+	}
+
+	var product db.Product
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		created, err := qtx.CreateProduct(ctx, db.CreateProductParams{
+			CategoryID:             catID,
+			Name:                   in.Name,
+			Description:            optionalText(in.Description),
+			ImageUrl:               optionalText(in.ImageURL),
+			IsActive:               isActive,
+			PersonalisationOptions: encodePersonalisationOptions(personalisation),
+			DietaryTags:            tags,
+		})
+		if err != nil {
+			return fmt.Errorf("create product: %w", err)
+		}
+		product = created
+
+		if len(variants) == 0 {
+			variants = append(variants, db.CreateProductVariantParams{
+				Sku:           defaultVariantSKU(product.ID),
+				Options:       encodeVariantOptions(nil),
+				Price:         moneyToNumeric(in.Price),
+				StockQuantity: in.StockQuantity,
+				IsActive:      true,
+			})
+		}
+		for _, v := range variants {
+			v.ProductID = product.ID
+			if _, err := qtx.CreateProductVariant(ctx, v); err != nil {
+				return mapVariantWriteError(err)
+			}
+		}
+		return replaceProductAllergens(ctx, qtx, product.ID, allergens)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adminGet(ctx, product.ID)
+}
+
+// ─── Admin: Update Product ────────────────────────────────────────────────────
+
+func (s *ProductService) Update(ctx context.Context, id string, in UpdateProductInput) (*ProductResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+
+	existing, err := s.q.GetProductByIDAdmin(ctx, uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+	if existing.DeletedAt.Valid {
+		return nil, &domain.AppError{Err: domain.ErrConflict, Message: "product is deleted, restore it before editing"}
+	}
+
+	params := db.UpdateProductParams{
+		ID:                     uid,
+		Name:                   existing.Name,
+		Description:            existing.Description,
+		ImageUrl:               existing.ImageUrl,
+		CategoryID:             existing.CategoryID,
+		IsActive:               existing.IsActive,
+		PersonalisationOptions: existing.PersonalisationOptions,
+		DietaryTags:            existing.DietaryTags,
 	}
-	// Convert big.Int mantissa to float
-	f := new(big.Float).SetInt(n.Int)
-	if n.Exp != 0 {
-		// 10^exp as a float (handles both positive and negative exponents)
-		scale := new(big.Float).SetFloat64(1)
-		ten := new(big.Float).SetFloat64(10)
-		if n.Exp > 0 {
-			for i := int32(0); i < n.Exp; i++ {
-				scale.Mul(scale, ten)
+	if in.Name != nil {
+		params.Name = strings.TrimSpace(*in.Name)
+	}
+
+	// Price and stock live on the variants; they can be edited here only
+	// while there is no doubt which variant is meant.
+	var variant *db.ProductVariant
+	var price domain.Money
+	var stock int32
+	if in.Price != nil || in.StockQuantity != nil {
+		variants, err := s.q.ListProductVariants(ctx, []uuid.UUID{uid})
+		if err != nil {
+			return nil, fmt.Errorf("list variants: %w", err)
+		}
+		if len(variants) != 1 {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "product has several variants, set price and stock_quantity on each variant"}
+		}
+		variant = &variants[0]
+		price, stock = numericToMoney(variant.Price), variant.StockQuantity
+		if in.Price != nil {
+			price = *in.Price
+		}
+		if in.StockQuantity != nil {
+			stock = *in.StockQuantity
+		}
+	}
+	if err := validateProductFields(params.Name, price, stock); err != nil {
+		return nil, err
+	}
+
+	if in.CategoryID != nil {
+		if params.CategoryID, err = s.resolveCategoryID(ctx, in.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+	if in.Description != nil {
+		params.Description = optionalText(in.Description)
+	}
+	if in.ImageURL != nil {
+		params.ImageUrl = optionalText(in.ImageURL)
+	}
+	if in.IsActive != nil {
+		params.IsActive = *in.IsActive
+	}
+	if in.PersonalisationOptions != nil {
+		options, err := validatePersonalisationOptions(*in.PersonalisationOptions)
+		if err != nil {
+			return nil, err
+		}
+		params.PersonalisationOptions = encodePersonalisationOptions(options)
+	}
+	if in.DietaryTags != nil {
+		if params.DietaryTags, err = normalizeDietaryTags(*in.DietaryTags); err != nil {
+			return nil, err
+		}
+	}
+	var allergens []db.CreateProductAllergenParams
+	if in.Allergens != nil {
+		if allergens, err = validateAllergenDeclarations(*in.Allergens); err != nil {
+			return nil, err
+		}
+	}
+
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		if _, err := qtx.UpdateProduct(ctx, params); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
 			}
-			f.Mul(f, scale)
-		} else {
-			for i := int32(0); i > n.Exp; i-- {
-				scale.Mul(scale, ten)
+			return fmt.Errorf("update product: %w", err)
+		}
+		if variant != nil {
+			if _, err := qtx.UpdateProductVariant(ctx, db.UpdateProductVariantParams{
+				ID:            variant.ID,
+				Sku:           variant.Sku,
+				Options:       variant.Options,
+				Price:         moneyToNumeric(price),
+				StockQuantity: stock,
+				Position:      variant.Position,
+				IsActive:      variant.IsActive,
+			}); err != nil {
+				return mapVariantWriteError(err)
 			}
-			f.Quo(f, scale)
 		}
+		if in.Allergens != nil {
+			return replaceProductAllergens(ctx, qtx, uid, allergens)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	result, _ := f.Float64()
-	return result
+
+	return s.adminGet(ctx, uid)
 }
 
-func mapListProductRow(r db.ListProductsRow) ProductResponse {
-	p := ProductResponse{
-		ID:            r.ID.String(),
-		Name:          r.Name,
-		Price:         numericToFloat(r.Price),
-		StockQuantity: r.StockQuantity,
-		IsActive:      r.IsActive,
+// ─── Admin: Delete / Restore ──────────────────────────────────────────────────
+
+// Delete soft-deletes a product. Existing orders keep referencing it.
+func (s *ProductService) Delete(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
 	}
-	if r.CategoryID.Valid {
-		id := uuid.UUID(r.CategoryID.Bytes).String()
-		p.CategoryID = &id
+
+	if _, err := s.q.SoftDeleteProduct(ctx, uid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		return fmt.Errorf("delete product: %w", err)
 	}
-	if r.CategoryName.Valid {
-		p.CategoryName = &r.CategoryName.String
+	return nil
+}
+
+// Restore brings a soft-deleted product back with its previous state.
+func (s *ProductService) Restore(ctx context.Context, id string) (*ProductResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
 	}
-	if r.CategorySlug.Valid {
-		p.CategorySlug = &r.CategorySlug.String
+
+	if _, err := s.q.RestoreProduct(ctx, uid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &domain.AppError{Err: domain.ErrNotFound, Message: "no deleted product with this id"}
+		}
+		return nil, fmt.Errorf("restore product: %w", err)
 	}
-	if r.Description.Valid {
-		p.Description = &r.Description.String
+
+	return s.adminGet(ctx, uid)
+}
+
+// ─── Helpers ─────────────────────────────────────────────────────────────────
+
+func (s *ProductService) adminGet(ctx context.Context, id uuid.UUID) (*ProductResponse, error) {
+	row, err := s.q.GetProductByIDAdmin(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get product: %w", err)
 	}
-	if r.ImageUrl.Valid {
-		p.ImageURL = &r.ImageUrl.String
+
+	products := []ProductResponse{mapProduct(row.Product, row.CategoryName, row.CategorySlug, row.Price, row.StockQuantity)}
+	if err := s.attachDetails(ctx, products, true); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+// resolveCategoryID parses an optional category id and checks that the
+// category exists. Nil or empty means "no category".
+func (s *ProductService) resolveCategoryID(ctx context.Context, id *string) (pgtype.UUID, error) {
+	if id == nil || *id == "" {
+		return pgtype.UUID{}, nil
+	}
+
+	uid, err := uuid.Parse(*id)
+	if err != nil {
+		return pgtype.UUID{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid category_id"}
+	}
+
+	if _, err := s.q.GetCategoryByID(ctx, uid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgtype.UUID{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "category does not exist"}
+		}
+		return pgtype.UUID{}, fmt.Errorf("get category: %w", err)
+	}
+	return pgtype.UUID{Bytes: uid, Valid: true}, nil
+}
+
+func validateProductFields(name string, price domain.Money, stock int32) error {
+	if name == "" {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "name is required"}
+	}
+	if len(name) > 255 {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "name must be at most 255 characters"}
+	}
+	return validatePriceAndStock(price, stock)
+}
+
+func validatePriceAndStock(price domain.Money, stock int32) error {
+	if price.IsNegative() {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "price must not be negative"}
+	}
+	if price > maxStoredAmount {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "price is too large"}
+	}
+	if stock < 0 {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "stock_quantity must not be negative"}
+	}
+	return nil
+}
+
+// attachDetails fills in the variants, allergens and images of products.
+func (s *ProductService) attachDetails(ctx context.Context, products []ProductResponse, includeInactive bool) error {
+	if err := s.attachVariants(ctx, products, includeInactive); err != nil {
+		return err
+	}
+	if err := s.attachAllergens(ctx, products); err != nil {
+		return err
+	}
+	return s.attachImages(ctx, products)
+}
+
+// optionalText maps a nil or empty string to SQL NULL.
+func optionalText(s *string) pgtype.Text {
+	if s == nil || *s == "" {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+// ─── Mappers ─────────────────────────────────────────────────────────────────
+
+// maxStoredAmount is the largest amount the NUMERIC(10,2) money columns hold.
+const maxStoredAmount = domain.Money(99999999_99)
+
+// numericToMoney converts a NUMERIC column to Money. NULL is zero; the
+// NUMERIC(10,2) columns used for prices always fit.
+func numericToMoney(n pgtype.Numeric) domain.Money {
+	if !n.Valid {
+		return 0
+	}
+	m, err := domain.MoneyFromDecimal(n.Int, n.Exp)
+	if err != nil {
+		return 0
+	}
+	return m
+}
+
+func moneyToNumeric(m domain.Money) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(m.Cents()), Exp: -2, Valid: true}
+}
+
+func mapListProductRow(r db.ListProductsRow) ProductResponse {
+	p := mapProduct(r.Product, r.CategoryName, r.CategorySlug, r.Price, r.StockQuantity)
+	if r.NameHeadline.Valid {
+		p.Highlight = &SearchHighlight{Name: renderHeadline(r.NameHeadline.String)}
+		if r.DescriptionHeadline.Valid {
+			desc := renderHeadline(r.DescriptionHeadline.String)
+			p.Highlight.Description = &desc
+		}
 	}
 	return p
 }
 
 func mapGetProductRow(r db.GetProductByIDRow) ProductResponse {
+	return mapProduct(r.Product, r.CategoryName, r.CategorySlug, r.Price, r.StockQuantity)
+}
+
+// mapProduct maps a product row; price and stock are the summary of its
+// active variants.
+func mapProduct(r db.Product, categoryName, categorySlug pgtype.Text, price pgtype.Numeric, stock int32) ProductResponse {
 	p := ProductResponse{
-		ID:            r.ID.String(),
-		Name:          r.Name,
-		Price:         numericToFloat(r.Price),
-		StockQuantity: r.StockQuantity,
-		IsActive:      r.IsActive,
+		ID:                     r.ID.String(),
+		Name:                   r.Name,
+		Price:                  numericToMoney(price),
+		StockQuantity:          stock,
+		AvailableQuantity:      stock,
+		IsActive:               r.IsActive,
+		PersonalisationOptions: decodePersonalisationOptions(r.PersonalisationOptions),
+		DietaryTags:            r.DietaryTags,
+	}
+	if p.DietaryTags == nil {
+		p.DietaryTags = []string{}
 	}
 	if r.CategoryID.Valid {
 		id := uuid.UUID(r.CategoryID.Bytes).String()
 		p.CategoryID = &id
 	}
-	if r.CategoryName.Valid {
-		p.CategoryName = &r.CategoryName.String
+	if categoryName.Valid {
+		p.CategoryName = &categoryName.String
 	}
-	if r.CategorySlug.Valid {
-		p.CategorySlug = &r.CategorySlug.String
+	if categorySlug.Valid {
+		p.CategorySlug = &categorySlug.String
 	}
 	if r.Description.Valid {
 		p.Description = &r.Description.String
@@ -221,5 +748,8 @@ func mapGetProductRow(r db.GetProductByIDRow) ProductResponse {
 	if r.ImageUrl.Valid {
 		p.ImageURL = &r.ImageUrl.String
 	}
+	if r.DeletedAt.Valid {
+		p.DeletedAt = &r.DeletedAt.Time
+	}
 	return p
 }
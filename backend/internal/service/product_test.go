@@ -2,18 +2,20 @@ package service_test
 
 import (
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/online-cake-shop/backend/internal/domain"
 	"github.com/online-cake-shop/backend/internal/service"
 )
 
-func TestNumericToFloat(t *testing.T) {
+func TestNumericToMoney(t *testing.T) {
 	tests := []struct {
 		name    string
 		numeric pgtype.Numeric
-		want    float64
+		want    domain.Money
 	}{
 		{
 			name:    "zero",
@@ -23,12 +25,17 @@ func TestNumericToFloat(t *testing.T) {
 		{
 			name:    "integer 10",
 			numeric: pgtype.Numeric{Int: big.NewInt(10), Exp: 0, Valid: true},
-			want:    10,
+			want:    1000,
 		},
 		{
 			name:    "decimal 9.99 (999 * 10^-2)",
 			numeric: pgtype.Numeric{Int: big.NewInt(999), Exp: -2, Valid: true},
-			want:    9.99,
+			want:    999,
+		},
+		{
+			name:    "extra precision rounds half away from zero",
+			numeric: pgtype.Numeric{Int: big.NewInt(9995), Exp: -3, Valid: true},
+			want:    1000,
 		},
 		{
 			name:    "invalid numeric returns 0",
@@ -39,11 +46,43 @@ func TestNumericToFloat(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := service.NumericToFloat(tt.numeric)
-			// Allow tiny floating-point delta
-			diff := got - tt.want
-			if diff < -0.0001 || diff > 0.0001 {
-				t.Errorf("NumericToFloat() = %v, want %v", got, tt.want)
+			if got := service.NumericToMoney(tt.numeric); got != tt.want {
+				t.Errorf("NumericToMoney() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyToNumericRoundTrip(t *testing.T) {
+	for _, m := range []domain.Money{0, 1, 999, 4500, 99999999_99} {
+		if got := service.NumericToMoney(service.MoneyToNumeric(m)); got != m {
+			t.Errorf("round trip of %s = %s", m, got)
+		}
+	}
+}
+
+func TestValidateProductFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		pname   string
+		price   domain.Money
+		stock   int32
+		wantErr bool
+	}{
+		{"valid", "Chocolate Cake", 2499, 10, false},
+		{"free and out of stock", "Sample", 0, 0, false},
+		{"empty name", "", 1000, 1, true},
+		{"name too long", strings.Repeat("a", 256), 1000, 1, true},
+		{"negative price", "Cake", -100, 1, true},
+		{"price too large for storage", "Cake", 100000000_00, 1, true},
+		{"negative stock", "Cake", 1000, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateProductFields(tt.pname, tt.price, tt.stock)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProductFields() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,628 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Promotions are discounts every cart gets automatically when it suits
+// them: buy X get Y free, a percentage off, or free delivery. Item
+// promotions apply to every product or only to one product or category.
+//
+// The running promotions are applied highest priority first, each to what
+// earlier ones left of the items' prices, so discounts never add up to more
+// than an item costs. An exclusive promotion is never combined with
+// another: it is skipped once any promotion has applied, and stops the
+// rest when it applies itself. A coupon then applies to what promotions
+// left.
+//
+// The engine prices carts each time they are read and orders again when
+// they are placed, and explains each promotion it applied as a line.
+
+const (
+	maxPromotionNameLength        = 100
+	maxPromotionDescriptionLength = 500
+	maxPromotionQuantity          = 1000
+)
+
+type PromotionService struct {
+	q *db.Queries
+}
+
+func NewPromotionService(q *db.Queries) *PromotionService {
+	return &PromotionService{q: q}
+}
+
+// PromotionEngine works out which running promotions an order of cart
+// lines gets and the delivery fee it pays. CartService and OrderService
+// share one so that orders are charged what the cart showed.
+type PromotionEngine struct {
+	deliveryFee domain.Money
+}
+
+func NewPromotionEngine(deliveryFee domain.Money) *PromotionEngine {
+	return &PromotionEngine{deliveryFee: deliveryFee}
+}
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+// PromotionInput creates or replaces a promotion. BuyQuantity and
+// GetQuantity are set for buy_x_get_y promotions and PercentOff, e.g. 12.5
+// for 12.5%, for percent_off ones. At most one of CategoryID and ProductID
+// limits an item promotion; without either it applies to every item. Nil
+// validity bounds mean none.
+type PromotionInput struct {
+	Name           string
+	Description    string
+	Kind           string
+	Priority       int32
+	Exclusive      bool
+	CategoryID     string
+	ProductID      string
+	BuyQuantity    int32
+	GetQuantity    int32
+	PercentOff     domain.Money
+	MinOrderAmount domain.Money
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	IsActive       bool
+}
+
+type PromotionResponse struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Description    *string       `json:"description"`
+	Kind           string        `json:"kind"`
+	Priority       int32         `json:"priority"`
+	Exclusive      bool          `json:"exclusive"`
+	CategoryID     *string       `json:"category_id"`
+	ProductID      *string       `json:"product_id"`
+	BuyQuantity    *int32        `json:"buy_quantity"`
+	GetQuantity    *int32        `json:"get_quantity"`
+	PercentOff     *domain.Money `json:"percent_off"`
+	MinOrderAmount domain.Money  `json:"min_order_amount"`
+	StartsAt       *time.Time    `json:"starts_at"`
+	EndsAt         *time.Time    `json:"ends_at"`
+	IsActive       bool          `json:"is_active"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// AppliedPromotion explains a promotion applied to a cart or order.
+// Discount is what it took off the items, or the delivery fee it waived.
+// PromotionID is empty on orders whose promotion has since been deleted.
+type AppliedPromotion struct {
+	PromotionID string       `json:"promotion_id,omitempty"`
+	Name        string       `json:"name"`
+	Detail      string       `json:"detail"`
+	Discount    domain.Money `json:"discount"`
+}
+
+// promotionLine is an order line as far as promotions are concerned.
+type promotionLine struct {
+	productID uuid.UUID
+	quantity  int32
+	amount    domain.Money
+}
+
+// promotionResult is what the promotions applied to some lines take off.
+type promotionResult struct {
+	applied []AppliedPromotion
+	// lineDiscounts is what item promotions took off each line, in the
+	// order of the lines, and discount their sum.
+	lineDiscounts []domain.Money
+	discount      domain.Money
+	// freeItems is how many items of each line were made free.
+	freeItems []int32
+	// deliveryFee is the fee left to pay.
+	deliveryFee domain.Money
+}
+
+// ─── Admin ────────────────────────────────────────────────────────────────────
+
+// ListPromotions returns all promotions in the order they are applied.
+func (s *PromotionService) ListPromotions(ctx context.Context) ([]PromotionResponse, error) {
+	promotions, err := s.q.ListPromotions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list promotions: %w", err)
+	}
+	out := make([]PromotionResponse, 0, len(promotions))
+	for _, p := range promotions {
+		out = append(out, mapPromotionResponse(p))
+	}
+	return out, nil
+}
+
+func (s *PromotionService) GetPromotion(ctx context.Context, id string) (*PromotionResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid promotion id"}
+	}
+
+	p, err := s.q.GetPromotionByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get promotion: %w", err)
+	}
+	resp := mapPromotionResponse(p)
+	return &resp, nil
+}
+
+func (s *PromotionService) CreatePromotion(ctx context.Context, in PromotionInput) (*PromotionResponse, error) {
+	params, err := validatePromotionInput(in)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.q.CreatePromotion(ctx, params)
+	if err != nil {
+		return nil, mapPromotionWriteError(err)
+	}
+	resp := mapPromotionResponse(p)
+	return &resp, nil
+}
+
+// UpdatePromotion replaces a promotion's settings. Carts are priced with
+// them from their next read; orders keep the promotions they were placed
+// with.
+func (s *PromotionService) UpdatePromotion(ctx context.Context, id string, in PromotionInput) (*PromotionResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid promotion id"}
+	}
+	params, err := validatePromotionInput(in)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.q.UpdatePromotion(ctx, db.UpdatePromotionParams{
+		ID:             uid,
+		Name:           params.Name,
+		Description:    params.Description,
+		Kind:           params.Kind,
+		Priority:       params.Priority,
+		Exclusive:      params.Exclusive,
+		CategoryID:     params.CategoryID,
+		ProductID:      params.ProductID,
+		BuyQuantity:    params.BuyQuantity,
+		GetQuantity:    params.GetQuantity,
+		PercentOff:     params.PercentOff,
+		MinOrderAmount: params.MinOrderAmount,
+		StartsAt:       params.StartsAt,
+		EndsAt:         params.EndsAt,
+		IsActive:       params.IsActive,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, mapPromotionWriteError(err)
+	}
+	resp := mapPromotionResponse(p)
+	return &resp, nil
+}
+
+// DeletePromotion removes a promotion. Orders placed with it keep its
+// explanation line.
+func (s *PromotionService) DeletePromotion(ctx context.Context, id string) error {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid promotion id"}
+	}
+
+	n, err := s.q.DeletePromotion(ctx, uid)
+	if err != nil {
+		return fmt.Errorf("delete promotion: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ─── Cart ─────────────────────────────────────────────────────────────────────
+
+// applyCartPromotions takes the running promotions off the cart's lines
+// that can be ordered and adds the delivery fee to its total.
+func (s *CartService) applyCartPromotions(ctx context.Context, resp *CartResponse) error {
+	lines := make([]promotionLine, 0, len(resp.Items))
+	items := make([]int, 0, len(resp.Items))
+	for i, item := range resp.Items {
+		if item.Available {
+			lines = append(lines, promotionLine{
+				productID: uuid.MustParse(item.ProductID),
+				quantity:  item.Quantity,
+				amount:    item.Subtotal,
+			})
+			items = append(items, i)
+		}
+	}
+
+	res, err := s.promotions.price(ctx, s.q, lines, time.Now())
+	if err != nil {
+		return err
+	}
+	for j, i := range items {
+		resp.Items[i].Discount = res.lineDiscounts[j]
+	}
+	resp.Promotions = res.applied
+	resp.PromotionDiscount = res.discount
+	resp.DeliveryFee = res.deliveryFee
+	resp.Total = resp.Subtotal.Sub(res.discount).Add(res.deliveryFee)
+	return nil
+}
+
+// ─── Orders ───────────────────────────────────────────────────────────────────
+
+// recordOrderPromotions keeps the explanation of each promotion an order
+// was placed with.
+func recordOrderPromotions(ctx context.Context, qtx *db.Queries, orderID uuid.UUID, applied []AppliedPromotion) error {
+	for i, p := range applied {
+		params := db.CreateOrderPromotionParams{
+			OrderID:        orderID,
+			Name:           p.Name,
+			Detail:         p.Detail,
+			DiscountAmount: moneyToNumeric(p.Discount),
+			Position:       int32(i),
+		}
+		if id, err := uuid.Parse(p.PromotionID); err == nil {
+			params.PromotionID = pgtype.UUID{Bytes: id, Valid: true}
+		}
+		if err := qtx.CreateOrderPromotion(ctx, params); err != nil {
+			return fmt.Errorf("record order promotion: %w", err)
+		}
+	}
+	return nil
+}
+
+func mapOrderPromotion(p db.OrderPromotion) AppliedPromotion {
+	applied := AppliedPromotion{
+		Name:     p.Name,
+		Detail:   p.Detail,
+		Discount: numericToMoney(p.DiscountAmount),
+	}
+	if p.PromotionID.Valid {
+		applied.PromotionID = uuid.UUID(p.PromotionID.Bytes).String()
+	}
+	return applied
+}
+
+// ─── Engine ───────────────────────────────────────────────────────────────────
+
+// price applies the promotions running at now to lines. An order without
+// lines pays no delivery fee.
+func (e *PromotionEngine) price(ctx context.Context, q *db.Queries, lines []promotionLine, now time.Time) (promotionResult, error) {
+	if len(lines) == 0 {
+		return applyPromotions(nil, nil, lines, e.deliveryFee), nil
+	}
+
+	promotions, err := q.ListActivePromotions(ctx, now)
+	if err != nil {
+		return promotionResult{}, fmt.Errorf("list promotions: %w", err)
+	}
+
+	eligible := make(map[uuid.UUID]map[uuid.UUID]bool, len(promotions))
+	if len(promotions) > 0 {
+		promotionIDs := make([]uuid.UUID, 0, len(promotions))
+		for _, p := range promotions {
+			promotionIDs = append(promotionIDs, p.ID)
+		}
+		productIDs := make([]uuid.UUID, 0, len(lines))
+		for _, l := range lines {
+			productIDs = append(productIDs, l.productID)
+		}
+		rows, err := q.ListPromotionEligibleProducts(ctx, promotionIDs, productIDs)
+		if err != nil {
+			return promotionResult{}, fmt.Errorf("list promotion products: %w", err)
+		}
+		for _, r := range rows {
+			if eligible[r.PromotionID] == nil {
+				eligible[r.PromotionID] = make(map[uuid.UUID]bool)
+			}
+			eligible[r.PromotionID][r.ProductID] = true
+		}
+	}
+	return applyPromotions(promotions, eligible, lines, e.deliveryFee), nil
+}
+
+// applyPromotions applies promotions, given in priority order, to lines.
+// eligible holds the products each item promotion applies to. Minimum
+// orders are measured against the lines before any discount. Promotions
+// that would take nothing off are not applied and so don't block
+// exclusive ones.
+func applyPromotions(promotions []db.ListActivePromotionsRow, eligible map[uuid.UUID]map[uuid.UUID]bool, lines []promotionLine, deliveryFee domain.Money) promotionResult {
+	res := promotionResult{
+		applied:       []AppliedPromotion{},
+		lineDiscounts: make([]domain.Money, len(lines)),
+		freeItems:     make([]int32, len(lines)),
+	}
+	if len(lines) == 0 {
+		return res
+	}
+	res.deliveryFee = deliveryFee
+
+	var subtotal domain.Money
+	for _, l := range lines {
+		subtotal = subtotal.Add(l.amount)
+	}
+
+	for _, p := range promotions {
+		if p.Exclusive && len(res.applied) > 0 {
+			continue
+		}
+		if subtotal < numericToMoney(p.MinOrderAmount) {
+			continue
+		}
+
+		var off domain.Money
+		var detail string
+		switch p.Kind {
+		case domain.PromotionKindBuyXGetY:
+			var free int64
+			off, free = res.buyXGetY(p, eligible[p.ID], lines)
+			detail = fmt.Sprintf("buy %d, get %d free on %s (%d free)",
+				p.BuyQuantity.Int32, p.GetQuantity.Int32, promotionScope(p), free)
+		case domain.PromotionKindPercentOff:
+			off = res.percentOff(p, eligible[p.ID], lines)
+			detail = fmt.Sprintf("%s%% off %s", formatPercent(numericToMoney(p.PercentOff)), promotionScope(p))
+		case domain.PromotionKindFreeDelivery:
+			off, res.deliveryFee = res.deliveryFee, 0
+			detail = "free delivery"
+			if minimum := numericToMoney(p.MinOrderAmount); minimum > 0 {
+				detail = fmt.Sprintf("free delivery on orders of %s or more", minimum)
+			}
+		}
+		if off == 0 {
+			continue
+		}
+
+		res.applied = append(res.applied, AppliedPromotion{
+			PromotionID: p.ID.String(),
+			Name:        p.Name,
+			Detail:      detail,
+			Discount:    off,
+		})
+		if p.Exclusive {
+			break
+		}
+	}
+	return res
+}
+
+// buyXGetY makes the cheapest items of every BuyQuantity + GetQuantity
+// eligible ones free, valuing each at what earlier promotions left of its
+// price. Items already free don't count. It returns the discount and how
+// many items it made free.
+func (r *promotionResult) buyXGetY(p db.ListActivePromotionsRow, eligible map[uuid.UUID]bool, lines []promotionLine) (domain.Money, int64) {
+	var candidates []int
+	var units int64
+	for i, l := range lines {
+		if eligible[l.productID] && r.remaining(i, l) > 0 && l.quantity > r.freeItems[i] {
+			candidates = append(candidates, i)
+			units += int64(l.quantity - r.freeItems[i])
+		}
+	}
+	free := units / int64(p.BuyQuantity.Int32+p.GetQuantity.Int32) * int64(p.GetQuantity.Int32)
+	if free == 0 {
+		return 0, 0
+	}
+
+	// Cheapest items first, comparing what is left of each line per item
+	// not yet free.
+	paid := func(i int) int64 { return int64(lines[i].quantity - r.freeItems[i]) }
+	sort.SliceStable(candidates, func(a, b int) bool {
+		i, j := candidates[a], candidates[b]
+		return r.remaining(i, lines[i]).Mul(paid(j)) < r.remaining(j, lines[j]).Mul(paid(i))
+	})
+
+	var off domain.Money
+	left := free
+	for _, i := range candidates {
+		if left == 0 {
+			break
+		}
+		n := min(left, paid(i))
+		d := r.remaining(i, lines[i]).MulFrac(n, paid(i))
+		r.take(i, d)
+		r.freeItems[i] += int32(n)
+		off = off.Add(d)
+		left -= n
+	}
+	return off, free
+}
+
+// percentOff takes PercentOff off what is left of the eligible lines.
+func (r *promotionResult) percentOff(p db.ListActivePromotionsRow, eligible map[uuid.UUID]bool, lines []promotionLine) domain.Money {
+	// PercentOff reads in hundredths of a percent, e.g. 1250 for 12.5%.
+	percent := numericToMoney(p.PercentOff).Cents()
+
+	var off domain.Money
+	for i, l := range lines {
+		if eligible[l.productID] {
+			d := r.remaining(i, l).MulFrac(percent, 100*100)
+			r.take(i, d)
+			off = off.Add(d)
+		}
+	}
+	return off
+}
+
+// remaining is what is left of line i after the promotions so far.
+func (r *promotionResult) remaining(i int, l promotionLine) domain.Money {
+	return l.amount.Sub(r.lineDiscounts[i])
+}
+
+func (r *promotionResult) take(i int, d domain.Money) {
+	r.lineDiscounts[i] = r.lineDiscounts[i].Add(d)
+	r.discount = r.discount.Add(d)
+}
+
+// promotionScope names what an item promotion applies to.
+func promotionScope(p db.ListActivePromotionsRow) string {
+	if p.ScopeName.Valid {
+		return p.ScopeName.String
+	}
+	return "everything"
+}
+
+// formatPercent formats a percentage held in hundredths without trailing
+// zeros: 10, 12.5.
+func formatPercent(p domain.Money) string {
+	return strings.TrimSuffix(strings.TrimRight(p.String(), "0"), ".")
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+// validatePromotionInput checks a promotion as set by an admin.
+func validatePromotionInput(in PromotionInput) (db.CreatePromotionParams, error) {
+	invalid := func(msg string) (db.CreatePromotionParams, error) {
+		return db.CreatePromotionParams{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: msg}
+	}
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" || len(name) > maxPromotionNameLength {
+		return invalid(fmt.Sprintf("name is required and must be at most %d characters", maxPromotionNameLength))
+	}
+	description := strings.TrimSpace(in.Description)
+	if len(description) > maxPromotionDescriptionLength {
+		return invalid(fmt.Sprintf("description must be at most %d characters", maxPromotionDescriptionLength))
+	}
+	if !domain.IsValidPromotionKind(in.Kind) {
+		return invalid("kind must be buy_x_get_y, percent_off or free_delivery")
+	}
+
+	params := db.CreatePromotionParams{
+		Name:           name,
+		Kind:           in.Kind,
+		Priority:       in.Priority,
+		Exclusive:      in.Exclusive,
+		MinOrderAmount: moneyToNumeric(in.MinOrderAmount),
+		IsActive:       in.IsActive,
+	}
+
+	switch in.Kind {
+	case domain.PromotionKindBuyXGetY:
+		if in.BuyQuantity < 1 || in.BuyQuantity > maxPromotionQuantity {
+			return invalid(fmt.Sprintf("buy_quantity must be between 1 and %d", maxPromotionQuantity))
+		}
+		if in.GetQuantity < 1 || in.GetQuantity > maxPromotionQuantity {
+			return invalid(fmt.Sprintf("get_quantity must be between 1 and %d", maxPromotionQuantity))
+		}
+		params.BuyQuantity = pgtype.Int4{Int32: in.BuyQuantity, Valid: true}
+		params.GetQuantity = pgtype.Int4{Int32: in.GetQuantity, Valid: true}
+	case domain.PromotionKindPercentOff:
+		if in.PercentOff <= 0 || in.PercentOff > 100*100 {
+			return invalid("percent_off must be greater than 0 and at most 100")
+		}
+		params.PercentOff = moneyToNumeric(in.PercentOff)
+	}
+	if in.Kind != domain.PromotionKindBuyXGetY && (in.BuyQuantity != 0 || in.GetQuantity != 0) {
+		return invalid("buy_quantity and get_quantity are only for buy_x_get_y promotions")
+	}
+	if in.Kind != domain.PromotionKindPercentOff && in.PercentOff != 0 {
+		return invalid("percent_off is only for percent_off promotions")
+	}
+
+	if in.CategoryID != "" && in.ProductID != "" {
+		return invalid("a promotion can be limited to a category or a product, not both")
+	}
+	if in.Kind == domain.PromotionKindFreeDelivery && (in.CategoryID != "" || in.ProductID != "") {
+		return invalid("free delivery can't be limited to a category or product")
+	}
+	if in.CategoryID != "" {
+		id, err := uuid.Parse(in.CategoryID)
+		if err != nil {
+			return invalid("invalid category id")
+		}
+		params.CategoryID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	if in.ProductID != "" {
+		id, err := uuid.Parse(in.ProductID)
+		if err != nil {
+			return invalid("invalid product id")
+		}
+		params.ProductID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	if in.MinOrderAmount < 0 || in.MinOrderAmount > maxStoredAmount {
+		return invalid("min_order_amount must be between 0 and " + maxStoredAmount.String())
+	}
+	if in.StartsAt != nil && in.EndsAt != nil && !in.StartsAt.Before(*in.EndsAt) {
+		return invalid("ends_at must be after starts_at")
+	}
+
+	if description != "" {
+		params.Description = pgtype.Text{String: description, Valid: true}
+	}
+	if in.StartsAt != nil {
+		params.StartsAt = pgtype.Timestamptz{Time: *in.StartsAt, Valid: true}
+	}
+	if in.EndsAt != nil {
+		params.EndsAt = pgtype.Timestamptz{Time: *in.EndsAt, Valid: true}
+	}
+	return params, nil
+}
+
+func mapPromotionResponse(p db.Promotion) PromotionResponse {
+	resp := PromotionResponse{
+		ID:             p.ID.String(),
+		Name:           p.Name,
+		Kind:           p.Kind,
+		Priority:       p.Priority,
+		Exclusive:      p.Exclusive,
+		MinOrderAmount: numericToMoney(p.MinOrderAmount),
+		IsActive:       p.IsActive,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+	if p.Description.Valid {
+		resp.Description = &p.Description.String
+	}
+	if p.CategoryID.Valid {
+		id := uuid.UUID(p.CategoryID.Bytes).String()
+		resp.CategoryID = &id
+	}
+	if p.ProductID.Valid {
+		id := uuid.UUID(p.ProductID.Bytes).String()
+		resp.ProductID = &id
+	}
+	if p.BuyQuantity.Valid {
+		resp.BuyQuantity = &p.BuyQuantity.Int32
+	}
+	if p.GetQuantity.Valid {
+		resp.GetQuantity = &p.GetQuantity.Int32
+	}
+	if p.PercentOff.Valid {
+		percent := numericToMoney(p.PercentOff)
+		resp.PercentOff = &percent
+	}
+	if p.StartsAt.Valid {
+		resp.StartsAt = &p.StartsAt.Time
+	}
+	if p.EndsAt.Valid {
+		resp.EndsAt = &p.EndsAt.Time
+	}
+	return resp
+}
+
+func mapPromotionWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "category or product does not exist"}
+	}
+	return fmt.Errorf("write promotion: %w", err)
+}
@@ -0,0 +1,224 @@
+package service_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	cupcake, cheesecake, candle := uuid.New(), uuid.New(), uuid.New()
+	lines := []service.PromotionLine{
+		{ProductID: cupcake, Quantity: 4, Amount: 1200}, // 3.00 each
+		{ProductID: cupcake, Quantity: 3, Amount: 750},  // 2.50 each
+		{ProductID: cheesecake, Quantity: 1, Amount: 2500},
+		{ProductID: candle, Quantity: 1, Amount: 300},
+	}
+
+	cupcakes := db.ListActivePromotionsRow{
+		ID: uuid.New(), Name: "Cupcake deal", Kind: domain.PromotionKindBuyXGetY,
+		BuyQuantity: pgtype.Int4{Int32: 6, Valid: true}, GetQuantity: pgtype.Int4{Int32: 1, Valid: true},
+		MinOrderAmount: cents(0), ScopeName: pgtype.Text{String: "Cupcakes", Valid: true},
+	}
+	cheesecakes := db.ListActivePromotionsRow{
+		ID: uuid.New(), Name: "Cheesecake weekend", Kind: domain.PromotionKindPercentOff,
+		PercentOff: cents(1000), MinOrderAmount: cents(0), ScopeName: pgtype.Text{String: "Cheesecakes", Valid: true},
+	}
+	everything := db.ListActivePromotionsRow{
+		ID: uuid.New(), Name: "Summer sale", Kind: domain.PromotionKindPercentOff,
+		PercentOff: cents(1250), MinOrderAmount: cents(0),
+	}
+	delivery := db.ListActivePromotionsRow{
+		ID: uuid.New(), Name: "Free delivery", Kind: domain.PromotionKindFreeDelivery, MinOrderAmount: cents(4500),
+	}
+	eligible := map[uuid.UUID]map[uuid.UUID]bool{
+		cupcakes.ID:    {cupcake: true},
+		cheesecakes.ID: {cheesecake: true},
+		everything.ID:  {cupcake: true, cheesecake: true, candle: true},
+	}
+	exclusive := func(p db.ListActivePromotionsRow) db.ListActivePromotionsRow {
+		p.Exclusive = true
+		return p
+	}
+	minimum := func(p db.ListActivePromotionsRow, amount int64) db.ListActivePromotionsRow {
+		p.MinOrderAmount = cents(amount)
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		promotions []db.ListActivePromotionsRow
+		lines      []service.PromotionLine
+		wantLines  []domain.Money
+		wantFee    domain.Money
+		wantDetail []string
+	}{
+		{
+			name:       "cheapest cupcake free",
+			promotions: []db.ListActivePromotionsRow{cupcakes},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 250, 0, 0},
+			wantFee:    499,
+			wantDetail: []string{"buy 6, get 1 free on Cupcakes (1 free)"},
+		},
+		{
+			name:       "percent off a category",
+			promotions: []db.ListActivePromotionsRow{cheesecakes},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 0, 250, 0},
+			wantFee:    499,
+			wantDetail: []string{"10% off Cheesecakes"},
+		},
+		{
+			name:       "free delivery over the threshold",
+			promotions: []db.ListActivePromotionsRow{delivery},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 0, 0, 0},
+			wantFee:    0,
+			wantDetail: []string{"free delivery on orders of 45.00 or more"},
+		},
+		{
+			name:       "free delivery measured before discounts",
+			promotions: []db.ListActivePromotionsRow{everything, minimum(delivery, 4750)},
+			lines:      lines,
+			wantLines:  []domain.Money{150, 94, 313, 38},
+			wantFee:    0,
+			wantDetail: []string{"12.5% off everything", "free delivery on orders of 47.50 or more"},
+		},
+		{
+			name:       "below the threshold",
+			promotions: []db.ListActivePromotionsRow{minimum(cheesecakes, 10000), minimum(delivery, 10000)},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 0, 0, 0},
+			wantFee:    499,
+			wantDetail: []string{},
+		},
+		{
+			name:       "stacking applies to what is left",
+			promotions: []db.ListActivePromotionsRow{cheesecakes, everything},
+			lines:      lines,
+			wantLines:  []domain.Money{150, 94, 250 + 281, 38},
+			wantFee:    499,
+			wantDetail: []string{"10% off Cheesecakes", "12.5% off everything"},
+		},
+		{
+			name:       "free items don't count again",
+			promotions: []db.ListActivePromotionsRow{cupcakes, cupcakes},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 250, 0, 0},
+			wantFee:    499,
+			wantDetail: []string{"buy 6, get 1 free on Cupcakes (1 free)"},
+		},
+		{
+			name:       "exclusive first stops the rest",
+			promotions: []db.ListActivePromotionsRow{exclusive(cheesecakes), everything, delivery},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 0, 250, 0},
+			wantFee:    499,
+			wantDetail: []string{"10% off Cheesecakes"},
+		},
+		{
+			name:       "exclusive after another is skipped",
+			promotions: []db.ListActivePromotionsRow{cheesecakes, exclusive(everything), delivery},
+			lines:      lines,
+			wantLines:  []domain.Money{0, 0, 250, 0},
+			wantFee:    0,
+			wantDetail: []string{"10% off Cheesecakes", "free delivery on orders of 45.00 or more"},
+		},
+		{
+			name:       "exclusive that takes nothing off is skipped",
+			promotions: []db.ListActivePromotionsRow{exclusive(cupcakes), cheesecakes},
+			lines:      lines[2:],
+			wantLines:  []domain.Money{250, 0},
+			wantFee:    499,
+			wantDetail: []string{"10% off Cheesecakes"},
+		},
+		{
+			name:       "empty cart pays no delivery",
+			promotions: []db.ListActivePromotionsRow{delivery},
+			lines:      nil,
+			wantLines:  []domain.Money{},
+			wantFee:    0,
+			wantDetail: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := service.ApplyPromotions(tt.promotions, eligible, tt.lines, 499)
+
+			if !reflect.DeepEqual(res.LineDiscounts, tt.wantLines) {
+				t.Errorf("line discounts = %v, want %v", res.LineDiscounts, tt.wantLines)
+			}
+			var sum domain.Money
+			for _, d := range res.LineDiscounts {
+				sum = sum.Add(d)
+			}
+			if res.Discount != sum {
+				t.Errorf("discount = %v, want the line sum %v", res.Discount, sum)
+			}
+			if res.DeliveryFee != tt.wantFee {
+				t.Errorf("delivery fee = %v, want %v", res.DeliveryFee, tt.wantFee)
+			}
+			details := []string{}
+			for _, a := range res.Applied {
+				details = append(details, a.Detail)
+			}
+			if !reflect.DeepEqual(details, tt.wantDetail) {
+				t.Errorf("applied = %q, want %q", details, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	tests := map[domain.Money]string{1000: "10", 1250: "12.5", 333: "3.33", 10000: "100"}
+	for in, want := range tests {
+		if got := service.FormatPercent(in); got != want {
+			t.Errorf("FormatPercent(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValidatePromotionInput(t *testing.T) {
+	start := time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+
+	tests := []struct {
+		name    string
+		in      service.PromotionInput
+		wantErr bool
+	}{
+		{"buy x get y", service.PromotionInput{Name: "Cupcakes", Kind: domain.PromotionKindBuyXGetY, BuyQuantity: 6, GetQuantity: 1, CategoryID: uuid.NewString()}, false},
+		{"percent off", service.PromotionInput{Name: "Weekend", Kind: domain.PromotionKindPercentOff, PercentOff: 1000, StartsAt: &start, EndsAt: &end}, false},
+		{"free delivery", service.PromotionInput{Name: "Delivery", Kind: domain.PromotionKindFreeDelivery, MinOrderAmount: 5000}, false},
+		{"missing name", service.PromotionInput{Kind: domain.PromotionKindFreeDelivery}, true},
+		{"unknown kind", service.PromotionInput{Name: "X", Kind: "bogof"}, true},
+		{"no get quantity", service.PromotionInput{Name: "X", Kind: domain.PromotionKindBuyXGetY, BuyQuantity: 6}, true},
+		{"percent over 100", service.PromotionInput{Name: "X", Kind: domain.PromotionKindPercentOff, PercentOff: 10001}, true},
+		{"percent on free delivery", service.PromotionInput{Name: "X", Kind: domain.PromotionKindFreeDelivery, PercentOff: 1000}, true},
+		{"category and product", service.PromotionInput{Name: "X", Kind: domain.PromotionKindPercentOff, PercentOff: 1000, CategoryID: uuid.NewString(), ProductID: uuid.NewString()}, true},
+		{"scoped free delivery", service.PromotionInput{Name: "X", Kind: domain.PromotionKindFreeDelivery, ProductID: uuid.NewString()}, true},
+		{"bad category id", service.PromotionInput{Name: "X", Kind: domain.PromotionKindPercentOff, PercentOff: 1000, CategoryID: "cakes"}, true},
+		{"ends before it starts", service.PromotionInput{Name: "X", Kind: domain.PromotionKindPercentOff, PercentOff: 1000, StartsAt: &end, EndsAt: &start}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ValidatePromotionInput(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidInput) {
+					t.Errorf("error = %v, want ErrInvalidInput", err)
+				}
+			} else if err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
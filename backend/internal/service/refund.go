@@ -0,0 +1,667 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/email"
+	"github.com/online-cake-shop/backend/internal/payment"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Refund statuses. Refunds are created pending and settled once the
+// payment provider has answered.
+const (
+	refundStatusPending   = "pending"
+	refundStatusSucceeded = "succeeded"
+	refundStatusFailed    = "failed"
+)
+
+const maxRefundReasonLength = 500
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+type RefundOrderInput struct {
+	// Items lists the order lines to refund. Leave it empty to refund
+	// everything that hasn't been refunded yet.
+	Items []RefundItemInput
+	// Restock returns the refunded quantities to stock.
+	Restock bool
+	Reason  string
+}
+
+type RefundItemInput struct {
+	OrderItemID string
+	Quantity    int32
+}
+
+type RefundResponse struct {
+	ID            string       `json:"id"`
+	Status        string       `json:"status"`
+	Amount        domain.Money `json:"amount"`
+	Reason        *string      `json:"reason,omitempty"`
+	Restock       bool         `json:"restock"`
+	FailureReason *string      `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// reservedRefund is a refund recorded as pending, waiting for the payment
+// provider. captured is nil for orders that weren't paid by card.
+type reservedRefund struct {
+	refund   db.Refund
+	lines    []refundLine
+	captured *db.Payment
+}
+
+// refundLine is the part of one order line covered by a refund.
+type refundLine struct {
+	item     db.GetOrderItemsRow
+	quantity int32
+	amount   domain.Money
+}
+
+// ─── Refund Order ─────────────────────────────────────────────────────────────
+
+// RefundOrder refunds a whole order or some of its lines. Card payments are
+// refunded through the payment provider; cash refunds are only recorded,
+// and only once the order has been delivered and the cash collected.
+//
+// The refunded quantities are reserved before the provider is called and
+// released again if it refuses, so concurrent refunds can never exceed the
+// order. If the provider can't be reached the refund stays pending, still
+// holding its quantities, until the RefundSweeper gets an answer. The order
+// moves to refunded once every line is refunded and to partially_refunded
+// before that.
+func (s *OrderService) RefundOrder(ctx context.Context, actorID uuid.UUID, orderID string, in RefundOrderInput) (*OrderResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order id"}
+	}
+	if len(in.Reason) > maxRefundReasonLength {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "reason must be at most 500 characters"}
+	}
+
+	var r reservedRefund
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		order, err := qtx.GetOrderByIDForUpdate(ctx, oid)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get order: %w", err)
+		}
+		r, err = reserveRefund(ctx, qtx, actorID, order, in)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order, status, err := s.payments.settleRefund(ctx, actorID, r)
+	if err != nil {
+		return nil, err
+	}
+	if status == refundStatusFailed {
+		return nil, &domain.AppError{Err: domain.ErrConflict, Message: "the payment provider could not refund this order"}
+	}
+
+	return s.orderDetails(ctx, order)
+}
+
+// reserveRefund records a pending refund and marks its quantities as
+// refunded on the order lines, so they can't be refunded twice. order must
+// be locked by the caller's transaction.
+func reserveRefund(ctx context.Context, qtx *db.Queries, actorID uuid.UUID, order db.Order, in RefundOrderInput) (reservedRefund, error) {
+	status, err := lifecycleStatus(ctx, qtx, order)
+	if err != nil {
+		return reservedRefund{}, err
+	}
+	if err := checkRefundable(order.PaymentMethod, status); err != nil {
+		return reservedRefund{}, err
+	}
+
+	items, err := qtx.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return reservedRefund{}, fmt.Errorf("get order items: %w", err)
+	}
+	lines, deliveryFee, err := planRefund(order, items, in.Items)
+	if err != nil {
+		return reservedRefund{}, err
+	}
+
+	// Rounding aside the lines and fee add up to the total, but never
+	// refund beyond what's left of it.
+	amount := deliveryFee
+	for _, l := range lines {
+		amount = amount.Add(l.amount)
+	}
+	refunded, err := qtx.SumOrderRefunds(ctx, order.ID)
+	if err != nil {
+		return reservedRefund{}, fmt.Errorf("sum refunds: %w", err)
+	}
+	amount = amount.Min(numericToMoney(order.TotalAmount).Sub(numericToMoney(refunded)))
+	if amount <= 0 {
+		return reservedRefund{}, &domain.AppError{Err: domain.ErrConflict, Message: "nothing left to refund on this order"}
+	}
+
+	var captured *db.Payment
+	paymentID := pgtype.UUID{}
+	if order.PaymentMethod == paymentMethodCard {
+		p, err := capturedPayment(ctx, qtx, order.ID)
+		if err != nil {
+			return reservedRefund{}, err
+		}
+		captured = &p
+		paymentID = pgtype.UUID{Bytes: p.ID, Valid: true}
+	}
+
+	reason := pgtype.Text{}
+	if in.Reason != "" {
+		reason = pgtype.Text{String: in.Reason, Valid: true}
+	}
+	refund, err := qtx.CreateRefund(ctx, db.CreateRefundParams{
+		OrderID:   order.ID,
+		PaymentID: paymentID,
+		Amount:    moneyToNumeric(amount),
+		Reason:    reason,
+		Restock:   in.Restock,
+		ActorID:   pgtype.UUID{Bytes: actorID, Valid: actorID != uuid.Nil},
+	})
+	if err != nil {
+		return reservedRefund{}, fmt.Errorf("create refund: %w", err)
+	}
+
+	for _, l := range lines {
+		if err := qtx.CreateRefundItem(ctx, db.CreateRefundItemParams{
+			RefundID:    refund.ID,
+			OrderItemID: l.item.ID,
+			Quantity:    l.quantity,
+			Amount:      moneyToNumeric(l.amount),
+		}); err != nil {
+			return reservedRefund{}, fmt.Errorf("create refund item: %w", err)
+		}
+		n, err := qtx.AddOrderItemRefundedQuantity(ctx, l.item.ID, l.quantity)
+		if err != nil {
+			return reservedRefund{}, fmt.Errorf("update refunded quantity: %w", err)
+		}
+		if n == 0 {
+			return reservedRefund{}, &domain.AppError{Err: domain.ErrConflict, Message: "order line has already been refunded"}
+		}
+	}
+	return reservedRefund{refund: refund, lines: lines, captured: captured}, nil
+}
+
+// settleRefund sends a reserved refund to the payment provider, records the
+// outcome and emails the customer, returning the refund's status. A refund
+// is only failed, freeing its quantities again, when the provider refuses
+// it. If the provider can't be reached or hasn't decided yet the refund
+// stays pending; retrying it with the same reference is safe.
+func (s *PaymentService) settleRefund(ctx context.Context, actorID uuid.UUID, r reservedRefund) (db.Order, string, error) {
+	var result *payment.Refund
+	if r.captured != nil {
+		var err error
+		result, err = s.refund(ctx, *r.captured, numericToMoney(r.refund.Amount), r.refund.ID.String())
+		if err != nil {
+			slog.Error("refund payment", "order_id", r.refund.OrderID, "refund_id", r.refund.ID, "error", err)
+		}
+		if err != nil || (result.Status != payment.StatusSucceeded && result.Status != payment.StatusFailed) {
+			order, err := s.q.GetOrderByIDAdmin(ctx, r.refund.OrderID)
+			if err != nil {
+				return db.Order{}, "", fmt.Errorf("get order: %w", err)
+			}
+			return order, refundStatusPending, nil
+		}
+	}
+
+	order, refund, settled, err := s.completeRefund(ctx, actorID, r.refund, result)
+	if err != nil {
+		return db.Order{}, "", err
+	}
+	if !settled {
+		return order, refund.Status, nil
+	}
+	if refund.Status == refundStatusFailed {
+		slog.Error("refund refused by the payment provider", "order_id", refund.OrderID, "refund_id", refund.ID, "reason", refund.FailureReason.String)
+		return order, refund.Status, nil
+	}
+
+	s.sendRefundEmail(ctx, order, refund, r.lines)
+	return order, refund.Status, nil
+}
+
+// reserveCancelRefund reserves a full refund of a cancelled card order whose
+// payment was captured. It returns nil when nothing was collected. order
+// must be locked by the caller.
+func reserveCancelRefund(ctx context.Context, qtx *db.Queries, actorID uuid.UUID, order db.Order, reason string) (*reservedRefund, error) {
+	if order.PaymentMethod != paymentMethodCard {
+		return nil, nil
+	}
+	latest, err := qtx.GetLatestPaymentByOrderID(ctx, order.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+	if !refundsOnCancel(order.PaymentMethod, latest.Status) {
+		return nil, nil
+	}
+
+	// The items went back to stock with the cancellation.
+	r, err := reserveRefund(ctx, qtx, actorID, order, RefundOrderInput{Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// completeRefund settles a pending refund with the provider's answer; result
+// is nil for refunds that don't go through a provider. On success it adds
+// the amount to the order's refunded total, restocks if asked and moves the
+// order to partially_refunded or refunded; on failure it frees the reserved
+// quantities again. settled is false, and nothing changes, if the refund had
+// already been settled by someone else.
+func (s *PaymentService) completeRefund(ctx context.Context, actorID uuid.UUID, pending db.Refund, result *payment.Refund) (order db.Order, refund db.Refund, settled bool, err error) {
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		current, err := qtx.GetOrderByIDForUpdate(ctx, pending.OrderID)
+		if err != nil {
+			return fmt.Errorf("get order: %w", err)
+		}
+		order = current
+
+		if refund, err = qtx.GetRefundByIDForUpdate(ctx, pending.ID); err != nil {
+			return fmt.Errorf("get refund: %w", err)
+		}
+		if refund.Status != refundStatusPending {
+			return nil
+		}
+		settled = true
+
+		refundItems, err := qtx.ListRefundItems(ctx, refund.ID)
+		if err != nil {
+			return fmt.Errorf("get refund items: %w", err)
+		}
+
+		providerRefundID := pgtype.Text{}
+		if result != nil {
+			providerRefundID = pgtype.Text{String: result.ID, Valid: result.ID != ""}
+		}
+
+		if result != nil && result.Status == payment.StatusFailed {
+			reason := result.FailureReason
+			if reason == "" {
+				reason = "refund declined by the payment provider"
+			}
+			if refund, err = qtx.UpdateRefundStatus(ctx, db.UpdateRefundStatusParams{
+				ID:               refund.ID,
+				Status:           refundStatusFailed,
+				ProviderRefundID: providerRefundID,
+				FailureReason:    pgtype.Text{String: reason, Valid: true},
+			}); err != nil {
+				return fmt.Errorf("update refund: %w", err)
+			}
+			for _, item := range refundItems {
+				if _, err := qtx.AddOrderItemRefundedQuantity(ctx, item.OrderItemID, -item.Quantity); err != nil {
+					return fmt.Errorf("update refunded quantity: %w", err)
+				}
+			}
+			return nil
+		}
+
+		if refund, err = qtx.UpdateRefundStatus(ctx, db.UpdateRefundStatusParams{
+			ID:               refund.ID,
+			Status:           refundStatusSucceeded,
+			ProviderRefundID: providerRefundID,
+		}); err != nil {
+			return fmt.Errorf("update refund: %w", err)
+		}
+		if current, err = qtx.AddOrderRefundedAmount(ctx, current.ID, refund.Amount); err != nil {
+			return fmt.Errorf("update refunded amount: %w", err)
+		}
+		order = current
+
+		// Cancelled orders were restocked when they were cancelled.
+		status, err := lifecycleStatus(ctx, qtx, current)
+		if err != nil {
+			return err
+		}
+		if refund.Restock && status != domain.OrderStatusCancelled {
+			for _, item := range refundItems {
+				if err := qtx.RestoreVariantStock(ctx, db.RestoreVariantStockParams{
+					ID:       item.VariantID,
+					Quantity: item.Quantity,
+				}); err != nil {
+					return fmt.Errorf("restore stock: %w", err)
+				}
+			}
+		}
+
+		items, err := qtx.GetOrderItems(ctx, refund.OrderID)
+		if err != nil {
+			return fmt.Errorf("get order items: %w", err)
+		}
+		to := refundedOrderStatus(items)
+		if to == current.Status {
+			return nil
+		}
+
+		note := "refunded " + numericToMoney(refund.Amount).String()
+		if refund.Reason.Valid {
+			note += ": " + refund.Reason.String
+		}
+		order, err = transitionOrder(ctx, qtx, current, to, actorID, note)
+		return err
+	})
+	if err != nil {
+		return db.Order{}, db.Refund{}, false, err
+	}
+	return order, refund, settled, nil
+}
+
+// sendRefundEmail tells the customer about a refund. The refund has
+// already happened, so failures are only logged.
+func (s *PaymentService) sendRefundEmail(ctx context.Context, order db.Order, refund db.Refund, lines []refundLine) {
+	user, err := s.q.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		slog.Error("get user for refund email", "order_id", order.ID, "error", err)
+		return
+	}
+
+	notice := email.RefundNotice{
+		OrderID: order.ID.String(),
+		Amount:  s.formatAmount(numericToMoney(refund.Amount)),
+		Full:    order.Status == domain.OrderStatusRefunded,
+		Items:   make([]email.RefundNoticeItem, 0, len(lines)),
+	}
+	if refund.Reason.Valid {
+		notice.Reason = refund.Reason.String
+	}
+	for _, l := range lines {
+		notice.Items = append(notice.Items, email.RefundNoticeItem{
+			Name:     variantName(l.item.ProductName, l.item.VariantOptions),
+			Quantity: l.quantity,
+			Amount:   s.formatAmount(l.amount),
+		})
+	}
+
+	if err := s.emailSvc.SendRefund(user.EmailAddress, user.FirstName, notice); err != nil {
+		slog.Error("send refund email", "order_id", order.ID, "refund_id", refund.ID, "error", err)
+	}
+}
+
+// ─── Sweeper ──────────────────────────────────────────────────────────────────
+
+// pendingRefundBatch caps how many pending refunds one sweep retries.
+const pendingRefundBatch = 100
+
+// RefundSweeper periodically retries refunds left pending because the
+// payment provider couldn't be reached, or because the server stopped
+// before they were settled. Until then their amounts and quantities count
+// as refunded, so nothing can be refunded twice.
+type RefundSweeper struct {
+	payments *PaymentService
+	interval time.Duration
+}
+
+func NewRefundSweeper(payments *PaymentService, interval time.Duration) *RefundSweeper {
+	return &RefundSweeper{payments: payments, interval: interval}
+}
+
+// Run retries pending refunds until ctx is cancelled. Refunds younger than
+// one interval are left to the request that created them.
+func (s *RefundSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.payments.retryPendingRefunds(ctx, time.Now().Add(-s.interval))
+			if err != nil {
+				slog.Error("sweep pending refunds", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("settled pending refunds", "count", n)
+			}
+		}
+	}
+}
+
+// retryPendingRefunds settles refunds that have been pending since before
+// the given time and reports how many it settled.
+func (s *PaymentService) retryPendingRefunds(ctx context.Context, before time.Time) (int, error) {
+	pending, err := s.q.ListPendingRefunds(ctx, before, pendingRefundBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list pending refunds: %w", err)
+	}
+
+	settled := 0
+	for _, refund := range pending {
+		r, err := s.loadPendingRefund(ctx, refund)
+		if err != nil {
+			slog.Error("load pending refund", "refund_id", refund.ID, "error", err)
+			continue
+		}
+		_, status, err := s.settleRefund(ctx, uuid.UUID(refund.ActorID.Bytes), r)
+		if err != nil {
+			slog.Error("retry pending refund", "refund_id", refund.ID, "error", err)
+			continue
+		}
+		if status != refundStatusPending {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// loadPendingRefund rebuilds the reservation of a refund that is still
+// pending, so it can be settled again.
+func (s *PaymentService) loadPendingRefund(ctx context.Context, refund db.Refund) (reservedRefund, error) {
+	r := reservedRefund{refund: refund}
+	if refund.PaymentID.Valid {
+		p, err := s.q.GetPaymentByID(ctx, refund.PaymentID.Bytes)
+		if err != nil {
+			return reservedRefund{}, fmt.Errorf("get payment: %w", err)
+		}
+		r.captured = &p
+	}
+
+	items, err := s.q.GetOrderItems(ctx, refund.OrderID)
+	if err != nil {
+		return reservedRefund{}, fmt.Errorf("get order items: %w", err)
+	}
+	refundItems, err := s.q.ListRefundItems(ctx, refund.ID)
+	if err != nil {
+		return reservedRefund{}, fmt.Errorf("get refund items: %w", err)
+	}
+	byID := make(map[uuid.UUID]db.GetOrderItemsRow, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	for _, ri := range refundItems {
+		r.lines = append(r.lines, refundLine{
+			item:     byID[ri.OrderItemID],
+			quantity: ri.Quantity,
+			amount:   numericToMoney(ri.Amount),
+		})
+	}
+	return r, nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+// checkRefundable reports why an order can't be refunded. Cash is only
+// collected on delivery, so there's nothing to give back before then;
+// undelivered cash orders are cancelled instead.
+func checkRefundable(paymentMethod, status string) error {
+	if !domain.CanTransitionOrder(status, domain.OrderStatusRefunded) {
+		return &domain.AppError{
+			Err:     domain.ErrConflict,
+			Message: fmt.Sprintf("a %s order can't be refunded", status),
+		}
+	}
+	if paymentMethod == paymentMethodCashOnDelivery && status != domain.OrderStatusDelivered {
+		return &domain.AppError{
+			Err:     domain.ErrConflict,
+			Message: "cash on delivery orders can only be refunded once delivered; cancel the order instead",
+		}
+	}
+	return nil
+}
+
+// refundsOnCancel reports whether cancelling an order has to give money
+// back: only card payments are collected before delivery.
+func refundsOnCancel(paymentMethod, paymentStatus string) bool {
+	return paymentMethod == paymentMethodCard && paymentStatus == string(payment.StatusSucceeded)
+}
+
+// planRefund works out which quantities of which lines a refund covers and
+// what they come to. With no requested lines it takes everything not
+// refunded yet. The delivery fee is returned too, and is only refunded once
+// the refund leaves nothing else on the order.
+func planRefund(order db.Order, items []db.GetOrderItemsRow, requested []RefundItemInput) ([]refundLine, domain.Money, error) {
+	var lines []refundLine
+	net := netLineAmounts(order, items)
+
+	if len(requested) == 0 {
+		for _, item := range items {
+			if remaining := item.Quantity - item.RefundedQuantity; remaining > 0 {
+				lines = append(lines, newRefundLine(item, remaining, net[item.ID]))
+			}
+		}
+		if len(lines) == 0 {
+			return nil, 0, &domain.AppError{Err: domain.ErrConflict, Message: "order has already been fully refunded"}
+		}
+		return lines, numericToMoney(order.DeliveryFee), nil
+	}
+
+	byID := make(map[uuid.UUID]db.GetOrderItemsRow, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	seen := make(map[uuid.UUID]bool, len(requested))
+	for _, r := range requested {
+		id, err := uuid.Parse(r.OrderItemID)
+		if err != nil {
+			return nil, 0, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid order_item_id"}
+		}
+		item, ok := byID[id]
+		if !ok {
+			return nil, 0, &domain.AppError{Err: domain.ErrInvalidInput, Message: "order_item_id " + r.OrderItemID + " is not part of this order"}
+		}
+		if seen[id] {
+			return nil, 0, &domain.AppError{Err: domain.ErrInvalidInput, Message: "order_item_id " + r.OrderItemID + " is listed twice"}
+		}
+		seen[id] = true
+
+		if r.Quantity < 1 {
+			return nil, 0, &domain.AppError{Err: domain.ErrInvalidInput, Message: "quantity must be at least 1"}
+		}
+		if remaining := item.Quantity - item.RefundedQuantity; r.Quantity > remaining {
+			return nil, 0, &domain.AppError{
+				Err:     domain.ErrConflict,
+				Message: fmt.Sprintf("only %d of %s can still be refunded", remaining, item.ProductName),
+			}
+		}
+		lines = append(lines, newRefundLine(item, r.Quantity, net[item.ID]))
+	}
+
+	covered := make(map[uuid.UUID]int32, len(lines))
+	for _, l := range lines {
+		covered[l.item.ID] = l.quantity
+	}
+	for _, item := range items {
+		if item.RefundedQuantity+covered[item.ID] < item.Quantity {
+			return lines, 0, nil
+		}
+	}
+	return lines, numericToMoney(order.DeliveryFee), nil
+}
+
+// netLineAmounts is what each order line cost after the order's promotion
+// and coupon discounts, spread over the lines in proportion to their value.
+// Shares are handed out cumulatively in a fixed order so that they add up
+// to the discounts exactly.
+func netLineAmounts(order db.Order, items []db.GetOrderItemsRow) map[uuid.UUID]domain.Money {
+	sorted := make([]db.GetOrderItemsRow, len(items))
+	copy(sorted, items)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID.String() < sorted[j].ID.String() })
+
+	var total domain.Money
+	for _, item := range sorted {
+		total = total.Add(numericToMoney(item.TotalPrice))
+	}
+	discount := numericToMoney(order.PromotionDiscount).Add(numericToMoney(order.DiscountAmount)).Min(total)
+
+	net := make(map[uuid.UUID]domain.Money, len(sorted))
+	var cumulative, allocated domain.Money
+	for _, item := range sorted {
+		lineTotal := numericToMoney(item.TotalPrice)
+		cumulative = cumulative.Add(lineTotal)
+		share := discount
+		if total > 0 {
+			share = discount.MulFrac(cumulative.Cents(), total.Cents()).Sub(allocated)
+		}
+		allocated = allocated.Add(share)
+		net[item.ID] = lineTotal.Sub(share)
+	}
+	return net
+}
+
+// newRefundLine refunds quantity more units of an order line that cost net
+// in all. Units are priced cumulatively, so refunding a line piecemeal
+// comes to the same as refunding it at once.
+func newRefundLine(item db.GetOrderItemsRow, quantity int32, net domain.Money) refundLine {
+	before := net.MulFrac(int64(item.RefundedQuantity), int64(item.Quantity))
+	after := net.MulFrac(int64(item.RefundedQuantity+quantity), int64(item.Quantity))
+	return refundLine{
+		item:     item,
+		quantity: quantity,
+		amount:   after.Sub(before),
+	}
+}
+
+// refundedOrderStatus is the status of an order after a refund: refunded
+// once every line is fully refunded, partially_refunded before that.
+func refundedOrderStatus(items []db.GetOrderItemsRow) string {
+	for _, item := range items {
+		if item.RefundedQuantity < item.Quantity {
+			return domain.OrderStatusPartiallyRefunded
+		}
+	}
+	return domain.OrderStatusRefunded
+}
+
+func mapRefundResponse(r db.Refund) RefundResponse {
+	resp := RefundResponse{
+		ID:        r.ID.String(),
+		Status:    r.Status,
+		Amount:    numericToMoney(r.Amount),
+		Restock:   r.Restock,
+		CreatedAt: r.CreatedAt,
+	}
+	if r.Reason.Valid {
+		resp.Reason = &r.Reason.String
+	}
+	if r.FailureReason.Valid {
+		resp.FailureReason = &r.FailureReason.String
+	}
+	return resp
+}
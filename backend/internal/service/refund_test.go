@@ -0,0 +1,218 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func orderItem(quantity, refunded int32, unitPrice domain.Money) db.GetOrderItemsRow {
+	return db.GetOrderItemsRow{
+		OrderItem: db.OrderItem{
+			ID:               uuid.New(),
+			Quantity:         quantity,
+			RefundedQuantity: refunded,
+			UnitPrice:        service.MoneyToNumeric(unitPrice),
+			TotalPrice:       service.MoneyToNumeric(unitPrice.Mul(int64(quantity))),
+		},
+		ProductName: "Chocolate Cake",
+	}
+}
+
+func TestPlanRefundFull(t *testing.T) {
+	a := orderItem(2, 0, 2500)
+	b := orderItem(3, 1, 400)
+	done := orderItem(1, 1, 1000)
+
+	lines, fee, err := service.PlanRefund(db.Order{}, []db.GetOrderItemsRow{a, b, done}, nil)
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2 (fully refunded lines are skipped)", len(lines))
+	}
+	if fee != 0 {
+		t.Errorf("delivery fee = %v, want 0 on an order without one", fee)
+	}
+	if got := lines[a.ID]; got.Quantity != 2 || got.Amount != 5000 {
+		t.Errorf("line a = %+v, want 2 for 50.00", got)
+	}
+	if got := lines[b.ID]; got.Quantity != 2 || got.Amount != 800 {
+		t.Errorf("line b = %+v, want the 2 remaining for 8.00", got)
+	}
+
+	if _, _, err := service.PlanRefund(db.Order{}, []db.GetOrderItemsRow{done}, nil); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("refunding a fully refunded order: error = %v, want ErrConflict", err)
+	}
+}
+
+func TestPlanRefundPartial(t *testing.T) {
+	a := orderItem(3, 1, 1250)
+	items := []db.GetOrderItemsRow{a, orderItem(1, 0, 900)}
+
+	lines, _, err := service.PlanRefund(db.Order{}, items, []service.RefundItemInput{{OrderItemID: a.ID.String(), Quantity: 2}})
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	if len(lines) != 1 || lines[a.ID].Quantity != 2 || lines[a.ID].Amount != 2500 {
+		t.Errorf("lines = %+v, want 2 of line a for 25.00", lines)
+	}
+
+	tests := []struct {
+		name    string
+		req     []service.RefundItemInput
+		wantErr error
+	}{
+		{"more than remains", []service.RefundItemInput{{OrderItemID: a.ID.String(), Quantity: 3}}, domain.ErrConflict},
+		{"zero quantity", []service.RefundItemInput{{OrderItemID: a.ID.String(), Quantity: 0}}, domain.ErrInvalidInput},
+		{"other order's line", []service.RefundItemInput{{OrderItemID: uuid.NewString(), Quantity: 1}}, domain.ErrInvalidInput},
+		{"invalid id", []service.RefundItemInput{{OrderItemID: "nope", Quantity: 1}}, domain.ErrInvalidInput},
+		{"listed twice", []service.RefundItemInput{
+			{OrderItemID: a.ID.String(), Quantity: 1},
+			{OrderItemID: a.ID.String(), Quantity: 1},
+		}, domain.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := service.PlanRefund(db.Order{}, items, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("PlanRefund() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlanRefundDiscountedOrder(t *testing.T) {
+	// 60.00 + 40.00 of cakes with 10.00 off from a promotion and 15.00 from
+	// a coupon: the lines cost 45.00 and 30.00 after discounts.
+	a := orderItem(2, 0, 3000)
+	b := orderItem(4, 0, 1000)
+	items := []db.GetOrderItemsRow{a, b}
+	order := db.Order{
+		PromotionDiscount: service.MoneyToNumeric(1000),
+		DiscountAmount:    service.MoneyToNumeric(1500),
+	}
+
+	lines, _, err := service.PlanRefund(order, items, []service.RefundItemInput{{OrderItemID: a.ID.String(), Quantity: 1}})
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	if got := lines[a.ID].Amount; got != 2250 {
+		t.Errorf("refund of 1 of line a = %v, want 22.50", got)
+	}
+
+	// Refunding the rest piecemeal adds up to what the customer paid.
+	a.RefundedQuantity = 1
+	var total domain.Money = 2250
+	for _, req := range []service.RefundItemInput{{OrderItemID: b.ID.String(), Quantity: 3}, {OrderItemID: b.ID.String(), Quantity: 1}} {
+		lines, _, err := service.PlanRefund(order, []db.GetOrderItemsRow{a, b}, []service.RefundItemInput{req})
+		if err != nil {
+			t.Fatalf("PlanRefund() error = %v", err)
+		}
+		total = total.Add(lines[b.ID].Amount)
+		b.RefundedQuantity += req.Quantity
+	}
+	lines, _, err = service.PlanRefund(order, []db.GetOrderItemsRow{a, b}, nil)
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	total = total.Add(lines[a.ID].Amount)
+	if total != 7500 {
+		t.Errorf("refunds add up to %v, want 75.00", total)
+	}
+}
+
+func TestPlanRefundDeliveryFee(t *testing.T) {
+	a := orderItem(1, 0, 2000)
+	b := orderItem(2, 0, 500)
+	items := []db.GetOrderItemsRow{a, b}
+	order := db.Order{DeliveryFee: service.MoneyToNumeric(499)}
+
+	_, fee, err := service.PlanRefund(order, items, []service.RefundItemInput{{OrderItemID: a.ID.String(), Quantity: 1}})
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	if fee != 0 {
+		t.Errorf("partial refund delivery fee = %v, want 0", fee)
+	}
+
+	_, fee, err = service.PlanRefund(order, items, []service.RefundItemInput{
+		{OrderItemID: a.ID.String(), Quantity: 1},
+		{OrderItemID: b.ID.String(), Quantity: 2},
+	})
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	if fee != 499 {
+		t.Errorf("refund of every line delivery fee = %v, want 4.99", fee)
+	}
+
+	a.RefundedQuantity = 1
+	_, fee, err = service.PlanRefund(order, []db.GetOrderItemsRow{a, b}, nil)
+	if err != nil {
+		t.Fatalf("PlanRefund() error = %v", err)
+	}
+	if fee != 499 {
+		t.Errorf("refund of the remaining lines delivery fee = %v, want 4.99", fee)
+	}
+}
+
+func TestRefundedOrderStatus(t *testing.T) {
+	partial := []db.GetOrderItemsRow{orderItem(2, 2, 100), orderItem(1, 0, 100)}
+	if got := service.RefundedOrderStatus(partial); got != domain.OrderStatusPartiallyRefunded {
+		t.Errorf("RefundedOrderStatus(partial) = %q, want %q", got, domain.OrderStatusPartiallyRefunded)
+	}
+
+	full := []db.GetOrderItemsRow{orderItem(2, 2, 100), orderItem(1, 1, 100)}
+	if got := service.RefundedOrderStatus(full); got != domain.OrderStatusRefunded {
+		t.Errorf("RefundedOrderStatus(full) = %q, want %q", got, domain.OrderStatusRefunded)
+	}
+}
+
+func TestPartiallyRefundedOrderCanBeDelivered(t *testing.T) {
+	status := service.RefundedOrderStatus([]db.GetOrderItemsRow{orderItem(1, 1, 100), orderItem(2, 0, 100)})
+	if !domain.CanTransitionOrder(domain.OrderStatusConfirmed, status) {
+		t.Fatalf("a confirmed order can't become %q", status)
+	}
+
+	// The rest of the order carries on from the status it was refunded in.
+	resumed := domain.OrderStatusConfirmed
+	for resumed != domain.OrderStatusDelivered {
+		next, ok := domain.NextOrderStatus(resumed)
+		if !ok || !domain.CanTransitionOrder(status, next) {
+			t.Fatalf("partially refunded order stuck at %q", resumed)
+		}
+		status, resumed = next, next
+	}
+}
+
+func TestCheckRefundable(t *testing.T) {
+	tests := []struct {
+		method, status string
+		wantErr        bool
+	}{
+		{"card", domain.OrderStatusConfirmed, false},
+		{"card", domain.OrderStatusCancelled, false},
+		{"card", domain.OrderStatusPending, true},
+		{"cash_on_delivery", domain.OrderStatusDelivered, false},
+		{"cash_on_delivery", domain.OrderStatusConfirmed, true},
+		{"cash_on_delivery", domain.OrderStatusOutForDelivery, true},
+		{"cash_on_delivery", domain.OrderStatusCancelled, true},
+		{"card", domain.OrderStatusRefunded, true},
+		{"card", domain.OrderStatusPartiallyRefunded, false},
+	}
+
+	for _, tt := range tests {
+		err := service.CheckRefundable(tt.method, tt.status)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckRefundable(%q, %q) error = %v, wantErr %v", tt.method, tt.status, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("CheckRefundable(%q, %q) error = %v, want ErrConflict", tt.method, tt.status, err)
+		}
+	}
+}
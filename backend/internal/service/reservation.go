@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Stock reservations hold cart quantities for a while so that two customers
+// can't both fill their carts with the last cake. A hold is taken or
+// refreshed whenever a cart line changes and lapses after the cart's TTL;
+// checkout converts the cart's holds into a stock deduction.
+//
+// Holds are per variant. Available-to-promise (ATP) stock is the variant's
+// stock_quantity minus unexpired holds.
+// Expired rows are ignored by every query, so the sweeper only keeps the
+// table small.
+
+// ReservationSweeper periodically deletes expired stock reservations.
+type ReservationSweeper struct {
+	q        *db.Queries
+	interval time.Duration
+}
+
+func NewReservationSweeper(q *db.Queries, interval time.Duration) *ReservationSweeper {
+	return &ReservationSweeper{q: q, interval: interval}
+}
+
+// Run sweeps expired reservations until ctx is cancelled.
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.q.DeleteExpiredReservations(ctx)
+			if err != nil {
+				slog.Error("sweep stock reservations", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("released expired stock reservations", "count", n)
+			}
+		}
+	}
+}
+
+// reservedStock returns the quantities held by unexpired reservations of
+// carts other than excludeCartID, per variant.
+func reservedStock(ctx context.Context, q *db.Queries, variantIDs []uuid.UUID, excludeCartID uuid.UUID) (map[uuid.UUID]int32, error) {
+	rows, err := q.SumActiveReservations(ctx, variantIDs, excludeCartID)
+	if err != nil {
+		return nil, fmt.Errorf("sum reservations: %w", err)
+	}
+	reserved := make(map[uuid.UUID]int32, len(rows))
+	for _, r := range rows {
+		reserved[r.VariantID] = r.Reserved
+	}
+	return reserved, nil
+}
+
+// availableToPromise is the stock that isn't held by anyone else.
+func availableToPromise(stock, reserved int32) int32 {
+	if reserved >= stock {
+		return 0
+	}
+	return stock - reserved
+}
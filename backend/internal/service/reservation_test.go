@@ -0,0 +1,30 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestAvailableToPromise(t *testing.T) {
+	tests := []struct {
+		name            string
+		stock, reserved int32
+		want            int32
+	}{
+		{"nothing reserved", 10, 0, 10},
+		{"partly reserved", 10, 4, 6},
+		{"fully reserved", 10, 10, 0},
+		// Stock can drop below the held quantity after an admin edit.
+		{"over-reserved", 3, 5, 0},
+		{"out of stock", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.AvailableToPromise(tt.stock, tt.reserved); got != tt.want {
+				t.Errorf("AvailableToPromise(%d, %d) = %d, want %d", tt.stock, tt.reserved, got, tt.want)
+			}
+		})
+	}
+}
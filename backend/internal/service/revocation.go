@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// RevocationStore answers "has this access token been revoked?" from memory.
+//
+// Two mechanisms are combined:
+//   - a jti denylist for single-token logout, mirrored from revoked_tokens
+//     and fully reloaded every sync interval;
+//   - per-user token versions for "log out everywhere", cached per user for
+//     the same interval.
+//
+// Revocations made by this process take effect immediately; revocations made
+// by other instances are picked up within one interval.
+type RevocationStore struct {
+	q        *db.Queries
+	interval time.Duration
+
+	mu       sync.RWMutex
+	denylist map[uuid.UUID]time.Time // jti -> token expiry
+	versions map[uuid.UUID]cachedTokenVersion
+}
+
+type cachedTokenVersion struct {
+	version   int32
+	fetchedAt time.Time
+}
+
+func NewRevocationStore(q *db.Queries, interval time.Duration) *RevocationStore {
+	return &RevocationStore{
+		q:        q,
+		interval: interval,
+		denylist: make(map[uuid.UUID]time.Time),
+		versions: make(map[uuid.UUID]cachedTokenVersion),
+	}
+}
+
+// Run loads the denylist and keeps it in sync until ctx is cancelled.
+func (s *RevocationStore) Run(ctx context.Context) {
+	if err := s.sync(ctx); err != nil {
+		slog.Error("sync revoked tokens", "error", err)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.sync(ctx); err != nil {
+				slog.Error("sync revoked tokens", "error", err)
+			}
+		}
+	}
+}
+
+// IsRevoked reports whether the token identified by tokenID, issued to userID
+// at the given token version, may no longer be used.
+func (s *RevocationStore) IsRevoked(ctx context.Context, userID, tokenID uuid.UUID, version int32) (bool, error) {
+	s.mu.RLock()
+	_, denied := s.denylist[tokenID]
+	cached, ok := s.versions[userID]
+	s.mu.RUnlock()
+
+	if denied {
+		return true, nil
+	}
+
+	// A token newer than the cached version means the version was bumped
+	// on another instance since it was cached, so look again rather than
+	// reject a token that is valid.
+	if !ok || time.Since(cached.fetchedAt) > s.interval || version > cached.version {
+		current, err := s.q.GetUserTokenVersion(ctx, userID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// Deleted users can't keep using their tokens.
+				return true, nil
+			}
+			return false, fmt.Errorf("get token version: %w", err)
+		}
+		cached = cachedTokenVersion{version: current, fetchedAt: time.Now()}
+
+		s.mu.Lock()
+		s.versions[userID] = cached
+		s.mu.Unlock()
+	}
+
+	return version < cached.version, nil
+}
+
+// RevokeToken denylists a single access token until it expires.
+func (s *RevocationStore) RevokeToken(ctx context.Context, userID, tokenID uuid.UUID, expiresAt time.Time) error {
+	if err := s.q.RevokeToken(ctx, db.RevokeTokenParams{
+		Jti:       tokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	s.mu.Lock()
+	s.denylist[tokenID] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllForUser invalidates every access token issued to the user so far.
+func (s *RevocationStore) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	version, err := s.q.IncrementUserTokenVersion(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("increment token version: %w", err)
+	}
+
+	s.mu.Lock()
+	s.versions[userID] = cachedTokenVersion{version: version, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *RevocationStore) sync(ctx context.Context) error {
+	if err := s.q.DeleteExpiredRevokedTokens(ctx); err != nil {
+		return fmt.Errorf("delete expired revoked tokens: %w", err)
+	}
+
+	rows, err := s.q.ListActiveRevokedTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("list revoked tokens: %w", err)
+	}
+
+	denylist := make(map[uuid.UUID]time.Time, len(rows))
+	for _, r := range rows {
+		denylist[r.Jti] = r.ExpiresAt
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	// Keep unexpired local entries: a token revoked while the list was
+	// being loaded must not drop out until the next sync.
+	for id, exp := range s.denylist {
+		if exp.After(now) {
+			denylist[id] = exp
+		}
+	}
+	s.denylist = denylist
+	for id, v := range s.versions {
+		if now.Sub(v.fetchedAt) > s.interval {
+			delete(s.versions, id)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+// tokenVersionDB answers the single-row token version queries with version,
+// counting how often it is asked.
+type tokenVersionDB struct {
+	version int32
+	queries int
+}
+
+func (d *tokenVersionDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (d *tokenVersionDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (d *tokenVersionDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	d.queries++
+	return versionRow(d.version)
+}
+
+type versionRow int32
+
+func (r versionRow) Scan(dest ...any) error {
+	*dest[0].(*int32) = int32(r)
+	return nil
+}
+
+func TestIsRevokedTokenVersions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	fake := &tokenVersionDB{version: 2}
+	store := service.NewRevocationStore(db.New(fake), time.Minute)
+
+	// Log out everywhere on this instance: version 2 is cached.
+	if err := store.RevokeAllForUser(ctx, userID); err != nil {
+		t.Fatalf("RevokeAllForUser() error = %v", err)
+	}
+
+	if revoked, err := store.IsRevoked(ctx, userID, uuid.New(), 1); err != nil || !revoked {
+		t.Errorf("IsRevoked(old version) = %v, %v; want true", revoked, err)
+	}
+	if revoked, err := store.IsRevoked(ctx, userID, uuid.New(), 2); err != nil || revoked {
+		t.Errorf("IsRevoked(current version) = %v, %v; want false", revoked, err)
+	}
+
+	// Another instance bumps the version and issues a token for it before
+	// this instance's cache expires.
+	fake.version = 3
+	before := fake.queries
+	if revoked, err := store.IsRevoked(ctx, userID, uuid.New(), 3); err != nil || revoked {
+		t.Errorf("IsRevoked(newer version) = %v, %v; want false", revoked, err)
+	}
+	if fake.queries != before+1 {
+		t.Errorf("token newer than the cache made %d queries, want 1", fake.queries-before)
+	}
+	if revoked, err := store.IsRevoked(ctx, userID, uuid.New(), 2); err != nil || !revoked {
+		t.Errorf("IsRevoked(version 2 after refetch) = %v, %v; want true", revoked, err)
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+)
+
+// Product search matches the words of a query against the products'
+// search_vector — name, category name and description, in that order of
+// weight — with every word taken as a prefix, so "choc cake" finds
+// "Chocolate Cakes".
+
+const (
+	maxSearchLength = 200
+	maxSearchTerms  = 8
+)
+
+// Markers ts_headline puts around matched words; see ListProducts.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// SearchHighlight is a product's name and description with the words that
+// matched the query wrapped in <mark> tags. The rest is HTML-escaped.
+type SearchHighlight struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
+// searchTSQuery turns a customer's query into a to_tsquery() expression of
+// prefix terms that must all match. It returns "" if the query has no
+// words.
+func searchTSQuery(q string) (string, error) {
+	if len(q) > maxSearchLength {
+		return "", &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("search query must be at most %d characters", maxSearchLength)}
+	}
+
+	// "Baker's" is one word; anything else that isn't a letter or digit
+	// separates words and can't reach to_tsquery's operators.
+	q = strings.NewReplacer("'", "", "’", "").Replace(q)
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		terms = append(terms, w+":*")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+// renderHeadline escapes a ts_headline result and turns its markers into
+// <mark> tags.
+func renderHeadline(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, headlineStart, "<mark>")
+	return strings.ReplaceAll(s, headlineStop, "</mark>")
+}
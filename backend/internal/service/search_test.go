@@ -0,0 +1,47 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestSearchTSQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"chocolate", "chocolate:*"},
+		{"  Choc  CAKE ", "choc:* & cake:*"},
+		{"baker's dozen", "bakers:* & dozen:*"},
+		{"crème brûlée", "crème:* & brûlée:*"},
+		{"8\" cake", "8:* & cake:*"},
+		{"a & !b | c:* <-> (d)", "a:* & b:* & c:* & d:*"},
+		{"!!! ---", ""},
+		{"", ""},
+		{"a b c d e f g h i j", "a:* & b:* & c:* & d:* & e:* & f:* & g:* & h:*"},
+	}
+	for _, tt := range tests {
+		got, err := service.SearchTSQuery(tt.q)
+		if err != nil {
+			t.Errorf("SearchTSQuery(%q) error = %v", tt.q, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SearchTSQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+
+	if _, err := service.SearchTSQuery(strings.Repeat("cake ", 41)); err == nil {
+		t.Error("SearchTSQuery() accepted a 205-character query")
+	}
+}
+
+func TestRenderHeadline(t *testing.T) {
+	got := service.RenderHeadline("Rich \x02chocolate\x03 <b>cake</b> & \x02choc\x03 chips")
+	want := "Rich <mark>chocolate</mark> &lt;b&gt;cake&lt;/b&gt; &amp; <mark>choc</mark> chips"
+	if got != want {
+		t.Errorf("RenderHeadline() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Products are sold as variants — a size, flavour or number of tiers — each
+// with its own SKU, price and stock. A product without options has a single
+// variant with empty options; it is created from the product's price and
+// stock when no variants are given.
+
+const (
+	maxSKULength         = 64
+	maxVariantOptions    = 5
+	maxOptionNameLength  = 50
+	maxOptionValueLength = 100
+)
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+type VariantResponse struct {
+	ID      string            `json:"id"`
+	SKU     string            `json:"sku"`
+	Options map[string]string `json:"options"`
+	Price   domain.Money      `json:"price"`
+	// StockQuantity is on the shelf; AvailableQuantity leaves out what is
+	// held by customers' carts.
+	StockQuantity     int32 `json:"stock_quantity"`
+	AvailableQuantity int32 `json:"available_quantity"`
+	Position          int32 `json:"position"`
+	IsActive          bool  `json:"is_active"`
+}
+
+// VariantInput describes a new variant. Options maps option names to values,
+// e.g. {"size": "8\"", "flavour": "chocolate"}; names are lower-cased. A nil
+// Position appends the variant after the existing ones.
+type VariantInput struct {
+	SKU           string
+	Options       map[string]string
+	Price         domain.Money
+	StockQuantity int32
+	Position      *int32
+	IsActive      *bool
+}
+
+// UpdateVariantInput is a partial update: nil fields are left unchanged.
+type UpdateVariantInput struct {
+	SKU           *string
+	Options       map[string]string
+	Price         *domain.Money
+	StockQuantity *int32
+	Position      *int32
+	IsActive      *bool
+}
+
+// ─── Admin: Add Variant ───────────────────────────────────────────────────────
+
+func (s *ProductService) AddVariant(ctx context.Context, productID string, in VariantInput) (*ProductResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+
+	product, err := s.q.GetProductByIDAdmin(ctx, pid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+	if product.DeletedAt.Valid {
+		return nil, &domain.AppError{Err: domain.ErrConflict, Message: "product is deleted, restore it before editing"}
+	}
+
+	params, err := newVariantParams(pid, in)
+	if err != nil {
+		return nil, err
+	}
+	if in.Position == nil {
+		existing, err := s.q.ListProductVariants(ctx, []uuid.UUID{pid})
+		if err != nil {
+			return nil, fmt.Errorf("list variants: %w", err)
+		}
+		params.Position = int32(len(existing))
+	}
+
+	if _, err := s.q.CreateProductVariant(ctx, params); err != nil {
+		return nil, mapVariantWriteError(err)
+	}
+	return s.adminGet(ctx, pid)
+}
+
+// ─── Admin: Update Variant ────────────────────────────────────────────────────
+
+func (s *ProductService) UpdateVariant(ctx context.Context, productID, variantID string, in UpdateVariantInput) (*ProductResponse, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+	vid, err := uuid.Parse(variantID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid variant id"}
+	}
+
+	existing, err := s.q.GetProductVariant(ctx, vid, pid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get variant: %w", err)
+	}
+
+	merged := VariantInput{
+		SKU:           existing.Sku,
+		Options:       decodeVariantOptions(existing.Options),
+		Price:         numericToMoney(existing.Price),
+		StockQuantity: existing.StockQuantity,
+		Position:      &existing.Position,
+		IsActive:      &existing.IsActive,
+	}
+	if in.SKU != nil {
+		merged.SKU = *in.SKU
+	}
+	if in.Options != nil {
+		merged.Options = in.Options
+	}
+	if in.Price != nil {
+		merged.Price = *in.Price
+	}
+	if in.StockQuantity != nil {
+		merged.StockQuantity = *in.StockQuantity
+	}
+	if in.Position != nil {
+		merged.Position = in.Position
+	}
+	if in.IsActive != nil {
+		merged.IsActive = in.IsActive
+	}
+
+	params, err := newVariantParams(pid, merged)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.q.UpdateProductVariant(ctx, db.UpdateProductVariantParams{
+		ID:            vid,
+		Sku:           params.Sku,
+		Options:       params.Options,
+		Price:         params.Price,
+		StockQuantity: params.StockQuantity,
+		Position:      params.Position,
+		IsActive:      params.IsActive,
+	}); err != nil {
+		return nil, mapVariantWriteError(err)
+	}
+	return s.adminGet(ctx, pid)
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+// attachVariants fills in the variants of products and their
+// available-to-promise stock. Inactive variants are only listed for admins
+// and never count towards a product's availability.
+func (s *ProductService) attachVariants(ctx context.Context, products []ProductResponse, includeInactive bool) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, uuid.MustParse(p.ID))
+	}
+
+	variants, err := s.q.ListProductVariants(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("list variants: %w", err)
+	}
+	variantIDs := make([]uuid.UUID, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+	reserved, err := reservedStock(ctx, s.q, variantIDs, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	byProduct := make(map[uuid.UUID][]VariantResponse, len(products))
+	for _, v := range variants {
+		if !v.IsActive && !includeInactive {
+			continue
+		}
+		resp := mapVariant(v)
+		resp.AvailableQuantity = availableToPromise(v.StockQuantity, reserved[v.ID])
+		byProduct[v.ProductID] = append(byProduct[v.ProductID], resp)
+	}
+
+	for i := range products {
+		products[i].Variants = byProduct[ids[i]]
+		if products[i].Variants == nil {
+			products[i].Variants = []VariantResponse{}
+		}
+		products[i].AvailableQuantity = 0
+		for _, v := range products[i].Variants {
+			if v.IsActive {
+				products[i].AvailableQuantity += v.AvailableQuantity
+			}
+		}
+	}
+	return nil
+}
+
+// newVariantParams validates a variant and prepares it for insertion.
+func newVariantParams(productID uuid.UUID, in VariantInput) (db.CreateProductVariantParams, error) {
+	sku := strings.TrimSpace(in.SKU)
+	options, err := normalizeVariantOptions(in.Options)
+	if err != nil {
+		return db.CreateProductVariantParams{}, err
+	}
+	if err := validateVariantFields(sku, in.Price, in.StockQuantity); err != nil {
+		return db.CreateProductVariantParams{}, err
+	}
+
+	params := db.CreateProductVariantParams{
+		ProductID:     productID,
+		Sku:           sku,
+		Options:       encodeVariantOptions(options),
+		Price:         moneyToNumeric(in.Price),
+		StockQuantity: in.StockQuantity,
+		IsActive:      true,
+	}
+	if in.Position != nil {
+		params.Position = *in.Position
+	}
+	if in.IsActive != nil {
+		params.IsActive = *in.IsActive
+	}
+	return params, nil
+}
+
+func validateVariantFields(sku string, price domain.Money, stock int32) error {
+	if sku == "" {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "sku is required"}
+	}
+	if len(sku) > maxSKULength {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("sku must be at most %d characters", maxSKULength)}
+	}
+	for _, r := range sku {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return &domain.AppError{Err: domain.ErrInvalidInput, Message: "sku may only contain letters, digits, '-', '_' and '.'"}
+		}
+	}
+	return validatePriceAndStock(price, stock)
+}
+
+// normalizeVariantOptions trims option names and values and lower-cases the
+// names, so that "Size" and "size " are the same option.
+func normalizeVariantOptions(options map[string]string) (map[string]string, error) {
+	if len(options) > maxVariantOptions {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("a variant can have at most %d options", maxVariantOptions)}
+	}
+	out := make(map[string]string, len(options))
+	for name, value := range options {
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if name == "" || value == "" {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "option names and values must not be empty"}
+		}
+		if len(name) > maxOptionNameLength || len(value) > maxOptionValueLength {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("option names must be at most %d and values at most %d characters", maxOptionNameLength, maxOptionValueLength)}
+		}
+		if _, dup := out[name]; dup {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("option %q is given twice", name)}
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+func encodeVariantOptions(options map[string]string) []byte {
+	if options == nil {
+		options = map[string]string{}
+	}
+	b, _ := json.Marshal(options)
+	return b
+}
+
+// decodeVariantOptions reads a variant's JSONB options. Anything unreadable
+// is treated as no options.
+func decodeVariantOptions(b []byte) map[string]string {
+	options := map[string]string{}
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, &options)
+	}
+	return options
+}
+
+// variantName names a variant for messages, e.g. "Chocolate Cake (flavour:
+// dark, size: 8")". Options are listed by name so the text is stable.
+func variantName(productName string, options []byte) string {
+	opts := decodeVariantOptions(options)
+	if len(opts) == 0 {
+		return productName
+	}
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+opts[name])
+	}
+	return productName + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// defaultVariantSKU derives a SKU for a product created without variants,
+// the same way the variants migration did for existing products.
+func defaultVariantSKU(productID uuid.UUID) string {
+	return "SKU-" + strings.ToUpper(strings.ReplaceAll(productID.String(), "-", "")[:12])
+}
+
+func mapVariant(v db.ProductVariant) VariantResponse {
+	return VariantResponse{
+		ID:                v.ID.String(),
+		SKU:               v.Sku,
+		Options:           decodeVariantOptions(v.Options),
+		Price:             numericToMoney(v.Price),
+		StockQuantity:     v.StockQuantity,
+		AvailableQuantity: v.StockQuantity,
+		Position:          v.Position,
+		IsActive:          v.IsActive,
+	}
+}
+
+func mapVariantWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		if pgErr.ConstraintName == "idx_product_variants_options" {
+			return &domain.AppError{Err: domain.ErrConflict, Message: "the product already has a variant with these options"}
+		}
+		return &domain.AppError{Err: domain.ErrConflict, Message: "a variant with this sku already exists"}
+	}
+	return fmt.Errorf("write variant: %w", err)
+}
@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestNormalizeVariantOptions(t *testing.T) {
+	got, err := service.NormalizeVariantOptions(map[string]string{" Size ": " 8\" ", "FLAVOUR": "Chocolate"})
+	if err != nil {
+		t.Fatalf("NormalizeVariantOptions() error = %v", err)
+	}
+	if got["size"] != "8\"" || got["flavour"] != "Chocolate" || len(got) != 2 {
+		t.Errorf("NormalizeVariantOptions() = %v", got)
+	}
+
+	bad := []map[string]string{
+		{"size": ""},
+		{"": "8\""},
+		{"Size": "6\"", "size": "8\""},
+		{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5", "f": "6"},
+		{"size": strings.Repeat("x", 101)},
+	}
+	for _, options := range bad {
+		if _, err := service.NormalizeVariantOptions(options); err == nil {
+			t.Errorf("NormalizeVariantOptions(%v) succeeded, want an error", options)
+		}
+	}
+}
+
+func TestValidateVariantFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		sku     string
+		price   domain.Money
+		stock   int32
+		wantErr bool
+	}{
+		{"valid", "CHOC-8.IN_2", 4500, 3, false},
+		{"missing sku", "", 4500, 3, true},
+		{"sku too long", strings.Repeat("A", 65), 4500, 3, true},
+		{"sku with spaces", "CHOC 8", 4500, 3, true},
+		{"negative price", "CHOC-8", -1, 3, true},
+		{"negative stock", "CHOC-8", 4500, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateVariantFields(tt.sku, tt.price, tt.stock)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateVariantFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVariantName(t *testing.T) {
+	tests := []struct {
+		options string
+		want    string
+	}{
+		{``, "Cake"},
+		{`{}`, "Cake"},
+		{`{"size": "8\""}`, `Cake (size: 8")`},
+		{`{"size": "8\"", "flavour": "lemon"}`, `Cake (flavour: lemon, size: 8")`},
+	}
+
+	for _, tt := range tests {
+		if got := service.VariantName("Cake", []byte(tt.options)); got != tt.want {
+			t.Errorf("VariantName(%s) = %q, want %q", tt.options, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultVariantSKU(t *testing.T) {
+	id := uuid.MustParse("0f8c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f")
+	if got, want := service.DefaultVariantSKU(id), "SKU-0F8C2D3E4A5B"; got != want {
+		t.Errorf("DefaultVariantSKU() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+)
+
+// Customers keep cakes for later in named wishlists, e.g. "Mum's birthday",
+// without holding stock the way the cart does. Items are a variant with its
+// personalisation and a quantity, and are moved into the cart through
+// CartService.AddItem when the customer is ready to order.
+//
+// Sharing a list gives it a random token; anyone with the link can read
+// the list but not change it, until the owner stops sharing it.
+
+const (
+	maxWishlists          = 20
+	maxWishlistItems      = 100
+	maxWishlistNameLength = 100
+	shareTokenBytes       = 24
+)
+
+type WishlistService struct {
+	pool  *pgxpool.Pool
+	q     *db.Queries
+	carts *CartService
+}
+
+func NewWishlistService(pool *pgxpool.Pool, q *db.Queries, carts *CartService) *WishlistService {
+	return &WishlistService{pool: pool, q: q, carts: carts}
+}
+
+// ─── DTOs ────────────────────────────────────────────────────────────────────
+
+type WishlistResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// ShareToken is present while the list is shared.
+	ShareToken *string `json:"share_token,omitempty"`
+	ItemCount  int     `json:"item_count"`
+	// Items are only included when fetching a single list.
+	Items     []WishlistItemResponse `json:"items,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// SharedWishlistResponse is a list as others see it through its share link.
+type SharedWishlistResponse struct {
+	Name      string                 `json:"name"`
+	Items     []WishlistItemResponse `json:"items"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+type WishlistItemResponse struct {
+	ID           string            `json:"id"`
+	ProductID    string            `json:"product_id"`
+	VariantID    string            `json:"variant_id"`
+	ProductName  string            `json:"product_name"`
+	ProductImage *string           `json:"product_image_url"`
+	SKU          string            `json:"sku"`
+	Options      map[string]string `json:"options"`
+	// Price is per cake at today's prices, including PersonalisationPrice.
+	Price                domain.Money     `json:"price"`
+	Personalisation      *Personalisation `json:"personalisation,omitempty"`
+	PersonalisationPrice domain.Money     `json:"personalisation_price"`
+	Quantity             int32            `json:"quantity"`
+	// Available is false once the product or variant is no longer sold;
+	// such items can't be moved to the cart.
+	Available bool      `json:"available"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// AddWishlistItemInput adds a variant of a product to one of the user's
+// lists. VariantID may be left empty for products that come in a single
+// variant. Adding an item the list already has sets its quantity.
+type AddWishlistItemInput struct {
+	UserID          uuid.UUID
+	WishlistID      string
+	ProductID       string
+	VariantID       string
+	Personalisation Personalisation
+	Quantity        int32
+}
+
+// ─── Lists ────────────────────────────────────────────────────────────────────
+
+func (s *WishlistService) ListWishlists(ctx context.Context, userID uuid.UUID) ([]WishlistResponse, error) {
+	rows, err := s.q.ListWishlists(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list wishlists: %w", err)
+	}
+	out := make([]WishlistResponse, 0, len(rows))
+	for _, row := range rows {
+		resp := mapWishlist(db.Wishlist{
+			ID:         row.ID,
+			UserID:     row.UserID,
+			Name:       row.Name,
+			ShareToken: row.ShareToken,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+		})
+		resp.ItemCount = int(row.ItemCount)
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// CreateWishlist creates an empty list. A user can have maxWishlists lists,
+// each with a different name.
+func (s *WishlistService) CreateWishlist(ctx context.Context, userID uuid.UUID, name string) (*WishlistResponse, error) {
+	name, err := validateWishlistName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var wishlist db.Wishlist
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		if err := qtx.LockUserWishlists(ctx, userID); err != nil {
+			return fmt.Errorf("lock user: %w", err)
+		}
+		n, err := qtx.CountWishlists(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("count wishlists: %w", err)
+		}
+		if n >= maxWishlists {
+			return &domain.AppError{Err: domain.ErrConflict, Message: fmt.Sprintf("you can have at most %d wishlists", maxWishlists)}
+		}
+
+		wishlist, err = qtx.CreateWishlist(ctx, userID, name)
+		if err != nil {
+			return mapWishlistWriteError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := mapWishlist(wishlist)
+	return &resp, nil
+}
+
+// GetWishlist returns one of the user's lists with its items.
+func (s *WishlistService) GetWishlist(ctx context.Context, userID uuid.UUID, wishlistID string) (*WishlistResponse, error) {
+	wishlist, err := s.findWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return s.wishlistDetails(ctx, wishlist)
+}
+
+func (s *WishlistService) RenameWishlist(ctx context.Context, userID uuid.UUID, wishlistID, name string) (*WishlistResponse, error) {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid wishlist id"}
+	}
+	name, err = validateWishlistName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := s.q.RenameWishlist(ctx, id, userID, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, mapWishlistWriteError(err)
+	}
+	return s.wishlistDetails(ctx, wishlist)
+}
+
+// DeleteWishlist deletes a list and its items. Its share link stops
+// working.
+func (s *WishlistService) DeleteWishlist(ctx context.Context, userID uuid.UUID, wishlistID string) error {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid wishlist id"}
+	}
+	n, err := s.q.DeleteWishlist(ctx, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete wishlist: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ─── Items ────────────────────────────────────────────────────────────────────
+
+// AddItem adds a cake to one of the user's lists, which can hold
+// maxWishlistItems items. Only cakes still sold can be added, with a
+// personalisation their product offers.
+func (s *WishlistService) AddItem(ctx context.Context, in AddWishlistItemInput) (*WishlistResponse, error) {
+	if in.Quantity < 1 {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "quantity must be at least 1"}
+	}
+	wishlistID, err := uuid.Parse(in.WishlistID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid wishlist id"}
+	}
+	productID, err := uuid.Parse(in.ProductID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid product id"}
+	}
+	var variantID uuid.UUID
+	if in.VariantID != "" {
+		if variantID, err = uuid.Parse(in.VariantID); err != nil {
+			return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid variant id"}
+		}
+	}
+
+	var wishlist db.Wishlist
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		// Locked so concurrent adds can't exceed the item limit.
+		wishlist, err = qtx.GetWishlistForUpdate(ctx, wishlistID, in.UserID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get wishlist: %w", err)
+		}
+
+		if variantID == uuid.Nil {
+			only, err := onlyVariant(ctx, qtx, productID)
+			if err != nil {
+				return err
+			}
+			variantID = only
+		}
+		variant, err := qtx.GetWishlistVariant(ctx, variantID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("get variant: %w", err)
+		}
+		if variant.ProductID != productID {
+			return &domain.AppError{Err: domain.ErrInvalidInput, Message: "variant does not belong to the product"}
+		}
+		personalisation, err := checkPersonalisation(decodePersonalisationOptions(variant.PersonalisationOptions), in.Personalisation)
+		if err != nil {
+			return err
+		}
+
+		encoded := encodePersonalisation(personalisation)
+
+		// Items already on the list only change quantity, so they are let
+		// through at the limit.
+		n, err := qtx.CountWishlistItems(ctx, wishlist.ID)
+		if err != nil {
+			return fmt.Errorf("count wishlist items: %w", err)
+		}
+		if n >= maxWishlistItems {
+			exists, err := qtx.WishlistItemExists(ctx, wishlist.ID, variantID, encoded)
+			if err != nil {
+				return fmt.Errorf("find wishlist item: %w", err)
+			}
+			if !exists {
+				return &domain.AppError{Err: domain.ErrConflict, Message: fmt.Sprintf("a wishlist can have at most %d items", maxWishlistItems)}
+			}
+		}
+
+		if _, err := qtx.UpsertWishlistItem(ctx, db.UpsertWishlistItemParams{
+			WishlistID:      wishlist.ID,
+			ProductID:       productID,
+			VariantID:       variantID,
+			Personalisation: encoded,
+			Quantity:        in.Quantity,
+		}); err != nil {
+			return fmt.Errorf("upsert wishlist item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.wishlistDetails(ctx, wishlist)
+}
+
+func (s *WishlistService) RemoveItem(ctx context.Context, userID uuid.UUID, wishlistID, itemID string) (*WishlistResponse, error) {
+	wishlist, err := s.findWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	iid, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid item id"}
+	}
+
+	n, err := s.q.DeleteWishlistItem(ctx, iid, wishlist.ID)
+	if err != nil {
+		return nil, fmt.Errorf("delete wishlist item: %w", err)
+	}
+	if n == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return s.wishlistDetails(ctx, wishlist)
+}
+
+// MoveItemToCart adds a list item to the user's cart, as CartService.AddItem
+// would, and takes it off the list in the same transaction, so a retry can
+// never add it twice. The cart line for the same cake and personalisation
+// takes the item's quantity. The item stays on the list if it can't be
+// added, e.g. because it is no longer sold or out of stock.
+func (s *WishlistService) MoveItemToCart(ctx context.Context, userID uuid.UUID, wishlistID, itemID string) (*CartResponse, error) {
+	wishlist, err := s.findWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	iid, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid item id"}
+	}
+	item, err := s.q.GetWishlistItem(ctx, iid, wishlist.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get wishlist item: %w", err)
+	}
+
+	owner := CartOwner{UserID: userID}
+	cart, err := s.carts.getOrCreateCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		qtx := s.q.WithTx(tx)
+
+		// Deleting first means a concurrent move of the same item finds
+		// nothing left to add.
+		n, err := qtx.DeleteWishlistItem(ctx, item.ID, wishlist.ID)
+		if err != nil {
+			return fmt.Errorf("delete wishlist item: %w", err)
+		}
+		if n == 0 {
+			return domain.ErrNotFound
+		}
+		return s.carts.addItem(ctx, qtx, cart.ID, item.ProductID, item.VariantID, decodePersonalisation(item.Personalisation), item.Quantity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.carts.GetCart(ctx, owner)
+}
+
+// ─── Sharing ──────────────────────────────────────────────────────────────────
+
+// ShareWishlist makes a list readable by anyone with its share token. A
+// list already shared keeps its token.
+func (s *WishlistService) ShareWishlist(ctx context.Context, userID uuid.UUID, wishlistID string) (*WishlistResponse, error) {
+	wishlist, err := s.findWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	if wishlist.ShareToken.Valid {
+		return s.wishlistDetails(ctx, wishlist)
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate share token: %w", err)
+	}
+	wishlist, err = s.q.SetWishlistShareToken(ctx, wishlist.ID, userID, pgtype.Text{String: token, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("share wishlist: %w", err)
+	}
+	return s.wishlistDetails(ctx, wishlist)
+}
+
+// UnshareWishlist stops a list's share link working. Sharing it again
+// gives it a new one.
+func (s *WishlistService) UnshareWishlist(ctx context.Context, userID uuid.UUID, wishlistID string) (*WishlistResponse, error) {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return nil, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid wishlist id"}
+	}
+	wishlist, err := s.q.SetWishlistShareToken(ctx, id, userID, pgtype.Text{})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("unshare wishlist: %w", err)
+	}
+	return s.wishlistDetails(ctx, wishlist)
+}
+
+// GetSharedWishlist returns the list a share token was issued for, as
+// anyone may see it.
+func (s *WishlistService) GetSharedWishlist(ctx context.Context, token string) (*SharedWishlistResponse, error) {
+	if token == "" {
+		return nil, domain.ErrNotFound
+	}
+	wishlist, err := s.q.GetWishlistByShareToken(ctx, pgtype.Text{String: token, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get shared wishlist: %w", err)
+	}
+	items, err := s.wishlistItems(ctx, wishlist.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedWishlistResponse{Name: wishlist.Name, Items: items, UpdatedAt: wishlist.UpdatedAt}, nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func (s *WishlistService) findWishlist(ctx context.Context, userID uuid.UUID, wishlistID string) (db.Wishlist, error) {
+	id, err := uuid.Parse(wishlistID)
+	if err != nil {
+		return db.Wishlist{}, &domain.AppError{Err: domain.ErrInvalidInput, Message: "invalid wishlist id"}
+	}
+	wishlist, err := s.q.GetWishlist(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Wishlist{}, domain.ErrNotFound
+		}
+		return db.Wishlist{}, fmt.Errorf("get wishlist: %w", err)
+	}
+	return wishlist, nil
+}
+
+func (s *WishlistService) wishlistDetails(ctx context.Context, wishlist db.Wishlist) (*WishlistResponse, error) {
+	items, err := s.wishlistItems(ctx, wishlist.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapWishlist(wishlist)
+	resp.Items = items
+	resp.ItemCount = len(items)
+	return &resp, nil
+}
+
+func (s *WishlistService) wishlistItems(ctx context.Context, wishlistID uuid.UUID) ([]WishlistItemResponse, error) {
+	rows, err := s.q.ListWishlistItems(ctx, wishlistID)
+	if err != nil {
+		return nil, fmt.Errorf("list wishlist items: %w", err)
+	}
+	items := make([]WishlistItemResponse, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapWishlistItem(row))
+	}
+	return items, nil
+}
+
+// generateShareToken returns a random, URL-safe share token.
+func generateShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validateWishlistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxWishlistNameLength {
+		return "", &domain.AppError{Err: domain.ErrInvalidInput, Message: fmt.Sprintf("name is required and must be at most %d characters", maxWishlistNameLength)}
+	}
+	return name, nil
+}
+
+func mapWishlist(w db.Wishlist) WishlistResponse {
+	resp := WishlistResponse{
+		ID:        w.ID.String(),
+		Name:      w.Name,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+	if w.ShareToken.Valid {
+		resp.ShareToken = &w.ShareToken.String
+	}
+	return resp
+}
+
+func mapWishlistItem(row db.ListWishlistItemsRow) WishlistItemResponse {
+	personalisation := decodePersonalisation(row.Personalisation)
+	surcharge := personalisationPrice(decodePersonalisationOptions(row.ProductPersonalisationOptions), personalisation)
+
+	item := WishlistItemResponse{
+		ID:                   row.ID.String(),
+		ProductID:            row.ProductID.String(),
+		VariantID:            row.VariantID.String(),
+		ProductName:          row.ProductName,
+		SKU:                  row.VariantSku,
+		Options:              decodeVariantOptions(row.VariantOptions),
+		Price:                numericToMoney(row.VariantPrice).Add(surcharge),
+		PersonalisationPrice: surcharge,
+		Quantity:             row.Quantity,
+		Available:            !row.Unavailable,
+		AddedAt:              row.CreatedAt,
+	}
+	if !personalisation.isZero() {
+		item.Personalisation = &personalisation
+	}
+	if row.ProductImageUrl.Valid {
+		item.ProductImage = &row.ProductImageUrl.String
+	}
+	return item
+}
+
+func mapWishlistWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation on the name
+		return &domain.AppError{Err: domain.ErrConflict, Message: "you already have a wishlist with this name"}
+	}
+	return fmt.Errorf("write wishlist: %w", err)
+}
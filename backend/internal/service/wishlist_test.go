@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/online-cake-shop/backend/internal/domain"
+	"github.com/online-cake-shop/backend/internal/repository/db"
+	"github.com/online-cake-shop/backend/internal/service"
+)
+
+func TestValidateWishlistName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"Mum's birthday", "Mum's birthday", false},
+		{"  Christmas  ", "Christmas", false},
+		{"", "", true},
+		{"   ", "", true},
+		{strings.Repeat("a", 100), strings.Repeat("a", 100), false},
+		{strings.Repeat("a", 101), "", true},
+	}
+	for _, tt := range tests {
+		got, err := service.ValidateWishlistName(tt.name)
+		if tt.wantErr {
+			if !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("ValidateWishlistName(%q) error = %v, want ErrInvalidInput", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ValidateWishlistName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestMapWishlistItem(t *testing.T) {
+	options, _ := json.Marshal(service.PersonalisationOptions{
+		Inscription: &service.InscriptionOption{MaxLength: 30, Price: 250},
+		Candles:     &service.CandlesOption{Max: 10, PriceEach: 20},
+	})
+	personalisation, _ := json.Marshal(service.Personalisation{Inscription: "Happy Birthday Mum", Candles: 5})
+
+	row := db.ListWishlistItemsRow{
+		ID:                            uuid.New(),
+		ProductID:                     uuid.New(),
+		VariantID:                     uuid.New(),
+		Personalisation:               personalisation,
+		Quantity:                      2,
+		ProductName:                   "Victoria Sponge",
+		VariantPrice:                  cents(2400),
+		VariantSku:                    "VS-8",
+		VariantOptions:                []byte(`{"size":"8 inch"}`),
+		ProductPersonalisationOptions: options,
+	}
+
+	item := service.MapWishlistItem(row)
+	if item.PersonalisationPrice != 350 || item.Price != 2750 {
+		t.Errorf("price = %v (personalisation %v), want 27.50 (3.50)", item.Price, item.PersonalisationPrice)
+	}
+	if item.Personalisation == nil || item.Personalisation.Inscription != "Happy Birthday Mum" {
+		t.Errorf("personalisation = %+v, want the inscription", item.Personalisation)
+	}
+	if !item.Available || item.Options["size"] != "8 inch" {
+		t.Errorf("available = %v, options = %v", item.Available, item.Options)
+	}
+
+	row.Unavailable = true
+	row.Personalisation = []byte(`{}`)
+	item = service.MapWishlistItem(row)
+	if item.Available || item.Personalisation != nil || item.Price != 2400 {
+		t.Errorf("unpersonalised withdrawn item = %+v", item)
+	}
+}
+
+func TestGenerateShareToken(t *testing.T) {
+	a, err := service.GenerateShareToken()
+	if err != nil {
+		t.Fatalf("GenerateShareToken() error = %v", err)
+	}
+	b, _ := service.GenerateShareToken()
+	if a == b {
+		t.Error("GenerateShareToken() returned the same token twice")
+	}
+	if len(a) != 32 || strings.ContainsAny(a, "+/=") {
+		t.Errorf("GenerateShareToken() = %q, want 32 URL-safe characters", a)
+	}
+}
@@ -0,0 +1,69 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/online-cake-shop/backend/internal/storage"
+)
+
+func TestLocalDisk(t *testing.T) {
+	ctx := context.Background()
+	disk, err := storage.NewLocalDisk(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalDisk() error = %v", err)
+	}
+
+	const key = "products/abc/image.jpg"
+	if err := disk.Put(ctx, key, strings.NewReader("cake")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	obj, err := disk.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	b, _ := io.ReadAll(obj)
+	obj.Close()
+	if string(b) != "cake" || obj.Size != 4 {
+		t.Errorf("Open() = %q (size %d), want \"cake\" (size 4)", b, obj.Size)
+	}
+
+	if err := disk.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := disk.Open(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Open() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := disk.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing key error = %v", err)
+	}
+	if _, err := disk.Open(ctx, "products"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Open() of a directory error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"products/abc/image.jpg", true},
+		{"products/abc/image-thumb.png", true},
+		{"", false},
+		{"../etc/passwd", false},
+		{"products/../secret", false},
+		{"/abs/path", false},
+		{"products//image.jpg", false},
+		{"Products/Image.JPG", false},
+		{`products\image.jpg`, false},
+	}
+	for _, tt := range tests {
+		if got := storage.ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Open for keys that hold nothing.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage is the abstract file store uploaded images are kept in. Keys are
+// slash-separated paths such as "products/<id>/<image>.jpg".
+type Storage interface {
+	// Put stores the contents of r under key, replacing anything there.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the object stored under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (*Object, error)
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is a stored file. Callers must close it.
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// ValidKey reports whether key is a relative path of lower-case letters,
+// digits, '-', '_' and '.', with no empty, "." or ".." segments.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 255 {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+		for _, r := range seg {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -4,18 +4,25 @@ import { useState } from 'react'
 import { Button } from '@/components/ui/button'
 import { useAuthStore } from '@/store/authStore'
 import { useCartStore } from '@/store/cartStore'
+import { authService } from '@/services/auth'
 import { cn } from '@/lib/utils'
 
 export function Header() {
   const { isAuthenticated, user, clearAuth } = useAuthStore()
-  const { itemCount, openCart } = useCartStore()
+  const { itemCount, openCart, clearCart } = useCartStore()
   const navigate = useNavigate()
   const [mobileMenuOpen, setMobileMenuOpen] = useState(false)
 
   const count = itemCount()
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
+    try {
+      await authService.logout()
+    } catch {
+      // the local session is cleared regardless
+    }
     clearAuth()
+    clearCart()
     navigate('/')
   }
 
@@ -46,23 +53,21 @@ export function Header() {
 
         {/* Actions */}
         <div className="flex items-center gap-2">
-          {/* Cart button (only when authenticated) */}
-          {isAuthenticated && (
-            <Button
-              variant="ghost"
-              size="icon"
-              onClick={openCart}
-              className="relative"
-              aria-label="Shopping cart"
-            >
-              <ShoppingCart className="h-5 w-5" />
-              {count > 0 && (
-                <span className="absolute -top-1 -right-1 flex h-5 w-5 items-center justify-center rounded-full bg-primary text-[10px] font-bold text-primary-foreground">
-                  {count > 99 ? '99+' : count}
-                </span>
-              )}
-            </Button>
-          )}
+          {/* Cart button (guests have a cart too) */}
+          <Button
+            variant="ghost"
+            size="icon"
+            onClick={openCart}
+            className="relative"
+            aria-label="Shopping cart"
+          >
+            <ShoppingCart className="h-5 w-5" />
+            {count > 0 && (
+              <span className="absolute -top-1 -right-1 flex h-5 w-5 items-center justify-center rounded-full bg-primary text-[10px] font-bold text-primary-foreground">
+                {count > 99 ? '99+' : count}
+              </span>
+            )}
+          </Button>
 
           {/* Auth buttons */}
           {isAuthenticated ? (
@@ -1,4 +1,4 @@
-import { X, Plus, Minus, Trash2, ShoppingBag } from 'lucide-react'
+import { X, Plus, Minus, Trash2, ShoppingBag, AlertTriangle } from 'lucide-react'
 import { Link } from 'react-router-dom'
 import { useState } from 'react'
 import { Button } from '@/components/ui/button'
@@ -78,7 +78,7 @@ export function CartDrawer() {
             </div>
           ) : (
             cart.items.map((item) => (
-              <div key={item.id} className="flex gap-3">
+              <div key={item.id} className={cn('flex gap-3', !item.available && 'opacity-60')}>
                 {/* Product image */}
                 <div className="h-16 w-16 shrink-0 rounded-lg overflow-hidden bg-muted">
                   {item.product_image_url ? (
@@ -98,6 +98,18 @@ export function CartDrawer() {
                 <div className="flex-1 min-w-0">
                   <p className="font-medium text-sm truncate">{item.product_name}</p>
                   <p className="text-primary font-bold text-sm">{formatCurrency(item.price)}</p>
+                  {item.warnings.map((warning) => (
+                    <p
+                      key={warning.code}
+                      className={cn(
+                        'flex items-center gap-1 text-xs',
+                        warning.code === 'price_changed' ? 'text-amber-600' : 'text-destructive'
+                      )}
+                    >
+                      <AlertTriangle className="h-3 w-3 shrink-0" />
+                      {warning.message}
+                    </p>
+                  ))}
 
                   {/* Quantity controls */}
                   <div className="flex items-center gap-2 mt-1.5">
@@ -111,7 +123,7 @@ export function CartDrawer() {
                     <span className="text-sm font-semibold w-6 text-center">{item.quantity}</span>
                     <button
                       onClick={() => handleUpdateQuantity(item.id, item.quantity + 1)}
-                      disabled={loadingItem === item.id}
+                      disabled={loadingItem === item.id || item.quantity >= item.available_quantity}
                       className="h-6 w-6 flex items-center justify-center rounded border hover:bg-muted transition-colors disabled:opacity-50"
                     >
                       <Plus className="h-3 w-3" />
@@ -140,8 +152,31 @@ export function CartDrawer() {
           <div className="border-t p-4 space-y-3">
             <div className="flex justify-between text-sm">
               <span className="text-muted-foreground">Subtotal</span>
-              <span className="font-semibold">{formatCurrency(cart.total)}</span>
+              <span className="font-semibold">{formatCurrency(cart.subtotal)}</span>
             </div>
+            {cart.promotions.map((promotion) => (
+              <div
+                key={promotion.promotion_id ?? promotion.name}
+                className="flex justify-between text-sm"
+              >
+                <span className="text-muted-foreground">{promotion.name}</span>
+                <span className="font-semibold text-green-600">
+                  −{formatCurrency(promotion.discount)}
+                </span>
+              </div>
+            ))}
+            {cart.discount > 0 && (
+              <div className="flex justify-between text-sm">
+                <span className="text-muted-foreground">Coupon {cart.coupon?.code}</span>
+                <span className="font-semibold text-green-600">−{formatCurrency(cart.discount)}</span>
+              </div>
+            )}
+            {cart.delivery_fee > 0 && (
+              <div className="flex justify-between text-sm">
+                <span className="text-muted-foreground">Delivery</span>
+                <span className="font-semibold">{formatCurrency(cart.delivery_fee)}</span>
+              </div>
+            )}
             <Separator />
             <div className="flex justify-between font-bold text-base">
               <span>Total</span>
@@ -0,0 +1,178 @@
+import type { ReactNode } from 'react'
+import { cn, formatCurrency } from '@/lib/utils'
+import type { FacetCount, ListProductsParams, ProductFacets } from '@/types'
+
+type FilterPatch = Partial<
+  Pick<ListProductsParams, 'category_id' | 'min_price' | 'max_price' | 'in_stock' | 'dietary_tag' | 'size' | 'free_from'>
+>
+
+interface FacetSidebarProps {
+  facets: ProductFacets
+  params: ListProductsParams
+  onChange: (patch: FilterPatch) => void
+}
+
+function priceLabel(min: number | null, max: number | null) {
+  if (min === null && max !== null) return `Under ${formatCurrency(max + 0.01)}`
+  if (max === null && min !== null) return `${formatCurrency(min)} and over`
+  return `${formatCurrency(min ?? 0)} – ${formatCurrency(max ?? 0)}`
+}
+
+function toggle(list: string[] | undefined, value: string) {
+  const next = list?.includes(value) ? list.filter((v) => v !== value) : [...(list ?? []), value]
+  return next.length ? next : undefined
+}
+
+function FacetOption({
+  label,
+  count,
+  active,
+  onClick,
+}: {
+  label: string
+  count: number
+  active: boolean
+  onClick: () => void
+}) {
+  return (
+    <button
+      type="button"
+      onClick={onClick}
+      disabled={count === 0 && !active}
+      className={cn(
+        'flex w-full items-center justify-between rounded-md px-2 py-1.5 text-sm text-left transition-colors',
+        active ? 'bg-orange-100 font-medium text-orange-900' : 'hover:bg-gray-100',
+        'disabled:cursor-not-allowed disabled:opacity-40'
+      )}
+    >
+      <span>{label}</span>
+      <span className="text-xs text-muted-foreground">{count}</span>
+    </button>
+  )
+}
+
+function FacetGroup({ title, children }: { title: string; children: ReactNode }) {
+  return (
+    <div className="space-y-1">
+      <h3 className="mb-2 text-xs font-semibold uppercase tracking-wide text-muted-foreground">{title}</h3>
+      {children}
+    </div>
+  )
+}
+
+function TagOptions({
+  counts,
+  selected,
+  onToggle,
+  format = (value) => value,
+}: {
+  counts: FacetCount[]
+  selected: string[] | undefined
+  onToggle: (value: string) => void
+  format?: (value: string) => string
+}) {
+  // Keep selected values listed even when nothing else matches them.
+  const missing = (selected ?? [])
+    .filter((v) => !counts.some((c) => c.value === v))
+    .map((value): FacetCount => ({ value, count: 0 }))
+  return (
+    <>
+      {[...counts, ...missing].map((c) => (
+        <FacetOption
+          key={c.value}
+          label={c.label ?? format(c.value)}
+          count={c.count}
+          active={selected?.includes(c.value) ?? false}
+          onClick={() => onToggle(c.value)}
+        />
+      ))}
+    </>
+  )
+}
+
+export function FacetSidebar({ facets, params, onChange }: FacetSidebarProps) {
+  return (
+    <aside className="w-full space-y-6 lg:w-56 lg:shrink-0">
+      {facets.categories.length > 0 && (
+        <FacetGroup title="Category">
+          {facets.categories.map((c) => (
+            <FacetOption
+              key={c.value}
+              label={c.label ?? c.value}
+              count={c.count}
+              active={params.category_id === c.value}
+              onClick={() =>
+                onChange({ category_id: params.category_id === c.value ? undefined : c.value })
+              }
+            />
+          ))}
+        </FacetGroup>
+      )}
+
+      <FacetGroup title="Price">
+        {facets.price_ranges.map((r) => {
+          const active =
+            params.min_price === (r.min ?? undefined) && params.max_price === (r.max ?? undefined)
+          return (
+            <FacetOption
+              key={`${r.min}-${r.max}`}
+              label={priceLabel(r.min, r.max)}
+              count={r.count}
+              active={active}
+              onClick={() =>
+                onChange(
+                  active
+                    ? { min_price: undefined, max_price: undefined }
+                    : { min_price: r.min ?? undefined, max_price: r.max ?? undefined }
+                )
+              }
+            />
+          )
+        })}
+      </FacetGroup>
+
+      <FacetGroup title="Availability">
+        <label className="flex items-center gap-2 px-2 text-sm">
+          <input
+            type="checkbox"
+            checked={params.in_stock ?? false}
+            onChange={(e) => onChange({ in_stock: e.target.checked || undefined })}
+          />
+          In stock only
+        </label>
+      </FacetGroup>
+
+      {(facets.dietary_tags.length > 0 || (params.dietary_tag?.length ?? 0) > 0) && (
+        <FacetGroup title="Dietary">
+          <TagOptions
+            counts={facets.dietary_tags}
+            selected={params.dietary_tag}
+            onToggle={(v) => onChange({ dietary_tag: toggle(params.dietary_tag, v) })}
+            format={(v) => v.replace(/-/g, ' ')}
+          />
+        </FacetGroup>
+      )}
+
+      {(facets.sizes.length > 0 || (params.size?.length ?? 0) > 0) && (
+        <FacetGroup title="Size">
+          <TagOptions
+            counts={facets.sizes}
+            selected={params.size}
+            onToggle={(v) => onChange({ size: toggle(params.size, v) })}
+          />
+        </FacetGroup>
+      )}
+
+      {(facets.free_from.length > 0 || (params.free_from?.length ?? 0) > 0) && (
+        <FacetGroup title="Free from">
+          <TagOptions
+            counts={facets.free_from}
+            selected={params.free_from}
+            onToggle={(v) => onChange({ free_from: toggle(params.free_from, v) })}
+            format={(v) => v.replace(/-/g, ' ')}
+          />
+        </FacetGroup>
+      )}
+    </aside>
+  )
+}
@@ -1,34 +1,50 @@
 import { ShoppingCart, ImageOff } from 'lucide-react'
 import { useState } from 'react'
-import { useNavigate } from 'react-router-dom'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
-import { useAuthStore } from '@/store/authStore'
 import { useCartStore } from '@/store/cartStore'
 import { cartService } from '@/services/cart'
 import { formatCurrency } from '@/lib/utils'
-import type { Product } from '@/types'
+import type { AllergenStatus, Product, ProductVariant } from '@/types'
+
+function variantLabel(variant: ProductVariant) {
+  return Object.entries(variant.options)
+    .map(([name, value]) => `${name}: ${value}`)
+    .join(', ')
+}
+
+const allergenLabels: Record<AllergenStatus, string> = {
+  contains: 'Contains',
+  may_contain: 'May contain',
+  free_from: 'Free from',
+}
+
+function allergenLines(product: Product) {
+  return (Object.keys(allergenLabels) as AllergenStatus[])
+    .map((status) => ({
+      status,
+      names: product.allergens.filter((a) => a.status === status).map((a) => a.name),
+    }))
+    .filter((line) => line.names.length > 0)
+}
 
 interface ProductCardProps {
   product: Product
 }
 
 export function ProductCard({ product }: ProductCardProps) {
-  const { isAuthenticated } = useAuthStore()
   const { setCart, openCart } = useCartStore()
-  const navigate = useNavigate()
   const [adding, setAdding] = useState(false)
   const [imgError, setImgError] = useState(false)
+  const [variantId, setVariantId] = useState(
+    () => (product.variants.find((v) => v.available_quantity > 0) ?? product.variants[0])?.id
+  )
+  const variant = product.variants.find((v) => v.id === variantId)
 
   const handleAddToCart = async () => {
-    if (!isAuthenticated) {
-      navigate('/register')
-      return
-    }
-
     setAdding(true)
     try {
-      const updatedCart = await cartService.addItem(product.id, 1)
+      const updatedCart = await cartService.addItem(product.id, 1, variant?.id)
       setCart(updatedCart)
       openCart()
     } catch (err) {
@@ -38,15 +54,16 @@ export function ProductCard({ product }: ProductCardProps) {
     }
   }
 
-  const outOfStock = product.stock_quantity === 0
+  const outOfStock = (variant ?? product).available_quantity === 0
+  const imageSrc = product.images[0]?.thumbnail_url ?? product.image_url
 
   return (
     <div className="group flex flex-col rounded-xl border bg-card shadow-sm hover:shadow-md transition-shadow overflow-hidden">
       {/* Image */}
       <div className="relative overflow-hidden aspect-[4/3] bg-muted">
-        {product.image_url && !imgError ? (
+        {imageSrc && !imgError ? (
           <img
-            src={product.image_url}
+            src={imageSrc}
             alt={product.name}
             className="h-full w-full object-cover group-hover:scale-105 transition-transform duration-300"
             onError={() => setImgError(true)}
@@ -78,17 +95,60 @@ export function ProductCard({ product }: ProductCardProps) {
       {/* Content */}
       <div className="flex flex-col flex-1 p-4 gap-3">
         <div className="flex-1">
-          <h3 className="font-semibold text-base leading-tight line-clamp-2">{product.name}</h3>
-          {product.description && (
-            <p className="mt-1.5 text-sm text-muted-foreground line-clamp-2">
-              {product.description}
-            </p>
+          {product.highlight ? (
+            <>
+              {/* The API escapes highlights; only <mark> tags remain. */}
+              <h3
+                className="font-semibold text-base leading-tight line-clamp-2"
+                dangerouslySetInnerHTML={{ __html: product.highlight.name }}
+              />
+              {product.highlight.description && (
+                <p
+                  className="mt-1.5 text-sm text-muted-foreground line-clamp-2"
+                  dangerouslySetInnerHTML={{ __html: product.highlight.description }}
+                />
+              )}
+            </>
+          ) : (
+            <>
+              <h3 className="font-semibold text-base leading-tight line-clamp-2">{product.name}</h3>
+              {product.description && (
+                <p className="mt-1.5 text-sm text-muted-foreground line-clamp-2">
+                  {product.description}
+                </p>
+              )}
+            </>
+          )}
+          {product.allergens.length > 0 && (
+            <div className="mt-2 space-y-0.5 text-xs text-muted-foreground">
+              {allergenLines(product).map((line) => (
+                <p key={line.status}>
+                  <span className="font-medium text-foreground">{allergenLabels[line.status]}:</span>{' '}
+                  {line.names.join(', ')}
+                </p>
+              ))}
+            </div>
           )}
         </div>
 
+        {product.variants.length > 1 && (
+          <select
+            value={variantId}
+            onChange={(e) => setVariantId(e.target.value)}
+            className="h-9 rounded-md border bg-background px-2 text-sm"
+            aria-label="Choose an option"
+          >
+            {product.variants.map((v) => (
+              <option key={v.id} value={v.id} disabled={v.available_quantity === 0}>
+                {variantLabel(v)}
+              </option>
+            ))}
+          </select>
+        )}
+
         <div className="flex items-center justify-between gap-2">
           <span className="text-lg font-bold text-primary">
-            {formatCurrency(product.price)}
+            {formatCurrency((variant ?? product).price)}
           </span>
           <Button
             size="sm"
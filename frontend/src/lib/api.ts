@@ -1,4 +1,4 @@
-import axios, { AxiosError } from 'axios'
+import axios, { AxiosError, type InternalAxiosRequestConfig } from 'axios'
 
 const api = axios.create({
   baseURL: '/api/v1',
@@ -15,10 +15,51 @@ api.interceptors.request.use((config) => {
   return config
 })
 
+// ─── Token refresh ────────────────────────────────────────────────────────────
+// Access tokens are short-lived; the refresh token lives in an HTTP-only
+// cookie scoped to /api/v1/auth. Concurrent 401s share one refresh call.
+let refreshPromise: Promise<string> | null = null
+
+const refreshAccessToken = (): Promise<string> => {
+  if (!refreshPromise) {
+    refreshPromise = axios
+      .post<{ success: boolean; data: { token: string } }>('/api/v1/auth/refresh', undefined, {
+        withCredentials: true,
+      })
+      .then(({ data }) => {
+        localStorage.setItem('auth_token', data.data.token)
+        return data.data.token
+      })
+      .finally(() => {
+        refreshPromise = null
+      })
+  }
+  return refreshPromise
+}
+
+type RetriableConfig = InternalAxiosRequestConfig & { _retried?: boolean }
+
 // ─── Response interceptor ─────────────────────────────────────────────────────
 api.interceptors.response.use(
   (response) => response,
-  (error: AxiosError<{ error?: string }>) => {
+  async (error: AxiosError<{ error?: string }>) => {
+    const original = error.config as RetriableConfig | undefined
+    if (
+      error.response?.status === 401 &&
+      original &&
+      !original._retried &&
+      !original.url?.startsWith('/auth/')
+    ) {
+      original._retried = true
+      try {
+        const token = await refreshAccessToken()
+        original.headers.Authorization = `Bearer ${token}`
+        return api(original)
+      } catch {
+        // fall through to sign-out below
+      }
+    }
+
     if (error.response?.status === 401) {
       localStorage.removeItem('auth_token')
       localStorage.removeItem('user')
@@ -34,9 +34,14 @@ export function getOrderStatusColor(status: string): string {
   const map: Record<string, string> = {
     pending: 'bg-yellow-100 text-yellow-800',
     confirmed: 'bg-blue-100 text-blue-800',
-    preparing: 'bg-purple-100 text-purple-800',
+    baking: 'bg-purple-100 text-purple-800',
+    ready: 'bg-indigo-100 text-indigo-800',
+    out_for_delivery: 'bg-cyan-100 text-cyan-800',
     delivered: 'bg-green-100 text-green-800',
     cancelled: 'bg-red-100 text-red-800',
+    refunded: 'bg-gray-100 text-gray-800',
+    payment_failed: 'bg-red-100 text-red-800',
+    partially_refunded: 'bg-gray-100 text-gray-800',
   }
   return map[status] ?? 'bg-gray-100 text-gray-800'
 }
@@ -1,9 +1,19 @@
-import { useEffect, useState } from 'react'
+import { useEffect, useRef, useState } from 'react'
 import { useForm } from 'react-hook-form'
 import { zodResolver } from '@hookform/resolvers/zod'
 import { z } from 'zod'
-import { Link, useNavigate } from 'react-router-dom'
-import { ShoppingBag, Minus, Plus, Trash2, Loader2, CheckCircle } from 'lucide-react'
+import { Link } from 'react-router-dom'
+import {
+  ShoppingBag,
+  Minus,
+  Plus,
+  Trash2,
+  Loader2,
+  CheckCircle,
+  AlertTriangle,
+  Tag,
+  X,
+} from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -28,13 +38,15 @@ type CheckoutFormValues = z.infer<typeof checkoutSchema>
 export function CartPage() {
   const { cart, setCart, clearCart } = useCartStore()
   const { isAuthenticated } = useAuthStore()
-  const navigate = useNavigate()
 
   const [isLoadingCart, setIsLoadingCart] = useState(true)
   const [loadingItem, setLoadingItem] = useState<string | null>(null)
   const [isOrdering, setIsOrdering] = useState(false)
   const [orderSuccess, setOrderSuccess] = useState<string | null>(null)
   const [orderError, setOrderError] = useState('')
+  const [couponCode, setCouponCode] = useState('')
+  const [couponError, setCouponError] = useState('')
+  const [isApplyingCoupon, setIsApplyingCoupon] = useState(false)
 
   const {
     register,
@@ -45,17 +57,14 @@ export function CartPage() {
     defaultValues: { payment_method: 'cash_on_delivery' },
   })
 
+  // Guests have a cart too; signing in merges it into the account's.
   useEffect(() => {
-    if (!isAuthenticated) {
-      navigate('/register')
-      return
-    }
     cartService
       .getCart()
       .then(setCart)
       .catch(console.error)
       .finally(() => setIsLoadingCart(false))
-  }, [isAuthenticated, navigate, setCart])
+  }, [isAuthenticated, setCart])
 
   const updateQty = async (itemId: string, qty: number) => {
     setLoadingItem(itemId)
@@ -82,6 +91,23 @@ export function CartPage() {
     }
   }
 
+  // An empty code removes the cart's coupon.
+  const applyCoupon = async (code: string) => {
+    setIsApplyingCoupon(true)
+    setCouponError('')
+    try {
+      const updated = await cartService.applyCoupon(code)
+      setCart(updated)
+      setCouponCode('')
+    } catch (err: unknown) {
+      setCouponError(err instanceof Error ? err.message : 'Failed to apply coupon.')
+    } finally {
+      setIsApplyingCoupon(false)
+    }
+  }
+
+  const checkoutAttempt = useRef<{ fingerprint: string; key: string } | null>(null)
+
   const onCheckout = async (values: CheckoutFormValues) => {
     if (!cart || cart.items.length === 0) return
     setIsOrdering(true)
@@ -91,12 +117,22 @@ export function CartPage() {
       const localDate = new Date(values.delivery_date)
       const deliveryISO = localDate.toISOString()
 
-      const order = await orderService.create({
+      const payload = {
         delivery_address: values.delivery_address,
         delivery_date: deliveryISO,
         notes: values.notes || undefined,
         payment_method: values.payment_method,
-      })
+      }
+
+      // Reuse the key when retrying the same checkout so a request that
+      // timed out but succeeded isn't placed twice.
+      const fingerprint = JSON.stringify(payload)
+      if (checkoutAttempt.current?.fingerprint !== fingerprint) {
+        checkoutAttempt.current = { fingerprint, key: crypto.randomUUID() }
+      }
+
+      const order = await orderService.create(payload, checkoutAttempt.current.key)
+      checkoutAttempt.current = null
       clearCart()
       setOrderSuccess(order.id)
     } catch (err: unknown) {
@@ -106,8 +142,6 @@ export function CartPage() {
     }
   }
 
-  if (!isAuthenticated) return null
-
   if (isLoadingCart) return <PageLoader />
 
   // Order success screen
@@ -156,7 +190,9 @@ export function CartPage() {
               {cart.items.map((item) => (
                 <div
                   key={item.id}
-                  className="flex gap-4 bg-white rounded-xl border p-4 shadow-sm"
+                  className={`flex gap-4 bg-white rounded-xl border p-4 shadow-sm ${
+                    item.available ? '' : 'opacity-60'
+                  }`}
                 >
                   {/* Image */}
                   <div className="h-20 w-20 shrink-0 rounded-lg overflow-hidden bg-muted">
@@ -174,7 +210,37 @@ export function CartPage() {
                   {/* Details */}
                   <div className="flex-1 min-w-0">
                     <p className="font-semibold truncate">{item.product_name}</p>
-                    <p className="text-sm text-primary font-bold">{formatCurrency(item.price)} / each</p>
+                    {Object.keys(item.options).length > 0 && (
+                      <p className="text-xs text-muted-foreground truncate">
+                        {Object.entries(item.options)
+                          .map(([name, value]) => `${name}: ${value}`)
+                          .join(', ')}
+                      </p>
+                    )}
+                    {item.personalisation?.inscription && (
+                      <p className="text-xs text-muted-foreground truncate">
+                        “{item.personalisation.inscription}”
+                      </p>
+                    )}
+                    <p className="text-sm text-primary font-bold">
+                      {item.previous_price !== undefined && (
+                        <span className="text-muted-foreground font-normal line-through mr-1.5">
+                          {formatCurrency(item.previous_price)}
+                        </span>
+                      )}
+                      {formatCurrency(item.price)} / each
+                    </p>
+                    {item.warnings.map((warning) => (
+                      <p
+                        key={warning.code}
+                        className={`flex items-center gap-1 text-xs ${
+                          warning.code === 'price_changed' ? 'text-amber-600' : 'text-destructive'
+                        }`}
+                      >
+                        <AlertTriangle className="h-3 w-3 shrink-0" />
+                        {warning.message}
+                      </p>
+                    ))}
 
                     {/* Quantity controls */}
                     <div className="flex items-center gap-2 mt-2">
@@ -188,7 +254,7 @@ export function CartPage() {
                       <span className="text-sm font-bold w-6 text-center">{item.quantity}</span>
                       <button
                         onClick={() => updateQty(item.id, item.quantity + 1)}
-                        disabled={loadingItem === item.id}
+                        disabled={loadingItem === item.id || item.quantity >= item.available_quantity}
                         className="h-7 w-7 flex items-center justify-center rounded border hover:bg-muted disabled:opacity-50"
                       >
                         <Plus className="h-3 w-3" />
@@ -229,6 +295,94 @@ export function CartPage() {
                   ))}
                 </div>
                 <Separator />
+                {cart.coupon ? (
+                  <div className="space-y-1">
+                    <div className="flex items-center justify-between text-sm">
+                      <span className="flex items-center gap-1.5 font-medium">
+                        <Tag className="h-3.5 w-3.5 text-primary" />
+                        {cart.coupon.code}
+                      </span>
+                      <button
+                        onClick={() => applyCoupon('')}
+                        disabled={isApplyingCoupon}
+                        aria-label="Remove coupon"
+                        className="text-muted-foreground hover:text-destructive transition-colors disabled:opacity-50"
+                      >
+                        <X className="h-4 w-4" />
+                      </button>
+                    </div>
+                    {cart.coupon.description && (
+                      <p className="text-xs text-muted-foreground">{cart.coupon.description}</p>
+                    )}
+                    {cart.coupon.warning && (
+                      <p className="flex items-center gap-1 text-xs text-destructive">
+                        <AlertTriangle className="h-3 w-3 shrink-0" />
+                        {cart.coupon.warning.message}
+                      </p>
+                    )}
+                  </div>
+                ) : (
+                  <form
+                    onSubmit={(e) => {
+                      e.preventDefault()
+                      if (couponCode.trim()) applyCoupon(couponCode.trim())
+                    }}
+                    className="space-y-1.5"
+                  >
+                    <div className="flex gap-2">
+                      <Input
+                        placeholder="Coupon code"
+                        value={couponCode}
+                        onChange={(e) => setCouponCode(e.target.value)}
+                        aria-invalid={!!couponError}
+                      />
+                      <Button
+                        type="submit"
+                        variant="outline"
+                        disabled={isApplyingCoupon || !couponCode.trim()}
+                      >
+                        {isApplyingCoupon && <Loader2 className="h-4 w-4 animate-spin mr-2" />}
+                        Apply
+                      </Button>
+                    </div>
+                    {couponError && <p className="text-xs text-destructive">{couponError}</p>}
+                  </form>
+                )}
+                <div className="space-y-2 text-sm">
+                  <div className="flex justify-between text-muted-foreground">
+                    <span>Subtotal</span>
+                    <span className="font-medium text-foreground">
+                      {formatCurrency(cart.subtotal)}
+                    </span>
+                  </div>
+                  {cart.promotions.map((promotion) => (
+                    <div
+                      key={promotion.promotion_id ?? promotion.name}
+                      className="flex justify-between gap-2 text-muted-foreground"
+                    >
+                      <span>
+                        {promotion.name}
+                        <span className="block text-xs">{promotion.detail}</span>
+                      </span>
+                      <span className="font-medium text-green-600">
+                        −{formatCurrency(promotion.discount)}
+                      </span>
+                    </div>
+                  ))}
+                  {cart.discount > 0 && (
+                    <div className="flex justify-between text-muted-foreground">
+                      <span>Discount</span>
+                      <span className="font-medium text-green-600">−{formatCurrency(cart.discount)}</span>
+                    </div>
+                  )}
+                  <div className="flex justify-between text-muted-foreground">
+                    <span>Delivery</span>
+                    <span className="font-medium text-foreground">
+                      {cart.delivery_fee > 0 ? formatCurrency(cart.delivery_fee) : 'Free'}
+                    </span>
+                  </div>
+                </div>
+                <Separator />
                 <div className="flex justify-between font-bold text-base">
                   <span>Total</span>
                   <span className="text-primary">{formatCurrency(cart.total)}</span>
@@ -245,67 +399,84 @@ export function CartPage() {
                   </div>
                 )}
 
-                <form onSubmit={handleSubmit(onCheckout)} className="space-y-4">
-                  <div className="space-y-1.5">
-                    <Label htmlFor="delivery_address">Delivery Address</Label>
-                    <Textarea
-                      id="delivery_address"
-                      placeholder="123 Main St, City, State, ZIP"
-                      rows={3}
-                      {...register('delivery_address')}
-                      aria-invalid={!!errors.delivery_address}
-                    />
-                    {errors.delivery_address && (
-                      <p className="text-xs text-destructive">{errors.delivery_address.message}</p>
-                    )}
-                  </div>
-
-                  <div className="space-y-1.5">
-                    <Label htmlFor="delivery_date">Delivery Date & Time</Label>
-                    <Input
-                      id="delivery_date"
-                      type="datetime-local"
-                      min={getMinDeliveryDate()}
-                      {...register('delivery_date')}
-                      aria-invalid={!!errors.delivery_date}
-                    />
-                    {errors.delivery_date && (
-                      <p className="text-xs text-destructive">{errors.delivery_date.message}</p>
-                    )}
-                  </div>
+                {isAuthenticated ? (
+                  <form onSubmit={handleSubmit(onCheckout)} className="space-y-4">
+                    <div className="space-y-1.5">
+                      <Label htmlFor="delivery_address">Delivery Address</Label>
+                      <Textarea
+                        id="delivery_address"
+                        placeholder="123 Main St, City, State, ZIP"
+                        rows={3}
+                        {...register('delivery_address')}
+                        aria-invalid={!!errors.delivery_address}
+                      />
+                      {errors.delivery_address && (
+                        <p className="text-xs text-destructive">{errors.delivery_address.message}</p>
+                      )}
+                    </div>
 
-                  <div className="space-y-1.5">
-                    <Label htmlFor="notes">Custom Message / Notes (optional)</Label>
-                    <Textarea
-                      id="notes"
-                      placeholder="e.g. 'Happy Birthday Sarah!' or special instructions"
-                      rows={2}
-                      {...register('notes')}
-                    />
-                  </div>
+                    <div className="space-y-1.5">
+                      <Label htmlFor="delivery_date">Delivery Date & Time</Label>
+                      <Input
+                        id="delivery_date"
+                        type="datetime-local"
+                        min={getMinDeliveryDate()}
+                        {...register('delivery_date')}
+                        aria-invalid={!!errors.delivery_date}
+                      />
+                      {errors.delivery_date && (
+                        <p className="text-xs text-destructive">{errors.delivery_date.message}</p>
+                      )}
+                    </div>
 
-                  {/* Payment method */}
-                  <div className="rounded-lg border bg-muted/40 p-3 flex items-center gap-3">
-                    <div className="h-8 w-8 rounded-full bg-green-100 flex items-center justify-center text-base">
-                      💵
+                    <div className="space-y-1.5">
+                      <Label htmlFor="notes">Custom Message / Notes (optional)</Label>
+                      <Textarea
+                        id="notes"
+                        placeholder="e.g. 'Happy Birthday Sarah!' or special instructions"
+                        rows={2}
+                        {...register('notes')}
+                      />
                     </div>
-                    <div>
-                      <p className="text-sm font-semibold">Cash on Delivery</p>
-                      <p className="text-xs text-muted-foreground">Pay when your order arrives</p>
+
+                    {/* Payment method */}
+                    <div className="rounded-lg border bg-muted/40 p-3 flex items-center gap-3">
+                      <div className="h-8 w-8 rounded-full bg-green-100 flex items-center justify-center text-base">
+                        💵
+                      </div>
+                      <div>
+                        <p className="text-sm font-semibold">Cash on Delivery</p>
+                        <p className="text-xs text-muted-foreground">Pay when your order arrives</p>
+                      </div>
+                      <input type="hidden" {...register('payment_method')} />
                     </div>
-                    <input type="hidden" {...register('payment_method')} />
-                  </div>
 
-                  <Button
-                    type="submit"
-                    className="w-full"
-                    size="lg"
-                    disabled={isOrdering}
-                  >
-                    {isOrdering && <Loader2 className="h-4 w-4 animate-spin mr-2" />}
-                    {isOrdering ? 'Placing Order…' : `Place Order · ${formatCurrency(cart.total)}`}
-                  </Button>
-                </form>
+                    {!cart.can_checkout && (
+                      <p className="text-xs text-destructive">
+                        Fix the items or coupon marked above to check out.
+                      </p>
+                    )}
+
+                    <Button
+                      type="submit"
+                      className="w-full"
+                      size="lg"
+                      disabled={isOrdering || !cart.can_checkout}
+                    >
+                      {isOrdering && <Loader2 className="h-4 w-4 animate-spin mr-2" />}
+                      {isOrdering ? 'Placing Order…' : `Place Order · ${formatCurrency(cart.total)}`}
+                    </Button>
+                  </form>
+                ) : (
+                  <div className="space-y-3">
+                    <p className="text-sm text-muted-foreground">
+                      Sign in or create an account to check out. Your cart comes with you.
+                    </p>
+                    <Button className="w-full" size="lg" asChild>
+                      <Link to="/register">Sign in to Check Out</Link>
+                    </Button>
+                  </div>
+                )}
               </div>
             </div>
           </div>
@@ -1,9 +1,11 @@
-import { useState } from 'react'
+import { useState, type FormEvent } from 'react'
 import { useQuery } from '@tanstack/react-query'
-import { Filter, SortAsc, SortDesc, ChevronLeft, ChevronRight } from 'lucide-react'
+import { Filter, SortAsc, SortDesc, ChevronLeft, ChevronRight, Search } from 'lucide-react'
 import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@/components/ui/select'
 import { ProductCard } from '@/components/shared/ProductCard'
+import { FacetSidebar } from '@/components/shared/FacetSidebar'
 import { PageLoader } from '@/components/shared/LoadingSpinner'
 import { productService } from '@/services/products'
 import type { ListProductsParams } from '@/types'
@@ -13,6 +15,7 @@ export function ProductsPage() {
     page: 1,
     limit: 12,
   })
+  const [search, setSearch] = useState('')
 
   const { data: categories } = useQuery({
     queryKey: ['categories'],
@@ -37,6 +40,14 @@ export function ProductsPage() {
 
   const setPage = (page: number) => setParams((p) => ({ ...p, page }))
 
+  const setFilters = (patch: Partial<ListProductsParams>) =>
+    setParams((p) => ({ ...p, ...patch, page: 1 }))
+
+  const submitSearch = (e: FormEvent) => {
+    e.preventDefault()
+    setParams((p) => ({ ...p, q: search.trim() || undefined, page: 1 }))
+  }
+
   const totalPages = data?.total_pages ?? 1
 
   return (
@@ -59,10 +70,23 @@ export function ProductsPage() {
             <span className="font-medium">Filters:</span>
           </div>
 
+          {/* Search */}
+          <form onSubmit={submitSearch} className="relative w-64">
+            <Search className="absolute left-3 top-1/2 h-4 w-4 -translate-y-1/2 text-muted-foreground" />
+            <Input
+              type="search"
+              value={search}
+              onChange={(e) => setSearch(e.target.value)}
+              placeholder="Search cakes…"
+              className="pl-9"
+              maxLength={200}
+            />
+          </form>
+
           {/* Category filter */}
           <Select
             onValueChange={setCategory}
-            defaultValue="all"
+            value={params.category_id ?? 'all'}
           >
             <SelectTrigger className="w-48">
               <SelectValue placeholder="All Categories" />
@@ -83,7 +107,7 @@ export function ProductsPage() {
               <SelectValue placeholder="Sort By" />
             </SelectTrigger>
             <SelectContent>
-              <SelectItem value="none">Default</SelectItem>
+              <SelectItem value="none">{params.q ? 'Best Match' : 'Default'}</SelectItem>
               <SelectItem value="price_asc">
                 <span className="flex items-center gap-2">
                   <SortAsc className="h-4 w-4" /> Price: Low to High
@@ -102,68 +126,79 @@ export function ProductsPage() {
           )}
         </div>
 
-        {/* Products grid */}
-        {isLoading ? (
-          <PageLoader />
-        ) : data?.products.length === 0 ? (
-          <div className="py-20 text-center">
-            <p className="text-4xl mb-4">🎂</p>
-            <p className="text-lg font-semibold text-gray-900">No cakes found</p>
-            <p className="text-muted-foreground mt-2">
-              Try adjusting your filters or check back later.
-            </p>
-            <Button
-              variant="outline"
-              className="mt-4"
-              onClick={() => setParams({ page: 1, limit: 12 })}
-            >
-              Clear Filters
-            </Button>
-          </div>
-        ) : (
-          <>
-            <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 gap-6">
-              {data?.products.map((product) => (
-                <ProductCard key={product.id} product={product} />
-              ))}
-            </div>
-
-            {/* Pagination */}
-            {totalPages > 1 && (
-              <div className="flex items-center justify-center gap-2 mt-10">
-                <Button
-                  variant="outline"
-                  size="icon"
-                  disabled={params.page === 1}
-                  onClick={() => setPage((params.page ?? 1) - 1)}
-                >
-                  <ChevronLeft className="h-4 w-4" />
-                </Button>
-
-                {Array.from({ length: totalPages }, (_, i) => i + 1).map((p) => (
-                  <Button
-                    key={p}
-                    variant={p === params.page ? 'default' : 'outline'}
-                    size="icon"
-                    onClick={() => setPage(p)}
-                    className="h-9 w-9"
-                  >
-                    {p}
-                  </Button>
-                ))}
+        <div className="flex flex-col gap-8 lg:flex-row">
+          {data?.facets && (
+            <FacetSidebar facets={data.facets} params={params} onChange={setFilters} />
+          )}
 
+          <div className="min-w-0 flex-1">
+            {/* Products grid */}
+            {isLoading ? (
+              <PageLoader />
+            ) : data?.products.length === 0 ? (
+              <div className="py-20 text-center">
+                <p className="text-4xl mb-4">🎂</p>
+                <p className="text-lg font-semibold text-gray-900">No cakes found</p>
+                <p className="text-muted-foreground mt-2">
+                  Try adjusting your filters or check back later.
+                </p>
                 <Button
                   variant="outline"
-                  size="icon"
-                  disabled={params.page === totalPages}
-                  onClick={() => setPage((params.page ?? 1) + 1)}
+                  className="mt-4"
+                  onClick={() => {
+                    setSearch('')
+                    setParams({ page: 1, limit: 12 })
+                  }}
                 >
-                  <ChevronRight className="h-4 w-4" />
+                  Clear Filters
                 </Button>
               </div>
+            ) : (
+              <>
+                <div className="grid grid-cols-1 sm:grid-cols-2 xl:grid-cols-3 gap-6">
+                  {data?.products.map((product) => (
+                    <ProductCard key={product.id} product={product} />
+                  ))}
+                </div>
+
+                {/* Pagination */}
+                {totalPages > 1 && (
+                  <div className="flex items-center justify-center gap-2 mt-10">
+                    <Button
+                      variant="outline"
+                      size="icon"
+                      disabled={params.page === 1}
+                      onClick={() => setPage((params.page ?? 1) - 1)}
+                    >
+                      <ChevronLeft className="h-4 w-4" />
+                    </Button>
+
+                    {Array.from({ length: totalPages }, (_, i) => i + 1).map((p) => (
+                      <Button
+                        key={p}
+                        variant={p === params.page ? 'default' : 'outline'}
+                        size="icon"
+                        onClick={() => setPage(p)}
+                        className="h-9 w-9"
+                      >
+                        {p}
+                      </Button>
+                    ))}
+
+                    <Button
+                      variant="outline"
+                      size="icon"
+                      disabled={params.page === totalPages}
+                      onClick={() => setPage((params.page ?? 1) + 1)}
+                    >
+                      <ChevronRight className="h-4 w-4" />
+                    </Button>
+                  </div>
+                )}
+              </>
             )}
-          </>
-        )}
+          </div>
+        </div>
       </div>
     </main>
   )
@@ -8,7 +8,9 @@ import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
 import { authService } from '@/services/auth'
+import { cartService } from '@/services/cart'
 import { useAuthStore } from '@/store/authStore'
+import { useCartStore } from '@/store/cartStore'
 
 const schema = z.object({
   email: z.string().email('Enter a valid email address'),
@@ -21,6 +23,7 @@ export function VerifyOTPPage() {
   const navigate = useNavigate()
   const location = useLocation()
   const { setAuth } = useAuthStore()
+  const { setCart } = useCartStore()
   const [serverError, setServerError] = useState('')
   const [successMsg, setSuccessMsg] = useState('')
   const [isLoading, setIsLoading] = useState(false)
@@ -53,6 +56,8 @@ export function VerifyOTPPage() {
     try {
       const result = await authService.verifyOTP(values)
       setAuth(result.user, result.token)
+      // Any guest cart has just been merged into the account's.
+      cartService.getCart().then(setCart).catch(console.error)
       navigate('/products', { replace: true })
     } catch (err: unknown) {
       setServerError(err instanceof Error ? err.message : 'Verification failed. Please try again.')
@@ -17,4 +17,8 @@ export const authService = {
   resendOTP: async (email: string): Promise<void> => {
     await api.post('/auth/resend-otp', { email })
   },
+
+  logout: async (): Promise<void> => {
+    await api.post('/auth/logout')
+  },
 }
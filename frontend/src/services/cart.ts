@@ -7,9 +7,10 @@ export const cartService = {
     return data.data!
   },
 
-  addItem: async (productId: string, quantity: number): Promise<Cart> => {
+  addItem: async (productId: string, quantity: number, variantId?: string): Promise<Cart> => {
     const { data } = await api.post<{ success: boolean; data: Cart }>('/cart/items', {
       product_id: productId,
+      variant_id: variantId,
       quantity,
     })
     return data.data!
@@ -30,6 +31,12 @@ export const cartService = {
     return data.data!
   },
 
+  /** Applies a coupon code, or removes the cart's coupon when code is empty. */
+  applyCoupon: async (code: string): Promise<Cart> => {
+    const { data } = await api.post<{ success: boolean; data: Cart }>('/cart/coupon', { code })
+    return data.data!
+  },
+
   clearCart: async (): Promise<void> => {
     await api.delete('/cart')
   },
@@ -2,8 +2,12 @@ import api from '@/lib/api'
 import type { CreateOrderPayload, Order, PaginatedOrders } from '@/types'
 
 export const orderService = {
-  create: async (payload: CreateOrderPayload): Promise<Order> => {
-    const { data } = await api.post<{ success: boolean; data: Order }>('/orders', payload)
+  // Retrying with the same idempotency key returns the original order
+  // instead of placing a second one.
+  create: async (payload: CreateOrderPayload, idempotencyKey?: string): Promise<Order> => {
+    const { data } = await api.post<{ success: boolean; data: Order }>('/orders', payload, {
+      headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+    })
     return data.data!
   },
 
@@ -18,4 +22,11 @@ export const orderService = {
     const { data } = await api.get<{ success: boolean; data: Order }>(`/orders/${id}`)
     return data.data!
   },
+
+  cancel: async (id: string, reason?: string): Promise<Order> => {
+    const { data } = await api.post<{ success: boolean; data: Order }>(`/orders/${id}/cancel`, {
+      reason,
+    })
+    return data.data!
+  },
 }
@@ -5,7 +5,8 @@ export const productService = {
   list: async (params: ListProductsParams = {}): Promise<PaginatedProducts> => {
     const { data } = await api.get<{ success: boolean; data: PaginatedProducts }>(
       '/products',
-      { params }
+      // Repeat array params (size=6"&size=8") rather than size[]=...
+      { params, paramsSerializer: { indexes: null } }
     )
     return data.data!
   },
@@ -0,0 +1,78 @@
+import api from '@/lib/api'
+import type {
+  AddWishlistItemPayload,
+  Cart,
+  SharedWishlist,
+  Wishlist,
+} from '@/types'
+
+export const wishlistService = {
+  list: async (): Promise<Wishlist[]> => {
+    const { data } = await api.get<{ success: boolean; data: Wishlist[] }>('/wishlists')
+    return data.data!
+  },
+
+  create: async (name: string): Promise<Wishlist> => {
+    const { data } = await api.post<{ success: boolean; data: Wishlist }>('/wishlists', { name })
+    return data.data!
+  },
+
+  getById: async (id: string): Promise<Wishlist> => {
+    const { data } = await api.get<{ success: boolean; data: Wishlist }>(`/wishlists/${id}`)
+    return data.data!
+  },
+
+  rename: async (id: string, name: string): Promise<Wishlist> => {
+    const { data } = await api.put<{ success: boolean; data: Wishlist }>(`/wishlists/${id}`, {
+      name,
+    })
+    return data.data!
+  },
+
+  remove: async (id: string): Promise<void> => {
+    await api.delete(`/wishlists/${id}`)
+  },
+
+  addItem: async (id: string, payload: AddWishlistItemPayload): Promise<Wishlist> => {
+    const { data } = await api.post<{ success: boolean; data: Wishlist }>(
+      `/wishlists/${id}/items`,
+      payload
+    )
+    return data.data!
+  },
+
+  removeItem: async (id: string, itemId: string): Promise<Wishlist> => {
+    const { data } = await api.delete<{ success: boolean; data: Wishlist }>(
+      `/wishlists/${id}/items/${itemId}`
+    )
+    return data.data!
+  },
+
+  /** Moves an item into the cart and returns the cart. */
+  moveToCart: async (id: string, itemId: string): Promise<Cart> => {
+    const { data } = await api.post<{ success: boolean; data: Cart }>(
+      `/wishlists/${id}/items/${itemId}/move-to-cart`
+    )
+    return data.data!
+  },
+
+  /** Shares the wishlist; its share_token opens it read-only for others. */
+  share: async (id: string): Promise<Wishlist> => {
+    const { data } = await api.post<{ success: boolean; data: Wishlist }>(`/wishlists/${id}/share`)
+    return data.data!
+  },
+
+  unshare: async (id: string): Promise<Wishlist> => {
+    const { data } = await api.delete<{ success: boolean; data: Wishlist }>(
+      `/wishlists/${id}/share`
+    )
+    return data.data!
+  },
+
+  getShared: async (token: string): Promise<SharedWishlist> => {
+    const { data } = await api.get<{ success: boolean; data: SharedWishlist }>(
+      `/shared-wishlists/${encodeURIComponent(token)}`
+    )
+    return data.data!
+  },
+}
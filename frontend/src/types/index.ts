@@ -12,10 +12,14 @@ export interface User {
   last_name: string
   email: string
   phone: string
+  role: 'customer' | 'staff' | 'admin'
 }
 
 export interface AuthResult {
   token: string
+  expires_at: string
+  refresh_token: string
+  refresh_token_expires_at: string
   user: User
 }
 
@@ -36,6 +40,7 @@ export interface Category {
   id: string
   name: string
   slug: string
+  parent_id: string | null
   created_at: string
   updated_at: string
 }
@@ -49,8 +54,66 @@ export interface Product {
   name: string
   description: string | null
   price: number
+  /** The first of images, or an image hosted elsewhere. */
   image_url: string | null
+  images: ProductImage[]
   stock_quantity: number
+  available_quantity: number
+  is_active: boolean
+  variants: ProductVariant[]
+  personalisation_options: PersonalisationOptions
+  dietary_tags: string[]
+  /** Ordered contains, may_contain, free_from. Allergens not listed are unknown. */
+  allergens: ProductAllergen[]
+  /** Search results only: matched words wrapped in <mark>, everything else HTML-escaped. */
+  highlight?: { name: string; description?: string }
+}
+
+export interface ProductImage {
+  id: string
+  url: string
+  /** Scaled to fit in 400×400. */
+  thumbnail_url: string
+  content_type: 'image/jpeg' | 'image/png'
+  width: number
+  height: number
+  position: number
+}
+
+export interface PersonalisationOptions {
+  inscription?: { max_length: number; price: number }
+  candles?: { max: number; price_each: number }
+  topper?: { choices: { name: string; price: number }[] }
+  gift_box?: { price: number }
+}
+
+export interface Personalisation {
+  inscription?: string
+  candles?: number
+  topper?: string
+  gift_box?: boolean
+}
+
+export type AllergenStatus = 'contains' | 'may_contain' | 'free_from'
+
+export interface Allergen {
+  id: string
+  name: string
+  slug: string
+}
+
+export interface ProductAllergen extends Allergen {
+  status: AllergenStatus
+}
+
+export interface ProductVariant {
+  id: string
+  sku: string
+  options: Record<string, string>
+  price: number
+  stock_quantity: number
+  available_quantity: number
+  position: number
   is_active: boolean
 }
 
@@ -58,7 +121,15 @@ export interface ListProductsParams {
   page?: number
   limit?: number
   category_id?: string
+  q?: string
   sort?: 'price_asc' | 'price_desc'
+  min_price?: number
+  max_price?: number
+  in_stock?: boolean
+  dietary_tag?: string[]
+  size?: string[]
+  /** Allergen slugs the products must be declared free from. */
+  free_from?: string[]
 }
 
 export interface PaginatedProducts {
@@ -67,6 +138,25 @@ export interface PaginatedProducts {
   page: number
   limit: number
   total_pages: number
+  facets?: ProductFacets
+}
+
+/** Each facet counts the products matching every filter but its own. */
+export interface ProductFacets {
+  /** value is the category id and label its name. */
+  categories: FacetCount[]
+  /** Inclusive bounds; null is open-ended. */
+  price_ranges: { min: number | null; max: number | null; count: number }[]
+  dietary_tags: FacetCount[]
+  sizes: FacetCount[]
+  /** value is the allergen slug and label its name. */
+  free_from: FacetCount[]
+}
+
+export interface FacetCount {
+  value: string
+  label?: string
+  count: number
 }
 
 // ─── Cart ────────────────────────────────────────────────────────────────────
@@ -75,15 +165,107 @@ export interface CartItem {
   product_id: string
   product_name: string
   product_image_url: string | null
+  variant_id: string
+  sku: string
+  options: Record<string, string>
   price: number
+  personalisation?: Personalisation
+  personalisation_price: number
   quantity: number
   subtotal: number
+  /** What promotions take off subtotal. */
+  discount: number
+  reserved_until?: string
+  /** Price when added, present only if it has changed since. */
+  previous_price?: number
+  available: boolean
+  available_quantity: number
+  warnings: CartWarning[]
+}
+
+export type CartWarningCode =
+  | 'price_changed'
+  | 'unavailable'
+  | 'insufficient_stock'
+  | 'coupon_unusable'
+
+export interface CartWarning {
+  code: CartWarningCode
+  message: string
+}
+
+export interface CartCoupon {
+  code: string
+  description?: string
+  /** Present when the coupon can't be used with the cart as it is. */
+  warning?: CartWarning
+}
+
+/** A promotion applied automatically, explained for display. */
+export interface AppliedPromotion {
+  promotion_id?: string
+  name: string
+  detail: string
+  /** What it took off the items, or the delivery fee it waived. */
+  discount: number
 }
 
 export interface Cart {
   id: string
   items: CartItem[]
+  subtotal: number
+  promotions: AppliedPromotion[]
+  promotion_discount: number
+  /** What the coupon takes off, after promotions. */
+  discount: number
+  delivery_fee: number
   total: number
+  coupon?: CartCoupon
+  can_checkout: boolean
+}
+
+// ─── Wishlist ────────────────────────────────────────────────────────────────
+export interface WishlistItem {
+  id: string
+  product_id: string
+  variant_id: string
+  product_name: string
+  product_image_url: string | null
+  sku: string
+  options: Record<string, string>
+  /** Per cake at today's prices, including personalisation_price. */
+  price: number
+  personalisation?: Personalisation
+  personalisation_price: number
+  quantity: number
+  available: boolean
+  added_at: string
+}
+
+export interface Wishlist {
+  id: string
+  name: string
+  /** Present while the wishlist is shared. */
+  share_token?: string
+  item_count: number
+  /** Only included when fetching or changing a single wishlist. */
+  items?: WishlistItem[]
+  created_at: string
+  updated_at: string
+}
+
+/** A wishlist as anyone with its share link sees it. */
+export interface SharedWishlist {
+  name: string
+  items: WishlistItem[]
+  updated_at: string
+}
+
+export interface AddWishlistItemPayload {
+  product_id: string
+  variant_id?: string
+  personalisation?: Personalisation
+  quantity?: number
 }
 
 // ─── Order ───────────────────────────────────────────────────────────────────
@@ -92,9 +274,17 @@ export interface OrderItem {
   product_id: string
   product_name: string
   image_url: string | null
+  variant_id: string
+  sku: string
+  options: Record<string, string>
   quantity: number
   unit_price: number
   total_price: number
+  personalisation?: Personalisation
+  personalisation_price: number
+  refunded_quantity: number
+  /** The product's declarations when it was ordered. */
+  allergens: Omit<ProductAllergen, 'id'>[]
 }
 
 export interface Order {
@@ -103,9 +293,57 @@ export interface Order {
   delivery_date: string
   notes: string | null
   payment_method: string
-  status: 'pending' | 'confirmed' | 'preparing' | 'delivered' | 'cancelled'
+  status: OrderStatus
   total_amount: number
+  promotion_discount: number
+  discount_amount: number
+  coupon_code?: string
+  delivery_fee: number
+  refunded_amount: number
+  promotions?: AppliedPromotion[]
   items: OrderItem[]
+  payment?: Payment
+  refunds?: Refund[]
+  status_history?: OrderStatusChange[]
+  created_at: string
+}
+
+export type OrderStatus =
+  | 'pending'
+  | 'confirmed'
+  | 'baking'
+  | 'ready'
+  | 'out_for_delivery'
+  | 'delivered'
+  | 'cancelled'
+  | 'refunded'
+  | 'payment_failed'
+  | 'partially_refunded'
+
+export interface OrderStatusChange {
+  from_status: OrderStatus | null
+  to_status: OrderStatus
+  actor_id: string | null
+  note?: string
+  created_at: string
+}
+
+export interface Payment {
+  id: string
+  provider: string
+  status: 'pending' | 'requires_capture' | 'processing' | 'succeeded' | 'failed'
+  amount: number
+  currency: string
+  failure_reason?: string
+}
+
+export interface Refund {
+  id: string
+  status: 'pending' | 'succeeded' | 'failed'
+  amount: number
+  reason?: string
+  restock: boolean
+  failure_reason?: string
   created_at: string
 }
 
@@ -113,7 +351,8 @@ export interface CreateOrderPayload {
   delivery_address: string
   delivery_date: string // RFC3339
   notes?: string
-  payment_method: string
+  payment_method: 'cash_on_delivery' | 'card'
+  payment_token?: string
 }
 
 export interface PaginatedOrders {